@@ -0,0 +1,192 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+
+	"github.com/gorilla/mux"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+// GetAccessControlEntries - Returns a collection containing the access control entries of an organization.
+// Filtered by entity if entity-type and entity-id query values are given.
+// Handler for HTTP Get - "/organizations/{organization}/access-control-entries"
+func GetAccessControlEntries(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	query := r.URL.Query()
+	entityType := query.Get("entity-type")
+	entityID := query.Get("entity-id")
+	// Get repo
+	entryRepo, err := repo.MakeAccessControlEntryRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var entries []models.AccessControlEntry
+	if entityType != "" && entityID != "" {
+		entries, err = entryRepo.GetByEntity(orgid, entityType, entityID)
+	} else {
+		entries, err = entryRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AccessControlEntriesResource{Data: entries})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateAccessControlEntry - Grants a user or a role an action over a single entity.
+// Handler for HTTP Post - "/organizations/{organization}/access-control-entries"
+func CreateAccessControlEntry(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res AccessControlEntryResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	entry := &res.Data
+	// Set Organization - Don't trust JSON value
+	entry.OrganizationID = models.ToNullsString(orgid)
+	entry.CreatedBy = models.ToNullsString(loggedInUserID(r))
+	entry.SetDefaults()
+	// Validate
+	if !entry.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	entryRepo, err := repo.MakeAccessControlEntryRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = entryRepo.Create(entry)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AccessControlEntryResource{Data: *entry})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetAccessControlEntry - Returns a single access control entry by its id.
+// Handler for HTTP Get - "/organizations/{organization}/access-control-entries/{access-control-entry}"
+func GetAccessControlEntry(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["access-control-entry"]
+	// Get repo
+	entryRepo, err := repo.MakeAccessControlEntryRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	entry, err := entryRepo.GetFromOrganization(id, orgid)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AccessControlEntryResource{Data: entry})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// DeleteAccessControlEntry - Revokes an access control entry.
+// Handler for HTTP Delete - "/organizations/{organization}/access-control-entries/{access-control-entry}"
+func DeleteAccessControlEntry(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["access-control-entry"]
+	// Get repo
+	entryRepo, err := repo.MakeAccessControlEntryRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Check entry belongs to organization
+	_, err = entryRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Delete
+	err = entryRepo.Delete(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
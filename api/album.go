@@ -0,0 +1,704 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/markbates/pop/nulls"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/services"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+const (
+	// Max images per upload request
+	albumMaxUploadFiles = 10
+	// Memory used parsing uploads, bigger ones go to temporary files
+	albumUploadMemory = 32 << 20
+)
+
+// GetAlbums - Returns the albums of an organization.
+// Handler for HTTP Get - "/organizations/{organization}/albums"
+// Optional query values 'owner-type' and 'owner-id' restrict the collection to the albums of a listing or building.
+// Users not taking part in the organization only get the albums of the organization and its published listings.
+func GetAlbums(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	ownerType := r.URL.Query().Get("owner-type")
+	ownerID := r.URL.Query().Get("owner-id")
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var albums []models.Album
+	if ownerType != "" && ownerID != "" {
+		albums, err = albumRepo.GetAllByOwner(orgid, ownerType, ownerID)
+	} else {
+		albums, err = albumRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role
+	canRead, err := galleryReader(r, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		albums, err = publicAlbums(orgid, albums)
+		if err != nil {
+			app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	// Marshal
+	j, err := json.Marshal(AlbumsResource{Data: albums})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateAlbum - Creates an album attached to a listing, a building or the organization itself.
+// Handler for HTTP Post - "/organizations/{organization}/albums"
+func CreateAlbum(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res AlbumResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	album := &res.Data
+	// Set values - Don't trust JSON value
+	u, _ := sessionUser(r)
+	album.OrganizationID = models.ToNullsString(orgid)
+	album.CreatedBy = u.ID
+	album.SetDefaults()
+	// Validate
+	if !album.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	err = albumOwner(orgid, album)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = albumRepo.Create(album)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AlbumResource{Data: *album})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetAlbum - Returns a single album and its images in order.
+// Handler for HTTP Get - "/organizations/{organization}/albums/{album}"
+func GetAlbum(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	album, err := visibleAlbum(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	images, err := albumRepo.GetImages(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AlbumResource{Data: album, Images: images})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateAlbum - Update name and description of an album.
+// Handler for HTTP Put - "/organizations/{organization}/albums/{album}"
+func UpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	// Decode
+	var res AlbumResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	album := &res.Data
+	album.ID = models.ToNullsString(id)
+	// Check against current album
+	current, err := organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(album.IdentifiableModel, current.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Owner is set on creation
+	album.OwnerType = current.OwnerType
+	album.OwnerID = current.OwnerID
+	album.CoverImageID = current.CoverImageID
+	album.OrganizationID = current.OrganizationID
+	// Validate
+	if !album.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Update
+	err = albumRepo.Update(album)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AlbumResource{Data: *album})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteAlbum - Deletes an album and its images.
+// Handler for HTTP Delete - "/organizations/{organization}/albums/{album}"
+func DeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	// Check album
+	_, err := organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = albumRepo.Delete(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadAlbumImages - Uploads images to an album generating their medium and thumbnail renditions.
+// Handler for HTTP Post - "/organizations/{organization}/albums/{album}/images"
+// Multipart form with one or more 'files' parts, optional 'caption' is set to every uploaded image.
+// Images are appended to the album in upload order.
+func UploadAlbumImages(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	userID, _ := sessionUserID(r)
+	// Check album
+	album, err := organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Decode
+	r.Body = http.MaxBytesReader(w, r.Body, albumMaxUploadFiles*models.ImageMaxSize)
+	err = r.ParseMultipartForm(albumUploadMemory)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	files := append(r.MultipartForm.File["files"], r.MultipartForm.File["file"]...)
+	if len(files) == 0 || len(files) > albumMaxUploadFiles {
+		app.ShowError(w, app.ErrRequestParsing, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	images := []models.Image{}
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+			return
+		}
+		data, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+			return
+		}
+		// Set values
+		image := models.Image{
+			Caption:        models.ToNullsString(r.FormValue("caption")),
+			AlbumID:        album.ID,
+			OrganizationID: album.OrganizationID,
+		}
+		image.Name = models.ToNullsString(header.Filename)
+		image.CreatedBy = models.ToNullsString(userID)
+		// Process
+		err = image.SetData(data)
+		if err != nil {
+			app.ShowError(w, app.ErrImageDecoding, err, http.StatusBadRequest)
+			return
+		}
+		images = append(images, image)
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	for i := range images {
+		err = albumRepo.AddImage(&images[i])
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+			return
+		}
+		// Data is not echoed back
+		images[i].Base64 = nulls.String{}
+		images[i].MediumBase64 = nulls.String{}
+		images[i].ThumbnailBase64 = nulls.String{}
+	}
+	// Marshal
+	j, err := json.Marshal(ImagesResource{Data: images})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetAlbumImage - Returns a single image of an album without its data.
+// Handler for HTTP Get - "/organizations/{organization}/albums/{album}/images/{image}"
+func GetAlbumImage(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	imageID := vars["image"]
+	// Select
+	image, err := albumImage(r, orgid, id, imageID, false)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ImageResource{Data: image})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetAlbumImageRendition - Returns the original, medium or thumbnail rendition of an image.
+// Handler for HTTP Get - "/organizations/{organization}/albums/{album}/images/{image}/{rendition}"
+func GetAlbumImageRendition(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	imageID := vars["image"]
+	// Select
+	image, err := albumImage(r, orgid, id, imageID, true)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	contentType, data, err := image.Rendition(vars["rendition"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// UpdateAlbumImage - Update name, description and caption of an image.
+// Handler for HTTP Put - "/organizations/{organization}/albums/{album}/images/{image}"
+func UpdateAlbumImage(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	imageID := vars["image"]
+	// Decode
+	var res ImageResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	// Check album
+	_, err = organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current image
+	image, err := albumRepo.GetImage(imageID, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Only descriptive values can be changed
+	image.Name = res.Data.Name
+	image.Description = res.Data.Description
+	image.Caption = res.Data.Caption
+	// Update
+	err = albumRepo.UpdateImage(&image)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ImageResource{Data: image})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteAlbumImage - Deletes an image of an album.
+// Handler for HTTP Delete - "/organizations/{organization}/albums/{album}/images/{image}"
+func DeleteAlbumImage(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	imageID := vars["image"]
+	// Check album
+	_, err := organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = albumRepo.DeleteImage(imageID, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetAlbumCover - Sets an image as the album cover.
+// Handler for HTTP Post - "/organizations/{organization}/albums/{album}/images/{image}/cover"
+func SetAlbumCover(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	imageID := vars["image"]
+	// Check album
+	_, err := organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Update
+	err = albumRepo.SetCover(id, imageID)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderAlbumImages - Sets the order of the images of an album.
+// Handler for HTTP Put - "/organizations/{organization}/albums/{album}/order"
+// Request data is the list of every album image ID in the new order.
+func ReorderAlbumImages(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["album"]
+	// Decode
+	var res AlbumOrderResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	// Check album
+	_, err = organizationAlbum(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Update
+	err = albumRepo.ReorderImages(id, res.Data)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// organizationAlbum - Returns an album if it belongs to the organization.
+func organizationAlbum(orgid, id string) (models.Album, error) {
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		return models.Album{}, err
+	}
+	return albumRepo.GetFromOrganization(id, orgid)
+}
+
+// galleryReader - Returns true if the session user can read the organization galleries or takes part in it.
+func galleryReader(r *http.Request, orgid string) (bool, error) {
+	canRead, err := sessionCan(r, models.GalleryResourceTag, orgid)
+	if err != nil || canRead {
+		return canRead, err
+	}
+	return services.IsOrganizationParty(loggedInUserID(r), orgid)
+}
+
+// publicAlbum - Returns true if album can be seen by any user: albums of the organization itself
+// and of its published listings. Building albums and those of other listings are internal.
+func publicAlbum(album models.Album, listings map[string]models.Listing) bool {
+	switch album.OwnerType.String {
+	case models.AlbumOwnerOrganization:
+		return true
+	case models.AlbumOwnerListing:
+		listing, ok := listings[album.OwnerID.String]
+		return ok && listing.Status.String == models.ListingStatusPublished
+	}
+	return false
+}
+
+// publicAlbums - Returns the albums any user can see.
+func publicAlbums(orgid string, albums []models.Album) ([]models.Album, error) {
+	listings, err := organizationListings(orgid)
+	if err != nil {
+		return nil, err
+	}
+	public := []models.Album{}
+	for _, album := range albums {
+		if publicAlbum(album, listings) {
+			public = append(public, album)
+		}
+	}
+	return public, nil
+}
+
+// visibleAlbum - Returns an organization album if the session user can see it.
+// Albums not visible for the user are reported as not found.
+func visibleAlbum(r *http.Request, orgid, id string) (models.Album, error) {
+	album, err := organizationAlbum(orgid, id)
+	if err != nil {
+		return album, err
+	}
+	canRead, err := galleryReader(r, orgid)
+	if err != nil || canRead {
+		return album, err
+	}
+	listings := map[string]models.Listing{}
+	if album.OwnerType.String == models.AlbumOwnerListing {
+		listing, err := organizationListing(orgid, album.OwnerID.String)
+		if err != nil && err != sql.ErrNoRows {
+			return album, err
+		}
+		if err == nil {
+			listings[listing.ID.String] = listing
+		}
+	}
+	if !publicAlbum(album, listings) {
+		return models.Album{}, sql.ErrNoRows
+	}
+	return album, nil
+}
+
+// albumImage - Returns an image of an organization album visible for the session user, with its data if requested.
+func albumImage(r *http.Request, orgid, id, imageID string, withData bool) (models.Image, error) {
+	_, err := visibleAlbum(r, orgid, id)
+	if err != nil {
+		return models.Image{}, err
+	}
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		return models.Image{}, err
+	}
+	if withData {
+		return albumRepo.GetImageData(imageID, id)
+	}
+	return albumRepo.GetImage(imageID, id)
+}
+
+// albumOwner - Checks album listing or building belongs to the organization.
+func albumOwner(orgid string, album *models.Album) error {
+	var err error
+	switch album.OwnerType.String {
+	case models.AlbumOwnerListing:
+		_, err = organizationListing(orgid, album.OwnerID.String)
+	case models.AlbumOwnerBuilding:
+		_, err = organizationBuilding(orgid, album.OwnerID.String)
+	case models.AlbumOwnerOrganization:
+		if album.OwnerID.String != orgid {
+			err = app.ErrEntityInvalidData
+		}
+	}
+	return err
+}
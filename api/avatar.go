@@ -25,10 +25,15 @@ package api
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"image"
 	_ "image/gif"  // Required by "image" library.
 	_ "image/jpeg" // Required by "image" library.
+	_ "image/png"  // Required by "image" library.
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -36,9 +41,12 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/gorilla/mux"
+
 	"github.com/adrianpk/fundacja/app"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/storage"
 )
 
 const (
@@ -54,6 +62,10 @@ const (
 	// If empty, only allow redirects to the referer protocol+host.
 	// Set to a regexp string for custom pattern matching:
 	redirectAllowTargetPattern = ""
+	// Base64 encoded upload and JSON envelope
+	profileImageMaxBody = models.ProfileImageMaxSize*4/3 + 4096
+	// Identicons never change
+	identiconMaxAge = 30 * 24 * 3600 // seconds
 )
 
 var (
@@ -64,6 +76,15 @@ var (
 
 // HandleAvatar - Handles avatar REST related functions
 func HandleAvatar(w http.ResponseWriter, r *http.Request) {
+	handleProfileImage(w, r, models.ProfileAvatarImage)
+}
+
+// HandleHeader - Handles profile header image REST related functions
+func HandleHeader(w http.ResponseWriter, r *http.Request) {
+	handleProfileImage(w, r, models.ProfileHeaderImage)
+}
+
+func handleProfileImage(w http.ResponseWriter, r *http.Request, name string) {
 	params, err := url.ParseQuery(r.URL.RawQuery)
 	if err != nil {
 		app.ShowError(w, app.ErrRequest, err, http.StatusInternalServerError)
@@ -76,60 +97,105 @@ func HandleAvatar(w http.ResponseWriter, r *http.Request) {
 	)
 	w.Header().Add(
 		"Access-Control-Allow-Headers",
-		"Content-Type, Content-Range, Content-Disposition",
+		"Content-Type, Content-Range, Content-Disposition, If-None-Match",
 	)
 	switch r.Method {
-	case "OPTIONS", "HEAD":
+	case "OPTIONS":
 		return
-	case "GET":
-		//get(w, r)
+	case "GET", "HEAD":
+		getProfileImage(w, r, name)
 	case "POST":
 		if len(params["_method"]) > 0 && params["_method"][0] == "DELETE" {
-			//delete(w, r)
+			deleteProfileImage(w, r, name)
 		} else {
-			post(w, r)
+			postProfileImage(w, r, name)
 		}
 	case "DELETE":
-		//delete(w, r)
+		deleteProfileImage(w, r, name)
 	default:
 		http.Error(w, "501 Not Implemented", http.StatusNotImplemented)
 	}
 }
 
-func post(w http.ResponseWriter, r *http.Request) {
-	result := make(map[string][]*models.Image, 1)
-	result["images"] = handleJSONUploads(w, r)
-	b, err := json.Marshal(result)
+// getProfileImage - Serves a rendition of a user avatar or header image,
+// users without avatar get an identicon.
+// Optional query value 'size' selects the rendition: large (default), medium or small.
+func getProfileImage(w http.ResponseWriter, r *http.Request, name string) {
+	// Get IDs
+	vars := mux.Vars(r)
+	key := vars["user"]
+	rendition := r.URL.Query().Get("size")
+	if rendition == "" {
+		rendition = models.ProfileImageLarge
+	}
+	if !isProfileImageRendition(name, rendition) {
+		app.ShowError(w, app.ErrRequestParsing, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Select
+	profile, err := profileByUserKey(key)
 	if err != nil {
-		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
 		return
 	}
-	if redirect := r.FormValue("redirect"); validateRedirect(r, redirect) {
-		if strings.Contains(redirect, "%s") {
-			redirect = fmt.Sprintf(
-				redirect,
-				escape(string(b)),
-			)
+	uri := profile.AvatarURI.String
+	if name == models.ProfileHeaderImage {
+		uri = profile.HeaderURI.String
+	}
+	if uri == "" || models.IsLegacyImageURI(uri) {
+		if name == models.ProfileHeaderImage {
+			app.ShowError(w, app.ErrEntityNotFound, storage.ErrBlobNotFound, http.StatusNotFound)
+			return
 		}
-		http.Redirect(w, r, redirect, http.StatusFound)
+		writeIdenticon(w, r, profile.ID.String, rendition)
 		return
 	}
-	w.Header().Set("Cache-Control", "no-cache")
-	jsonType := "application/json"
-	if strings.Index(r.Header.Get("Accept"), jsonType) != -1 {
-		w.Header().Set("Content-Type", jsonType)
+	blobKey := models.ProfileImageRenditionKey(uri, rendition)
+	// Blob keys are not reused, content is the same while key is
+	writeCacheHeaders(w, "private, no-cache", blobKey)
+	if notModified(w, r) {
+		return
+	}
+	store, err := storage.GetStore()
+	if err != nil {
+		app.ShowError(w, app.ErrFileStorage, err, http.StatusInternalServerError)
+		return
+	}
+	blob, err := store.Get(blobKey)
+	if err == storage.ErrBlobNotFound {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrFileStorage, err, http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+	// Respond
+	w.Header().Set("Content-Type", models.ImageRenditionType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != "HEAD" {
+		io.Copy(w, blob)
 	}
 }
 
-func handleJSONUploads(w http.ResponseWriter, r *http.Request) (fileInfos []*models.Image) {
-	fileInfos = make([]*models.Image, 0)
+// postProfileImage - Uploads session user avatar or header image.
+// Uploads are validated and turned into renditions, originals are not kept.
+func postProfileImage(w http.ResponseWriter, r *http.Request, name string) {
+	// Decode
 	var res AvatarResource
+	r.Body = http.MaxBytesReader(w, r.Body, profileImageMaxBody)
 	err := json.NewDecoder(r.Body).Decode(&res)
 	if err != nil {
-		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	data, err := decodeImageData(res.Data.Base64)
+	if err != nil {
+		app.ShowError(w, app.ErrImageDecoding, err, http.StatusBadRequest)
 		return
 	}
-	base64Data := res.Data.Base64
 	// Associated Profile
 	profile, err := profileFromSessionID(r)
 	if err != nil {
@@ -143,16 +209,145 @@ func handleJSONUploads(w http.ResponseWriter, r *http.Request) (fileInfos []*mod
 		return
 	}
 	// Persist
-
-	profile.SetAvatarAsBase64(base64Data)
-	profileRepo.SaveProfileAvatar(&profile)
+	if name == models.ProfileHeaderImage {
+		err = profileRepo.SaveProfileHeader(&profile, data)
+	} else {
+		err = profileRepo.SaveProfileAvatar(&profile, data)
+	}
+	if err == models.ErrImageTooBig {
+		app.ShowError(w, app.ErrFileSize, err, http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err == models.ErrImageTooSmall || err == image.ErrFormat {
+		app.ShowError(w, app.ErrImageDecoding, err, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
 		return
 	}
 	// Output
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteProfileImage - Deletes session user avatar or header image.
+func deleteProfileImage(w http.ResponseWriter, r *http.Request, name string) {
+	// Associated Profile
+	profile, err := profileFromSessionID(r)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	profileRepo, err := repo.MakeProfileRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	if name == models.ProfileHeaderImage {
+		err = profileRepo.DeleteProfileHeader(&profile)
+	} else {
+		err = profileRepo.DeleteProfileAvatar(&profile)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
-	return
+}
+
+// GetIdenticon - Serves the generated avatar of a profile.
+// Handler for HTTP Get - "/identicons/{profile}"
+// Optional query value 'size' selects the rendition: large (default), medium or small.
+func GetIdenticon(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rendition := r.URL.Query().Get("size")
+	if rendition == "" {
+		rendition = models.ProfileImageLarge
+	}
+	if !isProfileImageRendition(models.ProfileAvatarImage, rendition) {
+		app.ShowError(w, app.ErrRequestParsing, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	writeIdenticon(w, r, vars["profile"], rendition)
+}
+
+// writeIdenticon - Writes the identicon of a seed as response, it never changes.
+func writeIdenticon(w http.ResponseWriter, r *http.Request, seed, rendition string) {
+	writeCacheHeaders(w, fmt.Sprintf("public, max-age=%d", identiconMaxAge), "identicon/"+seed+"/"+rendition)
+	if notModified(w, r) {
+		return
+	}
+	png, err := models.Identicon(seed, models.ProfileImageSide(rendition))
+	if err != nil {
+		app.ShowError(w, app.ErrImageDecoding, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != "HEAD" {
+		w.Write(png)
+	}
+}
+
+// writeCacheHeaders - Sets Cache-Control and an ETag derived from the content identity.
+func writeCacheHeaders(w http.ResponseWriter, cacheControl, identity string) {
+	sum := sha256.Sum256([]byte(identity))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+}
+
+// notModified - Responds 304 if request ETag matches response one.
+func notModified(w http.ResponseWriter, r *http.Request) bool {
+	etag := w.Header().Get("ETag")
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimSpace(match)
+		if match == etag || match == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func isProfileImageRendition(name, rendition string) bool {
+	for _, renditionName := range models.ProfileImageRenditionNames(name) {
+		if renditionName == rendition {
+			return true
+		}
+	}
+	return false
+}
+
+// profileByUserKey - Returns the profile of a user referenced by its ID or username.
+func profileByUserKey(key string) (models.Profile, error) {
+	userID := key
+	if len(key) != 36 {
+		user, err := getUserByUsername(key)
+		if err != nil {
+			return models.Profile{}, err
+		}
+		userID = user.ID.String
+	}
+	profileRepo, err := repo.MakeProfileRepository()
+	if err != nil {
+		return models.Profile{}, err
+	}
+	return profileRepo.GetByUserID(userID)
+}
+
+// decodeImageData - Decodes base64 image data, data URL prefix is optional.
+func decodeImageData(data string) ([]byte, error) {
+	if strings.HasPrefix(data, "data:") {
+		comma := strings.Index(data, ",")
+		if comma < 0 {
+			return nil, image.ErrFormat
+		}
+		data = data[comma+1:]
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
 }
 
 // func storeAsFileOut(imageBin []byte) {
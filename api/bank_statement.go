@@ -0,0 +1,295 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetBankStatements - Returns a collection containing all bank statements from an organization.
+// Handler for HTTP Get - "/organizations/{organization}/bank/statements"
+func GetBankStatements(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	statements, err := statementRepo.GetAll(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BankStatementsResource{Data: statements})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// ImportBankStatement - Imports a CSV, MT940 or camt.053 bank statement.
+// Incoming transfers are matched against open invoices: unambiguous matches are posted
+// to the ledger, the rest are left with suggested matches for review.
+// Handler for HTTP Post - "/organizations/{organization}/bank/statements"
+func ImportBankStatement(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res BankStatementImportResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	// Parse
+	statement, err := res.Data.Parse()
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	statement.OrganizationID = models.ToNullsString(orgid)
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	u, _ := sessionUser(r)
+	err = statementRepo.Import(&statement, u.ID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BankStatementResource{Data: statement})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetBankStatement - Returns a single BankStatement and its transactions.
+// Handler for HTTP Get - "/organizations/{organization}/bank/statements/{statement}"
+func GetBankStatement(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["statement"]
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	statement, err := statementRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BankStatementResource{Data: statement})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetBankTransactions - Returns a collection containing bank transactions from an organization.
+// Handler for HTTP Get - "/organizations/{organization}/bank/transactions"
+// Optional query value 'status' restricts the collection to transactions having that status,
+// 'suggested' returns the review queue including suggested matches.
+func GetBankTransactions(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	status := r.URL.Query().Get("status")
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var transactions []models.BankTransaction
+	if status != "" {
+		transactions, err = statementRepo.GetTransactionsByStatus(orgid, status)
+	} else {
+		transactions, err = statementRepo.GetTransactions(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BankTransactionsResource{Data: transactions})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetBankTransaction - Returns a single BankTransaction and its suggested matches.
+// Handler for HTTP Get - "/organizations/{organization}/bank/transactions/{transaction}"
+func GetBankTransaction(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["transaction"]
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	transaction, err := statementRepo.GetTransaction(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BankTransactionResource{Data: transaction})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// MatchBankTransaction - Approves a match, posting the transaction as a payment of the invoice.
+// Handler for HTTP Post - "/organizations/{organization}/bank/transactions/{transaction}/match"
+func MatchBankTransaction(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["transaction"]
+	// Decode
+	var res BankMatchResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	u, _ := sessionUser(r)
+	transaction, err := statementRepo.Match(id, orgid, res.Data.InvoiceID.String, u.ID)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusConflict)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BankTransactionResource{Data: transaction})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// IgnoreBankTransaction - Dismisses a transaction not related to any invoice.
+// Handler for HTTP Post - "/organizations/{organization}/bank/transactions/{transaction}/ignore"
+func IgnoreBankTransaction(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["transaction"]
+	// Get repo
+	statementRepo, err := repo.MakeBankStatementRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = statementRepo.Ignore(id, orgid)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusConflict)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/storage"
+)
+
+const (
+	// Generated avatars are served under this path
+	identiconsPath = "/identicons"
+)
+
+// GetBlob - Serves a blob of the local store through a signed URL.
+// Handler for HTTP Get - "/blobs/{key}"
+// Blob keys don't change their content, responses are cacheable until the URL expires.
+func GetBlob(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	key := vars["key"]
+	expires := r.URL.Query().Get("expires")
+	// Get store
+	store, err := storage.GetStore()
+	if err != nil {
+		app.ShowError(w, app.ErrFileStorage, err, http.StatusInternalServerError)
+		return
+	}
+	// Other drivers sign their own URLs
+	local, ok := store.(*storage.LocalStore)
+	if !ok {
+		app.ShowError(w, app.ErrEntityNotFound, storage.ErrBlobNotFound, http.StatusNotFound)
+		return
+	}
+	// Check signature
+	err = local.VerifySignature(key, expires, r.URL.Query().Get("signature"))
+	if err != nil {
+		app.ShowError(w, app.ErrUnauthorized, err, http.StatusForbidden)
+		return
+	}
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	unix, _ := strconv.ParseInt(expires, 10, 64)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", unix-time.Now().Unix()))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	// Select
+	file, err := local.Get(key)
+	if err == storage.ErrBlobNotFound {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrFileStorage, err, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	sniff, _ := reader.Peek(512)
+	// Respond
+	w.Header().Set("Content-Type", http.DetectContentType(sniff))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != "HEAD" {
+		io.Copy(w, reader)
+	}
+}
+
+// signProfileImages - Sets profile avatar and header renditions signed URLs,
+// profiles without avatar get their identicon URLs.
+func signProfileImages(profile *models.Profile) {
+	store, err := storage.GetStore()
+	if err != nil {
+		logger.Debugf("Profile images not signed: %s", err.Error())
+		return
+	}
+	profile.AvatarURLs = signedRenditionURLs(store, models.ProfileAvatarImage, profile.AvatarURI.String)
+	if len(profile.AvatarURLs) == 0 {
+		profile.AvatarURLs = map[string]string{}
+		for _, rendition := range models.ProfileImageRenditionNames(models.ProfileAvatarImage) {
+			profile.AvatarURLs[rendition] = fmt.Sprintf("%s/%s?size=%s", identiconsPath, profile.ID.String, rendition)
+		}
+	}
+	profile.AvatarURL = profile.AvatarURLs[models.ProfileImageLarge]
+	profile.HeaderURLs = signedRenditionURLs(store, models.ProfileHeaderImage, profile.HeaderURI.String)
+	profile.HeaderURL = profile.HeaderURLs[models.ProfileImageLarge]
+}
+
+// signedRenditionURLs - Returns signed URLs of the renditions of a profile image by name,
+// empty for missing or legacy keys.
+func signedRenditionURLs(store storage.BlobStore, image, key string) map[string]string {
+	urls := map[string]string{}
+	if key == "" || models.IsLegacyImageURI(key) {
+		return urls
+	}
+	for _, rendition := range models.ProfileImageRenditionNames(image) {
+		url, err := store.SignedURL(models.ProfileImageRenditionKey(key, rendition), storage.URLExpiration())
+		if err != nil {
+			logger.Debugf("Blob %s not signed: %s", key, err.Error())
+			continue
+		}
+		urls[rendition] = url
+	}
+	return urls
+}
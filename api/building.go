@@ -0,0 +1,416 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetBuildings - Returns a collection containing all buildings from an organization.
+// Handler for HTTP Get - "/organizations/{organization}/buildings"
+func GetBuildings(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	buildings, err := buildingRepo.GetAll(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Restrict to visible buildings
+	visible, err := visibleIDs(r, models.BuildingResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if visible != nil {
+		filtered := []models.Building{}
+		for _, building := range buildings {
+			if visible[building.ID.String] {
+				filtered = append(filtered, building)
+			}
+		}
+		buildings = filtered
+	}
+	// Marshal
+	j, err := json.Marshal(BuildingsResource{Data: buildings})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateBuilding - Creates a new Building.
+// Handler for HTTP Post - "/organizations/{organization}/buildings"
+func CreateBuilding(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res BuildingResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	building := &res.Data
+	// Set Organization - Don't trust JSON value
+	building.OrganizationID = models.ToNullsString(orgid)
+	// Set values
+	u, _ := sessionUser(r)
+	building.CreatedBy = u.ID
+	// Validate
+	if !building.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = buildingRepo.Create(building)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BuildingResource{Data: *building})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetBuilding - Returns a single Building by its id or name.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}"
+func GetBuilding(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	key := vars["building"]
+	if len(key) == 36 {
+		GetBuildingByID(w, r)
+	} else {
+		GetBuildingByName(w, r)
+	}
+}
+
+// GetBuildingByID - Returns a single Building by its id.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}"
+func GetBuildingByID(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["building"]
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	building, err := buildingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BuildingResource{Data: building})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Repsond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetBuildingByName - Returns a single Building by its name.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}"
+func GetBuildingByName(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	name := vars["building"]
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	building, err := buildingRepo.GetByNameInOrganization(name, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BuildingResource{Data: building})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Repond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateBuilding - Update an existing Building.
+// Handler for HTTP Put - "/organizations/{organization}/buildings/{building}"
+func UpdateBuilding(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["building"]
+	// Decode
+	var res BuildingResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	building := &res.Data
+	building.ID = models.ToNullsString(id)
+	building.OrganizationID = models.ToNullsString(orgid)
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current building
+	currentBuilding, err := buildingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(building.IdentifiableModel, currentBuilding.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Validate
+	if !building.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = buildingRepo.Update(building)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BuildingResource{Data: *building})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteBuilding - Deletes an existing Building
+// Handler for HTTP Delete - "/organizations/{organization}/buildings/{building}"
+func DeleteBuilding(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["building"]
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = buildingRepo.DeleteFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBuildingPropertiesSets - Returns the properties sets holding the attributes of a Building.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}/properties-sets"
+func GetBuildingPropertiesSets(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["building"]
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check building belongs to organization
+	_, err = buildingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	propSets, err := buildingRepo.GetPropertiesSets(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetsResource{Data: propSets})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateBuildingPropertiesSet - Attaches a new properties set to a Building.
+// Handler for HTTP Post - "/organizations/{organization}/buildings/{building}/properties-sets"
+func CreateBuildingPropertiesSet(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["building"]
+	// Decode
+	var res PropertiesSetResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	propertiesSet := &res.Data
+	// Get repos
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	propertiesSetRepo, err := repo.MakePropertiesSetRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check building belongs to organization
+	building, err := buildingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Set holder - Don't trust JSON value
+	propertiesSet.HolderID = building.ID
+	u, _ := sessionUser(r)
+	propertiesSet.CreatedBy = u.ID
+	// Persist
+	err = propertiesSetRepo.Create(propertiesSet)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetResource{Data: *propertiesSet})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetBuildingOccupancy - Returns aggregated units occupancy of a Building.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}/occupancy"
+func GetBuildingOccupancy(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["building"]
+	// Get repo
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check building belongs to organization
+	_, err = buildingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	occupancy, err := buildingRepo.GetOccupancy(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(BuildingOccupancyResource{Data: occupancy})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
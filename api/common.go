@@ -34,6 +34,7 @@ import (
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/services"
 
 	"github.com/twinj/uuid"
 )
@@ -55,6 +56,27 @@ func loggedInUserID(r *http.Request) string {
 	return userID
 }
 
+// visibleIDs - Set of the IDs of the entities of some type the session user can read in an organization.
+// Returns a nil set if every entity is visible.
+func visibleIDs(r *http.Request, entityType, orgID string) (map[string]bool, error) {
+	ids, all, err := services.VisibleEntityIDs(loggedInUserID(r), entityType, orgID)
+	if err != nil || all {
+		return nil, err
+	}
+	visible := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		visible[id] = true
+	}
+	return visible, nil
+}
+
+// sessionCan - True if the session user can perform the action the request method maps to
+// over the resource tagged resourceTag in the organization, as the Allow middleware decides it.
+// Handlers behind AllowParty use it to tell managers apart from the other parties.
+func sessionCan(r *http.Request, resourceTag, orgID string) (bool, error) {
+	return services.Can(loggedInUserID(r), services.MethodAction(r.Method), resourceTag, orgID)
+}
+
 func sessionUserID(r *http.Request) (string, error) {
 	claims, ok := r.Context().Value(bootstrap.UserCtxKey).(bootstrap.AppClaims)
 	if ok {
@@ -0,0 +1,730 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/storage"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+const (
+	// Memory used parsing uploads, bigger ones go to temporary files
+	documentUploadMemory = 8 << 20
+	// Room for form values besides the file
+	documentFormOverhead = 1 << 20
+)
+
+// GetDocuments - Returns the documents of an organization visible for the user.
+// Handler for HTTP Get - "/organizations/{organization}/documents"
+// Optional query values 'holder-type' and 'holder-id' restrict the collection to the documents of a lease or unit.
+// Managers get every document, tenants get the documents of their leases and units.
+func GetDocuments(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	holderType := r.URL.Query().Get("holder-type")
+	holderID := r.URL.Query().Get("holder-id")
+	// Check access
+	var allowed bool
+	var err error
+	if holderType != "" && holderID != "" {
+		allowed, err = documentReader(r, orgid, holderType, holderID)
+	} else {
+		allowed, err = sessionCan(r, models.DocumentResourceTag, orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var documents []models.Document
+	if holderType != "" && holderID != "" {
+		documents, err = documentRepo.GetAllByHolder(orgid, holderType, holderID)
+	} else {
+		documents, err = documentRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(DocumentsResource{Data: documents})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateDocument - Uploads a document attached to a lease, a unit or the organization itself.
+// Handler for HTTP Post - "/organizations/{organization}/documents"
+// Multipart form with a 'file' part and 'name', 'description', 'kind', 'holder-type', 'holder-id',
+// 'retain-until' (unix time) and 'legal-hold' values.
+func CreateDocument(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	u, _ := sessionUser(r)
+	// Check manager
+	isManager, err := sessionCan(r, models.DocumentResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	if !isManager {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Decode
+	file, err := documentUpload(w, r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	document := &models.Document{
+		Kind:       models.ToNullsString(r.FormValue("kind")),
+		HolderType: models.ToNullsString(r.FormValue("holder-type")),
+		HolderID:   models.ToNullsString(r.FormValue("holder-id")),
+	}
+	document.Name = models.ToNullsString(r.FormValue("name"))
+	document.Description = models.ToNullsString(r.FormValue("description"))
+	err = setDocumentRetention(document, r.FormValue("retain-until"), r.FormValue("legal-hold"))
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	// Set values - Don't trust form value
+	document.OrganizationID = models.ToNullsString(orgid)
+	document.CreatedBy = u.ID
+	if document.Name.String == "" {
+		document.Name = models.ToNullsString(file.Filename)
+	}
+	document.SetDefaults()
+	// Validate
+	if !document.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	err = documentHolder(orgid, document)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+		return
+	}
+	// Store file
+	document.SetID()
+	version := &models.DocumentVersion{UserID: u.ID}
+	version.SetID()
+	status, err := storeDocumentFile(orgid, document, version, file)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, status)
+		return
+	}
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		discardDocumentFile(version)
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = documentRepo.Create(document, version)
+	if err != nil {
+		discardDocumentFile(version)
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(DocumentResource{Data: *document})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetDocument - Returns a single document by its id.
+// Handler for HTTP Get - "/organizations/{organization}/documents/{document}"
+func GetDocument(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	// Select
+	document, err := visibleDocument(r, orgid, id)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(DocumentResource{Data: document})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateDocument - Update name, description, kind and retention of a document.
+// Handler for HTTP Put - "/organizations/{organization}/documents/{document}"
+// Retention period can be extended but not shortened.
+func UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	// Decode
+	var res DocumentResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	document := &res.Data
+	document.ID = models.ToNullsString(id)
+	// Check manager
+	isManager, err := sessionCan(r, models.DocumentResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	if !isManager {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current document
+	current, err := documentRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(document.IdentifiableModel, current.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Holder and file are not changed here
+	document.HolderType = current.HolderType
+	document.HolderID = current.HolderID
+	document.OrganizationID = current.OrganizationID
+	if document.Kind.String == "" {
+		document.Kind = current.Kind
+	}
+	if !document.RetainUntil.Valid {
+		document.RetainUntil = current.RetainUntil
+	}
+	if !document.LegalHold.Valid {
+		document.LegalHold = current.LegalHold
+	}
+	// Validate
+	if !document.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = documentRepo.Update(document)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(DocumentResource{Data: *document})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteDocument - Deletes a document and the files of all its versions.
+// Handler for HTTP Delete - "/organizations/{organization}/documents/{document}"
+// Documents under legal hold or retention period cannot be deleted.
+func DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	// Check manager
+	isManager, err := sessionCan(r, models.DocumentResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	if !isManager {
+		app.ShowError(w, app.ErrEntityDelete, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	keys, err := documentRepo.Delete(id, orgid)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityDelete, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Files are removed once rows are gone, leftovers are only logged
+	store, err := storage.GetStore()
+	if err != nil {
+		logger.Debugf("Document %s files not deleted: %s", id, err.Error())
+	} else {
+		for _, key := range keys {
+			err = store.Delete(key)
+			if err != nil {
+				logger.Debugf("Document file %s not deleted: %s", key, err.Error())
+			}
+		}
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DownloadDocument - Returns the file of the current version of a document.
+// Handler for HTTP Get - "/organizations/{organization}/documents/{document}/download"
+func DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	// Select
+	document, err := visibleDocument(r, orgid, id)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	writeDocumentVersion(w, r, id, document.Version.Int64)
+}
+
+// GetDocumentVersions - Returns the versions of a document, latest first.
+// Handler for HTTP Get - "/organizations/{organization}/documents/{document}/versions"
+func GetDocumentVersions(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	// Check document
+	_, err := visibleDocument(r, orgid, id)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	versions, err := documentRepo.GetVersions(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(DocumentVersionsResource{Data: versions})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// AddDocumentVersion - Uploads a new version of a document, which becomes the current one.
+// Handler for HTTP Post - "/organizations/{organization}/documents/{document}/versions"
+// Multipart form with a 'file' part.
+func AddDocumentVersion(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	userID, _ := sessionUserID(r)
+	// Check manager
+	isManager, err := sessionCan(r, models.DocumentResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	if !isManager {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	document, err := documentRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Decode
+	file, err := documentUpload(w, r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	// Store file
+	version := &models.DocumentVersion{UserID: models.ToNullsString(userID)}
+	version.SetID()
+	status, err := storeDocumentFile(orgid, &document, version, file)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, status)
+		return
+	}
+	// Persist
+	err = documentRepo.AddVersion(&document, version)
+	if err != nil {
+		discardDocumentFile(version)
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(DocumentVersionResource{Data: *version})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// DownloadDocumentVersion - Returns the file of a version of a document.
+// Handler for HTTP Get - "/organizations/{organization}/documents/{document}/versions/{version}/download"
+func DownloadDocumentVersion(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["document"]
+	number, err := strconv.ParseInt(vars["version"], 10, 64)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	// Check document
+	_, err = visibleDocument(r, orgid, id)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	writeDocumentVersion(w, r, id, number)
+}
+
+// documentReader - Returns true if the session user can read the documents of a holder,
+// managers read every document, tenants the ones of their leases and units.
+func documentReader(r *http.Request, orgid, holderType, holderID string) (bool, error) {
+	userID := loggedInUserID(r)
+	isManager, err := sessionCan(r, models.DocumentResourceTag, orgid)
+	if err != nil || isManager {
+		return isManager, err
+	}
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		return false, err
+	}
+	switch holderType {
+	case models.DocumentHolderLease:
+		_, err = leaseRepo.GetFromOrganization(holderID, orgid)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return leaseRepo.IsLeaseTenant(holderID, userID)
+	case models.DocumentHolderUnit:
+		_, err = organizationUnit(orgid, holderID)
+		if err != nil {
+			return false, nil
+		}
+		return leaseRepo.IsUnitTenant(holderID, userID)
+	}
+	return false, nil
+}
+
+// visibleDocument - Returns an organization document if user can read it.
+func visibleDocument(r *http.Request, orgid, id string) (models.Document, error) {
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		return models.Document{}, err
+	}
+	document, err := documentRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		return document, err
+	}
+	allowed, err := documentReader(r, orgid, document.HolderType.String, document.HolderID.String)
+	if err != nil {
+		return models.Document{}, err
+	}
+	if !allowed {
+		return models.Document{}, app.ErrUnauthorized
+	}
+	return document, nil
+}
+
+// documentHolder - Checks document lease or unit belongs to the organization.
+func documentHolder(orgid string, document *models.Document) error {
+	var err error
+	switch document.HolderType.String {
+	case models.DocumentHolderLease:
+		_, err = organizationLease(orgid, document.HolderID.String)
+	case models.DocumentHolderUnit:
+		_, err = organizationUnit(orgid, document.HolderID.String)
+	case models.DocumentHolderOrganization:
+		if document.HolderID.String != orgid {
+			err = app.ErrEntityInvalidData
+		}
+	}
+	return err
+}
+
+// setDocumentRetention - Sets document retention from form values.
+func setDocumentRetention(document *models.Document, retainUntil, legalHold string) error {
+	if retainUntil != "" {
+		unix, err := strconv.ParseInt(retainUntil, 10, 64)
+		if err != nil {
+			return err
+		}
+		document.RetainUntil = models.ToNullsTime(time.Unix(unix, 0))
+	}
+	if legalHold != "" {
+		hold, err := strconv.ParseBool(legalHold)
+		if err != nil {
+			return err
+		}
+		document.LegalHold = models.ToNullsBool(hold)
+	}
+	return nil
+}
+
+// documentUpload - Parses a document upload returning its file part.
+func documentUpload(w http.ResponseWriter, r *http.Request) (*multipart.FileHeader, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, models.DocumentMaxSize+documentFormOverhead)
+	err := r.ParseMultipartForm(documentUploadMemory)
+	if err != nil {
+		return nil, err
+	}
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		r.MultipartForm.RemoveAll()
+		return nil, app.ErrEntityInvalidData
+	}
+	return files[0], nil
+}
+
+// storeDocumentFile - Checks the type of an uploaded file and stores it as a document version,
+// setting version file values. Returns the response status if it fails.
+func storeDocumentFile(orgid string, document *models.Document, version *models.DocumentVersion, header *multipart.FileHeader) (int, error) {
+	file, err := header.Open()
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	defer file.Close()
+	// Content type is sniffed, not taken from upload
+	reader := bufio.NewReaderSize(file, models.DocumentSniffLen)
+	sniff, err := reader.Peek(models.DocumentSniffLen)
+	if err != nil && err != io.EOF {
+		return http.StatusBadRequest, err
+	}
+	contentType, ok := models.SniffDocumentType(sniff)
+	if !ok {
+		return http.StatusUnsupportedMediaType, app.ErrFileType
+	}
+	store, err := storage.GetStore()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	key := models.DocumentStorageKey(orgid, document.ID.String, version.ID.String)
+	hash := sha256.New()
+	size, err := store.Put(key, io.TeeReader(io.LimitReader(reader, models.DocumentMaxSize+1), hash))
+	if err != nil {
+		return http.StatusInternalServerError, app.ErrFileStorage
+	}
+	if size > models.DocumentMaxSize {
+		store.Delete(key)
+		return http.StatusRequestEntityTooLarge, app.ErrFileSize
+	}
+	version.FileName = models.ToNullsString(header.Filename)
+	version.ContentType = models.ToNullsString(contentType)
+	version.Size = models.ToNullsInt64(size)
+	version.Checksum = models.ToNullsString(hex.EncodeToString(hash.Sum(nil)))
+	version.StorageKey = models.ToNullsString(key)
+	return http.StatusCreated, nil
+}
+
+// discardDocumentFile - Removes a stored file whose version could not be persisted.
+func discardDocumentFile(version *models.DocumentVersion) {
+	store, err := storage.GetStore()
+	if err == nil {
+		err = store.Delete(version.StorageKey.String)
+	}
+	if err != nil {
+		logger.Debugf("Document file %s not deleted: %s", version.StorageKey.String, err.Error())
+	}
+}
+
+// writeDocumentVersion - Writes the file of a document version as response.
+func writeDocumentVersion(w http.ResponseWriter, r *http.Request, id string, number int64) {
+	// Get repo
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	version, err := documentRepo.GetVersion(id, number)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	etag := `"` + version.Checksum.String + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	store, err := storage.GetStore()
+	if err != nil {
+		app.ShowError(w, app.ErrFileStorage, err, http.StatusInternalServerError)
+		return
+	}
+	file, err := store.Get(version.StorageKey.String)
+	if err != nil {
+		app.ShowError(w, app.ErrFileStorage, err, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	// Respond
+	w.Header().Set("Content-Type", version.ContentType.String)
+	w.Header().Set("Content-Length", strconv.FormatInt(version.Size.Int64, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": version.FileName.String}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+)
+
+const facetConditionPrefix = "prop."
+
+// facetQueryFromURL - Builds a facet query from request query values.
+// prop.rooms=3..          rooms >= 3
+// prop.area=50..80        50 <= area <= 80
+// prop.rooms=2,3          rooms is 2 or 3
+// prop.balcony=true       balcony is true
+// prop.heating=gas,none   heating is 'gas' or 'none'
+// facets=rooms,area:25    counts per rooms value and per 25 units area bucket
+func facetQueryFromURL(r *http.Request) (models.FacetQuery, error) {
+	query := models.FacetQuery{}
+	values := r.URL.Query()
+	// Conditions
+	names := []string{}
+	for key := range values {
+		if strings.HasPrefix(key, facetConditionPrefix) {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		condition, err := parseFacetCondition(strings.TrimPrefix(key, facetConditionPrefix), values.Get(key))
+		if err != nil {
+			return query, err
+		}
+		query.Conditions = append(query.Conditions, condition)
+	}
+	// Facets
+	if facets := values.Get("facets"); facets != "" {
+		for _, facet := range strings.Split(facets, ",") {
+			request, err := parseFacetRequest(facet)
+			if err != nil {
+				return query, err
+			}
+			query.Facets = append(query.Facets, request)
+		}
+	}
+	return query, nil
+}
+
+func parseFacetCondition(name, value string) (models.FacetCondition, error) {
+	condition := models.FacetCondition{Name: name}
+	value = strings.TrimSpace(value)
+	if name == "" || value == "" {
+		return condition, app.ErrRequestFacetQuery
+	}
+	// Range
+	if bounds := strings.SplitN(value, "..", 2); len(bounds) == 2 {
+		var err error
+		condition.Kind = models.FacetConditionRange
+		if condition.Min, err = parseNullFloat(strings.TrimSpace(bounds[0])); err != nil {
+			return condition, app.ErrRequestFacetQuery
+		}
+		if condition.Max, err = parseNullFloat(strings.TrimSpace(bounds[1])); err != nil {
+			return condition, app.ErrRequestFacetQuery
+		}
+		if !condition.Min.Valid && !condition.Max.Valid {
+			return condition, app.ErrRequestFacetQuery
+		}
+		return condition, nil
+	}
+	// Boolean
+	if value == "true" || value == "false" {
+		condition.Kind = models.FacetConditionBoolean
+		condition.Values = []string{value}
+		return condition, nil
+	}
+	// Number or string values
+	condition.Kind = models.FacetConditionNumber
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			condition.Kind = models.FacetConditionString
+		}
+		condition.Values = append(condition.Values, v)
+	}
+	return condition, nil
+}
+
+func parseFacetRequest(value string) (models.FacetRequest, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), ":", 2)
+	request := models.FacetRequest{Name: parts[0]}
+	if request.Name == "" {
+		return request, app.ErrRequestFacetQuery
+	}
+	if len(parts) == 2 {
+		width, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || width <= 0 {
+			return request, app.ErrRequestFacetQuery
+		}
+		request.Width = width
+	}
+	return request, nil
+}
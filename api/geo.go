@@ -0,0 +1,93 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/types"
+)
+
+// geoFilterFromURL - Builds a spatial filter from request query values.
+// near=lat,lng&radius=meters
+// bbox=minLng,minLat,maxLng,maxLat
+func geoFilterFromURL(r *http.Request) (types.GeoFilter, error) {
+	filter := types.GeoFilter{}
+	query := r.URL.Query()
+	// Near
+	if near := query.Get("near"); near != "" {
+		coords, err := parseCoordinates(near, 2)
+		if err != nil {
+			return filter, err
+		}
+		point := types.Point{Lat: coords[0], Lng: coords[1]}
+		if !point.IsValid() {
+			return filter, app.ErrRequestGeoFilter
+		}
+		filter.Near = types.NullPoint{Point: point, Valid: true}
+	}
+	// Radius
+	if radius := query.Get("radius"); radius != "" {
+		if !filter.Near.Valid {
+			return filter, app.ErrRequestGeoFilter
+		}
+		meters, err := strconv.ParseFloat(radius, 64)
+		if err != nil || meters <= 0 {
+			return filter, app.ErrRequestGeoFilter
+		}
+		filter.Radius = meters
+	}
+	// Bounding box
+	if bbox := query.Get("bbox"); bbox != "" {
+		coords, err := parseCoordinates(bbox, 4)
+		if err != nil {
+			return filter, err
+		}
+		filter.BBox = types.BBox{MinLng: coords[0], MinLat: coords[1], MaxLng: coords[2], MaxLat: coords[3]}
+		if !filter.BBox.IsValid() {
+			return filter, app.ErrRequestGeoFilter
+		}
+		filter.BBoxValid = true
+	}
+	return filter, nil
+}
+
+func parseCoordinates(value string, count int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != count {
+		return nil, app.ErrRequestGeoFilter
+	}
+	coords := make([]float64, count)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, app.ErrRequestGeoFilter
+		}
+		coords[i] = v
+	}
+	return coords, nil
+}
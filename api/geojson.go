@@ -0,0 +1,60 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/adrianpk/fundacja/types"
+)
+
+// isGeoJSONRequest - True if client asked for GeoJSON output
+// either by '?format=geojson' or 'Accept: application/geo+json'.
+func isGeoJSONRequest(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "geojson" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), types.GeoJSONMediaType)
+}
+
+// marshalFeatureCollection - Marshals a collection of entities as a GeoJSON FeatureCollection.
+// geometryKeys are the entity JSON fields that can hold its geometry.
+func marshalFeatureCollection(entities interface{}, geometryKeys ...string) ([]byte, error) {
+	j, err := json.Marshal(entities)
+	if err != nil {
+		return nil, err
+	}
+	objects := []map[string]json.RawMessage{}
+	err = json.Unmarshal(j, &objects)
+	if err != nil {
+		return nil, err
+	}
+	fc := types.MakeFeatureCollection()
+	for _, object := range objects {
+		fc.AddFeature(object, geometryKeys...)
+	}
+	return json.Marshal(fc)
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetInvoices - Returns a collection containing all invoices from an organization.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/invoices"
+// Optional query value 'status' restricts the collection to invoices having that status.
+func GetInvoices(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	status := r.URL.Query().Get("status")
+	// Get repo
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var invoices []models.Invoice
+	if status != "" {
+		invoices, err = invoiceRepo.GetAllByStatus(orgid, status)
+	} else {
+		invoices, err = invoiceRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(InvoicesResource{Data: invoices})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetInvoice - Returns a single Invoice by its id.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/invoices/{invoice}"
+func GetInvoice(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["invoice"]
+	// Get repo
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	invoice, err := invoiceRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Check role
+	canRead, err := invoiceReader(r, orgid, invoice)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(InvoiceResource{Data: invoice})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetLeaseInvoices - Returns a collection containing all invoices of a lease.
+// Handler for HTTP Get - "/organizations/{organization}/leases/{lease}/invoices"
+func GetLeaseInvoices(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	// Check lease belongs to organization
+	_, err := organizationLease(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Check role
+	canRead, err := leaseReader(r, orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Get repo
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	invoices, err := invoiceRepo.GetAllFromLease(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(InvoicesResource{Data: invoices})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GenerateLeaseInvoices - Issues rent and late fee invoices of a lease due until a date.
+// Handler for HTTP Post - "/organizations/{organization}/leases/{lease}/invoices?until=1489536000"
+// Date defaults to current time.
+func GenerateLeaseInvoices(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	until := time.Now()
+	if v := r.URL.Query().Get("until"); v != "" {
+		secs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+			return
+		}
+		until = time.Unix(secs, 0)
+	}
+	// Check lease belongs to organization
+	lease, err := organizationLease(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Draft leases are not invoiced
+	if lease.Status.String == models.LeaseStatusDraft {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityStatusChange, http.StatusConflict)
+		return
+	}
+	// Get repo
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	u, _ := sessionUser(r)
+	invoices, err := invoiceRepo.Generate(&lease, until, u.ID)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(InvoicesResource{Data: invoices})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// PayInvoice - Posts a full or partial payment of an Invoice.
+// Handler for HTTP Post - "/organizations/{organization}/ledger/invoices/{invoice}/payments"
+func PayInvoice(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["invoice"]
+	// Decode
+	var res PaymentResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check invoice belongs to organization
+	_, err = invoiceRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Persist
+	u, _ := sessionUser(r)
+	entry, err := invoiceRepo.Pay(id, orgid, res.Data, u.ID)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(JournalEntryResource{Data: entry})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// organizationLease - Returns the lease if it belongs to the organization.
+func organizationLease(orgid, id string) (models.Lease, error) {
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		return models.Lease{}, err
+	}
+	return leaseRepo.GetFromOrganization(id, orgid)
+}
+
+// invoiceReader - Returns true if the session user can read the organization ledger or rents under the invoiced lease.
+func invoiceReader(r *http.Request, orgid string, invoice models.Invoice) (bool, error) {
+	canRead, err := sessionCan(r, models.LedgerResourceTag, orgid)
+	if err != nil || canRead {
+		return canRead, err
+	}
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		return false, err
+	}
+	return leaseRepo.IsLeaseTenant(invoice.LeaseID.String, loggedInUserID(r))
+}
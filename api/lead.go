@@ -0,0 +1,909 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/markbates/pop/nulls"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetLeadStages - Returns the pipeline stages of an organization.
+// Handler for HTTP Get - "/organizations/{organization}/crm/stages"
+func GetLeadStages(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	userID, _ := sessionUserID(r)
+	// Check member
+	err := crmMember(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	stages, err := leadRepo.GetStages(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadStagesResource{Data: stages})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateLeadStage - Adds a stage to the organization pipeline.
+// Handler for HTTP Post - "/organizations/{organization}/crm/stages"
+// Only the organization owner can configure the pipeline, stages without position are appended.
+func CreateLeadStage(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res LeadStageResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	stage := &res.Data
+	// Set values - Don't trust JSON value
+	u, _ := sessionUser(r)
+	stage.OrganizationID = models.ToNullsString(orgid)
+	stage.CreatedBy = u.ID
+	stage.SetDefaults()
+	// Check owner
+	err = crmOwner(orgid, u.ID.String)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Validate
+	if !stage.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = leadRepo.CreateStage(stage)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadStageResource{Data: *stage})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// UpdateLeadStage - Renames, reorders or changes the outcome of a pipeline stage.
+// Handler for HTTP Put - "/organizations/{organization}/crm/stages/{stage}"
+func UpdateLeadStage(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["stage"]
+	userID, _ := sessionUserID(r)
+	// Decode
+	var res LeadStageResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	stage := &res.Data
+	stage.ID = models.ToNullsString(id)
+	stage.OrganizationID = models.ToNullsString(orgid)
+	// Check owner
+	err = crmOwner(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current stage
+	current, err := leadRepo.GetStage(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(stage.IdentifiableModel, current.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Keep values not provided
+	if !stage.Position.Valid {
+		stage.Position = current.Position
+	}
+	if stage.Outcome.String == "" {
+		stage.Outcome = current.Outcome
+	}
+	// Validate
+	if !stage.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = leadRepo.UpdateStage(stage)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadStageResource{Data: *stage})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteLeadStage - Removes a stage from the organization pipeline.
+// Handler for HTTP Delete - "/organizations/{organization}/crm/stages/{stage}"
+// Stages with leads cannot be removed.
+func DeleteLeadStage(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["stage"]
+	userID, _ := sessionUserID(r)
+	// Check owner
+	err := crmOwner(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = leadRepo.DeleteStage(id, orgid)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityDelete, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLeads - Returns the leads of an organization.
+// Handler for HTTP Get - "/organizations/{organization}/crm/leads"
+// Optional query values 'stage' or 'assignee' filter the collection.
+func GetLeads(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	stageID := r.URL.Query().Get("stage")
+	assigneeID := r.URL.Query().Get("assignee")
+	userID, _ := sessionUserID(r)
+	// Check member
+	err := crmMember(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var leads []models.Lead
+	switch {
+	case stageID != "":
+		leads, err = leadRepo.GetAllByStage(orgid, stageID)
+	case assigneeID != "":
+		leads, err = leadRepo.GetAllByAssignee(orgid, assigneeID)
+	default:
+		leads, err = leadRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadsResource{Data: leads})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateLead - Registers an inquiry in the organization pipeline.
+// Handler for HTTP Post - "/organizations/{organization}/crm/leads"
+// Leads without stage enter the pipeline at its first stage.
+func CreateLead(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res LeadResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	lead := &res.Data
+	// Set values - Don't trust JSON value
+	u, _ := sessionUser(r)
+	lead.OrganizationID = models.ToNullsString(orgid)
+	lead.CreatedBy = u.ID
+	lead.SetDefaults()
+	// Check member
+	err = crmMember(orgid, u.ID.String)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Validate
+	if !lead.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	errs, err := checkLeadReferences(orgid, lead)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = leadRepo.Create(lead)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadResource{Data: *lead})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetLead - Returns a single lead by its id.
+// Handler for HTTP Get - "/organizations/{organization}/crm/leads/{lead}"
+func GetLead(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	userID, _ := sessionUserID(r)
+	// Select
+	lead, err := organizationLead(orgid, id, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadResource{Data: lead})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateLead - Update an existing lead.
+// Handler for HTTP Put - "/organizations/{organization}/crm/leads/{lead}"
+// Stage is not changed here, leads are moved between stages through their move action.
+func UpdateLead(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	u, _ := sessionUser(r)
+	// Decode
+	var res LeadResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	lead := &res.Data
+	lead.ID = models.ToNullsString(id)
+	lead.OrganizationID = models.ToNullsString(orgid)
+	// Check against current lead
+	current, err := organizationLead(orgid, id, u.ID.String)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(lead.IdentifiableModel, current.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	if lead.Source.String == "" {
+		lead.Source = current.Source
+	}
+	// Validate
+	if !lead.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	errs, err := checkLeadReferences(orgid, lead)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Update
+	err = leadRepo.Update(lead, u.ID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadResource{Data: *lead})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteLead - Deletes an existing lead and its history.
+// Handler for HTTP Delete - "/organizations/{organization}/crm/leads/{lead}"
+func DeleteLead(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	userID, _ := sessionUserID(r)
+	// Check member
+	err := crmMember(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = leadRepo.DeleteFromOrganization(id, orgid)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveLead - Moves a lead to another stage of the pipeline, recording the change in its history.
+// Handler for HTTP Post - "/organizations/{organization}/crm/leads/{lead}/move"
+// Request data carries the target stage as 'toStageID' and an optional note as 'body'.
+func MoveLead(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	userID, _ := sessionUserID(r)
+	// Decode
+	var res LeadActivityResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	lead, err := organizationLead(orgid, id, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	activity, err := leadRepo.Move(&lead, res.Data.ToStageID.String, res.Data.Body.String, models.ToNullsString(userID))
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadActivityResource{Data: activity})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetLeadActivities - Returns the history of a lead.
+// Handler for HTTP Get - "/organizations/{organization}/crm/leads/{lead}/activities"
+func GetLeadActivities(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	userID, _ := sessionUserID(r)
+	// Check lead
+	_, err := organizationLead(orgid, id, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	activities, err := leadRepo.GetActivities(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadActivitiesResource{Data: activities})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateLeadActivity - Logs a note, call, email or follow up reminder on a lead.
+// Handler for HTTP Post - "/organizations/{organization}/crm/leads/{lead}/activities"
+func CreateLeadActivity(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	userID, _ := sessionUserID(r)
+	// Decode
+	var res LeadActivityResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	activity := &res.Data
+	// Check lead
+	lead, err := organizationLead(orgid, id, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Set values - Don't trust JSON value
+	activity.LeadID = lead.ID
+	activity.OrganizationID = lead.OrganizationID
+	activity.UserID = models.ToNullsString(userID)
+	activity.FromStageID = nulls.String{}
+	activity.ToStageID = nulls.String{}
+	// Validate
+	if !activity.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = leadRepo.AddActivity(activity)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadActivityResource{Data: *activity})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// CompleteLeadReminder - Sets a follow up reminder of a lead as done.
+// Handler for HTTP Post - "/organizations/{organization}/crm/leads/{lead}/activities/{activity}/done"
+func CompleteLeadReminder(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lead"]
+	activityID := vars["activity"]
+	userID, _ := sessionUserID(r)
+	// Check lead
+	_, err := organizationLead(orgid, id, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusForbidden)
+		return
+	}
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	activity, err := leadRepo.GetActivity(activityID, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Update
+	err = leadRepo.CompleteReminder(&activity)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLeadReminders - Returns the pending follow up reminders of an organization.
+// Handler for HTTP Get - "/organizations/{organization}/crm/reminders?until=1489536000"
+// Time defaults to now, optional query value 'agent' restricts reminders to the ones set by
+// the agent or on leads assigned to it.
+func GetLeadReminders(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	agentID := r.URL.Query().Get("agent")
+	userID, _ := sessionUserID(r)
+	until := time.Now()
+	if value := r.URL.Query().Get("until"); value != "" {
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+			return
+		}
+		until = time.Unix(unix, 0)
+	}
+	// Check member
+	err := crmMember(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	reminders, err := leadRepo.GetDueReminders(orgid, until, agentID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeadActivitiesResource{Data: reminders})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetLeadFunnel - Returns leads count and value at every stage of the organization pipeline.
+// Handler for HTTP Get - "/organizations/{organization}/crm/funnel"
+func GetLeadFunnel(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	userID, _ := sessionUserID(r)
+	// Check member
+	err := crmMember(orgid, userID)
+	if err == app.ErrUnauthorized {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	funnel, err := leadRepo.GetFunnel(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(FunnelResource{Data: funnel})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// crmMember - Returns ErrUnauthorized if user is not a member of the organization.
+func crmMember(orgid, userID string) error {
+	isMember, err := organizationMember(orgid, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return app.ErrUnauthorized
+	}
+	return nil
+}
+
+// crmOwner - Returns ErrUnauthorized if user is not the organization owner.
+func crmOwner(orgid, userID string) error {
+	org, err := getOrganization(orgid)
+	if err != nil {
+		return err
+	}
+	if org.UserID.String != userID {
+		return app.ErrUnauthorized
+	}
+	return nil
+}
+
+// organizationLead - Returns a lead of the organization if user is one of its members.
+func organizationLead(orgid, id, userID string) (models.Lead, error) {
+	err := crmMember(orgid, userID)
+	if err != nil {
+		return models.Lead{}, err
+	}
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		return models.Lead{}, err
+	}
+	return leadRepo.GetFromOrganization(id, orgid)
+}
+
+// checkLeadReferences - Checks lead listing and assignee belong to the organization.
+func checkLeadReferences(orgid string, lead *models.Lead) (models.ValidationErrors, error) {
+	errs := models.ValidationErrors{}
+	if lead.ListingID.String != "" {
+		_, err := organizationListing(orgid, lead.ListingID.String)
+		if err == sql.ErrNoRows {
+			errs["listingID"] = "unknown listing"
+		} else if err != nil {
+			return errs, err
+		}
+	}
+	if lead.AssigneeID.String != "" {
+		isMember, err := organizationMember(orgid, lead.AssigneeID.String)
+		if err != nil {
+			return errs, err
+		}
+		if !isMember {
+			errs["assigneeID"] = "assignee is not an organization member"
+		}
+	}
+	return errs, nil
+}
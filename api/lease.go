@@ -0,0 +1,571 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetLeases - Returns a collection containing all leases from an organization.
+// Handler for HTTP Get - "/organizations/{organization}/leases"
+// Optional query value 'unit' restricts the collection to the leases of a unit.
+func GetLeases(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	unitID := r.URL.Query().Get("unit")
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Expire overdue leases
+	err = leaseRepo.Expire(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var leases []models.Lease
+	if unitID != "" {
+		_, err = organizationUnit(orgid, unitID)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+			return
+		}
+		leases, err = leaseRepo.GetAllFromUnit(unitID)
+	} else {
+		leases, err = leaseRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeasesResource{Data: leases})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateLease - Creates a new Lease.
+// Handler for HTTP Post - "/organizations/{organization}/leases"
+func CreateLease(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res LeaseResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	lease := &res.Data
+	// Set Organization - Don't trust JSON value
+	lease.OrganizationID = models.ToNullsString(orgid)
+	// Set values
+	u, _ := sessionUser(r)
+	lease.CreatedBy = u.ID
+	lease.SetDefaults()
+	// Validate
+	if !lease.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// New leases start as draft or active
+	if lease.Status.String != models.LeaseStatusDraft && lease.Status.String != models.LeaseStatusActive {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityStatusChange, http.StatusBadRequest)
+		return
+	}
+	// Check unit belongs to organization
+	_, err = organizationUnit(orgid, lease.UnitID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+		return
+	}
+	// Check tenants
+	for _, userID := range lease.TenantIDs {
+		_, err = getUser(userID)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+			return
+		}
+	}
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = leaseRepo.Create(lease)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeaseResource{Data: *lease})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetLease - Returns a single Lease by its id or name.
+// Handler for HTTP Get - "/organizations/{organization}/leases/{lease}"
+func GetLease(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	key := vars["lease"]
+	if len(key) == 36 {
+		GetLeaseByID(w, r)
+	} else {
+		GetLeaseByName(w, r)
+	}
+}
+
+// GetLeaseByID - Returns a single Lease by its id.
+// Handler for HTTP Get - "/organizations/{organization}/leases/{lease}"
+func GetLeaseByID(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	lease, err := leaseRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role
+	canRead, err := leaseReader(r, orgid, lease.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeaseResource{Data: lease})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetLeaseByName - Returns a single Lease by its name.
+// Handler for HTTP Get - "/organizations/{organization}/leases/{lease}"
+func GetLeaseByName(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	name := vars["lease"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	lease, err := leaseRepo.GetByNameInOrganization(name, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role
+	canRead, err := leaseReader(r, orgid, lease.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeaseResource{Data: lease})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateLease - Update an existing Lease.
+// Handler for HTTP Put - "/organizations/{organization}/leases/{lease}"
+func UpdateLease(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	// Decode
+	var res LeaseResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	lease := &res.Data
+	lease.ID = models.ToNullsString(id)
+	lease.OrganizationID = models.ToNullsString(orgid)
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current lease
+	currentLease, err := leaseRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(lease.IdentifiableModel, currentLease.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Keep current terms if not provided
+	keepLeaseTerms(lease, currentLease)
+	// Check status change
+	if !currentLease.CanChangeStatus(lease.Status.String) {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityStatusChange, http.StatusConflict)
+		return
+	}
+	// Terminated leases end now unless an earlier end was agreed
+	now := time.Now()
+	if lease.Status.String == models.LeaseStatusTerminated && currentLease.Status.String != models.LeaseStatusTerminated {
+		if !lease.EndsAt.Valid || lease.EndsAt.Time.After(now) {
+			lease.EndsAt = models.ToNullsTime(now)
+		}
+	}
+	// Validate
+	if !lease.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = leaseRepo.Update(lease)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(LeaseResource{Data: *lease})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteLease - Deletes an existing Lease
+// Handler for HTTP Delete - "/organizations/{organization}/leases/{lease}"
+func DeleteLease(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = leaseRepo.DeleteFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLeaseSchedule - Returns the rent schedule of a Lease.
+// Handler for HTTP Get - "/organizations/{organization}/leases/{lease}/schedule"
+func GetLeaseSchedule(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	lease, err := leaseRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Check role
+	canRead, err := leaseReader(r, orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(RentScheduleResource{Data: lease.RentSchedule()})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetLeaseTenants - Returns the users renting under a Lease.
+// Handler for HTTP Get - "/organizations/{organization}/leases/{lease}/tenants"
+func GetLeaseTenants(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check lease belongs to organization
+	_, err = leaseRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Check role
+	canRead, err := leaseReader(r, orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !canRead {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Select
+	users, err := leaseRepo.GetTenants(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(UsersResource{Data: users})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// AddLeaseTenant - Adds a user to the tenants of a Lease.
+// Handler for HTTP Post - "/organizations/{organization}/leases/{lease}/tenants/{user}"
+func AddLeaseTenant(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	userID := vars["user"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check lease belongs to organization
+	_, err = leaseRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Check user
+	_, err = getUser(userID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Persist
+	err = leaseRepo.AddTenant(id, userID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveLeaseTenant - Removes a user from the tenants of a Lease.
+// Handler for HTTP Delete - "/organizations/{organization}/leases/{lease}/tenants/{user}"
+func RemoveLeaseTenant(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["lease"]
+	userID := vars["user"]
+	// Get repo
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Check lease belongs to organization
+	_, err = leaseRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Delete
+	err = leaseRepo.RemoveTenant(id, userID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keepLeaseTerms - Keeps current lease terms not provided in update.
+// Leased unit can't be changed.
+func keepLeaseTerms(lease *models.Lease, current models.Lease) {
+	lease.UnitID = current.UnitID
+	if lease.Status.String == "" {
+		lease.Status = current.Status
+	}
+	if !lease.StartedAt.Valid {
+		lease.StartedAt = current.StartedAt
+	}
+	if !lease.EndsAt.Valid {
+		lease.EndsAt = current.EndsAt
+	}
+	if !lease.RentAmount.Valid {
+		lease.RentAmount = current.RentAmount
+	}
+	if !lease.Deposit.Valid {
+		lease.Deposit = current.Deposit
+	}
+	if lease.Currency.String == "" {
+		lease.Currency = current.Currency
+	}
+	if !lease.PaymentDay.Valid {
+		lease.PaymentDay = current.PaymentDay
+	}
+	if !lease.IndexationRate.Valid {
+		lease.IndexationRate = current.IndexationRate
+	}
+	if !lease.IndexationPeriod.Valid {
+		lease.IndexationPeriod = current.IndexationPeriod
+	}
+	if !lease.LateFeeAmount.Valid {
+		lease.LateFeeAmount = current.LateFeeAmount
+	}
+	if !lease.LateFeeRate.Valid {
+		lease.LateFeeRate = current.LateFeeRate
+	}
+	if !lease.GraceDays.Valid {
+		lease.GraceDays = current.GraceDays
+	}
+}
+
+// organizationUnit - Returns the unit if it belongs to the organization.
+func organizationUnit(orgid, id string) (models.Unit, error) {
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		return models.Unit{}, err
+	}
+	unit, err := unitRepo.Get(id)
+	if err != nil {
+		return models.Unit{}, err
+	}
+	if unit.OrganizationID.String != orgid {
+		return models.Unit{}, app.ErrEntityNotFound
+	}
+	return unit, nil
+}
+
+// leaseReader - Returns true if the session user can read the organization leases or rents under the lease.
+func leaseReader(r *http.Request, orgid, id string) (bool, error) {
+	canRead, err := sessionCan(r, models.LeaseResourceTag, orgid)
+	if err != nil || canRead {
+		return canRead, err
+	}
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		return false, err
+	}
+	return leaseRepo.IsLeaseTenant(id, loggedInUserID(r))
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetAccounts - Returns the ledger accounts of an organization.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/accounts"
+func GetAccounts(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Get repo
+	ledgerRepo, err := repo.MakeLedgerRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	accounts, err := ledgerRepo.GetAccounts(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AccountsResource{Data: accounts})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetJournalEntries - Returns the journal entries of an organization.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/journal-entries"
+func GetJournalEntries(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Get repo
+	ledgerRepo, err := repo.MakeLedgerRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	entries, err := ledgerRepo.GetJournalEntries(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(JournalEntriesResource{Data: entries})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetJournalEntry - Returns a single JournalEntry and its postings.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/journal-entries/{entry}"
+func GetJournalEntry(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["entry"]
+	// Get repo
+	ledgerRepo, err := repo.MakeLedgerRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	entry, err := ledgerRepo.GetJournalEntry(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(JournalEntryResource{Data: entry})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// ReverseJournalEntry - Posts an entry cancelling an existing one.
+// Handler for HTTP Post - "/organizations/{organization}/ledger/journal-entries/{entry}/reversal"
+func ReverseJournalEntry(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["entry"]
+	// Get repo
+	ledgerRepo, err := repo.MakeLedgerRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check entry belongs to organization
+	_, err = ledgerRepo.GetJournalEntry(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Persist
+	u, _ := sessionUser(r)
+	reversal, err := ledgerRepo.Reverse(id, orgid, u.ID)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(JournalEntryResource{Data: reversal})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetUnitStatement - Returns the receivables statement of a unit.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/statements/units/{unit}"
+func GetUnitStatement(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["unit"]
+	// Check unit belongs to organization
+	_, err := organizationUnit(orgid, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	ledgerRepo, err := repo.MakeLedgerRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	statement, err := ledgerRepo.GetUnitStatement(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(StatementResource{Data: statement})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetTenantStatement - Returns the receivables statement of a tenant.
+// Handler for HTTP Get - "/organizations/{organization}/ledger/statements/tenants/{user}"
+func GetTenantStatement(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["user"]
+	// Check role
+	isManager, err := sessionCan(r, models.LedgerResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !isManager && id != loggedInUserID(r) {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Check user
+	_, err = getUser(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	ledgerRepo, err := repo.MakeLedgerRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	statement, err := ledgerRepo.GetTenantStatement(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(StatementResource{Data: statement})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
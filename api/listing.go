@@ -0,0 +1,536 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/markbates/pop/nulls"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/types"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetListings - Returns a collection containing all listings from an organization.
+// Handler for HTTP Get - "/organizations/{organization}/listings"
+func GetListings(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Location filter
+	geoFilter, err := geoFilterFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestGeoFilter, err, http.StatusBadRequest)
+		return
+	}
+	// Property filter
+	facetQuery, err := facetQueryFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestFacetQuery, err, http.StatusBadRequest)
+		return
+	}
+	// Statistics filter
+	statsFilter, err := listingStatsFilterFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestStatsFilter, err, http.StatusBadRequest)
+		return
+	}
+	// Select
+	var listings []models.Listing
+	switch {
+	case len(facetQuery.Conditions) > 0:
+		listings, err = listingRepo.GetAllFaceted(orgid, facetQuery, geoFilter)
+	case !geoFilter.IsEmpty():
+		listings, err = listingRepo.GetAllByLocation(orgid, geoFilter)
+	default:
+		listings, err = listingRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	listings, err = listingRepo.FilterByStats(listings, statsFilter)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Restrict to visible listings
+	visible, err := visibleIDs(r, models.ListingResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if visible != nil {
+		filtered := []models.Listing{}
+		for _, listing := range listings {
+			if visible[listing.ID.String] {
+				filtered = append(filtered, listing)
+			}
+		}
+		listings = filtered
+	}
+	// Statistics
+	err = listingRepo.SetStats(listings)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Facets
+	var facets []models.Facet
+	if len(facetQuery.Facets) > 0 {
+		facets, err = listingRepo.GetFacets(listings, facetQuery.Facets)
+		if err != nil {
+			app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	// Marshal
+	var j []byte
+	contentType := "application/json"
+	if isGeoJSONRequest(r) {
+		j, err = marshalFeatureCollection(listings, "geolocation", "footprint")
+		contentType = types.GeoJSONMediaType
+	} else {
+		j, err = json.Marshal(ListingsResource{Data: listings, Facets: facets})
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateListing - Creates a new Listing.
+// Handler for HTTP Post - "/organizations/{organization}/listings"
+func CreateListing(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res ListingResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	listing := &res.Data
+	// Set Organization - Don't trust JSON value
+	listing.OrganizationID = models.ToNullsString(orgid)
+	// Set values
+	u, _ := sessionUser(r)
+	listing.CreatedBy = u.ID
+	listing.SetDefaults()
+	// Validate
+	if !listing.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = listingRepo.Create(listing)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ListingResource{Data: *listing})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetListing - Returns a single Listing by its id or name.
+// Handler for HTTP Get - "/organizations/{organization}/listings/{listing}"
+func GetListing(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	key := vars["listing"]
+	if len(key) == 36 {
+		GetListingByID(w, r)
+	} else {
+		GetListingByName(w, r)
+	}
+}
+
+// GetListingByID - Returns a single Listing by its id.
+// Handler for HTTP Get - "/organizations/{organization}/listings/{listing}"
+func GetListingByID(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["listing"]
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	listing, err := listingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Statistics
+	stats, err := listingRepo.GetStats(listing.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	listing.Stats = &stats
+	// Marshal
+	j, err := json.Marshal(ListingResource{Data: listing})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Repsond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetListingByName - Returns a single Listing by its name.
+// Handler for HTTP Get - "/organizations/{organization}/listings/{listing}"
+func GetListingByName(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	name := vars["listing"]
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	listing, err := listingRepo.GetByNameInOrganization(name, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Statistics
+	stats, err := listingRepo.GetStats(listing.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	listing.Stats = &stats
+	// Marshal
+	j, err := json.Marshal(ListingResource{Data: listing})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Repond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateListing - Update an existing Listing.
+// Handler for HTTP Put - "/organizations/{organization}/listings/{listing}"
+func UpdateListing(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["listing"]
+	// Decode
+	var res ListingResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	listing := &res.Data
+	listing.ID = models.ToNullsString(id)
+	listing.OrganizationID = models.ToNullsString(orgid)
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current listing
+	currentListing, err := listingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(listing.IdentifiableModel, currentListing.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Keep current operation and status if not provided
+	if listing.Operation.String == "" {
+		listing.Operation = currentListing.Operation
+	}
+	if listing.Status.String == "" {
+		listing.Status = currentListing.Status
+	}
+	// Validate
+	if !listing.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	u, _ := sessionUser(r)
+	err = listingRepo.Update(listing, u.ID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ListingResource{Data: *listing})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteListing - Deletes an existing Listing
+// Handler for HTTP Delete - "/organizations/{organization}/listings/{listing}"
+func DeleteListing(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["listing"]
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = listingRepo.DeleteFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetListingPropertiesSets - Returns the properties sets holding the attributes of a Listing.
+// Handler for HTTP Get - "/organizations/{organization}/listings/{listing}/properties-sets"
+func GetListingPropertiesSets(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["listing"]
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check listing belongs to organization
+	_, err = listingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	propSets, err := listingRepo.GetPropertiesSets(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetsResource{Data: propSets})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateListingPropertiesSet - Attaches a new properties set to a Listing.
+// Handler for HTTP Post - "/organizations/{organization}/listings/{listing}/properties-sets"
+func CreateListingPropertiesSet(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["listing"]
+	// Decode
+	var res PropertiesSetResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	propertiesSet := &res.Data
+	// Get repos
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	propertiesSetRepo, err := repo.MakePropertiesSetRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check listing belongs to organization
+	listing, err := listingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Set holder - Don't trust JSON value
+	propertiesSet.HolderID = listing.ID
+	u, _ := sessionUser(r)
+	propertiesSet.CreatedBy = u.ID
+	// Persist
+	err = propertiesSetRepo.Create(propertiesSet)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetResource{Data: *propertiesSet})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetListingHistory - Returns the price and status changes of a Listing along with its market statistics.
+// Handler for HTTP Get - "/organizations/{organization}/listings/{listing}/history"
+func GetListingHistory(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["listing"]
+	// Get repo
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check listing belongs to organization
+	_, err = listingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	history, err := listingRepo.GetHistory(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	stats, err := listingRepo.GetStats(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ListingHistoryResource{Data: history, Stats: stats})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// listingStatsFilterFromURL - Builds a market statistics filter from request query values.
+// min-days-on-market=n&max-days-on-market=n&price-reduced=true&min-price-change=-10.5&max-price-change=0
+func listingStatsFilterFromURL(r *http.Request) (models.ListingStatsFilter, error) {
+	filter := models.ListingStatsFilter{}
+	query := r.URL.Query()
+	// Days on market
+	for key, bound := range map[string]*nulls.Int64{"min-days-on-market": &filter.MinDaysOnMarket, "max-days-on-market": &filter.MaxDaysOnMarket} {
+		if value := query.Get(key); value != "" {
+			days, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || days < 0 {
+				return filter, app.ErrRequestStatsFilter
+			}
+			*bound = models.ToNullsInt64(days)
+		}
+	}
+	// Price reductions
+	if value := query.Get("price-reduced"); value != "" {
+		reduced, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, app.ErrRequestStatsFilter
+		}
+		filter.PriceReduced = models.ToNullsBool(reduced)
+	}
+	// Price change
+	for key, bound := range map[string]*nulls.Float64{"min-price-change": &filter.MinPriceChange, "max-price-change": &filter.MaxPriceChange} {
+		if value := query.Get(key); value != "" {
+			change, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return filter, app.ErrRequestStatsFilter
+			}
+			*bound = models.ToNullsFoat64(change)
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,942 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/markbates/pop/nulls"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetMaintenanceRequests - Returns the maintenance requests of an organization visible for the user.
+// Handler for HTTP Get - "/organizations/{organization}/maintenance/requests"
+// Managers get all requests, other users only those they reported or were assigned to.
+// Optional query value 'status' filters the collection.
+func GetMaintenanceRequests(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	status := r.URL.Query().Get("status")
+	userID, _ := sessionUserID(r)
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role
+	isManager, err := sessionCan(r, models.MaintenanceResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var requests []models.MaintenanceRequest
+	switch {
+	case !isManager:
+		requests, err = requestRepo.GetAllForUser(orgid, userID)
+		if status != "" {
+			requests = filterMaintenanceRequests(requests, status)
+		}
+	case status != "":
+		requests, err = requestRepo.GetAllByStatus(orgid, status)
+	default:
+		requests, err = requestRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenanceRequestsResource{Data: requests})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateMaintenanceRequest - Reports a new maintenance request.
+// Handler for HTTP Post - "/organizations/{organization}/maintenance/requests"
+// Tenants can only report requests for the units they rent.
+func CreateMaintenanceRequest(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res MaintenanceRequestResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	request := &res.Data
+	// Set Organization - Don't trust JSON value
+	request.OrganizationID = models.ToNullsString(orgid)
+	// Set values
+	u, _ := sessionUser(r)
+	request.CreatedBy = u.ID
+	request.ReportedBy = u.ID
+	request.SetDefaults()
+	// Check role
+	isManager, err := sessionCan(r, models.MaintenanceResourceTag, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	if !isManager && request.UnitID.String == "" {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Check unit or building belongs to organization
+	if request.UnitID.String != "" {
+		unit, err := organizationUnit(orgid, request.UnitID.String)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+			return
+		}
+		request.BuildingID = unit.BuildingID
+	} else {
+		_, err = organizationBuilding(orgid, request.BuildingID.String)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check tenant rents the unit
+	if !isManager {
+		leaseRepo, err := repo.MakeLeaseRepository()
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+			return
+		}
+		isTenant, err := leaseRepo.IsUnitTenant(request.UnitID.String, u.ID.String)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+			return
+		}
+		if !isTenant {
+			app.ShowError(w, app.ErrEntityCreate, app.ErrUnauthorized, http.StatusForbidden)
+			return
+		}
+	}
+	// Validate
+	if !request.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = requestRepo.Create(request)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenanceRequestResource{Data: *request})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetMaintenanceRequest - Returns a single maintenance request with its work orders and costs.
+// Handler for HTTP Get - "/organizations/{organization}/maintenance/requests/{request}"
+func GetMaintenanceRequest(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Select
+	request, _, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenanceRequestResource{Data: request})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateMaintenanceRequest - Update an existing maintenance request.
+// Handler for HTTP Put - "/organizations/{organization}/maintenance/requests/{request}"
+// Only managers can edit request details, other roles can only change its status.
+func UpdateMaintenanceRequest(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Decode
+	var res MaintenanceRequestResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	request := &res.Data
+	request.ID = models.ToNullsString(id)
+	request.OrganizationID = models.ToNullsString(orgid)
+	// Check against current request
+	current, role, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(request.IdentifiableModel, current.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Keep values not provided or not editable by role
+	keepMaintenanceRequest(request, current, role)
+	// Check status change
+	if !current.CanChangeStatus(request.Status.String) {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityStatusChange, http.StatusConflict)
+		return
+	}
+	if !current.StatusChangeAllowed(role, request.Status.String) {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Validate
+	if !request.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Update
+	err = requestRepo.Update(request)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenanceRequestResource{Data: *request})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteMaintenanceRequest - Deletes an existing maintenance request.
+// Handler for HTTP Delete - "/organizations/{organization}/maintenance/requests/{request}"
+func DeleteMaintenanceRequest(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Check role
+	_, role, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	if role != models.MaintenanceRoleManager {
+		app.ShowError(w, app.ErrEntityDelete, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = requestRepo.DeleteFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWorkOrders - Returns the work orders of a maintenance request.
+// Handler for HTTP Get - "/organizations/{organization}/maintenance/requests/{request}/work-orders"
+func GetWorkOrders(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Select
+	request, _, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(WorkOrdersResource{Data: request.WorkOrders})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateWorkOrder - Assigns a new work order to a user or a vendor organization.
+// Handler for HTTP Post - "/organizations/{organization}/maintenance/requests/{request}/work-orders"
+func CreateWorkOrder(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Decode
+	var res WorkOrderResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	order := &res.Data
+	// Check role
+	u, _ := sessionUser(r)
+	request, role, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	if role != models.MaintenanceRoleManager {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Work is assigned once the request was triaged and until it is done
+	switch request.Status.String {
+	case models.MaintenanceStatusTriaged, models.MaintenanceStatusAssigned, models.MaintenanceStatusInProgress:
+	default:
+		errs := models.ValidationErrors{"status": "request must be triaged and not yet done"}
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusConflict)
+		return
+	}
+	// Set values - Don't trust JSON value
+	order.MaintenanceRequestID = request.ID
+	order.OrganizationID = models.ToNullsString(orgid)
+	order.CreatedBy = u.ID
+	order.CompletedAt = nulls.Time{}
+	order.SetDefaults()
+	// Check assignee
+	if order.AssigneeUserID.String != "" {
+		_, err = getUser(order.AssigneeUserID.String)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+			return
+		}
+	}
+	if order.AssigneeOrganizationID.String != "" {
+		_, err = getOrganization(order.AssigneeOrganizationID.String)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+			return
+		}
+	}
+	// Validate
+	if !order.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	orderRepo, err := repo.MakeWorkOrderRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = orderRepo.Create(order)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(WorkOrderResource{Data: *order})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// UpdateWorkOrder - Update an existing work order.
+// Handler for HTTP Put - "/organizations/{organization}/maintenance/requests/{request}/work-orders/{order}"
+// Assignees can only report progress and actual cost.
+func UpdateWorkOrder(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	requestID := vars["request"]
+	id := vars["order"]
+	userID, _ := sessionUserID(r)
+	// Decode
+	var res WorkOrderResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	order := &res.Data
+	order.ID = models.ToNullsString(id)
+	// Check role
+	_, role, err := maintenanceRequest(r, orgid, requestID)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	orderRepo, err := repo.MakeWorkOrderRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current work order
+	current, err := orderRepo.GetFromRequest(id, requestID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	isManager := role == models.MaintenanceRoleManager
+	if !isManager {
+		isAssignee, err := workOrderAssignee(current, userID)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+			return
+		}
+		if !isAssignee {
+			app.ShowError(w, app.ErrEntityUpdate, app.ErrUnauthorized, http.StatusForbidden)
+			return
+		}
+	}
+	// Avoid ID spoofing
+	err = verifyID(order.IdentifiableModel, current.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Keep values not provided or not editable by role
+	keepWorkOrder(order, current, isManager)
+	// Check status change
+	if !current.CanChangeStatus(order.Status.String) {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityStatusChange, http.StatusConflict)
+		return
+	}
+	if order.Status.String == models.WorkOrderStatusCompleted {
+		order.Complete(time.Now())
+	}
+	// Validate
+	if !order.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = orderRepo.Update(order)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(WorkOrderResource{Data: *order})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// GetMaintenanceComments - Returns the comments of a maintenance request.
+// Handler for HTTP Get - "/organizations/{organization}/maintenance/requests/{request}/comments"
+func GetMaintenanceComments(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Check request is visible
+	_, _, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	comments, err := requestRepo.GetComments(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenanceCommentsResource{Data: comments})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateMaintenanceComment - Adds a comment to a maintenance request.
+// Handler for HTTP Post - "/organizations/{organization}/maintenance/requests/{request}/comments"
+func CreateMaintenanceComment(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	userID, _ := sessionUserID(r)
+	// Decode
+	var res MaintenanceCommentResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	comment := &res.Data
+	// Check request is visible
+	_, _, err = maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Set values - Don't trust JSON value
+	comment.MaintenanceRequestID = models.ToNullsString(id)
+	comment.OrganizationID = models.ToNullsString(orgid)
+	comment.UserID = models.ToNullsString(userID)
+	// Validate
+	if comment.Body.String == "" {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = requestRepo.AddComment(comment)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenanceCommentResource{Data: *comment})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetMaintenancePhotos - Returns the photos of a maintenance request without their data.
+// Handler for HTTP Get - "/organizations/{organization}/maintenance/requests/{request}/photos"
+func GetMaintenancePhotos(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	// Check request is visible
+	_, _, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	photos, err := requestRepo.GetPhotos(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenancePhotosResource{Data: photos})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetMaintenancePhoto - Returns a photo of a maintenance request including its data.
+// Handler for HTTP Get - "/organizations/{organization}/maintenance/requests/{request}/photos/{photo}"
+func GetMaintenancePhoto(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	photoID := vars["photo"]
+	// Check request is visible
+	_, _, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	photo, err := requestRepo.GetPhoto(photoID, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(MaintenancePhotoResource{Data: photo})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateMaintenancePhoto - Attaches a base64 encoded photo to a maintenance request.
+// Handler for HTTP Post - "/organizations/{organization}/maintenance/requests/{request}/photos"
+func CreateMaintenancePhoto(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	userID, _ := sessionUserID(r)
+	// Decode
+	var res MaintenancePhotoResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	photo := &res.Data
+	// Check request is visible
+	_, _, err = maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Set values - Don't trust JSON value
+	photo.MaintenanceRequestID = models.ToNullsString(id)
+	photo.OrganizationID = models.ToNullsString(orgid)
+	photo.UserID = models.ToNullsString(userID)
+	// Validate
+	if !photo.Decode() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrImageDecoding, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = requestRepo.AddPhoto(photo)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Data is not echoed back
+	photo.Base64 = nulls.String{}
+	// Marshal
+	j, err := json.Marshal(MaintenancePhotoResource{Data: *photo})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// DeleteMaintenancePhoto - Deletes a photo of a maintenance request.
+// Handler for HTTP Delete - "/organizations/{organization}/maintenance/requests/{request}/photos/{photo}"
+// Photos can be deleted by managers and by the user who uploaded them.
+func DeleteMaintenancePhoto(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["request"]
+	photoID := vars["photo"]
+	userID, _ := sessionUserID(r)
+	// Check role
+	_, role, err := maintenanceRequest(r, orgid, id)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Check uploader
+	photo, err := requestRepo.GetPhoto(photoID, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if role != models.MaintenanceRoleManager && photo.UserID.String != userID {
+		app.ShowError(w, app.ErrEntityDelete, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Delete
+	err = requestRepo.DeletePhoto(photoID, id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maintenanceRequest - Returns an organization maintenance request and the role the session user has over it.
+// Requests not visible for the user are reported as not found.
+func maintenanceRequest(r *http.Request, orgid, id string) (models.MaintenanceRequest, string, error) {
+	userID := loggedInUserID(r)
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		return models.MaintenanceRequest{}, "", err
+	}
+	request, err := requestRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		return request, "", err
+	}
+	isManager, err := sessionCan(r, models.MaintenanceResourceTag, orgid)
+	if err != nil {
+		return request, "", err
+	}
+	if isManager {
+		return request, models.MaintenanceRoleManager, nil
+	}
+	isAssignee, err := requestRepo.IsAssignee(id, userID)
+	if err != nil {
+		return request, "", err
+	}
+	if isAssignee {
+		return request, models.MaintenanceRoleAssignee, nil
+	}
+	if request.ReportedBy.String == userID {
+		return request, models.MaintenanceRoleReporter, nil
+	}
+	return models.MaintenanceRequest{}, "", sql.ErrNoRows
+}
+
+// workOrderAssignee - Returns true if work order is assigned to the user,
+// directly or through a vendor organization the user owns.
+func workOrderAssignee(order models.WorkOrder, userID string) (bool, error) {
+	if order.AssigneeUserID.String == userID {
+		return true, nil
+	}
+	if order.AssigneeOrganizationID.String == "" {
+		return false, nil
+	}
+	org, err := getOrganization(order.AssigneeOrganizationID.String)
+	if err != nil {
+		return false, err
+	}
+	return org.UserID.String == userID, nil
+}
+
+// filterMaintenanceRequests - Returns the requests having a status.
+func filterMaintenanceRequests(requests []models.MaintenanceRequest, status string) []models.MaintenanceRequest {
+	filtered := []models.MaintenanceRequest{}
+	for _, request := range requests {
+		if request.Status.String == status {
+			filtered = append(filtered, request)
+		}
+	}
+	return filtered
+}
+
+// keepMaintenanceRequest - Keeps current values not provided in update.
+// Location and reporter can't be changed, and only managers can edit request details.
+func keepMaintenanceRequest(request *models.MaintenanceRequest, current models.MaintenanceRequest, role string) {
+	request.BuildingID = current.BuildingID
+	request.UnitID = current.UnitID
+	request.ReportedBy = current.ReportedBy
+	if request.Status.String == "" {
+		request.Status = current.Status
+	}
+	if role != models.MaintenanceRoleManager || request.Name.String == "" {
+		request.Name = current.Name
+	}
+	if role != models.MaintenanceRoleManager || request.Priority.String == "" {
+		request.Priority = current.Priority
+	}
+	if role != models.MaintenanceRoleManager {
+		request.Description = current.Description
+	}
+	request.IsActive = current.IsActive
+	request.IsLogicalDeleted = current.IsLogicalDeleted
+}
+
+// keepWorkOrder - Keeps current values not provided in update.
+// Assignees can only change status and actual cost.
+func keepWorkOrder(order *models.WorkOrder, current models.WorkOrder, isManager bool) {
+	order.MaintenanceRequestID = current.MaintenanceRequestID
+	order.OrganizationID = current.OrganizationID
+	if order.Status.String == "" {
+		order.Status = current.Status
+	}
+	if !order.ActualCost.Valid {
+		order.ActualCost = current.ActualCost
+	}
+	if !order.CompletedAt.Valid {
+		order.CompletedAt = current.CompletedAt
+	}
+	if !isManager || order.Name.String == "" {
+		order.Name = current.Name
+	}
+	if !isManager {
+		order.Description = current.Description
+	}
+	if !isManager || (order.AssigneeUserID.String == "" && order.AssigneeOrganizationID.String == "") {
+		order.AssigneeUserID = current.AssigneeUserID
+		order.AssigneeOrganizationID = current.AssigneeOrganizationID
+	}
+	if !isManager || !order.ScheduledAt.Valid {
+		order.ScheduledAt = current.ScheduledAt
+	}
+	if !isManager || !order.EstimatedCost.Valid {
+		order.EstimatedCost = current.EstimatedCost
+	}
+	if !isManager || order.Currency.String == "" {
+		order.Currency = current.Currency
+	}
+	order.IsActive = current.IsActive
+	order.IsLogicalDeleted = current.IsLogicalDeleted
+}
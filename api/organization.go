@@ -35,6 +35,8 @@ import (
 	"github.com/adrianpk/fundacja/app"
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/services"
+	"github.com/adrianpk/fundacja/types"
 
 	_ "github.com/lib/pq" // Import pq without side effects
 
@@ -50,21 +52,39 @@ func GetOrganizations(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
 		return
 	}
+	// Location filter
+	geoFilter, err := geoFilterFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestGeoFilter, err, http.StatusBadRequest)
+		return
+	}
 	// Select
-	organizations, err := organizationRepo.GetAll()
+	var organizations []models.Organization
+	if geoFilter.IsEmpty() {
+		organizations, err = organizationRepo.GetAll()
+	} else {
+		organizations, err = organizationRepo.GetAllByLocation(geoFilter)
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
 	// Marshal
-	j, err := json.Marshal(OrganizationsResource{Data: organizations})
+	var j []byte
+	contentType := "application/json"
+	if isGeoJSONRequest(r) {
+		j, err = marshalFeatureCollection(organizations, "geolocation")
+		contentType = types.GeoJSONMediaType
+	} else {
+		j, err = json.Marshal(OrganizationsResource{Data: organizations})
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
 		return
 	}
 	// Respond
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
 	w.Write(j)
 }
 
@@ -134,6 +154,16 @@ func GetOrganizationByID(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
+	// Check role
+	allowed, err := organizationReader(loggedInUserID(r), organization.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
 	// Marshal
 	j, err := json.Marshal(organization)
 	if err != nil {
@@ -164,6 +194,16 @@ func GetOrganizationByName(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
+	// Check role
+	allowed, err := organizationReader(loggedInUserID(r), organization.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
 	// Marshal
 	j, err := json.Marshal(organization)
 	if err != nil {
@@ -249,6 +289,15 @@ func DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// organizationReader - True if user can read the organization or takes part in it.
+func organizationReader(userID, orgID string) (bool, error) {
+	can, err := services.Can(userID, models.ActionRead, models.OrganizationResourceTag, orgID)
+	if err != nil || can {
+		return can, err
+	}
+	return services.IsOrganizationParty(userID, orgID)
+}
+
 func organizationIDfromURL(r *http.Request) string {
 	u, _ := url.Parse(r.URL.Path)
 	dir := path.Dir(u.Path)
@@ -43,13 +43,6 @@ import (
 // GetPermissions - Returns a collection containing all permissions.
 // Handler for HTTP Get - "/organizations/{organization}/permissions"
 func GetPermissions(w http.ResponseWriter, r *http.Request) {
-	// Check permissions
-	// defer func() {
-	// 	recover()
-	// 	app.ShowError(w, app.ErrUnauthorized, app.ErrUnauthorized, http.StatusUnauthorized)
-	// }()
-	// services.IsAllowed("f254cfe5", loggedInUserID(r))
-
 	// Get ID
 	vars := mux.Vars(r)
 	orgid := vars["organization"]
@@ -66,6 +66,7 @@ func GetProfileByUserID(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
+	signProfileImages(&profile)
 	// Marshal
 	j, err := json.Marshal(profile)
 	if err != nil {
@@ -102,6 +103,7 @@ func GetProfileByUsername(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
+	signProfileImages(&profile)
 	// Marshal
 	j, err := json.Marshal(profile)
 	if err != nil {
@@ -49,14 +49,34 @@ func GetPropertiesSets(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
 		return
 	}
+	// Property filter
+	facetQuery, err := facetQueryFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestFacetQuery, err, http.StatusBadRequest)
+		return
+	}
 	// Select
-	propSets, err := propSetRepo.GetAll(holderID)
+	var propSets []models.PropertiesSet
+	if len(facetQuery.Conditions) > 0 {
+		propSets, err = propSetRepo.GetAllFaceted(holderID, facetQuery)
+	} else {
+		propSets, err = propSetRepo.GetAll(holderID)
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
+	// Facets
+	var facets []models.Facet
+	if len(facetQuery.Facets) > 0 {
+		facets, err = propSetRepo.GetFacets(propSets, facetQuery.Facets)
+		if err != nil {
+			app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+			return
+		}
+	}
 	// Marshal
-	j, err := json.Marshal(PropertiesSetsResource{Data: propSets})
+	j, err := json.Marshal(PropertiesSetsResource{Data: propSets, Facets: facets})
 	if err != nil {
 		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
 		return
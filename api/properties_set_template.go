@@ -0,0 +1,284 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetPropertiesSetTemplates - Returns a collection containing all properties set templates available to an organization.
+// Handler for HTTP Get - "/organizations/{organization}/properties-set-templates"
+func GetPropertiesSetTemplates(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Get repo
+	templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	templates, err := templateRepo.GetAll(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetTemplatesResource{Data: templates})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreatePropertiesSetTemplate - Creates a new PropertiesSetTemplate.
+// Handler for HTTP Post - "/organizations/{organization}/properties-set-templates"
+func CreatePropertiesSetTemplate(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res PropertiesSetTemplateResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	template := &res.Data
+	// Set Organization - Don't trust JSON value
+	template.OrganizationID = models.ToNullsString(orgid)
+	u, _ := sessionUser(r)
+	template.CreatedBy = u.ID
+	// Validate
+	errs := template.Validate()
+	if len(errs) > 0 {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = templateRepo.Create(template)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetTemplateResource{Data: *template})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetPropertiesSetTemplate - Returns a single PropertiesSetTemplate by its id or name.
+// Handler for HTTP Get - "/organizations/{organization}/properties-set-templates/{template}"
+func GetPropertiesSetTemplate(w http.ResponseWriter, r *http.Request) {
+	// Get ID
+	vars := mux.Vars(r)
+	key := vars["template"]
+	if len(key) == 36 {
+		GetPropertiesSetTemplateByID(w, r)
+	} else {
+		GetPropertiesSetTemplateByName(w, r)
+	}
+}
+
+// GetPropertiesSetTemplateByID - Returns a single PropertiesSetTemplate by its id.
+// Handler for HTTP Get - "/organizations/{organization}/properties-set-templates/{template}"
+func GetPropertiesSetTemplateByID(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["template"]
+	// Get repo
+	templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	template, err := templateRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetTemplateResource{Data: template})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetPropertiesSetTemplateByName - Returns a single PropertiesSetTemplate by its name.
+// Handler for HTTP Get - "/organizations/{organization}/properties-set-templates/{template}"
+func GetPropertiesSetTemplateByName(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	name := vars["template"]
+	// Get repo
+	templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	template, err := templateRepo.GetByNameInOrganization(name, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetTemplateResource{Data: template})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdatePropertiesSetTemplate - Update an existing PropertiesSetTemplate.
+// Handler for HTTP Put - "/organizations/{organization}/properties-set-templates/{template}"
+func UpdatePropertiesSetTemplate(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["template"]
+	// Decode
+	var res PropertiesSetTemplateResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	template := &res.Data
+	template.ID = models.ToNullsString(id)
+	template.OrganizationID = models.ToNullsString(orgid)
+	// Get repo
+	templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current template
+	currentTemplate, err := templateRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Shared templates are read only
+	if currentTemplate.OrganizationID.String != orgid {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrOwnerOnlyCanManage, http.StatusUnauthorized)
+		return
+	}
+	// Validate
+	errs := template.Validate()
+	if len(errs) > 0 {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = templateRepo.Update(template)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetTemplateResource{Data: *template})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeletePropertiesSetTemplate - Deletes an existing PropertiesSetTemplate
+// Handler for HTTP Delete - "/organizations/{organization}/properties-set-templates/{template}"
+func DeletePropertiesSetTemplate(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["template"]
+	// Get repo
+	templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current template
+	currentTemplate, err := templateRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusNotFound)
+		return
+	}
+	// Shared templates are read only
+	if currentTemplate.OrganizationID.String != orgid {
+		app.ShowError(w, app.ErrEntityDelete, app.ErrOwnerOnlyCanManage, http.StatusUnauthorized)
+		return
+	}
+	// Delete
+	err = templateRepo.Delete(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -24,6 +24,7 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 
 	"github.com/gorilla/mux"
@@ -35,6 +36,7 @@ import (
 	"github.com/adrianpk/fundacja/app"
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/types"
 
 	_ "github.com/lib/pq" // Import pq without side effects
 
@@ -53,21 +55,48 @@ func GetProperties(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
 		return
 	}
+	// Location filter
+	geoFilter, err := geoFilterFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestGeoFilter, err, http.StatusBadRequest)
+		return
+	}
+	// Value filter
+	propertyFilter, err := propertyFilterFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestPropertyFilter, err, http.StatusBadRequest)
+		return
+	}
 	// Select
-	properties, err := propertyRepo.GetAll(propsetID)
+	var properties []models.Property
+	switch {
+	case !propertyFilter.IsEmpty():
+		properties, err = propertyRepo.GetAllFiltered(propsetID, propertyFilter, geoFilter)
+	case !geoFilter.IsEmpty():
+		properties, err = propertyRepo.GetAllByLocation(propsetID, geoFilter)
+	default:
+		properties, err = propertyRepo.GetAll(propsetID)
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
 	// Marshal
-	j, err := json.Marshal(PropertiesResource{Data: properties})
+	var j []byte
+	contentType := "application/json"
+	if isGeoJSONRequest(r) {
+		j, err = marshalFeatureCollection(properties, "geolocationValue", "polygonValue", "multipolygonValue", "linestringValue")
+		contentType = types.GeoJSONMediaType
+	} else {
+		j, err = json.Marshal(PropertiesResource{Data: properties})
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
 		return
 	}
 	// Respond
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
 	w.Write(j)
 }
 
@@ -94,7 +123,11 @@ func CreateProperty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Persist
-	propertyRepo.Create(property)
+	err = propertyRepo.Create(property)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
 		return
@@ -211,8 +244,22 @@ func UpdateProperty(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
 		return
 	}
+	// Check against current property, its set decides the template
+	currentProperty, err := propertyRepo.Get(id)
+	if err == sql.ErrNoRows || (err == nil && currentProperty.PropertiesSetID.String != propsetID) {
+		app.ShowError(w, app.ErrEntityNotFound, sql.ErrNoRows, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
 	// Update
 	err = propertyRepo.Update(property)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
 		return
@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/markbates/pop/nulls"
+)
+
+// propertyFilterFromURL - Builds a property value filter from request query values.
+// name=rooms&valueType=i&value=gas
+// min=1&max=3&currency=EUR
+// date=2017-06-01
+// referenceType=listing&referenceID=uuid
+// contains=pool,garden
+func propertyFilterFromURL(r *http.Request) (models.PropertyFilter, error) {
+	query := r.URL.Query()
+	filter := models.PropertyFilter{
+		Name:          query.Get("name"),
+		ValueType:     query.Get("valueType"),
+		Value:         query.Get("value"),
+		Currency:      strings.ToUpper(query.Get("currency")),
+		ReferenceType: query.Get("referenceType"),
+		ReferenceID:   query.Get("referenceID"),
+	}
+	// Value type
+	if filter.ValueType != "" {
+		if _, ok := models.PropertyValueFields[filter.ValueType]; !ok {
+			return filter, app.ErrRequestPropertyFilter
+		}
+	}
+	// Numeric range
+	var err error
+	if filter.Min, err = parseNullFloat(query.Get("min")); err != nil {
+		return filter, app.ErrRequestPropertyFilter
+	}
+	if filter.Max, err = parseNullFloat(query.Get("max")); err != nil {
+		return filter, app.ErrRequestPropertyFilter
+	}
+	// Date
+	if date := query.Get("date"); date != "" {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return filter, app.ErrRequestPropertyFilter
+		}
+		filter.Date = nulls.NewTime(t)
+	}
+	// Reference
+	if filter.ReferenceID != "" && len(filter.ReferenceID) != 36 {
+		return filter, app.ErrRequestPropertyFilter
+	}
+	// List items
+	if contains := query.Get("contains"); contains != "" {
+		for _, item := range strings.Split(contains, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				filter.Contains = append(filter.Contains, item)
+			}
+		}
+	}
+	return filter, nil
+}
+
+func parseNullFloat(value string) (nulls.Float64, error) {
+	if value == "" {
+		return nulls.Float64{}, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nulls.Float64{}, err
+	}
+	return nulls.NewFloat64(v), nil
+}
@@ -24,6 +24,7 @@
 package api
 
 import (
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/models"
 )
 
@@ -160,7 +161,8 @@ type (
 
 	// PropertiesSetsResource - Resource
 	PropertiesSetsResource struct {
-		Data []models.PropertiesSet `json:"data"`
+		Data   []models.PropertiesSet `json:"data"`
+		Facets []models.Facet         `json:"facets,omitempty"`
 	}
 
 	// PropertiesSetResource  - Resource
@@ -197,4 +199,307 @@ type (
 	PlanResource struct {
 		Data models.Plan `json:"data"`
 	}
+
+	// PropertiesSetTemplatesResource - Resource
+	PropertiesSetTemplatesResource struct {
+		Data []models.PropertiesSetTemplate `json:"data"`
+	}
+
+	// PropertiesSetTemplateResource - Resource
+	PropertiesSetTemplateResource struct {
+		Data models.PropertiesSetTemplate `json:"data"`
+	}
+
+	// ListingsResource - Resource
+	ListingsResource struct {
+		Data   []models.Listing `json:"data"`
+		Facets []models.Facet   `json:"facets,omitempty"`
+	}
+
+	// ListingResource - Resource
+	ListingResource struct {
+		Data models.Listing `json:"data"`
+	}
+
+	// PermissionCacheStatsResource - Resource
+	PermissionCacheStatsResource struct {
+		Data cache.DecisionStats `json:"data"`
+	}
+
+	// SearchResultsResource - Resource
+	SearchResultsResource struct {
+		Data []models.SearchResult `json:"data"`
+	}
+
+	// BuildingsResource - Resource
+	BuildingsResource struct {
+		Data []models.Building `json:"data"`
+	}
+
+	// BuildingResource - Resource
+	BuildingResource struct {
+		Data models.Building `json:"data"`
+	}
+
+	// BuildingOccupancyResource - Resource
+	BuildingOccupancyResource struct {
+		Data models.BuildingOccupancy `json:"data"`
+	}
+
+	// UnitsResource - Resource
+	UnitsResource struct {
+		Data []models.Unit `json:"data"`
+	}
+
+	// UnitResource - Resource
+	UnitResource struct {
+		Data models.Unit `json:"data"`
+	}
+
+	// LeasesResource - Resource
+	LeasesResource struct {
+		Data []models.Lease `json:"data"`
+	}
+
+	// LeaseResource - Resource
+	LeaseResource struct {
+		Data models.Lease `json:"data"`
+	}
+
+	// RentScheduleResource - Resource
+	RentScheduleResource struct {
+		Data []models.RentInstallment `json:"data"`
+	}
+
+	// AccountsResource - Resource
+	AccountsResource struct {
+		Data []models.Account `json:"data"`
+	}
+
+	// JournalEntriesResource - Resource
+	JournalEntriesResource struct {
+		Data []models.JournalEntry `json:"data"`
+	}
+
+	// JournalEntryResource - Resource
+	JournalEntryResource struct {
+		Data models.JournalEntry `json:"data"`
+	}
+
+	// InvoicesResource - Resource
+	InvoicesResource struct {
+		Data []models.Invoice `json:"data"`
+	}
+
+	// InvoiceResource - Resource
+	InvoiceResource struct {
+		Data models.Invoice `json:"data"`
+	}
+
+	// PaymentResource - Resource
+	PaymentResource struct {
+		Data models.Payment `json:"data"`
+	}
+
+	// StatementResource - Resource
+	StatementResource struct {
+		Data models.Statement `json:"data"`
+	}
+
+	// BankStatementsResource - Resource
+	BankStatementsResource struct {
+		Data []models.BankStatement `json:"data"`
+	}
+
+	// BankStatementResource - Resource
+	BankStatementResource struct {
+		Data models.BankStatement `json:"data"`
+	}
+
+	// BankStatementImportResource - Resource
+	BankStatementImportResource struct {
+		Data models.BankStatementImport `json:"data"`
+	}
+
+	// BankTransactionsResource - Resource
+	BankTransactionsResource struct {
+		Data []models.BankTransaction `json:"data"`
+	}
+
+	// BankTransactionResource - Resource
+	BankTransactionResource struct {
+		Data models.BankTransaction `json:"data"`
+	}
+
+	// BankMatchResource - Resource
+	BankMatchResource struct {
+		Data models.BankMatch `json:"data"`
+	}
+
+	// MaintenanceRequestsResource - Resource
+	MaintenanceRequestsResource struct {
+		Data []models.MaintenanceRequest `json:"data"`
+	}
+
+	// MaintenanceRequestResource - Resource
+	MaintenanceRequestResource struct {
+		Data models.MaintenanceRequest `json:"data"`
+	}
+
+	// WorkOrdersResource - Resource
+	WorkOrdersResource struct {
+		Data []models.WorkOrder `json:"data"`
+	}
+
+	// WorkOrderResource - Resource
+	WorkOrderResource struct {
+		Data models.WorkOrder `json:"data"`
+	}
+
+	// MaintenanceCommentsResource - Resource
+	MaintenanceCommentsResource struct {
+		Data []models.MaintenanceComment `json:"data"`
+	}
+
+	// MaintenanceCommentResource - Resource
+	MaintenanceCommentResource struct {
+		Data models.MaintenanceComment `json:"data"`
+	}
+
+	// MaintenancePhotosResource - Resource
+	MaintenancePhotosResource struct {
+		Data []models.MaintenancePhoto `json:"data"`
+	}
+
+	// MaintenancePhotoResource - Resource
+	MaintenancePhotoResource struct {
+		Data models.MaintenancePhoto `json:"data"`
+	}
+
+	// AvailabilityWindowsResource - Resource
+	AvailabilityWindowsResource struct {
+		Data []models.AvailabilityWindow `json:"data"`
+	}
+
+	// AvailabilityWindowResource - Resource
+	AvailabilityWindowResource struct {
+		Data models.AvailabilityWindow `json:"data"`
+	}
+
+	// ViewingsResource - Resource
+	ViewingsResource struct {
+		Data []models.Viewing `json:"data"`
+	}
+
+	// ViewingResource - Resource
+	ViewingResource struct {
+		Data models.Viewing `json:"data"`
+	}
+
+	// ViewingSlotsResource - Resource
+	ViewingSlotsResource struct {
+		Data []models.ViewingSlot `json:"data"`
+	}
+
+	// LeadStagesResource - Resource
+	LeadStagesResource struct {
+		Data []models.LeadStage `json:"data"`
+	}
+
+	// LeadStageResource - Resource
+	LeadStageResource struct {
+		Data models.LeadStage `json:"data"`
+	}
+
+	// LeadsResource - Resource
+	LeadsResource struct {
+		Data []models.Lead `json:"data"`
+	}
+
+	// LeadResource - Resource
+	LeadResource struct {
+		Data models.Lead `json:"data"`
+	}
+
+	// LeadActivitiesResource - Resource
+	LeadActivitiesResource struct {
+		Data []models.LeadActivity `json:"data"`
+	}
+
+	// LeadActivityResource - Resource
+	LeadActivityResource struct {
+		Data models.LeadActivity `json:"data"`
+	}
+
+	// FunnelResource - Resource
+	FunnelResource struct {
+		Data []models.FunnelStage `json:"data"`
+	}
+
+	// AlbumsResource - Resource
+	AlbumsResource struct {
+		Data []models.Album `json:"data"`
+	}
+
+	// AlbumResource - Resource
+	AlbumResource struct {
+		Data   models.Album   `json:"data"`
+		Images []models.Image `json:"images,omitempty"`
+	}
+
+	// AlbumOrderResource - Resource
+	AlbumOrderResource struct {
+		Data []string `json:"data"`
+	}
+
+	// ImagesResource - Resource
+	ImagesResource struct {
+		Data []models.Image `json:"data"`
+	}
+
+	// ImageResource - Resource
+	ImageResource struct {
+		Data models.Image `json:"data"`
+	}
+
+	// DocumentsResource - Resource
+	DocumentsResource struct {
+		Data []models.Document `json:"data"`
+	}
+
+	// DocumentResource - Resource
+	DocumentResource struct {
+		Data models.Document `json:"data"`
+	}
+
+	// DocumentVersionsResource - Resource
+	DocumentVersionsResource struct {
+		Data []models.DocumentVersion `json:"data"`
+	}
+
+	// DocumentVersionResource - Resource
+	DocumentVersionResource struct {
+		Data models.DocumentVersion `json:"data"`
+	}
+
+	// ListingHistoryResource - Resource
+	ListingHistoryResource struct {
+		Data  []models.ListingHistory `json:"data"`
+		Stats models.ListingStats     `json:"stats"`
+	}
+
+	// RoleParentResource - Resource
+	RoleParentResource struct {
+		Data models.RoleParent `json:"data"`
+	}
+
+	// AccessControlEntryResource - Resource
+	AccessControlEntryResource struct {
+		Data models.AccessControlEntry `json:"data"`
+	}
+
+	// AccessControlEntriesResource - Resource
+	AccessControlEntriesResource struct {
+		Data []models.AccessControlEntry `json:"data"`
+	}
 )
@@ -92,9 +92,15 @@ func CreateResourcePermission(w http.ResponseWriter, r *http.Request) {
 	// Set values
 	u, _ := sessionUser(r)
 	resourcePermission.CreatedBy = u.ID
+	resourcePermission.SetDefaults()
 	genResourcePermissionName(resourcePermission)
+	// Validate
+	if !resourcePermission.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
 	// Persist
-	resourcePermissionRepo.Create(resourcePermission)
+	err = resourcePermissionRepo.Create(resourcePermission)
 	if err != nil {
 		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
 		return
@@ -217,6 +223,15 @@ func UpdateResourcePermission(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
 		return
 	}
+	// Keep current action if not provided
+	if resourcePermission.Action.String == "" {
+		resourcePermission.Action = currentResourcePermission.Action
+	}
+	// Validate
+	if !resourcePermission.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
 	// Update
 	err = resourcePermissionRepo.Update(resourcePermission)
 	if err != nil {
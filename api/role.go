@@ -24,6 +24,7 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/url"
@@ -265,3 +266,168 @@ func rolenameFromURL(r *http.Request) string {
 	logger.Debugf("RoleName in url is %s", rolename)
 	return rolename
 }
+
+// GetRoleParents - Returns a collection containing the roles a role directly inherits from.
+// Handler for HTTP Get - "/organizations/{organization}/roles/{role}/parents"
+func GetRoleParents(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["role"]
+	// Get repo
+	roleRepo, err := repo.MakeRoleRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role belongs to organization
+	_, err = roleRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	roles, err := roleRepo.GetParents(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(RolesResource{Data: roles})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// AddRoleParent - Makes a role inherit the permissions of another role of the organization.
+// Handler for HTTP Post - "/organizations/{organization}/roles/{role}/parents"
+func AddRoleParent(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["role"]
+	// Decode
+	var res RoleParentResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	parent := &res.Data
+	// Get repo
+	roleRepo, err := repo.MakeRoleRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role belongs to organization
+	_, err = roleRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Validate
+	if !isUUID(parent.ParentID.String) {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	parent.RoleID = models.ToNullsString(id)
+	parent.OrganizationID = models.ToNullsString(orgid)
+	parent.CreatedBy = models.ToNullsString(loggedInUserID(r))
+	// Persist
+	err = roleRepo.AddParent(parent)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(RoleParentResource{Data: *parent})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// DeleteRoleParent - Stops a role from inheriting the permissions of a parent role.
+// Handler for HTTP Delete - "/organizations/{organization}/roles/{role}/parents/{parent}"
+func DeleteRoleParent(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["role"]
+	parentID := vars["parent"]
+	// Get repo
+	roleRepo, err := repo.MakeRoleRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role belongs to organization
+	_, err = roleRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Delete
+	err = roleRepo.RemoveParent(id, parentID)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRoleEffectivePermissions - Returns the permissions of a role, including the ones inherited from its ancestors.
+// Handler for HTTP Get - "/organizations/{organization}/roles/{role}/permissions"
+func GetRoleEffectivePermissions(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["role"]
+	// Get repo
+	roleRepo, err := repo.MakeRoleRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role belongs to organization
+	_, err = roleRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	permissions, err := roleRepo.GetEffectivePermissions(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PermissionsResource{Data: permissions})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+)
+
+// Search - Returns documents matching a full text search.
+// Handler for HTTP Get - "/search?q=text&types=listing,organization&organization=id&limit=20&offset=0"
+func Search(w http.ResponseWriter, r *http.Request) {
+	// Query
+	query, err := searchQueryFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrSearch, err, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	searchRepo, err := repo.MakeSearchRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrSearch, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	results, err := searchRepo.Search(query)
+	if err != nil {
+		app.ShowError(w, app.ErrSearch, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(SearchResultsResource{Data: results})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+func searchQueryFromURL(r *http.Request) (models.SearchQuery, error) {
+	values := r.URL.Query()
+	query := models.SearchQuery{
+		Text:           strings.TrimSpace(values.Get("q")),
+		OrganizationID: values.Get("organization"),
+		UserID:         loggedInUserID(r),
+		Limit:          searchDefaultLimit,
+	}
+	if query.Text == "" {
+		return query, app.ErrRequest
+	}
+	// Types
+	if types := values.Get("types"); types != "" {
+		for _, searchType := range strings.Split(types, ",") {
+			searchType = strings.TrimSpace(searchType)
+			if !models.IsSearchType(searchType) {
+				return query, app.ErrRequest
+			}
+			query.Types = append(query.Types, searchType)
+		}
+	}
+	// Pagination
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > searchMaxLimit {
+			return query, app.ErrRequest
+		}
+		query.Limit = n
+	}
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return query, app.ErrRequest
+		}
+		query.Offset = n
+	}
+	return query, nil
+}
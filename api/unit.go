@@ -0,0 +1,379 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/mux"
+
+	"net/http"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+)
+
+// GetUnits - Returns a collection containing all units from a building.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}/units"
+func GetUnits(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	units, err := unitRepo.GetAll(building.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(UnitsResource{Data: units})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateUnit - Creates a new Unit.
+// Handler for HTTP Post - "/organizations/{organization}/buildings/{building}/units"
+func CreateUnit(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Decode
+	var res UnitResource
+	err = json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	unit := &res.Data
+	// Set Building and Organization - Don't trust JSON value
+	unit.BuildingID = building.ID
+	unit.OrganizationID = building.OrganizationID
+	// Set values
+	u, _ := sessionUser(r)
+	unit.CreatedBy = u.ID
+	unit.SetDefaults()
+	// Validate
+	if !unit.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = unitRepo.Create(unit)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(UnitResource{Data: *unit})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetUnit - Returns a single Unit by its id or name.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}/units/{unit}"
+func GetUnit(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	key := vars["unit"]
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var unit models.Unit
+	if len(key) == 36 {
+		unit, err = unitRepo.GetFromBuilding(key, building.ID.String)
+	} else {
+		unit, err = unitRepo.GetByNameInBuilding(key, building.ID.String)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(UnitResource{Data: unit})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// UpdateUnit - Update an existing Unit.
+// Handler for HTTP Put - "/organizations/{organization}/buildings/{building}/units/{unit}"
+func UpdateUnit(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	id := vars["unit"]
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Decode
+	var res UnitResource
+	err = json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	unit := &res.Data
+	unit.ID = models.ToNullsString(id)
+	unit.BuildingID = building.ID
+	unit.OrganizationID = building.OrganizationID
+	// Get repo
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check against current unit
+	currentUnit, err := unitRepo.GetFromBuilding(id, building.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Avoid ID spoofing
+	err = verifyID(unit.IdentifiableModel, currentUnit.IdentifiableModel)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusUnauthorized)
+		return
+	}
+	// Keep current kind and status if not provided
+	if unit.Kind.String == "" {
+		unit.Kind = currentUnit.Kind
+	}
+	if unit.Status.String == "" {
+		unit.Status = currentUnit.Status
+	}
+	// Validate
+	if !unit.IsValid() {
+		app.ShowError(w, app.ErrEntityUpdate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Update
+	err = unitRepo.Update(unit)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(UnitResource{Data: *unit})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNoContent)
+	w.Write(j)
+}
+
+// DeleteUnit - Deletes an existing Unit
+// Handler for HTTP Delete - "/organizations/{organization}/buildings/{building}/units/{unit}"
+func DeleteUnit(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	id := vars["unit"]
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Delete
+	err = unitRepo.DeleteFromBuilding(id, building.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUnitPropertiesSets - Returns the properties sets holding the attributes of a Unit.
+// Handler for HTTP Get - "/organizations/{organization}/buildings/{building}/units/{unit}/properties-sets"
+func GetUnitPropertiesSets(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	id := vars["unit"]
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check unit belongs to building
+	_, err = unitRepo.GetFromBuilding(id, building.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Select
+	propSets, err := unitRepo.GetPropertiesSets(id)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetsResource{Data: propSets})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateUnitPropertiesSet - Attaches a new properties set to a Unit.
+// Handler for HTTP Post - "/organizations/{organization}/buildings/{building}/units/{unit}/properties-sets"
+func CreateUnitPropertiesSet(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	id := vars["unit"]
+	// Check building belongs to organization
+	building, err := organizationBuilding(vars["organization"], vars["building"])
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Decode
+	var res PropertiesSetResource
+	err = json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	propertiesSet := &res.Data
+	// Get repos
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	propertiesSetRepo, err := repo.MakePropertiesSetRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Check unit belongs to building
+	unit, err := unitRepo.GetFromBuilding(id, building.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Set holder - Don't trust JSON value
+	propertiesSet.HolderID = unit.ID
+	u, _ := sessionUser(r)
+	propertiesSet.CreatedBy = u.ID
+	// Persist
+	err = propertiesSetRepo.Create(propertiesSet)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(PropertiesSetResource{Data: *propertiesSet})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// organizationBuilding - Returns the building if it belongs to the organization.
+func organizationBuilding(orgid, id string) (models.Building, error) {
+	buildingRepo, err := repo.MakeBuildingRepository()
+	if err != nil {
+		return models.Building{}, err
+	}
+	return buildingRepo.GetFromOrganization(id, orgid)
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/types"
 
 	_ "github.com/lib/pq" // Import pq without side effects
 )
@@ -155,21 +156,39 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusInternalServerError)
 		return
 	}
+	// Location filter
+	geoFilter, err := geoFilterFromURL(r)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestGeoFilter, err, http.StatusBadRequest)
+		return
+	}
 	// Select
-	users, err := userRepo.GetAll()
+	var users []models.User
+	if geoFilter.IsEmpty() {
+		users, err = userRepo.GetAll()
+	} else {
+		users, err = userRepo.GetAllByLocation(geoFilter)
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
 		return
 	}
 	// Marshal
-	j, err := json.Marshal(UsersResource{Data: users})
+	var j []byte
+	contentType := "application/json"
+	if isGeoJSONRequest(r) {
+		j, err = marshalFeatureCollection(users, "geolocation")
+		contentType = types.GeoJSONMediaType
+	} else {
+		j, err = json.Marshal(UsersResource{Data: users})
+	}
 	if err != nil {
 		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
 		return
 	}
 	// Respond
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
 	w.Write(j)
 }
 
@@ -0,0 +1,643 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/models"
+
+	_ "github.com/lib/pq" // Import pq without side effects
+
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/services"
+)
+
+// GetAvailabilityWindows - Returns the current and upcoming availability windows of the organization agents.
+// Handler for HTTP Get - "/organizations/{organization}/appointments/availability"
+// Optional query value 'agent' restricts the collection to the windows of an agent.
+func GetAvailabilityWindows(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	agentID := r.URL.Query().Get("agent")
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var windows []models.AvailabilityWindow
+	if agentID != "" {
+		windows, err = viewingRepo.GetAgentWindows(orgid, agentID, time.Now())
+	} else {
+		windows, err = viewingRepo.GetWindows(orgid, time.Now())
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AvailabilityWindowsResource{Data: windows})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CreateAvailabilityWindow - Publishes an availability window of the session user.
+// Handler for HTTP Post - "/organizations/{organization}/appointments/availability"
+func CreateAvailabilityWindow(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res AvailabilityWindowResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	window := &res.Data
+	// Set values - Don't trust JSON value
+	u, _ := sessionUser(r)
+	window.OrganizationID = models.ToNullsString(orgid)
+	window.AgentID = u.ID
+	window.CreatedBy = u.ID
+	window.SetDefaults()
+	// Check agent
+	isMember, err := organizationMember(orgid, u.ID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	if !isMember {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	// Validate
+	if !window.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = viewingRepo.CreateWindow(window)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(AvailabilityWindowResource{Data: *window})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// DeleteAvailabilityWindow - Withdraws an availability window, already booked viewings are kept.
+// Handler for HTTP Delete - "/organizations/{organization}/appointments/availability/{window}"
+func DeleteAvailabilityWindow(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["window"]
+	userID, _ := sessionUserID(r)
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Check agent
+	window, err := viewingRepo.GetWindow(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if window.AgentID.String != userID {
+		org, err := getOrganization(orgid)
+		if err != nil {
+			app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+			return
+		}
+		if org.UserID.String != userID {
+			app.ShowError(w, app.ErrEntityDelete, app.ErrUnauthorized, http.StatusForbidden)
+			return
+		}
+	}
+	// Delete
+	err = viewingRepo.DeleteWindow(id, orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityDelete, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetViewingSlots - Returns the free viewing slots of a listing.
+// Handler for HTTP Get - "/organizations/{organization}/appointments/slots?listing={listing}&from=1489536000&to=1490140800"
+// Period defaults to the next two weeks, optional query value 'agent' restricts slots to an agent.
+func GetViewingSlots(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	query := r.URL.Query()
+	listingID := query.Get("listing")
+	agentID := query.Get("agent")
+	from, to, err := viewingPeriod(query.Get("from"), query.Get("to"))
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusBadRequest)
+		return
+	}
+	// Check listing belongs to organization and is visible for the user
+	_, err = viewableListing(r, orgid, listingID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var windows []models.AvailabilityWindow
+	if agentID != "" {
+		windows, err = viewingRepo.GetAgentWindows(orgid, agentID, from)
+	} else {
+		windows, err = viewingRepo.GetWindows(orgid, from)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	booked, err := viewingRepo.GetScheduled(orgid, from, to)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	slots := []models.ViewingSlot{}
+	for _, window := range windows {
+		slots = append(slots, window.Slots(from, to, listingID, booked)...)
+	}
+	// Marshal
+	j, err := json.Marshal(ViewingSlotsResource{Data: slots})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// GetViewings - Returns the viewings of an organization visible for the user.
+// Handler for HTTP Get - "/organizations/{organization}/appointments/viewings"
+// Organization members get all viewings, optionally filtered by query values 'agent' or 'listing'.
+// Other users get the viewings they requested.
+func GetViewings(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	agentID := r.URL.Query().Get("agent")
+	listingID := r.URL.Query().Get("listing")
+	userID, _ := sessionUserID(r)
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Check role
+	isMember, err := organizationMember(orgid, userID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	var viewings []models.Viewing
+	switch {
+	case !isMember:
+		viewings, err = viewingRepo.GetAllFromProspect(orgid, userID)
+	case agentID != "":
+		viewings, err = viewingRepo.GetAllFromAgent(orgid, agentID)
+	case listingID != "":
+		viewings, err = viewingRepo.GetAllFromListing(orgid, listingID)
+	default:
+		viewings, err = viewingRepo.GetAll(orgid)
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ViewingsResource{Data: viewings})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// BookViewing - Books a viewing of a published listing.
+// Handler for HTTP Post - "/organizations/{organization}/appointments/viewings"
+// Slot is taken from the first available agent unless one is requested.
+func BookViewing(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	// Decode
+	var res ViewingResource
+	err := json.NewDecoder(r.Body).Decode(&res)
+	if err != nil {
+		app.ShowError(w, app.ErrRequestParsing, err, http.StatusInternalServerError)
+		return
+	}
+	viewing := &res.Data
+	// Set values - Don't trust JSON value
+	u, _ := sessionUser(r)
+	viewing.OrganizationID = models.ToNullsString(orgid)
+	viewing.ProspectUserID = u.ID
+	viewing.CreatedBy = u.ID
+	setViewingProspect(viewing, u)
+	viewing.SetDefaults()
+	// Validate
+	if !viewing.IsValid() {
+		app.ShowError(w, app.ErrEntityCreate, app.ErrEntityInvalidData, http.StatusBadRequest)
+		return
+	}
+	if viewing.StartsAt.Time.Before(time.Now()) {
+		errs := models.ValidationErrors{"startsAt": "viewings can't be booked in the past"}
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusBadRequest)
+		return
+	}
+	// Check listing belongs to organization, is visible for the user and is published
+	listing, err := viewableListing(r, orgid, viewing.ListingID.String)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusBadRequest)
+		return
+	}
+	if listing.Status.String != models.ListingStatusPublished {
+		errs := models.ValidationErrors{"listingID": "listing is not published"}
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusConflict)
+		return
+	}
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = viewingRepo.Book(viewing)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityCreate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ViewingResource{Data: *viewing})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusNoContent)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(j)
+}
+
+// GetViewing - Returns a single viewing.
+// Handler for HTTP Get - "/organizations/{organization}/appointments/viewings/{viewing}"
+func GetViewing(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["viewing"]
+	userID, _ := sessionUserID(r)
+	// Select
+	viewing, err := organizationViewing(orgid, id, userID)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Marshal
+	j, err := json.Marshal(ViewingResource{Data: viewing})
+	if err != nil {
+		app.ShowError(w, app.ErrResponseMarshalling, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// CancelViewing - Cancels a scheduled viewing.
+// Handler for HTTP Post - "/organizations/{organization}/appointments/viewings/{viewing}/cancel"
+func CancelViewing(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	id := vars["viewing"]
+	userID, _ := sessionUserID(r)
+	// Select
+	viewing, err := organizationViewing(orgid, id, userID)
+	if err == sql.ErrNoRows {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Persist
+	err = viewingRepo.Cancel(&viewing)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		app.ShowValidationErrors(w, app.ErrEntityUpdate, errs, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		app.ShowError(w, app.ErrEntityUpdate, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAgentViewingsCalendar - Returns the viewings of an agent as an iCalendar feed.
+// Handler for HTTP Get - "/organizations/{organization}/appointments/agents/{agent}/viewings.ics"
+// Calendar clients can subscribe passing the token in the 'access_token' query value.
+func GetAgentViewingsCalendar(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	agentID := vars["agent"]
+	userID, _ := sessionUserID(r)
+	// Check role
+	isMember, err := organizationMember(orgid, userID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !isMember {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	agent, err := getUser(agentID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	viewings, err := viewingRepo.GetAllFromAgent(orgid, agentID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	listings, err := organizationListings(orgid)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Respond
+	writeCalendar(w, models.ViewingsCalendar("Viewings - "+agent.Username.String, viewings, listings))
+}
+
+// GetListingViewingsCalendar - Returns the viewings of a listing as an iCalendar feed.
+// Handler for HTTP Get - "/organizations/{organization}/appointments/listings/{listing}/viewings.ics"
+// Calendar clients can subscribe passing the token in the 'access_token' query value.
+func GetListingViewingsCalendar(w http.ResponseWriter, r *http.Request) {
+	// Get IDs
+	vars := mux.Vars(r)
+	orgid := vars["organization"]
+	listingID := vars["listing"]
+	userID, _ := sessionUserID(r)
+	// Check role
+	isMember, err := organizationMember(orgid, userID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	if !isMember {
+		app.ShowError(w, app.ErrEntitySelect, app.ErrUnauthorized, http.StatusForbidden)
+		return
+	}
+	listing, err := organizationListing(orgid, listingID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntityNotFound, err, http.StatusNotFound)
+		return
+	}
+	// Get repo
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	// Select
+	viewings, err := viewingRepo.GetAllFromListing(orgid, listingID)
+	if err != nil {
+		app.ShowError(w, app.ErrEntitySelect, err, http.StatusInternalServerError)
+		return
+	}
+	listings := map[string]models.Listing{listing.ID.String: listing}
+	// Respond
+	writeCalendar(w, models.ViewingsCalendar("Viewings - "+listing.Name.String, viewings, listings))
+}
+
+func writeCalendar(w http.ResponseWriter, cal []byte) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=\"viewings.ics\"")
+	w.WriteHeader(http.StatusOK)
+	w.Write(cal)
+}
+
+// organizationMember - Returns true if user owns the organization or holds a role in it.
+func organizationMember(orgid, userID string) (bool, error) {
+	orgRepo, err := repo.MakeOrganizationRepository()
+	if err != nil {
+		return false, err
+	}
+	return orgRepo.IsMember(orgid, userID)
+}
+
+// organizationListing - Returns a listing if it belongs to the organization.
+func organizationListing(orgid, id string) (models.Listing, error) {
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		return models.Listing{}, err
+	}
+	return listingRepo.GetFromOrganization(id, orgid)
+}
+
+// viewableListing - Returns a listing of the organization visible for the session user.
+// Users taking part in the organization see all its listings, others only the published ones.
+func viewableListing(r *http.Request, orgid, id string) (models.Listing, error) {
+	listing, err := organizationListing(orgid, id)
+	if err != nil || listing.Status.String == models.ListingStatusPublished {
+		return listing, err
+	}
+	canView, err := sessionCan(r, models.AppointmentResourceTag, orgid)
+	if err == nil && !canView {
+		canView, err = services.IsOrganizationParty(loggedInUserID(r), orgid)
+	}
+	if err != nil {
+		return listing, err
+	}
+	if !canView {
+		return models.Listing{}, sql.ErrNoRows
+	}
+	return listing, nil
+}
+
+// organizationListings - Returns the organization listings by ID.
+func organizationListings(orgid string) (map[string]models.Listing, error) {
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		return nil, err
+	}
+	listings, err := listingRepo.GetAll(orgid)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Listing)
+	for _, listing := range listings {
+		byID[listing.ID.String] = listing
+	}
+	return byID, nil
+}
+
+// organizationViewing - Returns a viewing visible for the user, organization members
+// see all viewings and prospects those they requested.
+func organizationViewing(orgid, id, userID string) (models.Viewing, error) {
+	viewingRepo, err := repo.MakeViewingRepository()
+	if err != nil {
+		return models.Viewing{}, err
+	}
+	viewing, err := viewingRepo.GetFromOrganization(id, orgid)
+	if err != nil {
+		return viewing, err
+	}
+	if viewing.ProspectUserID.String == userID {
+		return viewing, nil
+	}
+	isMember, err := organizationMember(orgid, userID)
+	if err != nil {
+		return viewing, err
+	}
+	if !isMember {
+		return models.Viewing{}, sql.ErrNoRows
+	}
+	return viewing, nil
+}
+
+// setViewingProspect - Uses user data as prospect contact data if not provided.
+func setViewingProspect(viewing *models.Viewing, user models.User) {
+	if viewing.Name.String == "" {
+		name := strings.TrimSpace(user.FirstName.String + " " + user.LastName.String)
+		if name == "" {
+			name = user.Username.String
+		}
+		viewing.Name = models.ToNullsString(name)
+	}
+	if viewing.ProspectEmail.String == "" && viewing.ProspectPhone.String == "" {
+		viewing.ProspectEmail = user.Email
+	}
+}
+
+// viewingPeriod - Parses a period from unix times, defaults to the next days.
+func viewingPeriod(fromValue, toValue string) (time.Time, time.Time, error) {
+	from := time.Now()
+	if fromValue != "" {
+		secs, err := strconv.ParseInt(fromValue, 10, 64)
+		if err != nil {
+			return from, from, err
+		}
+		from = time.Unix(secs, 0)
+	}
+	to := from.AddDate(0, 0, models.ViewingSlotsDays)
+	if toValue != "" {
+		secs, err := strconv.ParseInt(toValue, 10, 64)
+		if err != nil {
+			return from, to, err
+		}
+		to = time.Unix(secs, 0)
+	}
+	if !to.After(from) {
+		return from, to, app.ErrRequest
+	}
+	return from, to, nil
+}
@@ -46,6 +46,14 @@ var (
 	ErrRequest = errors.New("Bad request")
 	// ErrRequestParsing - Error parsing request data.
 	ErrRequestParsing = errors.New("Error parsing request data")
+	// ErrRequestGeoFilter - Invalid location filter.
+	ErrRequestGeoFilter = errors.New("Invalid location filter")
+	// ErrRequestPropertyFilter - Invalid property filter.
+	ErrRequestPropertyFilter = errors.New("Invalid property filter")
+	// ErrRequestFacetQuery - Invalid facet query.
+	ErrRequestFacetQuery = errors.New("Invalid facet query")
+	// ErrRequestStatsFilter - Invalid listing statistics filter.
+	ErrRequestStatsFilter = errors.New("Invalid listing statistics filter")
 	// ErrImageDecoding - Error decoding image data.
 	ErrImageDecoding = errors.New("Error decoding image data")
 	// ErrResponseMarshalling - Error marshalling response data.
@@ -66,6 +74,8 @@ var (
 	ErrNotLoggedIn = errors.New("Not logged in")
 	// ErrUnauthorized - Unauthorized
 	ErrUnauthorized = errors.New("Unauthorized")
+	// ErrForbidden - Not enough permissions.
+	ErrForbidden = errors.New("Forbidden")
 	// ErrOwnerOnlyCanManage - Error while generating the access token
 	ErrOwnerOnlyCanManage = errors.New("Only entity owner are allowed to manage entity")
 	// ErrEntityInvalidData - Entity invalid data.
@@ -80,6 +90,8 @@ var (
 	ErrEntityUpdate = errors.New("Cannot update entity")
 	// ErrEntityDelete - Cannot delete entity.
 	ErrEntityDelete = errors.New("Cannot delete entity")
+	// ErrEntityStatusChange - Invalid entity status change.
+	ErrEntityStatusChange = errors.New("Invalid entity status change")
 	// ErrEntitySetProperty - Cannot set property.
 	ErrEntitySetProperty = errors.New("Cannot set property")
 	// ErrSearch - Search error.
@@ -88,6 +100,12 @@ var (
 	ErrRequestProcessing = errors.New("Cannot process your request")
 	// ErrImageProcessing - Error processing image.
 	ErrImageProcessing = errors.New("Error processing image")
+	// ErrFileType - File type not allowed.
+	ErrFileType = errors.New("File type not allowed")
+	// ErrFileSize - File exceeds size limit.
+	ErrFileSize = errors.New("File is too big")
+	// ErrFileStorage - Error storing or reading file.
+	ErrFileStorage = errors.New("File storage error")
 	// ErrPageNotFoud - Error page not found.
 	ErrPageNotFoud = errors.New("Page not found")
 	// ErrTemplateExecution - Error template execution.
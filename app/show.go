@@ -35,9 +35,10 @@ const exposeSourceError = true
 
 type (
 	appError struct {
-		Error      string `json:"error"`
-		Cause      string `json:"cause"`
-		HTTPStatus int    `json:"status"`
+		Error      string            `json:"error"`
+		Cause      string            `json:"cause"`
+		HTTPStatus int               `json:"status"`
+		Fields     map[string]string `json:"fields,omitempty"`
 	}
 	errorResource struct {
 		Data appError `json:"data"`
@@ -63,3 +64,19 @@ func ShowError(w http.ResponseWriter, err error, cause error, code int) {
 		w.Write(j)
 	}
 }
+
+// ShowValidationErrors - Shows error, per field validation errors and http code.
+func ShowValidationErrors(w http.ResponseWriter, err error, fields map[string]string, code int) {
+	errObj := appError{
+		Error:      err.Error(),
+		Cause:      ErrEntityInvalidData.Error(),
+		HTTPStatus: code,
+		Fields:     fields,
+	}
+	logger.Errorf("[Fundacja]: %s - %v\n", err, fields)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if j, err := json.Marshal(errorResource{Data: errObj}); err == nil {
+		w.Write(j)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bootstrap
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/adrianpk/fundacja/cache"
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/logger"
+)
+
+// permissionsChannel - Database triggers notify changes of roles, permissions and grants here.
+const permissionsChannel = "permissions_changed"
+
+func initPermissionCache() {
+	ttl := cache.DefaultDecisionTTL
+	if AppConfig.PermissionCacheTTL != 0 {
+		ttl = time.Duration(AppConfig.PermissionCacheTTL) * time.Second
+	}
+	cache.Decisions = cache.NewDecisionCache(ttl, cache.DefaultMaxDecisions)
+	// Changes made by other instances or straight in the database
+	err := db.Listen(permissionsChannel, cache.Decisions.Invalidate)
+	if err != nil {
+		// Still invalidated by changes made through this instance, the rest are picked up on expiration
+		logger.Debugf("Not listening to permission changes: %s", err.Error())
+	}
+	if expvar.Get("permissionCache") == nil {
+		expvar.Publish("permissionCache", expvar.Func(func() interface{} {
+			return cache.Decisions.Stats()
+		}))
+	}
+}
@@ -30,7 +30,7 @@ import (
 
 const (
 	rollbackAll   = true
-	migrationsNum = 13
+	migrationsNum = 35
 )
 
 var (
@@ -55,8 +55,12 @@ func Boot() {
 	initConfig(env)
 	// Initialize Logger objects with Log Level
 	initLogger()
+	// Initialize blob store
+	initStorage()
 	// Initialize migrations
 	initMigrationOrRollback()
+	// Initialize permission decision cache
+	initPermissionCache()
 	// Initialize private/public keys for JWT authentication
 	initKeys()
 	// Start a MongoDB session
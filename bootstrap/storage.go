@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package bootstrap
+
+import (
+	"crypto/rand"
+	"path"
+	"time"
+
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/storage"
+)
+
+const (
+	blobsDir = "blobs"
+	// Signed local blob URLs are served under this path
+	blobsURLPath = "/blobs"
+)
+
+func initStorage() {
+	conf := storage.Config{
+		Driver:        AppConfig.StorageDriver,
+		Root:          AppConfig.StorageRoot,
+		URLBase:       AppConfig.BlobURLBase,
+		SigningKey:    []byte(AppConfig.BlobSigningKey),
+		Endpoint:      AppConfig.S3Endpoint,
+		Region:        AppConfig.S3Region,
+		Bucket:        AppConfig.S3Bucket,
+		AccessKey:     AppConfig.S3AccessKey,
+		SecretKey:     AppConfig.S3SecretKey,
+		PathStyle:     AppConfig.S3PathStyle,
+		URLExpiration: time.Duration(AppConfig.BlobURLExpiration) * time.Second,
+	}
+	if conf.Driver == "" {
+		conf.Driver = storage.LocalDriver
+	}
+	if conf.Root == "" {
+		conf.Root = path.Join(AppConfig.GetResourcesDir(), blobsDir)
+	}
+	if conf.URLBase == "" {
+		conf.URLBase = blobsURLPath
+	}
+	if len(conf.SigningKey) == 0 {
+		// Signed URLs won't survive a restart nor work across instances
+		logger.Debug("BlobSigningKey not configured, using a random one.")
+		conf.SigningKey = make([]byte, 32)
+		rand.Read(conf.SigningKey)
+	}
+	storage.StoreConfig = conf
+}
@@ -48,6 +48,20 @@ type (
 		LogLevel                                int
 		LogFile                                 string
 		Autoreload                              bool
+		// Blob store
+		StorageDriver     string
+		StorageRoot       string
+		BlobURLBase       string
+		BlobSigningKey    string
+		BlobURLExpiration int
+		S3Endpoint        string
+		S3Region          string
+		S3Bucket          string
+		S3AccessKey       string
+		S3SecretKey       string
+		S3PathStyle       bool
+		// Permission cache
+		PermissionCacheTTL int
 	}
 )
 
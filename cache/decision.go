@@ -0,0 +1,143 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultDecisionTTL - Time an authorization decision is reused if no change invalidates it before.
+	DefaultDecisionTTL = 60 * time.Second
+	// DefaultMaxDecisions - Decisions kept before the cache is cleared to bound its memory.
+	DefaultMaxDecisions = 10000
+)
+
+// Decisions - Authorization decisions cache shared by the application.
+var Decisions = NewDecisionCache(DefaultDecisionTTL, DefaultMaxDecisions)
+
+type (
+	// DecisionCache - In-process cache of authorization decisions.
+	// Decisions expire after a TTL and are all dropped whenever
+	// roles, permissions or grants change.
+	DecisionCache struct {
+		// Metrics, first so they are 64-bit aligned for atomic access
+		hits          uint64
+		misses        uint64
+		invalidations uint64
+		ttl           time.Duration
+		max           int
+		mutex         sync.RWMutex
+		decisions     map[string]decision
+		generation    uint64
+	}
+
+	decision struct {
+		allowed bool
+		expires time.Time
+	}
+
+	// DecisionStats - Decision cache metrics.
+	DecisionStats struct {
+		Hits          uint64  `json:"hits"`
+		Misses        uint64  `json:"misses"`
+		Invalidations uint64  `json:"invalidations"`
+		Entries       int     `json:"entries"`
+		HitRatio      float64 `json:"hitRatio"`
+	}
+)
+
+// NewDecisionCache - DecisionCache constructor.
+// A zero TTL disables caching, every lookup computes the decision.
+func NewDecisionCache(ttl time.Duration, max int) *DecisionCache {
+	return &DecisionCache{
+		ttl:       ttl,
+		max:       max,
+		decisions: make(map[string]decision),
+	}
+}
+
+// DecisionKey - Builds the key of a decision from the user, resource, action and scope it was taken for.
+func DecisionKey(parts ...string) string {
+	return strings.Join(parts, "|")
+}
+
+// Lookup - Returns the cached decision for key or computes and caches it.
+// Errors are not cached. A decision computed while the cache was invalidated is
+// returned but not cached, it could have been taken over stale data.
+func (cache *DecisionCache) Lookup(key string, compute func() (bool, error)) (bool, error) {
+	now := time.Now()
+	cache.mutex.RLock()
+	cached, ok := cache.decisions[key]
+	generation := cache.generation
+	cache.mutex.RUnlock()
+	if ok && now.Before(cached.expires) {
+		atomic.AddUint64(&cache.hits, 1)
+		return cached.allowed, nil
+	}
+	atomic.AddUint64(&cache.misses, 1)
+	allowed, err := compute()
+	if err != nil || cache.ttl <= 0 {
+		return allowed, err
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.generation != generation {
+		return allowed, nil
+	}
+	if len(cache.decisions) >= cache.max {
+		cache.decisions = make(map[string]decision)
+	}
+	cache.decisions[key] = decision{allowed: allowed, expires: now.Add(cache.ttl)}
+	return allowed, nil
+}
+
+// Invalidate - Drops every cached decision.
+func (cache *DecisionCache) Invalidate() {
+	cache.mutex.Lock()
+	cache.decisions = make(map[string]decision)
+	cache.generation++
+	cache.mutex.Unlock()
+	atomic.AddUint64(&cache.invalidations, 1)
+}
+
+// Stats - Returns the cache metrics.
+func (cache *DecisionCache) Stats() DecisionStats {
+	cache.mutex.RLock()
+	entries := len(cache.decisions)
+	cache.mutex.RUnlock()
+	stats := DecisionStats{
+		Hits:          atomic.LoadUint64(&cache.hits),
+		Misses:        atomic.LoadUint64(&cache.misses),
+		Invalidations: atomic.LoadUint64(&cache.invalidations),
+		Entries:       entries,
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
@@ -25,6 +25,7 @@ package controllers
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	_ "image/gif"  // Required by "image" library.
@@ -144,9 +145,12 @@ func handleJSONUploads(w http.ResponseWriter, r *http.Request) (fileInfos []*mod
 		return
 	}
 	// Persist
-
-	profile.SetAvatarAsBase64(base64Data)
-	profileRepo.SaveProfileAvatar(&profile)
+	data, err := base64.StdEncoding.DecodeString(base64Data)
+	if err != nil {
+		app.ShowError(w, app.ErrImageDecoding, err, http.StatusBadRequest)
+		return
+	}
+	err = profileRepo.SaveProfileAvatar(&profile, data)
 	if err != nil {
 		app.ShowError(w, app.ErrEntityCreate, err, http.StatusInternalServerError)
 		return
@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	listenerPing         = 90 * time.Second
+)
+
+// Listen - Calls notify on every notification sent to a channel.
+// notify is also called after reconnecting since notifications sent while disconnected are lost.
+func Listen(channel string, notify func()) error {
+	connStr := fmt.Sprintf("user=%s password=%s dbname=%s sslmode=%s", DBConfig.User, DBConfig.Pass, DBConfig.DB, DBConfig.SSL)
+	listener := pq.NewListener(connStr, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if event == pq.ListenerEventReconnected {
+			notify()
+		}
+	})
+	err := listener.Listen(channel)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	go func() {
+		for {
+			select {
+			case <-listener.Notify:
+				notify()
+			case <-time.After(listenerPing):
+				go listener.Ping()
+			}
+		}
+	}()
+	return nil
+}
@@ -15,3 +15,21 @@ go test tests/user_role_test.go
 go test tests/properties_set_test.go
 go test tests/plan_test.go
 go test tests/plan_subscription_test.go
+go test tests/listing_test.go
+go test tests/properties_set_template_test.go
+go test tests/search_test.go
+go test tests/building_test.go
+go test tests/unit_test.go
+go test tests/lease_test.go
+go test tests/invoice_test.go
+go test tests/ledger_test.go
+go test tests/bank_statement_test.go
+go test tests/maintenance_test.go
+go test tests/viewing_test.go
+go test tests/lead_test.go
+go test tests/album_test.go
+go test tests/document_test.go
+go test tests/storage_test.go
+go test tests/rbac_test.go
+go test tests/access_control_entry_test.go
+go test tests/permission_cache_test.go
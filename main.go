@@ -35,11 +35,13 @@ import (
 	"github.com/adrianpk/fundacja/controllers"
 	"github.com/adrianpk/fundacja/handler"
 	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
 )
 
 func main() {
 	bootstrap.SetBootParameters(mockBootParameters())
 	bootstrap.Boot()
+	moveLegacyImages()
 	controllers.Initialize()
 	handler := handler.AppHandler(bootstrap.AppConfig)
 	server := &http.Server{
@@ -54,6 +56,23 @@ func main() {
 	logger.Debug("Listening...")
 }
 
+// moveLegacyImages - Moves profile images still kept in database or filesystem to the blob store.
+func moveLegacyImages() {
+	profileRepo, err := repo.MakeProfileRepository()
+	if err != nil {
+		logger.Debugf("Error: %s", err)
+		return
+	}
+	moved, err := profileRepo.MoveLegacyImages()
+	if err != nil {
+		logger.Debugf("Error: %s", err)
+		return
+	}
+	if moved > 0 {
+		logger.Debugf("Moved images of %d profiles to blob store.", moved)
+	}
+}
+
 // FIX: Just for framework testing
 func mockBootParameters() map[string]string {
 	params := make(map[string]string)
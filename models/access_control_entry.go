@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import "encoding/json"
+
+// EntityTypes - Types of the entities access control entries can grant access to.
+// Each one is the tag of the resource its entities belong to.
+var EntityTypes = []string{ListingResourceTag, BuildingResourceTag}
+
+// IsEntityType - True if name is a type of entity access control entries can grant access to.
+func IsEntityType(name string) bool {
+	for _, entityType := range EntityTypes {
+		if entityType == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDefaults - Access control entries grant any action if none is set.
+func (entry *AccessControlEntry) SetDefaults() {
+	if entry.Action.String == "" {
+		entry.Action = ToNullsString(ActionManage)
+	}
+}
+
+// IsValid - Returns true if the entry grants a known action over a known entity type
+// either to a user or to a role.
+func (entry *AccessControlEntry) IsValid() bool {
+	hasUser := entry.UserID.String != ""
+	hasRole := entry.RoleID.String != ""
+	return IsEntityType(entry.EntityType.String) &&
+		entry.EntityID.String != "" &&
+		IsAction(entry.Action.String) &&
+		hasUser != hasRole
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (entry AccessControlEntry) MarshalJSON() ([]byte, error) {
+	type Alias AccessControlEntry
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(entry),
+		CreatedAt: entry.CreatedAt.Time.Unix(),
+	})
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	_ "image/gif" // Required by "image" library.
+	"image/jpeg"
+)
+
+const (
+	// GalleryResourceTag - Tag of the organization resource whose permissions grant albums management.
+	GalleryResourceTag = "gallery"
+	// AlbumOwnerListing - Album of a listing.
+	AlbumOwnerListing = "listing"
+	// AlbumOwnerBuilding - Album of a building.
+	AlbumOwnerBuilding = "building"
+	// AlbumOwnerOrganization - Album of the organization itself.
+	AlbumOwnerOrganization = "organization"
+	// ImageOriginal - Uploaded image rendition.
+	ImageOriginal = "original"
+	// ImageMedium - Image rendition fitting in ImageMediumSize.
+	ImageMedium = "medium"
+	// ImageThumbnail - Square image rendition of ImageThumbnailSize side.
+	ImageThumbnail = "thumbnail"
+	// ImageMaxSize - Max uploaded image size in bytes.
+	ImageMaxSize = 10 << 20
+	// ImageMaxPixels - Max uploaded image pixels, bigger images are not decoded.
+	ImageMaxPixels = 40000000
+	// ImageMediumSize - Max side of medium renditions.
+	ImageMediumSize = 1024
+	// ImageThumbnailSize - Side of thumbnail renditions.
+	ImageThumbnailSize = 240
+	// ImageRenditionType - Content type of generated renditions.
+	ImageRenditionType = "image/jpeg"
+	imageQuality       = 85
+)
+
+var (
+	// ErrImageTooBig - Image exceeds size or pixels limit.
+	ErrImageTooBig = errors.New("image is too big")
+)
+
+// SetDefaults - Default values for albums before creation.
+func (album *Album) SetDefaults() {
+	if album.OwnerType.String == "" {
+		album.OwnerType = ToNullsString(AlbumOwnerOrganization)
+	}
+	if album.OwnerType.String == AlbumOwnerOrganization {
+		album.OwnerID = album.OrganizationID
+	}
+}
+
+// IsValid - Returns true if album has a name and a known owner.
+func (album *Album) IsValid() bool {
+	if album.Name.String == "" || album.OwnerID.String == "" {
+		return false
+	}
+	switch album.OwnerType.String {
+	case AlbumOwnerListing, AlbumOwnerBuilding, AlbumOwnerOrganization:
+		return true
+	}
+	return false
+}
+
+// SetData - Sets uploaded image data, its size and dimensions and generates its medium and thumbnail renditions.
+// Content type is taken from data, not from the upload.
+func (img *Image) SetData(data []byte) error {
+	if len(data) == 0 {
+		return image.ErrFormat
+	}
+	if len(data) > ImageMaxSize {
+		return ErrImageTooBig
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if config.Width*config.Height > ImageMaxPixels {
+		return ErrImageTooBig
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	bounds := src.Bounds()
+	// Medium fits in a square, smaller images are not enlarged
+	width, height := fit(bounds.Dx(), bounds.Dy(), ImageMediumSize)
+	medium := scale(src, bounds, width, height)
+	// Thumbnail is the centered square of medium
+	side := width
+	if height < side {
+		side = height
+	}
+	square := image.Rect((width-side)/2, (height-side)/2, (width-side)/2+side, (height-side)/2+side)
+	thumbSide := ImageThumbnailSize
+	if side < thumbSide {
+		thumbSide = side
+	}
+	thumbnail := scale(medium, square, thumbSide, thumbSide)
+	mediumData, err := jpegEncode(medium)
+	if err != nil {
+		return err
+	}
+	thumbnailData, err := jpegEncode(thumbnail)
+	if err != nil {
+		return err
+	}
+	img.ContentType = ToNullsString("image/" + format)
+	img.Size = ToNullsInt64(int64(len(data)))
+	img.Width = ToNullsInt64(int64(bounds.Dx()))
+	img.Height = ToNullsInt64(int64(bounds.Dy()))
+	img.Base64 = ToNullsString(base64Encode(data))
+	img.MediumBase64 = ToNullsString(base64Encode(mediumData))
+	img.ThumbnailBase64 = ToNullsString(base64Encode(thumbnailData))
+	return nil
+}
+
+// Rendition - Returns the content type and data of an image rendition.
+func (img *Image) Rendition(name string) (string, []byte, error) {
+	switch name {
+	case ImageOriginal:
+		data, err := base64Decode(img.Base64.String)
+		return img.ContentType.String, data, err
+	case ImageMedium:
+		data, err := base64Decode(img.MediumBase64.String)
+		return ImageRenditionType, data, err
+	case ImageThumbnail:
+		data, err := base64Decode(img.ThumbnailBase64.String)
+		return ImageRenditionType, data, err
+	}
+	return "", nil, image.ErrFormat
+}
+
+// fit - Returns dimensions scaled down to fit in a square of side max keeping aspect ratio.
+func fit(width, height, max int) (int, int) {
+	if width <= max && height <= max {
+		return width, height
+	}
+	if width >= height {
+		return max, maxInt(1, height*max/width)
+	}
+	return maxInt(1, width*max/height), max
+}
+
+// scale - Scales down a region of src to width x height averaging source pixels.
+// Transparent pixels are composed over white, renditions are encoded as JPEG.
+func scale(src image.Image, region image.Rectangle, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := region.Dx(), region.Dy()
+	for y := 0; y < height; y++ {
+		y0 := region.Min.Y + y*sh/height
+		y1 := maxInt(y0+1, region.Min.Y+(y+1)*sh/height)
+		for x := 0; x < width; x++ {
+			x0 := region.Min.X + x*sw/width
+			x1 := maxInt(x0+1, region.Min.X+(x+1)*sw/width)
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					b += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+func jpegEncode(img image.Image) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	err := jpeg.Encode(buffer, img, &jpeg.Options{Quality: imageQuality})
+	if err != nil {
+		return []byte{}, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (album *Album) MarshalJSON() ([]byte, error) {
+	type Alias Album
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(album),
+		StartedAt: album.StartedAt.Time.Unix(),
+		CreatedAt: album.CreatedAt.Time.Unix(),
+		UpdatedAt: album.UpdatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (img *Image) MarshalJSON() ([]byte, error) {
+	type Alias Image
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(img),
+		StartedAt: img.StartedAt.Time.Unix(),
+		CreatedAt: img.CreatedAt.Time.Unix(),
+		UpdatedAt: img.UpdatedAt.Time.Unix(),
+	})
+}
@@ -0,0 +1,444 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// csvColumns - Accepted header names for each transaction field.
+	csvColumns = map[string][]string{
+		"date":          {"date", "booking date", "booked at", "transaction date", "value date", "data", "data operacji", "data księgowania"},
+		"amount":        {"amount", "kwota"},
+		"currency":      {"currency", "waluta"},
+		"reference":     {"reference", "title", "description", "details", "tytuł", "tytul", "opis"},
+		"payer":         {"payer", "payer name", "name", "counterparty", "nadawca", "kontrahent"},
+		"account":       {"account", "payer account", "counterparty account", "iban", "rachunek"},
+		"bankReference": {"id", "transaction id", "bank reference"},
+	}
+	bankDateLayouts = []string{"2006-01-02", "02.01.2006", "02/01/2006", "2006/01/02", "2006-01-02T15:04:05", time.RFC3339}
+	mt940TagRe      = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+	mt940LineRe     = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)[A-Z]?(\d+,\d*)([A-Z][A-Z0-9]{3})?(.*)$`)
+	mt940BalanceRe  = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)`)
+	mt940SubfieldRe = regexp.MustCompile(`[?~^](\d{2})`)
+)
+
+type (
+	camtDocument struct {
+		Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+	}
+
+	camtStatement struct {
+		ID        string        `xml:"Id"`
+		CreatedAt string        `xml:"CreDtTm"`
+		IBAN      string        `xml:"Acct>Id>IBAN"`
+		Other     string        `xml:"Acct>Id>Othr>Id"`
+		Currency  string        `xml:"Acct>Ccy"`
+		Balances  []camtBalance `xml:"Bal"`
+		Entries   []camtEntry   `xml:"Ntry"`
+	}
+
+	camtAmount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	}
+
+	camtBalance struct {
+		Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+		Amount    camtAmount `xml:"Amt"`
+		Indicator string     `xml:"CdtDbtInd"`
+		Date      string     `xml:"Dt>Dt"`
+		DateTime  string     `xml:"Dt>DtTm"`
+	}
+
+	camtEntry struct {
+		Amount          camtAmount      `xml:"Amt"`
+		Indicator       string          `xml:"CdtDbtInd"`
+		BookingDate     string          `xml:"BookgDt>Dt"`
+		BookingDateTime string          `xml:"BookgDt>DtTm"`
+		Reference       string          `xml:"AcctSvcrRef"`
+		Details         []camtTxDetails `xml:"NtryDtls>TxDtls"`
+	}
+
+	camtTxDetails struct {
+		Amount            camtAmount `xml:"Amt"`
+		EndToEndID        string     `xml:"Refs>EndToEndId"`
+		DebtorName        string     `xml:"RltdPties>Dbtr>Nm"`
+		DebtorPartyName   string     `xml:"RltdPties>Dbtr>Pty>Nm"`
+		DebtorIBAN        string     `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+		CreditorName      string     `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPartyName string     `xml:"RltdPties>Cdtr>Pty>Nm"`
+		CreditorIBAN      string     `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+		Unstructured      []string   `xml:"RmtInf>Ustrd"`
+		Structured        []string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	}
+)
+
+// parseCSV - Parses a CSV statement. First row names the columns,
+// date and amount columns are required.
+func parseCSV(content string) (BankStatement, error) {
+	statement := BankStatement{}
+	content = strings.TrimPrefix(content, "\ufeff")
+	header := strings.SplitN(content, "\n", 2)[0]
+	reader := csv.NewReader(strings.NewReader(content))
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return statement, err
+	}
+	if len(records) < 2 {
+		return statement, ErrBankStatementEmpty
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, aliases := range csvColumns {
+			for _, alias := range aliases {
+				if _, ok := columns[field]; !ok && name == alias {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	for _, field := range []string{"date", "amount"} {
+		if _, ok := columns[field]; !ok {
+			return statement, fmt.Errorf("Missing CSV column '%s'", field)
+		}
+	}
+	value := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	for n, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		bookedAt, err := parseBankDate(value(record, "date"))
+		if err != nil {
+			return statement, fmt.Errorf("Row %d: %s", n+2, err.Error())
+		}
+		amount, err := parseBankAmount(value(record, "amount"))
+		if err != nil {
+			return statement, fmt.Errorf("Row %d: %s", n+2, err.Error())
+		}
+		transaction := BankTransaction{}
+		transaction.BookedAt = ToNullsTime(bookedAt)
+		transaction.Amount = ToNullsFoat64(amount)
+		transaction.Reference = ToNullsString(value(record, "reference"))
+		transaction.PayerName = ToNullsString(value(record, "payer"))
+		transaction.PayerAccount = ToNullsString(value(record, "account"))
+		transaction.BankReference = ToNullsString(value(record, "bankReference"))
+		if currency := value(record, "currency"); currency != "" {
+			transaction.Currency = ToNullsString(strings.ToUpper(currency))
+			statement.Currency = transaction.Currency
+		}
+		statement.Transactions = append(statement.Transactions, transaction)
+	}
+	return statement, nil
+}
+
+// parseMT940 - Parses a SWIFT MT940 statement.
+// Structured :86: subfields (?20-?29 remittance, ?32-?33 name, ?31/?38 account) are used if present.
+func parseMT940(content string) (BankStatement, error) {
+	statement := BankStatement{}
+	fields := [][2]string{}
+	for _, line := range strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n") {
+		line = strings.TrimRight(line, " ")
+		if m := mt940TagRe.FindStringSubmatch(line); m != nil {
+			fields = append(fields, [2]string{m[1], line[len(m[0]):]})
+			continue
+		}
+		if len(fields) == 0 || line == "-" || strings.HasPrefix(line, "-}") {
+			continue
+		}
+		fields[len(fields)-1][1] += "\n" + line
+	}
+	var last *BankTransaction
+	for _, field := range fields {
+		tag, value := field[0], field[1]
+		switch tag {
+		case "20":
+			statement.Name = ToNullsString(strings.TrimSpace(value))
+		case "25":
+			statement.AccountNumber = ToNullsString(strings.TrimSpace(value))
+		case "60F", "60M", "62F", "62M":
+			amount, date, currency, err := parseMT940Balance(value)
+			if err != nil {
+				return statement, err
+			}
+			statement.Currency = ToNullsString(currency)
+			if strings.HasPrefix(tag, "60") {
+				statement.OpeningBalance = ToNullsFoat64(amount)
+			} else {
+				statement.ClosingBalance = ToNullsFoat64(amount)
+				statement.StatementDate = ToNullsTime(date)
+			}
+		case "61":
+			transaction, err := parseMT940Line(value)
+			if err != nil {
+				return statement, err
+			}
+			statement.Transactions = append(statement.Transactions, transaction)
+			last = &statement.Transactions[len(statement.Transactions)-1]
+		case "86":
+			if last != nil {
+				parseMT940Information(last, value)
+			}
+		}
+	}
+	return statement, nil
+}
+
+func parseMT940Balance(value string) (float64, time.Time, string, error) {
+	m := mt940BalanceRe.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, time.Time{}, "", fmt.Errorf("Invalid MT940 balance '%s'", value)
+	}
+	date, err := time.Parse("060102", m[2])
+	if err != nil {
+		return 0, date, "", err
+	}
+	amount, err := parseBankAmount(m[4])
+	if m[1] == "D" {
+		amount = -amount
+	}
+	return amount, date, m[3], err
+}
+
+func parseMT940Line(value string) (BankTransaction, error) {
+	transaction := BankTransaction{}
+	lines := strings.SplitN(value, "\n", 2)
+	m := mt940LineRe.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if m == nil {
+		return transaction, fmt.Errorf("Invalid MT940 statement line '%s'", lines[0])
+	}
+	bookedAt, err := time.Parse("060102", m[1])
+	if err != nil {
+		return transaction, err
+	}
+	amount, err := parseBankAmount(m[4])
+	if err != nil {
+		return transaction, err
+	}
+	// Debits and reversals of credits decrease balance.
+	if m[3] == "D" || m[3] == "RC" {
+		amount = -amount
+	}
+	transaction.BookedAt = ToNullsTime(bookedAt)
+	transaction.Amount = ToNullsFoat64(amount)
+	references := strings.SplitN(m[6], "//", 2)
+	if len(references) == 2 {
+		transaction.BankReference = ToNullsString(strings.TrimSpace(references[1]))
+	} else if ref := strings.TrimSpace(references[0]); ref != "" && ref != "NONREF" {
+		transaction.BankReference = ToNullsString(ref)
+	}
+	if len(lines) > 1 {
+		transaction.Description = ToNullsString(strings.TrimSpace(lines[1]))
+	}
+	return transaction, nil
+}
+
+func parseMT940Information(transaction *BankTransaction, value string) {
+	value = strings.Replace(value, "\n", "", -1)
+	indexes := mt940SubfieldRe.FindAllStringSubmatchIndex(value, -1)
+	if len(indexes) == 0 {
+		transaction.Reference = ToNullsString(strings.TrimSpace(value))
+		return
+	}
+	var reference, name, account string
+	for i, index := range indexes {
+		end := len(value)
+		if i+1 < len(indexes) {
+			end = indexes[i+1][0]
+		}
+		code, text := value[index[2]:index[3]], value[index[1]:end]
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			reference += text
+		case code == "32" || code == "33":
+			name += text
+		case code == "31" || code == "38":
+			account = text
+		}
+	}
+	transaction.Reference = ToNullsString(strings.TrimSpace(reference))
+	transaction.PayerName = ToNullsString(strings.TrimSpace(name))
+	transaction.PayerAccount = ToNullsString(strings.TrimSpace(account))
+}
+
+// parseCAMT053 - Parses an ISO 20022 camt.053 statement.
+// Batched entries produce one transaction for each of their details.
+func parseCAMT053(content string) (BankStatement, error) {
+	statement := BankStatement{}
+	document := camtDocument{}
+	err := xml.Unmarshal([]byte(content), &document)
+	if err != nil {
+		return statement, err
+	}
+	if len(document.Statements) == 0 {
+		return statement, ErrBankStatementEmpty
+	}
+	for i, stmt := range document.Statements {
+		if i == 0 {
+			statement.Name = ToNullsString(stmt.ID)
+			statement.AccountNumber = ToNullsString(firstNonEmpty(stmt.IBAN, stmt.Other))
+			statement.Currency = ToNullsString(stmt.Currency)
+			if date, err := parseBankDate(stmt.CreatedAt); err == nil {
+				statement.StatementDate = ToNullsTime(date)
+			}
+		}
+		for _, balance := range stmt.Balances {
+			amount, err := parseCAMTAmount(balance.Amount, balance.Indicator)
+			if err != nil {
+				return statement, err
+			}
+			switch balance.Code {
+			case "OPBD", "PRCD":
+				if i == 0 {
+					statement.OpeningBalance = ToNullsFoat64(amount)
+				}
+			case "CLBD":
+				statement.ClosingBalance = ToNullsFoat64(amount)
+				if date, err := parseBankDate(firstNonEmpty(balance.Date, balance.DateTime)); err == nil {
+					statement.StatementDate = ToNullsTime(date)
+				}
+			}
+			if !statement.Currency.Valid || statement.Currency.String == "" {
+				statement.Currency = ToNullsString(balance.Amount.Currency)
+			}
+		}
+		for _, entry := range stmt.Entries {
+			transactions, err := parseCAMTEntry(entry)
+			if err != nil {
+				return statement, err
+			}
+			statement.Transactions = append(statement.Transactions, transactions...)
+		}
+	}
+	return statement, nil
+}
+
+func parseCAMTEntry(entry camtEntry) ([]BankTransaction, error) {
+	transactions := []BankTransaction{}
+	bookedAt, err := parseBankDate(firstNonEmpty(entry.BookingDate, entry.BookingDateTime))
+	if err != nil {
+		return transactions, err
+	}
+	// CdtDbtInd already is the booked direction, also for reversals (RvslInd).
+	indicator := entry.Indicator
+	details := entry.Details
+	if len(details) == 0 {
+		details = []camtTxDetails{{}}
+	}
+	for _, detail := range details {
+		amount := detail.Amount
+		if amount.Value == "" || len(entry.Details) < 2 {
+			amount = entry.Amount
+		}
+		value, err := parseCAMTAmount(amount, indicator)
+		if err != nil {
+			return transactions, err
+		}
+		transaction := BankTransaction{}
+		transaction.BookedAt = ToNullsTime(bookedAt)
+		transaction.Amount = ToNullsFoat64(value)
+		transaction.Currency = ToNullsString(amount.Currency)
+		transaction.BankReference = ToNullsString(firstNonEmpty(entry.Reference, detail.EndToEndID))
+		transaction.Reference = ToNullsString(strings.TrimSpace(strings.Join(append(detail.Structured, detail.Unstructured...), " ")))
+		// Counterparty is the debtor of incoming and the creditor of outgoing transfers.
+		if indicator == "CRDT" {
+			transaction.PayerName = ToNullsString(firstNonEmpty(detail.DebtorName, detail.DebtorPartyName))
+			transaction.PayerAccount = ToNullsString(detail.DebtorIBAN)
+		} else {
+			transaction.PayerName = ToNullsString(firstNonEmpty(detail.CreditorName, detail.CreditorPartyName))
+			transaction.PayerAccount = ToNullsString(detail.CreditorIBAN)
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+func parseCAMTAmount(amount camtAmount, indicator string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(amount.Value), 64)
+	if err != nil {
+		return 0, err
+	}
+	if indicator == "DBIT" {
+		value = -value
+	}
+	return value, nil
+}
+
+// parseBankAmount - Parses amounts using either dot or comma as decimal separator.
+func parseBankAmount(value string) (float64, error) {
+	value = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(value)
+	comma, dot := strings.LastIndex(value, ","), strings.LastIndex(value, ".")
+	switch {
+	case comma >= 0 && dot >= 0 && comma > dot:
+		value = strings.Replace(strings.Replace(value, ".", "", -1), ",", ".", 1)
+	case comma >= 0 && dot >= 0:
+		value = strings.Replace(value, ",", "", -1)
+	case comma >= 0:
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid amount '%s'", value)
+	}
+	return RoundAmount(amount), nil
+}
+
+func parseBankDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range bankDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("Invalid date '" + value + "'")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,368 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// BankResourceTag - Tag of the organization resource whose permissions grant bank statements management.
+	BankResourceTag = "bank"
+	// BankFormatCSV - Comma or semicolon separated values with a header row.
+	BankFormatCSV = "csv"
+	// BankFormatMT940 - SWIFT MT940 customer statement.
+	BankFormatMT940 = "mt940"
+	// BankFormatCAMT053 - ISO 20022 camt.053 bank to customer statement.
+	BankFormatCAMT053 = "camt053"
+	// BankTransactionStatusUnmatched - Transaction without candidate invoices.
+	BankTransactionStatusUnmatched = "unmatched"
+	// BankTransactionStatusSuggested - Transaction with candidate invoices waiting for review.
+	BankTransactionStatusSuggested = "suggested"
+	// BankTransactionStatusMatched - Transaction posted to the ledger as an invoice payment.
+	BankTransactionStatusMatched = "matched"
+	// BankTransactionStatusIgnored - Outgoing or dismissed transaction.
+	BankTransactionStatusIgnored = "ignored"
+	// BankMatchScoreReference - Score when transaction reference contains invoice name as a whole token.
+	BankMatchScoreReference = 60
+	// BankMatchScoreAmount - Score when transaction amount equals invoice balance.
+	BankMatchScoreAmount = 30
+	// BankMatchScorePayer - Score when payer name contains a lease tenant name.
+	BankMatchScorePayer = 20
+	// BankMatchAutoScore - Minimum score of an unambiguous match to be posted without review.
+	BankMatchAutoScore = 80
+	// BankMatchMinScore - Minimum score of a suggested match.
+	BankMatchMinScore = 30
+	// BankMatchMaxSuggestions - Maximum number of suggested matches per transaction.
+	BankMatchMaxSuggestions = 5
+)
+
+var (
+	// ErrBankStatementFormat - Unknown bank statement format.
+	ErrBankStatementFormat = errors.New("Unknown bank statement format")
+	// ErrBankStatementEmpty - Bank statement without transactions.
+	ErrBankStatementEmpty = errors.New("Bank statement has no transactions")
+)
+
+type (
+	// BankStatementImport - Bank statement file to import.
+	BankStatementImport struct {
+		Format  string `json:"format"`
+		Name    string `json:"name"`
+		Content string `json:"content"`
+	}
+
+	// TenantName - Name of a lease tenant, used to match payers.
+	TenantName struct {
+		LeaseID   string `db:"lease_id"`
+		FirstName string `db:"first_name"`
+		LastName  string `db:"last_name"`
+	}
+)
+
+// DetectFormat - Guess statement format from its content.
+func (statementImport *BankStatementImport) DetectFormat() string {
+	content := strings.TrimSpace(statementImport.Content)
+	switch {
+	case strings.HasPrefix(content, "<"):
+		return BankFormatCAMT053
+	case strings.Contains(content, ":61:") && strings.Contains(content, ":20:"):
+		return BankFormatMT940
+	}
+	return BankFormatCSV
+}
+
+// Parse - Parses the statement file into a statement and its transactions.
+func (statementImport *BankStatementImport) Parse() (BankStatement, error) {
+	format := strings.ToLower(statementImport.Format)
+	if format == "" {
+		format = statementImport.DetectFormat()
+	}
+	var statement BankStatement
+	var err error
+	switch format {
+	case BankFormatCSV:
+		statement, err = parseCSV(statementImport.Content)
+	case BankFormatMT940:
+		statement, err = parseMT940(statementImport.Content)
+	case BankFormatCAMT053:
+		statement, err = parseCAMT053(statementImport.Content)
+	default:
+		return statement, ErrBankStatementFormat
+	}
+	if err != nil {
+		return statement, err
+	}
+	if len(statement.Transactions) == 0 {
+		return statement, ErrBankStatementEmpty
+	}
+	statement.Format = ToNullsString(format)
+	if statementImport.Name != "" {
+		statement.Name = ToNullsString(statementImport.Name)
+	}
+	for i := range statement.Transactions {
+		transaction := &statement.Transactions[i]
+		if !transaction.Currency.Valid {
+			transaction.Currency = statement.Currency
+		}
+		transaction.Fingerprint = ToNullsString(transaction.fingerprint(statement.AccountNumber.String))
+		transaction.Status = ToNullsString(BankTransactionStatusUnmatched)
+		if transaction.Amount.Float64 <= 0 {
+			transaction.Status = ToNullsString(BankTransactionStatusIgnored)
+		}
+	}
+	return statement, nil
+}
+
+// fingerprint - Identifies a transaction so that overlapping statements are imported once.
+func (transaction *BankTransaction) fingerprint(account string) string {
+	fields := []string{
+		account,
+		transaction.BookedAt.Time.Format("2006-01-02"),
+		fmt.Sprintf("%.2f", transaction.Amount.Float64),
+		transaction.Currency.String,
+		transaction.BankReference.String,
+		transaction.Reference.String,
+		transaction.PayerName.String,
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(fields, "|"))))
+}
+
+// MatchScore - Scores an invoice as the one paid by the transaction.
+func (transaction *BankTransaction) MatchScore(invoice *Invoice, tenants []TenantName) (int64, []string) {
+	var score int64
+	reasons := []string{}
+	if referencesName(transaction.Reference.String, invoice.Name.String) {
+		score += BankMatchScoreReference
+		reasons = append(reasons, "reference")
+	}
+	if RoundAmount(transaction.Amount.Float64) == invoice.Balance.Float64 {
+		score += BankMatchScoreAmount
+		reasons = append(reasons, "amount")
+	}
+	payer := normalizeMatchText(transaction.PayerName.String)
+	for _, tenant := range tenants {
+		if tenant.LeaseID == invoice.LeaseID.String && tenant.matches(payer) {
+			score += BankMatchScorePayer
+			reasons = append(reasons, "payer")
+			break
+		}
+	}
+	return score, reasons
+}
+
+// Candidates - Invoices the transaction may pay, best first.
+// Invoices in another currency or with a balance lower than the amount are discarded.
+func (transaction *BankTransaction) Candidates(invoices []Invoice, tenants []TenantName) []BankMatch {
+	matches := []BankMatch{}
+	for i := range invoices {
+		invoice := &invoices[i]
+		if invoice.Currency.String != transaction.Currency.String || RoundAmount(transaction.Amount.Float64) > invoice.Balance.Float64 {
+			continue
+		}
+		if invoice.Status.String != InvoiceStatusOpen && invoice.Status.String != InvoiceStatusPartiallyPaid {
+			continue
+		}
+		score, reasons := transaction.MatchScore(invoice, tenants)
+		if score < BankMatchMinScore {
+			continue
+		}
+		match := BankMatch{}
+		match.BankTransactionID = transaction.ID
+		match.InvoiceID = invoice.ID
+		match.Name = invoice.Name
+		match.Score = ToNullsInt64(score)
+		match.Reasons = ToNullsString(strings.Join(reasons, ","))
+		match.OrganizationID = transaction.OrganizationID
+		matches = append(matches, match)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score.Int64 > matches[j].Score.Int64
+	})
+	if len(matches) > BankMatchMaxSuggestions {
+		matches = matches[:BankMatchMaxSuggestions]
+	}
+	return matches
+}
+
+// AutoMatch - Returns the best candidate if it is strong enough and not tied with another one.
+func AutoMatch(matches []BankMatch) (BankMatch, bool) {
+	if len(matches) == 0 || matches[0].Score.Int64 < BankMatchAutoScore {
+		return BankMatch{}, false
+	}
+	if len(matches) > 1 && matches[1].Score.Int64 == matches[0].Score.Int64 {
+		return BankMatch{}, false
+	}
+	return matches[0], true
+}
+
+// Payment - Invoice payment for the transaction.
+func (transaction *BankTransaction) Payment() Payment {
+	return Payment{
+		Amount:      transaction.Amount.Float64,
+		PaidAt:      transaction.BookedAt.Time,
+		Description: strings.TrimSpace("Bank transfer " + transaction.Reference.String),
+	}
+}
+
+// IsReconciled - Returns true if transaction was already posted or dismissed.
+func (transaction *BankTransaction) IsReconciled() bool {
+	return transaction.Status.String == BankTransactionStatusMatched || transaction.Status.String == BankTransactionStatusIgnored
+}
+
+func (tenant TenantName) matches(payer string) bool {
+	first, last := normalizeMatchText(tenant.FirstName), normalizeMatchText(tenant.LastName)
+	if first == "" && last == "" {
+		return false
+	}
+	return strings.Contains(payer, first) && strings.Contains(payer, last)
+}
+
+// matchWord - Run of letters and digits of a text and its byte offsets.
+type matchWord struct {
+	text  string
+	start int
+	end   int
+}
+
+// matchWords - Upper case runs of letters and digits of a text.
+func matchWords(text string) []matchWord {
+	words := []matchWord{}
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = append(words, matchWord{text: strings.ToUpper(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, matchWord{text: strings.ToUpper(text[start:]), start: start, end: len(text)})
+	}
+	return words
+}
+
+// referencesName - True if reference contains the name as a whole token.
+// Name words must appear in sequence and the match must be bounded by spaces, punctuation
+// or the reference ends: 'B/001' is not referenced by 'AB/001' nor 'B/001/LF'.
+func referencesName(reference, name string) bool {
+	nameWords := matchWords(name)
+	if len(nameWords) == 0 {
+		return false
+	}
+	words := matchWords(reference)
+	for i := 0; i+len(nameWords) <= len(words); i++ {
+		matched := true
+		for j, nameWord := range nameWords {
+			if words[i+j].text != nameWord.text {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		before, _ := utf8.DecodeLastRuneInString(reference[:words[i].start])
+		after, _ := utf8.DecodeRuneInString(reference[words[i+len(nameWords)-1].end:])
+		if isMatchBoundary(before) && isMatchBoundary(after) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMatchBoundary - True for runes ending a token: reference ends, spaces and punctuation not joining name parts.
+func isMatchBoundary(r rune) bool {
+	return r == utf8.RuneError || unicode.IsSpace(r) || strings.ContainsRune(",.;:()[]\"'", r)
+}
+
+// normalizeMatchText - Upper case letters and digits only.
+func normalizeMatchText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, text)
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (statement *BankStatement) MarshalJSON() ([]byte, error) {
+	type Alias BankStatement
+	return json.Marshal(&struct {
+		*Alias
+		StatementDate int64 `json:"statementDate"`
+		StartedAt     int64 `json:"startedAt"`
+		CreatedAt     int64 `json:"createdAt"`
+		UpdatedAt     int64 `json:"updatedAt"`
+	}{
+		Alias:         (*Alias)(statement),
+		StatementDate: statement.StatementDate.Time.Unix(),
+		StartedAt:     statement.StartedAt.Time.Unix(),
+		CreatedAt:     statement.CreatedAt.Time.Unix(),
+		UpdatedAt:     statement.UpdatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (transaction *BankTransaction) MarshalJSON() ([]byte, error) {
+	type Alias BankTransaction
+	return json.Marshal(&struct {
+		*Alias
+		BookedAt  int64 `json:"bookedAt"`
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(transaction),
+		BookedAt:  transaction.BookedAt.Time.Unix(),
+		StartedAt: transaction.StartedAt.Time.Unix(),
+		CreatedAt: transaction.CreatedAt.Time.Unix(),
+		UpdatedAt: transaction.UpdatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (match BankMatch) MarshalJSON() ([]byte, error) {
+	type Alias BankMatch
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(match),
+		CreatedAt: match.CreatedAt.Time.Unix(),
+	})
+}
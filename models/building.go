@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+// IsValid - Returns true if building values are consistent.
+func (building *Building) IsValid() bool {
+	return building.Name.String != "" && building.Floors.Int64 >= 0
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (building *Building) MarshalJSON() ([]byte, error) {
+	type Alias Building
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(building),
+		StartedAt: building.StartedAt.Time.Unix(),
+		CreatedAt: building.CreatedAt.Time.Unix(),
+		UpdatedAt: building.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (building *Building) UnmarshalJSON(data []byte) error {
+	type Alias Building
+	aux := &struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(building),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	building.StartedAt = nulls.Time{Time: time.Unix(aux.StartedAt, 0)}
+	building.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	building.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// DocumentResourceTag - Tag of the organization resource whose permissions grant documents management.
+	DocumentResourceTag = "documents"
+	// DocumentHolderLease - Document attached to a lease.
+	DocumentHolderLease = "lease"
+	// DocumentHolderUnit - Document attached to a unit.
+	DocumentHolderUnit = "unit"
+	// DocumentHolderOrganization - Document of the organization itself.
+	DocumentHolderOrganization = "organization"
+	// DocumentKindContract - Contract or annex.
+	DocumentKindContract = "contract"
+	// DocumentKindCertificate - Certificate, e.g. energy performance or inspection.
+	DocumentKindCertificate = "certificate"
+	// DocumentKindInvoice - Invoice or receipt.
+	DocumentKindInvoice = "invoice"
+	// DocumentKindOther - Any other attachment.
+	DocumentKindOther = "other"
+	// DocumentMaxSize - Max document file size in bytes.
+	DocumentMaxSize = 20 << 20
+	// DocumentSniffLen - Bytes read to detect document content type.
+	DocumentSniffLen = 512
+)
+
+var (
+	documentTypes = map[string]bool{
+		"application/pdf": true,
+		"image/png":       true,
+		"image/jpeg":      true,
+	}
+)
+
+// SetDefaults - Default values for documents before creation.
+func (document *Document) SetDefaults() {
+	if document.Kind.String == "" {
+		document.Kind = ToNullsString(DocumentKindOther)
+	}
+	if document.HolderType.String == "" {
+		document.HolderType = ToNullsString(DocumentHolderOrganization)
+	}
+	if document.HolderType.String == DocumentHolderOrganization {
+		document.HolderID = document.OrganizationID
+	}
+	if !document.LegalHold.Valid {
+		document.LegalHold = NullsFalseBool()
+	}
+}
+
+// IsValid - Returns true if document has a name, a known kind and a holder.
+func (document *Document) IsValid() bool {
+	if document.Name.String == "" || document.HolderID.String == "" {
+		return false
+	}
+	switch document.Kind.String {
+	case DocumentKindContract, DocumentKindCertificate, DocumentKindInvoice, DocumentKindOther:
+	default:
+		return false
+	}
+	switch document.HolderType.String {
+	case DocumentHolderLease, DocumentHolderUnit, DocumentHolderOrganization:
+		return true
+	}
+	return false
+}
+
+// IsRetained - Returns true if document is under legal hold or its retention period has not ended.
+func (document *Document) IsRetained(now time.Time) bool {
+	return document.LegalHold.Bool || (document.RetainUntil.Valid && document.RetainUntil.Time.After(now))
+}
+
+// SetFile - Sets document current file values from one of its versions.
+func (document *Document) SetFile(version *DocumentVersion) {
+	document.Version = version.Version
+	document.FileName = version.FileName
+	document.ContentType = version.ContentType
+	document.Size = version.Size
+	document.Checksum = version.Checksum
+}
+
+// SniffDocumentType - Returns the content type detected from the first bytes of a document
+// and true if it is an accepted one.
+func SniffDocumentType(header []byte) (string, bool) {
+	contentType := http.DetectContentType(header)
+	contentType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	return contentType, documentTypes[contentType]
+}
+
+// DocumentStorageKey - Returns the blob store key of a document version.
+func DocumentStorageKey(orgID, documentID, versionID string) string {
+	return fmt.Sprintf("documents/%s/%s/%s", orgID, documentID, versionID)
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (document *Document) MarshalJSON() ([]byte, error) {
+	type Alias Document
+	aux := &struct {
+		*Alias
+		RetainUntil nulls.Int64 `json:"retainUntil"`
+		StartedAt   int64       `json:"startedAt"`
+		CreatedAt   int64       `json:"createdAt"`
+		UpdatedAt   int64       `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(document),
+		StartedAt: document.StartedAt.Time.Unix(),
+		CreatedAt: document.CreatedAt.Time.Unix(),
+		UpdatedAt: document.UpdatedAt.Time.Unix(),
+	}
+	if document.RetainUntil.Valid {
+		aux.RetainUntil = ToNullsInt64(document.RetainUntil.Time.Unix())
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (document *Document) UnmarshalJSON(data []byte) error {
+	type Alias Document
+	aux := &struct {
+		*Alias
+		RetainUntil nulls.Int64 `json:"retainUntil"`
+		CreatedAt   int64       `json:"createdAt"`
+		UpdatedAt   int64       `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(document),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.RetainUntil.Valid {
+		document.RetainUntil = ToNullsTime(time.Unix(aux.RetainUntil.Int64, 0))
+	}
+	document.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	document.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (version DocumentVersion) MarshalJSON() ([]byte, error) {
+	type Alias DocumentVersion
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(version),
+		CreatedAt: version.CreatedAt.Time.Unix(),
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import "github.com/markbates/pop/nulls"
+
+// Facet condition kinds.
+const (
+	FacetConditionRange   = "range"
+	FacetConditionNumber  = "number"
+	FacetConditionBoolean = "boolean"
+	FacetConditionString  = "string"
+)
+
+// FacetQuery - Conditions over named properties and facets requested for a holders collection.
+// Holders are returned if they own properties matching every condition.
+type FacetQuery struct {
+	Conditions []FacetCondition
+	Facets     []FacetRequest
+}
+
+// FacetCondition - Condition over a named property.
+// Range conditions use Min and / or Max, the others match any of Values.
+type FacetCondition struct {
+	Name   string
+	Kind   string
+	Min    nulls.Float64
+	Max    nulls.Float64
+	Values []string
+}
+
+// FacetRequest - Facet counts requested for a named property.
+// Numeric values are grouped in buckets of Width if greater than zero,
+// otherwise there is a bucket per distinct value.
+type FacetRequest struct {
+	Name  string
+	Width float64
+}
+
+// Facet - Holder counts per value bucket of a named property.
+type Facet struct {
+	Name    string        `json:"name"`
+	Buckets []FacetBucket `json:"buckets"`
+}
+
+// FacetBucket - Number of holders having a property value in bucket.
+// Value is the bucket lower bound for numeric buckets.
+type FacetBucket struct {
+	Value string `db:"value" json:"value"`
+	Count int64  `db:"count" json:"count"`
+}
+
+// IsEmpty - True if neither conditions nor facets were requested.
+func (q *FacetQuery) IsEmpty() bool {
+	return len(q.Conditions) == 0 && len(q.Facets) == 0
+}
@@ -23,9 +23,12 @@
 
 package models
 
-import "github.com/adrianpk/fundacja/types"
+import (
+	"github.com/adrianpk/fundacja/types"
+)
 
 // GeolocalizableModel - Common properties for auditable models.
 type GeolocalizableModel struct {
 	Geolocation types.NullPoint `db:"geolocation" json:"geolocation"`
+	Distance    *float64        `db:"distance" json:"distance,omitempty"`
 }
@@ -0,0 +1,104 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icalProdID     = "-//Kuguar//Fundacja//EN"
+	icalTimeFormat = "20060102T150405Z"
+	icalLineLength = 75
+)
+
+var icalEscaper = strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n")
+
+// ViewingsCalendar - Returns an iCalendar (RFC 5545) feed publishing the viewings as events.
+// Listings are used to name and locate the events, cancelled viewings are kept
+// so subscribed calendars remove them.
+func ViewingsCalendar(name string, viewings []Viewing, listings map[string]Listing) []byte {
+	var cal bytes.Buffer
+	icalLine(&cal, "BEGIN:VCALENDAR")
+	icalLine(&cal, "VERSION:2.0")
+	icalLine(&cal, "PRODID:"+icalProdID)
+	icalLine(&cal, "CALSCALE:GREGORIAN")
+	icalLine(&cal, "METHOD:PUBLISH")
+	icalLine(&cal, "X-WR-CALNAME:"+icalText(name))
+	for _, viewing := range viewings {
+		listing := listings[viewing.ListingID.String]
+		status := "CONFIRMED"
+		if viewing.Status.String == ViewingStatusCancelled {
+			status = "CANCELLED"
+		}
+		description := fmt.Sprintf("Prospect: %s\nEmail: %s\nPhone: %s", viewing.Name.String, viewing.ProspectEmail.String, viewing.ProspectPhone.String)
+		if viewing.Description.String != "" {
+			description = description + "\n\n" + viewing.Description.String
+		}
+		icalLine(&cal, "BEGIN:VEVENT")
+		icalLine(&cal, fmt.Sprintf("UID:%s@fundacja", viewing.ID.String))
+		icalLine(&cal, "DTSTAMP:"+icalTime(viewing.UpdatedAt.Time))
+		icalLine(&cal, "DTSTART:"+icalTime(viewing.StartsAt.Time))
+		icalLine(&cal, "DTEND:"+icalTime(viewing.EndsAt.Time))
+		icalLine(&cal, "SUMMARY:"+icalText("Viewing: "+listing.Name.String))
+		if listing.Address.String != "" {
+			icalLine(&cal, "LOCATION:"+icalText(listing.Address.String))
+		}
+		icalLine(&cal, "DESCRIPTION:"+icalText(description))
+		icalLine(&cal, "STATUS:"+status)
+		icalLine(&cal, "END:VEVENT")
+	}
+	icalLine(&cal, "END:VCALENDAR")
+	return cal.Bytes()
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format(icalTimeFormat)
+}
+
+func icalText(text string) string {
+	return icalEscaper.Replace(text)
+}
+
+// icalLine - Writes a content line folded at 75 octets without splitting UTF-8 characters.
+func icalLine(cal *bytes.Buffer, line string) {
+	limit := icalLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		cal.WriteString(line[:cut])
+		cal.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space
+		limit = icalLineLength - 1
+	}
+	cal.WriteString(line)
+	cal.WriteString("\r\n")
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// InvoiceKindRent - Rent installment invoice.
+	InvoiceKindRent = "rent"
+	// InvoiceKindLateFee - Late fee charged for an overdue rent invoice.
+	InvoiceKindLateFee = "late_fee"
+	// InvoiceStatusOpen - Invoice not paid.
+	InvoiceStatusOpen = "open"
+	// InvoiceStatusPartiallyPaid - Invoice partially paid.
+	InvoiceStatusPartiallyPaid = "partially_paid"
+	// InvoiceStatusPaid - Invoice fully paid.
+	InvoiceStatusPaid = "paid"
+	// InvoiceStatusVoid - Invoice whose issuing entry was reversed.
+	InvoiceStatusVoid = "void"
+)
+
+// InvoiceFromInstallment - Rent invoice for a lease rent schedule installment.
+func InvoiceFromInstallment(lease *Lease, installment RentInstallment) Invoice {
+	invoice := Invoice{}
+	invoice.Name = ToNullsString(fmt.Sprintf("%s/%03d", lease.Name.String, installment.Number))
+	invoice.Description = ToNullsString(fmt.Sprintf("Rent %s - %s", installment.PeriodStart.Format("2006-01-02"), installment.PeriodEnd.Format("2006-01-02")))
+	invoice.Kind = ToNullsString(InvoiceKindRent)
+	invoice.InstallmentNumber = ToNullsInt64(installment.Number)
+	invoice.PeriodStart = ToNullsTime(installment.PeriodStart)
+	invoice.PeriodEnd = ToNullsTime(installment.PeriodEnd)
+	invoice.DueDate = ToNullsTime(installment.DueDate)
+	invoice.Amount = ToNullsFoat64(installment.Amount)
+	invoice.Balance = ToNullsFoat64(installment.Amount)
+	invoice.Currency = ToNullsString(installment.Currency)
+	invoice.Status = ToNullsString(InvoiceStatusOpen)
+	invoice.LeaseID = lease.ID
+	invoice.UnitID = lease.UnitID
+	invoice.OrganizationID = lease.OrganizationID
+	return invoice
+}
+
+// LateFeeInvoice - Late fee invoice for an overdue rent invoice, due at charge date.
+func LateFeeInvoice(lease *Lease, overdue Invoice, at time.Time) Invoice {
+	amount := lease.LateFee(overdue.Balance.Float64)
+	invoice := Invoice{}
+	invoice.Name = ToNullsString(overdue.Name.String + "/LF")
+	invoice.Description = ToNullsString(fmt.Sprintf("Late fee for %s", overdue.Name.String))
+	invoice.Kind = ToNullsString(InvoiceKindLateFee)
+	invoice.InstallmentNumber = overdue.InstallmentNumber
+	invoice.DueDate = ToNullsTime(at)
+	invoice.Amount = ToNullsFoat64(amount)
+	invoice.Balance = ToNullsFoat64(amount)
+	invoice.Currency = overdue.Currency
+	invoice.Status = ToNullsString(InvoiceStatusOpen)
+	invoice.LeaseID = overdue.LeaseID
+	invoice.UnitID = overdue.UnitID
+	invoice.OrganizationID = overdue.OrganizationID
+	return invoice
+}
+
+// IsOverdue - Returns true if an unpaid invoice is past its due date plus grace days.
+func (invoice *Invoice) IsOverdue(at time.Time, graceDays int64) bool {
+	switch invoice.Status.String {
+	case InvoiceStatusOpen, InvoiceStatusPartiallyPaid:
+	default:
+		return false
+	}
+	limit := invoice.DueDate.Time.AddDate(0, 0, int(graceDays))
+	return invoice.Balance.Float64 > 0 && at.After(limit)
+}
+
+// UpdateStatus - Sets status from invoice amount and outstanding balance.
+func (invoice *Invoice) UpdateStatus() {
+	switch {
+	case invoice.Balance.Float64 <= 0:
+		invoice.Status = ToNullsString(InvoiceStatusPaid)
+	case invoice.Balance.Float64 < invoice.Amount.Float64:
+		invoice.Status = ToNullsString(InvoiceStatusPartiallyPaid)
+	default:
+		invoice.Status = ToNullsString(InvoiceStatusOpen)
+	}
+}
+
+// IssueEntry - Entry debiting receivables and crediting rent or late fee income.
+func (invoice *Invoice) IssueEntry(accounts map[string]Account) JournalEntry {
+	income := AccountRentIncome
+	if invoice.Kind.String == InvoiceKindLateFee {
+		income = AccountLateFeeIncome
+	}
+	entry := JournalEntry{}
+	entry.Name = ToNullsString("Invoice " + invoice.Name.String)
+	entry.Kind = ToNullsString(JournalEntryKindInvoice)
+	entry.PostedAt = ToNullsTime(time.Now())
+	entry.OrganizationID = invoice.OrganizationID
+	entry.Postings = []Posting{
+		invoice.posting(accounts[AccountReceivable], invoice.Amount.Float64, 0),
+		invoice.posting(accounts[income], 0, invoice.Amount.Float64),
+	}
+	return entry
+}
+
+// PaymentEntry - Entry debiting cash and crediting receivables.
+func (invoice *Invoice) PaymentEntry(payment Payment, accounts map[string]Account) JournalEntry {
+	entry := JournalEntry{}
+	entry.Name = ToNullsString("Payment " + invoice.Name.String)
+	entry.Description = ToNullsString(payment.Description)
+	entry.Kind = ToNullsString(JournalEntryKindPayment)
+	entry.PostedAt = ToNullsTime(payment.PaidAt)
+	entry.OrganizationID = invoice.OrganizationID
+	entry.Postings = []Posting{
+		invoice.posting(accounts[AccountCash], payment.Amount, 0),
+		invoice.posting(accounts[AccountReceivable], 0, payment.Amount),
+	}
+	return entry
+}
+
+func (invoice *Invoice) posting(account Account, debit, credit float64) Posting {
+	posting := Posting{}
+	posting.AccountID = account.ID
+	posting.Debit = ToNullsFoat64(RoundAmount(debit))
+	posting.Credit = ToNullsFoat64(RoundAmount(credit))
+	posting.Currency = invoice.Currency
+	posting.LeaseID = invoice.LeaseID
+	posting.UnitID = invoice.UnitID
+	posting.InvoiceID = invoice.ID
+	posting.OrganizationID = invoice.OrganizationID
+	return posting
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (invoice *Invoice) MarshalJSON() ([]byte, error) {
+	type Alias Invoice
+	aux := &struct {
+		*Alias
+		PeriodStart nulls.Int64 `json:"periodStart"`
+		PeriodEnd   nulls.Int64 `json:"periodEnd"`
+		DueDate     int64       `json:"dueDate"`
+		StartedAt   int64       `json:"startedAt"`
+		CreatedAt   int64       `json:"createdAt"`
+		UpdatedAt   int64       `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(invoice),
+		DueDate:   invoice.DueDate.Time.Unix(),
+		StartedAt: invoice.StartedAt.Time.Unix(),
+		CreatedAt: invoice.CreatedAt.Time.Unix(),
+		UpdatedAt: invoice.UpdatedAt.Time.Unix(),
+	}
+	if invoice.PeriodStart.Valid {
+		aux.PeriodStart = ToNullsInt64(invoice.PeriodStart.Time.Unix())
+	}
+	if invoice.PeriodEnd.Valid {
+		aux.PeriodEnd = ToNullsInt64(invoice.PeriodEnd.Time.Unix())
+	}
+	return json.Marshal(aux)
+}
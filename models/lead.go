@@ -0,0 +1,267 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// CRMResourceTag - Tag of the organization resource whose permissions grant leads pipeline access.
+	CRMResourceTag = "crm"
+	// LeadOutcomeOpen - Stage of leads still in the funnel.
+	LeadOutcomeOpen = "open"
+	// LeadOutcomeWon - Stage of leads turned into deals.
+	LeadOutcomeWon = "won"
+	// LeadOutcomeLost - Stage of discarded leads.
+	LeadOutcomeLost = "lost"
+	// LeadSourceWeb - Inquiry from the organization site.
+	LeadSourceWeb = "web"
+	// LeadSourcePortal - Inquiry from a listings portal.
+	LeadSourcePortal = "portal"
+	// LeadSourceEmail - Inquiry received by email.
+	LeadSourceEmail = "email"
+	// LeadSourcePhone - Inquiry received by phone.
+	LeadSourcePhone = "phone"
+	// LeadSourceWalkIn - Inquiry made at the office.
+	LeadSourceWalkIn = "walk_in"
+	// LeadSourceReferral - Lead referred by a client or partner.
+	LeadSourceReferral = "referral"
+	// LeadSourceOther - Any other source.
+	LeadSourceOther = "other"
+	// LeadActivityNote - Free note.
+	LeadActivityNote = "note"
+	// LeadActivityCall - Phone call log.
+	LeadActivityCall = "call"
+	// LeadActivityEmail - Email log.
+	LeadActivityEmail = "email"
+	// LeadActivityReminder - Follow up reminder due at a time.
+	LeadActivityReminder = "reminder"
+	// LeadActivityStageChange - Lead moved between stages, recorded by the system.
+	LeadActivityStageChange = "stage_change"
+	// LeadActivityAssignment - Lead assigned to an agent, recorded by the system.
+	LeadActivityAssignment = "assignment"
+)
+
+var defaultLeadStages = []struct {
+	name    string
+	outcome string
+}{
+	{"New", LeadOutcomeOpen},
+	{"Contacted", LeadOutcomeOpen},
+	{"Viewing", LeadOutcomeOpen},
+	{"Offer", LeadOutcomeOpen},
+	{"Won", LeadOutcomeWon},
+	{"Lost", LeadOutcomeLost},
+}
+
+type (
+	// FunnelStage - Leads count and value at a pipeline stage.
+	FunnelStage struct {
+		StageID  string  `db:"stage_id" json:"stageID"`
+		Name     string  `db:"name" json:"name"`
+		Position int64   `db:"position" json:"position"`
+		Outcome  string  `db:"outcome" json:"outcome"`
+		Leads    int64   `db:"leads" json:"leads"`
+		Value    float64 `db:"value" json:"value"`
+	}
+)
+
+// DefaultLeadStages - Returns the pipeline organizations start with.
+func DefaultLeadStages() []LeadStage {
+	stages := []LeadStage{}
+	for i, stage := range defaultLeadStages {
+		leadStage := LeadStage{
+			Position: ToNullsInt64(int64(i + 1)),
+			Outcome:  ToNullsString(stage.outcome),
+		}
+		leadStage.Name = ToNullsString(stage.name)
+		stages = append(stages, leadStage)
+	}
+	return stages
+}
+
+// SetDefaults - Default values for stages before creation.
+func (stage *LeadStage) SetDefaults() {
+	if stage.Outcome.String == "" {
+		stage.Outcome = ToNullsString(LeadOutcomeOpen)
+	}
+}
+
+// IsValid - Returns true if stage has a name and a known outcome.
+func (stage *LeadStage) IsValid() bool {
+	if stage.Name.String == "" || stage.Position.Int64 < 0 {
+		return false
+	}
+	switch stage.Outcome.String {
+	case LeadOutcomeOpen, LeadOutcomeWon, LeadOutcomeLost:
+		return true
+	}
+	return false
+}
+
+// IsClosed - Returns true if leads at the stage left the funnel.
+func (stage *LeadStage) IsClosed() bool {
+	return stage.Outcome.String == LeadOutcomeWon || stage.Outcome.String == LeadOutcomeLost
+}
+
+// SetDefaults - Default values for leads before creation.
+func (lead *Lead) SetDefaults() {
+	if lead.Source.String == "" {
+		lead.Source = ToNullsString(LeadSourceOther)
+	}
+}
+
+// IsValid - Returns true if lead has a name, a way to contact it and a known source.
+func (lead *Lead) IsValid() bool {
+	if lead.Name.String == "" || (lead.Email.String == "" && lead.Phone.String == "") {
+		return false
+	}
+	switch lead.Source.String {
+	case LeadSourceWeb, LeadSourcePortal, LeadSourceEmail, LeadSourcePhone, LeadSourceWalkIn, LeadSourceReferral, LeadSourceOther:
+	default:
+		return false
+	}
+	return lead.Value.Float64 >= 0
+}
+
+// IsValid - Returns true if a user activity has a known kind and its content.
+// Reminders need a due time, other kinds a body.
+func (activity *LeadActivity) IsValid() bool {
+	switch activity.Kind.String {
+	case LeadActivityReminder:
+		return activity.DueAt.Valid
+	case LeadActivityNote, LeadActivityCall, LeadActivityEmail:
+		return activity.Body.String != ""
+	}
+	return false
+}
+
+// IsPending - Returns true if activity is a reminder not yet done.
+func (activity *LeadActivity) IsPending() bool {
+	return activity.Kind.String == LeadActivityReminder && !activity.DoneAt.Valid
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (stage *LeadStage) MarshalJSON() ([]byte, error) {
+	type Alias LeadStage
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(stage),
+		StartedAt: stage.StartedAt.Time.Unix(),
+		CreatedAt: stage.CreatedAt.Time.Unix(),
+		UpdatedAt: stage.UpdatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (lead *Lead) MarshalJSON() ([]byte, error) {
+	type Alias Lead
+	return json.Marshal(&struct {
+		*Alias
+		StageChangedAt int64 `json:"stageChangedAt"`
+		StartedAt      int64 `json:"startedAt"`
+		CreatedAt      int64 `json:"createdAt"`
+		UpdatedAt      int64 `json:"updatedAt"`
+	}{
+		Alias:          (*Alias)(lead),
+		StageChangedAt: lead.StageChangedAt.Time.Unix(),
+		StartedAt:      lead.StartedAt.Time.Unix(),
+		CreatedAt:      lead.CreatedAt.Time.Unix(),
+		UpdatedAt:      lead.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (lead *Lead) UnmarshalJSON(data []byte) error {
+	type Alias Lead
+	aux := &struct {
+		*Alias
+		StageChangedAt int64 `json:"stageChangedAt"`
+		StartedAt      int64 `json:"startedAt"`
+		CreatedAt      int64 `json:"createdAt"`
+		UpdatedAt      int64 `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(lead),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	lead.StartedAt = nulls.Time{Time: time.Unix(aux.StartedAt, 0)}
+	lead.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	lead.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (activity LeadActivity) MarshalJSON() ([]byte, error) {
+	type Alias LeadActivity
+	aux := &struct {
+		Alias
+		DueAt     nulls.Int64 `json:"dueAt"`
+		DoneAt    nulls.Int64 `json:"doneAt"`
+		CreatedAt int64       `json:"createdAt"`
+	}{
+		Alias:     (Alias)(activity),
+		CreatedAt: activity.CreatedAt.Time.Unix(),
+	}
+	if activity.DueAt.Valid {
+		aux.DueAt = ToNullsInt64(activity.DueAt.Time.Unix())
+	}
+	if activity.DoneAt.Valid {
+		aux.DoneAt = ToNullsInt64(activity.DoneAt.Time.Unix())
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (activity *LeadActivity) UnmarshalJSON(data []byte) error {
+	type Alias LeadActivity
+	aux := &struct {
+		*Alias
+		DueAt     nulls.Int64 `json:"dueAt"`
+		DoneAt    nulls.Int64 `json:"doneAt"`
+		CreatedAt int64       `json:"createdAt"`
+	}{
+		Alias: (*Alias)(activity),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.DueAt.Valid {
+		activity.DueAt = ToNullsTime(time.Unix(aux.DueAt.Int64, 0))
+	}
+	if aux.DoneAt.Valid {
+		activity.DoneAt = ToNullsTime(time.Unix(aux.DoneAt.Int64, 0))
+	}
+	activity.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	return nil
+}
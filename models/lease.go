@@ -0,0 +1,274 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// LeaseResourceTag - Tag of the organization resource whose permissions grant lease management.
+	LeaseResourceTag = "leases"
+	// LeaseStatusDraft - Lease being negotiated, not yet in force.
+	LeaseStatusDraft = "draft"
+	// LeaseStatusActive - Lease in force.
+	LeaseStatusActive = "active"
+	// LeaseStatusTerminated - Lease ended before its end date.
+	LeaseStatusTerminated = "terminated"
+	// LeaseStatusExpired - Lease reached its end date.
+	LeaseStatusExpired = "expired"
+	// LeaseScheduleHorizon - Months of rent schedule generated for open-ended leases.
+	LeaseScheduleHorizon = 12
+)
+
+type (
+	// RentInstallment - A single entry of a lease rent schedule.
+	RentInstallment struct {
+		Number      int64     `json:"number"`
+		PeriodStart time.Time `json:"-"`
+		PeriodEnd   time.Time `json:"-"`
+		DueDate     time.Time `json:"-"`
+		Amount      float64   `json:"amount"`
+		Currency    string    `json:"currency"`
+	}
+)
+
+// SetDefaults - Default values for leases before creation.
+func (lease *Lease) SetDefaults() {
+	if lease.Status.String == "" {
+		lease.Status = ToNullsString(LeaseStatusDraft)
+	}
+	if lease.PaymentDay.Int64 == 0 {
+		lease.PaymentDay = ToNullsInt64(1)
+	}
+}
+
+// IsValid - Returns true if status, amounts, payment day and dates hold valid values.
+func (lease *Lease) IsValid() bool {
+	switch lease.Status.String {
+	case LeaseStatusDraft, LeaseStatusActive, LeaseStatusTerminated, LeaseStatusExpired:
+	default:
+		return false
+	}
+	if lease.UnitID.String == "" {
+		return false
+	}
+	if lease.RentAmount.Float64 < 0 || lease.Deposit.Float64 < 0 {
+		return false
+	}
+	if lease.PaymentDay.Int64 < 1 || lease.PaymentDay.Int64 > 31 {
+		return false
+	}
+	if lease.IndexationRate.Float64 <= -100 || lease.IndexationPeriod.Int64 < 0 {
+		return false
+	}
+	if lease.LateFeeAmount.Float64 < 0 || lease.LateFeeRate.Float64 < 0 || lease.GraceDays.Int64 < 0 {
+		return false
+	}
+	if lease.EndsAt.Valid && lease.StartedAt.Valid && !lease.EndsAt.Time.After(lease.StartedAt.Time) {
+		return false
+	}
+	return true
+}
+
+// CanChangeStatus - Returns true if lease can move from its current status to the new one.
+// Draft leases can be activated, active ones can be terminated or expire.
+func (lease *Lease) CanChangeStatus(status string) bool {
+	if lease.Status.String == status {
+		return true
+	}
+	switch lease.Status.String {
+	case LeaseStatusDraft:
+		return status == LeaseStatusActive
+	case LeaseStatusActive:
+		return status == LeaseStatusTerminated || status == LeaseStatusExpired
+	}
+	return false
+}
+
+// IsExpired - Returns true if an active lease has reached its end date.
+func (lease *Lease) IsExpired(now time.Time) bool {
+	return lease.Status.String == LeaseStatusActive && lease.EndsAt.Valid && !lease.EndsAt.Time.After(now)
+}
+
+// RentSchedule - Generates one installment per calendar month from lease start to lease end.
+// First and last installments are prorated by days, open-ended leases are scheduled
+// LeaseScheduleHorizon months ahead. Rent is indexed by IndexationRate (percent)
+// every IndexationPeriod months since the start month. Installments are due on
+// PaymentDay, moved to the month last day or to the lease start when needed.
+func (lease *Lease) RentSchedule() []RentInstallment {
+	end := truncateToDay(lease.StartedAt.Time).AddDate(0, LeaseScheduleHorizon, 0)
+	if lease.EndsAt.Valid {
+		end = truncateToDay(lease.EndsAt.Time)
+	}
+	return lease.rentSchedule(end)
+}
+
+// RentScheduleUntil - Installments of the rent schedule due until a date.
+// Open-ended leases are scheduled as far as needed.
+func (lease *Lease) RentScheduleUntil(until time.Time) []RentInstallment {
+	end := truncateToDay(until).AddDate(0, 1, 0)
+	if lease.EndsAt.Valid {
+		end = truncateToDay(lease.EndsAt.Time)
+	}
+	installments := []RentInstallment{}
+	for _, installment := range lease.rentSchedule(end) {
+		if !installment.DueDate.After(until) {
+			installments = append(installments, installment)
+		}
+	}
+	return installments
+}
+
+// rentSchedule - Installments from lease start to end (exclusive).
+func (lease *Lease) rentSchedule(end time.Time) []RentInstallment {
+	installments := []RentInstallment{}
+	if !lease.StartedAt.Valid {
+		return installments
+	}
+	start := truncateToDay(lease.StartedAt.Time)
+	for i := 0; ; i++ {
+		month := time.Date(start.Year(), start.Month()+time.Month(i), 1, 0, 0, 0, 0, start.Location())
+		if !month.Before(end) {
+			break
+		}
+		next := month.AddDate(0, 1, 0)
+		from, to := month, next
+		if start.After(from) {
+			from = start
+		}
+		if end.Before(to) {
+			to = end
+		}
+		monthDays := daysBetween(month, next)
+		amount := lease.RentAmount.Float64 * lease.indexationFactor(i)
+		amount = amount * float64(daysBetween(from, to)) / float64(monthDays)
+		dueDay := int(lease.PaymentDay.Int64)
+		if dueDay > monthDays {
+			dueDay = monthDays
+		}
+		dueDate := time.Date(month.Year(), month.Month(), dueDay, 0, 0, 0, 0, month.Location())
+		if dueDate.Before(from) {
+			dueDate = from
+		}
+		installments = append(installments, RentInstallment{
+			Number:      int64(i + 1),
+			PeriodStart: from,
+			PeriodEnd:   to.AddDate(0, 0, -1),
+			DueDate:     dueDate,
+			Amount:      RoundAmount(amount),
+			Currency:    lease.Currency.String,
+		})
+	}
+	return installments
+}
+
+// LateFee - Fee charged for an overdue balance: fixed amount plus LateFeeRate (percent) of the balance.
+func (lease *Lease) LateFee(balance float64) float64 {
+	return RoundAmount(lease.LateFeeAmount.Float64 + balance*lease.LateFeeRate.Float64/100)
+}
+
+// indexationFactor - Rent multiplier applied to the installment of the n-th month since start.
+func (lease *Lease) indexationFactor(month int) float64 {
+	if lease.IndexationPeriod.Int64 <= 0 || lease.IndexationRate.Float64 == 0 {
+		return 1
+	}
+	steps := float64(int64(month) / lease.IndexationPeriod.Int64)
+	return math.Pow(1+lease.IndexationRate.Float64/100, steps)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(math.Floor(to.Sub(from).Hours()/24 + 0.5))
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (lease *Lease) MarshalJSON() ([]byte, error) {
+	type Alias Lease
+	aux := &struct {
+		*Alias
+		StartedAt int64       `json:"startedAt"`
+		EndsAt    nulls.Int64 `json:"endsAt"`
+		CreatedAt int64       `json:"createdAt"`
+		UpdatedAt int64       `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(lease),
+		StartedAt: lease.StartedAt.Time.Unix(),
+		CreatedAt: lease.CreatedAt.Time.Unix(),
+		UpdatedAt: lease.UpdatedAt.Time.Unix(),
+	}
+	if lease.EndsAt.Valid {
+		aux.EndsAt = ToNullsInt64(lease.EndsAt.Time.Unix())
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (lease *Lease) UnmarshalJSON(data []byte) error {
+	type Alias Lease
+	aux := &struct {
+		*Alias
+		StartedAt nulls.Int64 `json:"startedAt"`
+		EndsAt    nulls.Int64 `json:"endsAt"`
+		CreatedAt int64       `json:"createdAt"`
+		UpdatedAt int64       `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(lease),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.StartedAt.Valid {
+		lease.StartedAt = ToNullsTime(time.Unix(aux.StartedAt.Int64, 0))
+	}
+	if aux.EndsAt.Valid {
+		lease.EndsAt = ToNullsTime(time.Unix(aux.EndsAt.Int64, 0))
+	}
+	lease.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	lease.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (installment RentInstallment) MarshalJSON() ([]byte, error) {
+	type Alias RentInstallment
+	return json.Marshal(&struct {
+		Alias
+		PeriodStart int64 `json:"periodStart"`
+		PeriodEnd   int64 `json:"periodEnd"`
+		DueDate     int64 `json:"dueDate"`
+	}{
+		Alias:       (Alias)(installment),
+		PeriodStart: installment.PeriodStart.Unix(),
+		PeriodEnd:   installment.PeriodEnd.Unix(),
+		DueDate:     installment.DueDate.Unix(),
+	})
+}
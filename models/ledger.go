@@ -0,0 +1,245 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// LedgerResourceTag - Tag of the organization resource whose permissions grant ledger management.
+	LedgerResourceTag = "ledger"
+	// AccountCash - Cash and bank account code.
+	AccountCash = "1000"
+	// AccountReceivable - Tenants receivable account code.
+	AccountReceivable = "1200"
+	// AccountRentIncome - Rent income account code.
+	AccountRentIncome = "4000"
+	// AccountLateFeeIncome - Late fees income account code.
+	AccountLateFeeIncome = "4100"
+	// AccountKindAsset - Asset account.
+	AccountKindAsset = "asset"
+	// AccountKindLiability - Liability account.
+	AccountKindLiability = "liability"
+	// AccountKindIncome - Income account.
+	AccountKindIncome = "income"
+	// JournalEntryKindInvoice - Entry posted when an invoice is issued.
+	JournalEntryKindInvoice = "invoice"
+	// JournalEntryKindPayment - Entry posted when an invoice payment is received.
+	JournalEntryKindPayment = "payment"
+	// JournalEntryKindReversal - Entry cancelling a previous one.
+	JournalEntryKindReversal = "reversal"
+	// StatementHolderUnit - Statement of a unit.
+	StatementHolderUnit = "unit"
+	// StatementHolderTenant - Statement of a tenant.
+	StatementHolderTenant = "tenant"
+)
+
+type (
+	// Payment - Payment received for an invoice.
+	Payment struct {
+		Amount      float64   `json:"amount"`
+		PaidAt      time.Time `json:"-"`
+		Description string    `json:"description, omitempty"`
+	}
+
+	// Statement - Receivables statement of a unit or a tenant.
+	Statement struct {
+		HolderType string             `json:"holderType"`
+		HolderID   string             `json:"holderID"`
+		Balances   []StatementBalance `json:"balances"`
+		Lines      []StatementLine    `json:"lines"`
+	}
+
+	// StatementBalance - Statement totals for a currency.
+	StatementBalance struct {
+		Currency string  `json:"currency"`
+		Debit    float64 `json:"debit"`
+		Credit   float64 `json:"credit"`
+		Balance  float64 `json:"balance"`
+	}
+
+	// StatementLine - Receivables posting in a statement.
+	StatementLine struct {
+		PostedAt       time.Time    `db:"posted_at" json:"-"`
+		JournalEntryID string       `db:"journal_entry_id" json:"journalEntryID"`
+		Kind           string       `db:"kind" json:"kind"`
+		Name           string       `db:"name" json:"name"`
+		InvoiceID      nulls.String `db:"invoice_id" json:"invoiceID"`
+		Currency       string       `db:"currency" json:"currency"`
+		Debit          float64      `db:"debit" json:"debit"`
+		Credit         float64      `db:"credit" json:"credit"`
+		Balance        float64      `db:"-" json:"balance"`
+	}
+)
+
+// DefaultAccounts - Chart of accounts used by rent invoicing.
+func DefaultAccounts() []Account {
+	return []Account{
+		makeAccount(AccountCash, "Cash", AccountKindAsset),
+		makeAccount(AccountReceivable, "Receivables", AccountKindAsset),
+		makeAccount(AccountRentIncome, "Rent income", AccountKindIncome),
+		makeAccount(AccountLateFeeIncome, "Late fees income", AccountKindIncome),
+	}
+}
+
+func makeAccount(code, name, kind string) Account {
+	account := Account{}
+	account.Code = ToNullsString(code)
+	account.Name = ToNullsString(name)
+	account.Kind = ToNullsString(kind)
+	return account
+}
+
+// RoundAmount - Rounds a money amount to cents.
+func RoundAmount(amount float64) float64 {
+	return math.Floor(amount*100+0.5) / 100
+}
+
+// IsBalanced - Returns true if entry has postings and their debits and credits
+// are equal for every currency.
+func (entry *JournalEntry) IsBalanced() bool {
+	if len(entry.Postings) < 2 {
+		return false
+	}
+	totals := make(map[string]float64)
+	for _, posting := range entry.Postings {
+		if posting.Debit.Float64 < 0 || posting.Credit.Float64 < 0 {
+			return false
+		}
+		if (posting.Debit.Float64 == 0) == (posting.Credit.Float64 == 0) {
+			return false
+		}
+		totals[posting.Currency.String] += posting.Debit.Float64 - posting.Credit.Float64
+	}
+	for _, total := range totals {
+		if RoundAmount(total) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reversal - Returns an entry cancelling this one: same accounts and references,
+// debits and credits swapped.
+func (entry *JournalEntry) Reversal() JournalEntry {
+	reversal := JournalEntry{}
+	reversal.Name = ToNullsString("Reversal of " + entry.Name.String)
+	reversal.Kind = ToNullsString(JournalEntryKindReversal)
+	reversal.ReversalOf = entry.ID
+	reversal.OrganizationID = entry.OrganizationID
+	for _, posting := range entry.Postings {
+		reversed := posting
+		reversed.ID = ToNullsString("")
+		reversed.Debit, reversed.Credit = posting.Credit, posting.Debit
+		reversal.Postings = append(reversal.Postings, reversed)
+	}
+	return reversal
+}
+
+// MakeStatement - Builds a statement computing running balances and totals per currency.
+func MakeStatement(holderType, holderID string, lines []StatementLine) Statement {
+	statement := Statement{HolderType: holderType, HolderID: holderID, Balances: []StatementBalance{}, Lines: lines}
+	index := make(map[string]int)
+	for i := range statement.Lines {
+		line := &statement.Lines[i]
+		pos, ok := index[line.Currency]
+		if !ok {
+			pos = len(statement.Balances)
+			index[line.Currency] = pos
+			statement.Balances = append(statement.Balances, StatementBalance{Currency: line.Currency})
+		}
+		balance := &statement.Balances[pos]
+		balance.Debit = RoundAmount(balance.Debit + line.Debit)
+		balance.Credit = RoundAmount(balance.Credit + line.Credit)
+		balance.Balance = RoundAmount(balance.Debit - balance.Credit)
+		line.Balance = balance.Balance
+	}
+	return statement
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (entry *JournalEntry) MarshalJSON() ([]byte, error) {
+	type Alias JournalEntry
+	return json.Marshal(&struct {
+		*Alias
+		PostedAt  int64 `json:"postedAt"`
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(entry),
+		PostedAt:  entry.PostedAt.Time.Unix(),
+		StartedAt: entry.StartedAt.Time.Unix(),
+		CreatedAt: entry.CreatedAt.Time.Unix(),
+		UpdatedAt: entry.UpdatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (posting Posting) MarshalJSON() ([]byte, error) {
+	type Alias Posting
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(posting),
+		CreatedAt: posting.CreatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (payment *Payment) UnmarshalJSON(data []byte) error {
+	type Alias Payment
+	aux := &struct {
+		*Alias
+		PaidAt nulls.Int64 `json:"paidAt"`
+	}{
+		Alias: (*Alias)(payment),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	payment.PaidAt = time.Now()
+	if aux.PaidAt.Valid {
+		payment.PaidAt = time.Unix(aux.PaidAt.Int64, 0)
+	}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (line StatementLine) MarshalJSON() ([]byte, error) {
+	type Alias StatementLine
+	return json.Marshal(&struct {
+		Alias
+		PostedAt int64 `json:"postedAt"`
+	}{
+		Alias:    (Alias)(line),
+		PostedAt: line.PostedAt.Unix(),
+	})
+}
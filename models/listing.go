@@ -0,0 +1,172 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// ListingOperationSale - Listing offered for sale.
+	ListingOperationSale = "sale"
+	// ListingOperationRent - Listing offered for rent.
+	ListingOperationRent = "rent"
+	// ListingStatusDraft - Listing not yet published.
+	ListingStatusDraft = "draft"
+	// ListingStatusPublished - Listing visible to prospects.
+	ListingStatusPublished = "published"
+	// ListingStatusReserved - Listing reserved by a prospect.
+	ListingStatusReserved = "reserved"
+	// ListingStatusClosed - Listing sold, rented or withdrawn.
+	ListingStatusClosed = "closed"
+)
+
+type (
+	// ListingStats - Market statistics of a Listing computed from its history.
+	// Days on market are counted since first publication, price change is a percentage over the initial price.
+	ListingStats struct {
+		ListingID       string        `db:"listing_id" json:"-"`
+		ListedAt        nulls.Time    `db:"listed_at" json:"-"`
+		DaysOnMarket    int64         `db:"days_on_market" json:"daysOnMarket"`
+		PriceReductions int64         `db:"price_reductions" json:"priceReductions"`
+		InitialPrice    nulls.Float64 `db:"initial_price" json:"initialPrice"`
+		PriceChange     float64       `db:"price_change" json:"priceChange"`
+	}
+
+	// ListingStatsFilter - Conditions over Listing statistics, unset bounds don't apply.
+	ListingStatsFilter struct {
+		MinDaysOnMarket nulls.Int64
+		MaxDaysOnMarket nulls.Int64
+		PriceReduced    nulls.Bool
+		MinPriceChange  nulls.Float64
+		MaxPriceChange  nulls.Float64
+	}
+)
+
+// SetDefaults - Default values for listings before creation.
+func (listing *Listing) SetDefaults() {
+	if listing.Status.String == "" {
+		listing.Status = ToNullsString(ListingStatusDraft)
+	}
+}
+
+// IsValid - Returns true if operation and status hold known values.
+func (listing *Listing) IsValid() bool {
+	switch listing.Operation.String {
+	case ListingOperationSale, ListingOperationRent:
+	default:
+		return false
+	}
+	switch listing.Status.String {
+	case ListingStatusDraft, ListingStatusPublished, ListingStatusReserved, ListingStatusClosed:
+	default:
+		return false
+	}
+	return listing.Price.Float64 >= 0
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (listing *Listing) MarshalJSON() ([]byte, error) {
+	type Alias Listing
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(listing),
+		StartedAt: listing.StartedAt.Time.Unix(),
+		CreatedAt: listing.CreatedAt.Time.Unix(),
+		UpdatedAt: listing.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (listing *Listing) UnmarshalJSON(data []byte) error {
+	type Alias Listing
+	aux := &struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(listing),
+		StartedAt: listing.StartedAt.Time.Unix(),
+		CreatedAt: listing.CreatedAt.Time.Unix(),
+		UpdatedAt: listing.UpdatedAt.Time.Unix(),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	ts := time.Unix(aux.StartedAt, 0)
+	tc := time.Unix(aux.CreatedAt, 0)
+	tu := time.Unix(aux.UpdatedAt, 0)
+	listing.StartedAt = nulls.Time{Time: ts}
+	listing.CreatedAt = nulls.Time{Time: tc}
+	listing.UpdatedAt = nulls.Time{Time: tu}
+	return nil
+}
+
+// HasHistoryChanges - Returns true if price, currency or status differ from reference.
+func (listing *Listing) HasHistoryChanges(reference Listing) bool {
+	return listing.Price.Float64 != reference.Price.Float64 ||
+		listing.Currency.String != reference.Currency.String ||
+		listing.Status.String != reference.Status.String
+}
+
+// IsEmpty - Returns true if no condition is set.
+func (filter *ListingStatsFilter) IsEmpty() bool {
+	return !filter.MinDaysOnMarket.Valid && !filter.MaxDaysOnMarket.Valid && !filter.PriceReduced.Valid &&
+		!filter.MinPriceChange.Valid && !filter.MaxPriceChange.Valid
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (stats ListingStats) MarshalJSON() ([]byte, error) {
+	type Alias ListingStats
+	aux := &struct {
+		Alias
+		ListedAt nulls.Int64 `json:"listedAt"`
+	}{
+		Alias: (Alias)(stats),
+	}
+	if stats.ListedAt.Valid {
+		aux.ListedAt = ToNullsInt64(stats.ListedAt.Time.Unix())
+	}
+	return json.Marshal(aux)
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (history ListingHistory) MarshalJSON() ([]byte, error) {
+	type Alias ListingHistory
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(history),
+		CreatedAt: history.CreatedAt.Time.Unix(),
+	})
+}
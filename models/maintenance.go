@@ -0,0 +1,323 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// MaintenanceResourceTag - Tag of the organization resource whose permissions grant maintenance management.
+	MaintenanceResourceTag = "maintenance"
+	// MaintenanceRoleManager - Organization owner or user with a permission over the maintenance resource.
+	MaintenanceRoleManager = "manager"
+	// MaintenanceRoleAssignee - User assigned to a work order, directly or as vendor organization owner.
+	MaintenanceRoleAssignee = "assignee"
+	// MaintenanceRoleReporter - User who reported the request.
+	MaintenanceRoleReporter = "reporter"
+	// MaintenanceStatusReported - Request reported, not yet reviewed.
+	MaintenanceStatusReported = "reported"
+	// MaintenanceStatusTriaged - Request reviewed and prioritized.
+	MaintenanceStatusTriaged = "triaged"
+	// MaintenanceStatusAssigned - Request with work orders assigned.
+	MaintenanceStatusAssigned = "assigned"
+	// MaintenanceStatusInProgress - Request being worked on.
+	MaintenanceStatusInProgress = "in_progress"
+	// MaintenanceStatusDone - Work finished, waiting for verification.
+	MaintenanceStatusDone = "done"
+	// MaintenanceStatusVerified - Work verified, request closed.
+	MaintenanceStatusVerified = "verified"
+	// MaintenancePriorityLow - Low priority.
+	MaintenancePriorityLow = "low"
+	// MaintenancePriorityNormal - Normal priority.
+	MaintenancePriorityNormal = "normal"
+	// MaintenancePriorityHigh - High priority.
+	MaintenancePriorityHigh = "high"
+	// MaintenancePriorityUrgent - Urgent priority.
+	MaintenancePriorityUrgent = "urgent"
+	// WorkOrderStatusOpen - Work order not started.
+	WorkOrderStatusOpen = "open"
+	// WorkOrderStatusInProgress - Work order started.
+	WorkOrderStatusInProgress = "in_progress"
+	// WorkOrderStatusCompleted - Work order finished.
+	WorkOrderStatusCompleted = "completed"
+	// WorkOrderStatusCancelled - Work order cancelled.
+	WorkOrderStatusCancelled = "cancelled"
+	// MaintenancePhotoMaxSize - Max decoded photo size in bytes.
+	MaintenancePhotoMaxSize = 999000
+)
+
+var (
+	// maintenanceTransitions - Allowed request status changes, done requests can be reopened.
+	maintenanceTransitions = map[string][]string{
+		MaintenanceStatusReported:   {MaintenanceStatusTriaged},
+		MaintenanceStatusTriaged:    {MaintenanceStatusAssigned},
+		MaintenanceStatusAssigned:   {MaintenanceStatusInProgress},
+		MaintenanceStatusInProgress: {MaintenanceStatusDone},
+		MaintenanceStatusDone:       {MaintenanceStatusVerified, MaintenanceStatusInProgress},
+	}
+	// workOrderTransitions - Allowed work order status changes.
+	workOrderTransitions = map[string][]string{
+		WorkOrderStatusOpen:       {WorkOrderStatusInProgress, WorkOrderStatusCancelled},
+		WorkOrderStatusInProgress: {WorkOrderStatusCompleted, WorkOrderStatusCancelled},
+	}
+	photoTypes = regexp.MustCompile("^image/(gif|p?jpeg|(x-)?png)$")
+)
+
+type (
+	// MaintenanceCost - Work orders estimated and actual costs for a currency.
+	MaintenanceCost struct {
+		Currency  string  `json:"currency"`
+		Estimated float64 `json:"estimated"`
+		Actual    float64 `json:"actual"`
+	}
+)
+
+// SetDefaults - Default values for maintenance requests before creation.
+func (request *MaintenanceRequest) SetDefaults() {
+	request.Status = ToNullsString(MaintenanceStatusReported)
+	if request.Priority.String == "" {
+		request.Priority = ToNullsString(MaintenancePriorityNormal)
+	}
+}
+
+// IsValid - Returns true if request has a title, a building and valid priority and status.
+func (request *MaintenanceRequest) IsValid() bool {
+	if request.Name.String == "" || request.BuildingID.String == "" {
+		return false
+	}
+	switch request.Priority.String {
+	case MaintenancePriorityLow, MaintenancePriorityNormal, MaintenancePriorityHigh, MaintenancePriorityUrgent:
+	default:
+		return false
+	}
+	if _, ok := maintenanceTransitions[request.Status.String]; !ok && request.Status.String != MaintenanceStatusVerified {
+		return false
+	}
+	return true
+}
+
+// CanChangeStatus - Returns true if request can move from its current status to the new one.
+func (request *MaintenanceRequest) CanChangeStatus(status string) bool {
+	return canTransition(maintenanceTransitions, request.Status.String, status)
+}
+
+// StatusChangeAllowed - Returns true if a user having the role can move the request to the status.
+// Managers drive the whole workflow, assignees report progress and
+// reporters verify or reopen finished work.
+func (request *MaintenanceRequest) StatusChangeAllowed(role, status string) bool {
+	if request.Status.String == status {
+		return true
+	}
+	if !request.CanChangeStatus(status) {
+		return false
+	}
+	switch role {
+	case MaintenanceRoleManager:
+		return true
+	case MaintenanceRoleAssignee:
+		return status == MaintenanceStatusInProgress || status == MaintenanceStatusDone
+	case MaintenanceRoleReporter:
+		return request.Status.String == MaintenanceStatusDone
+	}
+	return false
+}
+
+// SumCosts - Sets estimated and actual costs per currency from not cancelled work orders.
+func (request *MaintenanceRequest) SumCosts() {
+	request.Costs = []MaintenanceCost{}
+	for _, order := range request.WorkOrders {
+		if order.Status.String == WorkOrderStatusCancelled {
+			continue
+		}
+		pos := -1
+		for i, cost := range request.Costs {
+			if cost.Currency == order.Currency.String {
+				pos = i
+			}
+		}
+		if pos < 0 {
+			pos = len(request.Costs)
+			request.Costs = append(request.Costs, MaintenanceCost{Currency: order.Currency.String})
+		}
+		request.Costs[pos].Estimated = RoundAmount(request.Costs[pos].Estimated + order.EstimatedCost.Float64)
+		request.Costs[pos].Actual = RoundAmount(request.Costs[pos].Actual + order.ActualCost.Float64)
+	}
+}
+
+// SetDefaults - Default values for work orders before creation.
+func (order *WorkOrder) SetDefaults() {
+	order.Status = ToNullsString(WorkOrderStatusOpen)
+}
+
+// IsValid - Returns true if work order has an assignee, a valid status and non negative costs.
+func (order *WorkOrder) IsValid() bool {
+	if order.AssigneeUserID.String == "" && order.AssigneeOrganizationID.String == "" {
+		return false
+	}
+	switch order.Status.String {
+	case WorkOrderStatusOpen, WorkOrderStatusInProgress, WorkOrderStatusCompleted, WorkOrderStatusCancelled:
+	default:
+		return false
+	}
+	return order.EstimatedCost.Float64 >= 0 && order.ActualCost.Float64 >= 0
+}
+
+// CanChangeStatus - Returns true if work order can move from its current status to the new one.
+func (order *WorkOrder) CanChangeStatus(status string) bool {
+	return canTransition(workOrderTransitions, order.Status.String, status)
+}
+
+// IsPending - Returns true if work order is neither completed nor cancelled.
+func (order *WorkOrder) IsPending() bool {
+	return order.Status.String == WorkOrderStatusOpen || order.Status.String == WorkOrderStatusInProgress
+}
+
+// Complete - Sets completion time if not provided.
+func (order *WorkOrder) Complete(at time.Time) {
+	if !order.CompletedAt.Valid {
+		order.CompletedAt = ToNullsTime(at)
+	}
+}
+
+// Decode - Validates photo content type and data, setting its size.
+func (photo *MaintenancePhoto) Decode() bool {
+	if !photoTypes.MatchString(photo.ContentType.String) {
+		return false
+	}
+	data, err := base64.StdEncoding.DecodeString(photo.Base64.String)
+	if err != nil || len(data) == 0 || len(data) > MaintenancePhotoMaxSize {
+		return false
+	}
+	photo.Size = ToNullsInt64(int64(len(data)))
+	return true
+}
+
+func canTransition(transitions map[string][]string, from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (request *MaintenanceRequest) MarshalJSON() ([]byte, error) {
+	type Alias MaintenanceRequest
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(request),
+		StartedAt: request.StartedAt.Time.Unix(),
+		CreatedAt: request.CreatedAt.Time.Unix(),
+		UpdatedAt: request.UpdatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (order *WorkOrder) MarshalJSON() ([]byte, error) {
+	type Alias WorkOrder
+	aux := &struct {
+		*Alias
+		ScheduledAt nulls.Int64 `json:"scheduledAt"`
+		CompletedAt nulls.Int64 `json:"completedAt"`
+		StartedAt   int64       `json:"startedAt"`
+		CreatedAt   int64       `json:"createdAt"`
+		UpdatedAt   int64       `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(order),
+		StartedAt: order.StartedAt.Time.Unix(),
+		CreatedAt: order.CreatedAt.Time.Unix(),
+		UpdatedAt: order.UpdatedAt.Time.Unix(),
+	}
+	if order.ScheduledAt.Valid {
+		aux.ScheduledAt = ToNullsInt64(order.ScheduledAt.Time.Unix())
+	}
+	if order.CompletedAt.Valid {
+		aux.CompletedAt = ToNullsInt64(order.CompletedAt.Time.Unix())
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (order *WorkOrder) UnmarshalJSON(data []byte) error {
+	type Alias WorkOrder
+	aux := &struct {
+		*Alias
+		ScheduledAt nulls.Int64 `json:"scheduledAt"`
+		CompletedAt nulls.Int64 `json:"completedAt"`
+		CreatedAt   int64       `json:"createdAt"`
+		UpdatedAt   int64       `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(order),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.ScheduledAt.Valid {
+		order.ScheduledAt = ToNullsTime(time.Unix(aux.ScheduledAt.Int64, 0))
+	}
+	if aux.CompletedAt.Valid {
+		order.CompletedAt = ToNullsTime(time.Unix(aux.CompletedAt.Int64, 0))
+	}
+	order.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	order.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (comment MaintenanceComment) MarshalJSON() ([]byte, error) {
+	type Alias MaintenanceComment
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(comment),
+		CreatedAt: comment.CreatedAt.Time.Unix(),
+	})
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (photo MaintenancePhoto) MarshalJSON() ([]byte, error) {
+	type Alias MaintenancePhoto
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(photo),
+		CreatedAt: photo.CreatedAt.Time.Unix(),
+	})
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/adrianpk/fundacja/types"
 
 	sqlxtypes "github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 	"github.com/markbates/pop/nulls"
 )
 
@@ -59,8 +60,12 @@ type (
 		AnniversaryDate nulls.Time         `db:"anniversary_date" json:"anniversaryDate" schema:"anniversary-date"`
 		Avatar          nulls.ByteSlice    `db:"avatar" json:"-"`
 		AvatarBase64    string             `json:"avatar, omitempty" schema:"avatar-base-64"`
-		AvatarURI       nulls.String       `db:"avatar_uri" json:"avatarURL" schema:"avatar-uri"`
-		HeaderURI       nulls.String       `db:"header_uri" json:"headerURL" schema:"header-uri"`
+		AvatarURI       nulls.String       `db:"avatar_uri" json:"-"`
+		AvatarURL       string             `db:"-" json:"avatarURL"`
+		AvatarURLs      map[string]string  `db:"-" json:"avatarURLs,omitempty"`
+		HeaderURI       nulls.String       `db:"header_uri" json:"-"`
+		HeaderURL       string             `db:"-" json:"headerURL"`
+		HeaderURLs      map[string]string  `db:"-" json:"headerURLs,omitempty"`
 		Card            sqlxtypes.JSONText `db:"card" json:"card"`
 		Geolocation     types.NullPoint    `db:"geolocation" json:"geolocation"`
 		//UserUsername    nulls.String       `db:"username" json:"username" schema:"user-username"`
@@ -75,8 +80,9 @@ type (
 	// Organization - Resource model
 	Organization struct {
 		IdentifiableModel
-		UserUsername nulls.String `db:"user_username" json:"userUsername, omitempty" schema:"user-username"`
-		UserID       nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		UserUsername   nulls.String `db:"user_username" json:"userUsername, omitempty" schema:"user-username"`
+		UserID         nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		SearchLanguage nulls.String `db:"search_language" json:"searchLanguage, omitempty" schema:"search-language"`
 		AnnotableModel
 		GeolocalizableModel
 		AuditableModel
@@ -115,6 +121,7 @@ type (
 		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
 		ResourceID     nulls.String `db:"resource_id" json:"resourceID, omitempty" schema:"resource-id"`
 		PermissionID   nulls.String `db:"permission_id" json:"permissionID, omitempty" schema:"permission-id"`
+		Action         nulls.String `db:"action" json:"action, omitempty" schema:"action"`
 		AuditableModel
 		ValidableDate
 	}
@@ -130,6 +137,25 @@ type (
 	}
 
 	// UserRole - UserRole model
+	AccessControlEntry struct {
+		IdentifiableModel
+		EntityType     nulls.String `db:"entity_type" json:"entityType, omitempty" schema:"entity-type"`
+		EntityID       nulls.String `db:"entity_id" json:"entityID, omitempty" schema:"entity-id"`
+		UserID         nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		RoleID         nulls.String `db:"role_id" json:"roleID, omitempty" schema:"role-id"`
+		Action         nulls.String `db:"action" json:"action, omitempty" schema:"action"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedBy      nulls.String `db:"created_by" json:"createdBy, omitempty" schema:"created-by"`
+		CreatedAt      nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+	RoleParent struct {
+		IdentifiableModel
+		RoleID         nulls.String `db:"role_id" json:"roleID, omitempty" schema:"role-id"`
+		ParentID       nulls.String `db:"parent_id" json:"parentID, omitempty" schema:"parent-id"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedBy      nulls.String `db:"created_by" json:"createdBy, omitempty" schema:"created-by"`
+		CreatedAt      nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
 	UserRole struct {
 		IdentifiableModel
 		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
@@ -142,8 +168,33 @@ type (
 	// PropertiesSet - PropertiesSet model
 	PropertiesSet struct {
 		IdentifiableModel
-		Position nulls.Int64  `db:"position" json:"position, omitempty" schema:"position-id"`
-		HolderID nulls.String `db:"holder_id" json:"holderID, omitempty" schema:"holder-id"`
+		Position   nulls.Int64  `db:"position" json:"position, omitempty" schema:"position-id"`
+		HolderID   nulls.String `db:"holder_id" json:"holderID, omitempty" schema:"holder-id"`
+		TemplateID nulls.String `db:"template_id" json:"templateID, omitempty" schema:"template-id"`
+		AuditableModel
+		ValidableDate
+	}
+
+	// PropertiesSetTemplate - PropertiesSetTemplate model
+	PropertiesSetTemplate struct {
+		IdentifiableModel
+		OrganizationID    nulls.String       `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		PropertyTemplates []PropertyTemplate `db:"-" json:"propertyTemplates"`
+		AuditableModel
+		ValidableDate
+	}
+
+	// PropertyTemplate - PropertyTemplate model
+	PropertyTemplate struct {
+		IdentifiableModel
+		ValueType               nulls.String   `db:"value_type" json:"valueType, omitempty" schema:"value-type"`
+		Required                nulls.Bool     `db:"required" json:"required, omitempty" schema:"required"`
+		MinValue                nulls.Float64  `db:"min_value" json:"minValue, omitempty" schema:"min-value"`
+		MaxValue                nulls.Float64  `db:"max_value" json:"maxValue, omitempty" schema:"max-value"`
+		AllowedValues           pq.StringArray `db:"allowed_values" json:"allowedValues, omitempty" schema:"allowed-values"`
+		Unit                    nulls.String   `db:"unit" json:"unit, omitempty" schema:"unit"`
+		Position                nulls.Int64    `db:"position" json:"position, omitempty" schema:"position"`
+		PropertiesSetTemplateID nulls.String   `db:"properties_set_template_id" json:"propertiesSetTemplateID, omitempty" schema:"properties-set-template-id"`
 		AuditableModel
 		ValidableDate
 	}
@@ -151,15 +202,25 @@ type (
 	// Property - Property model
 	Property struct {
 		IdentifiableModel
-		StringValue      nulls.String    `db:"string_value" json:"stringValue, omitempty" schema:"string-value"`
-		IntValue         nulls.Int64     `db:"int_value" json:"intValue, omitempty" schema:"int-value"`
-		FloatValue       nulls.Float64   `db:"float_value" json:"floatValue, omitempty" schema:"float-value"`
-		BooleanValue     nulls.Bool      `db:"boolean_value" json:"booleanValue, omitempty" schema:"boolean-value"`
-		TimestampValue   nulls.Time      `db:"timestamp_value" json:"timestampValue, omitempty" schema:"timestamp-value"`
-		GeolocationValue types.NullPoint `db:"geolocation_value" json:"geolocationValue" schema:"geolocation-value"`
-		ValueType        nulls.String    `db:"value_type" json:"valueType, omitempty" schema:"value-type"`
-		Position         nulls.Int64     `db:"position" json:"position, omitempty"`
-		PropertiesSetID  nulls.String    `db:"properties_set_id" json:"propertiesSetID, omitempty" schema:"properties-set-id"`
+		StringValue       nulls.String           `db:"string_value" json:"stringValue, omitempty" schema:"string-value"`
+		IntValue          nulls.Int64            `db:"int_value" json:"intValue, omitempty" schema:"int-value"`
+		FloatValue        nulls.Float64          `db:"float_value" json:"floatValue, omitempty" schema:"float-value"`
+		BooleanValue      nulls.Bool             `db:"boolean_value" json:"booleanValue, omitempty" schema:"boolean-value"`
+		TimestampValue    nulls.Time             `db:"timestamp_value" json:"timestampValue, omitempty" schema:"timestamp-value"`
+		GeolocationValue  types.NullPoint        `db:"geolocation_value" json:"geolocationValue" schema:"geolocation-value"`
+		PolygonValue      types.NullPolygon      `db:"polygon_value" json:"polygonValue" schema:"polygon-value"`
+		MultiPolygonValue types.NullMultiPolygon `db:"multipolygon_value" json:"multipolygonValue" schema:"multipolygon-value"`
+		LineStringValue   types.NullLineString   `db:"linestring_value" json:"linestringValue" schema:"linestring-value"`
+		MoneyValue        nulls.Float64          `db:"money_value" json:"moneyValue, omitempty" schema:"money-value"`
+		CurrencyValue     nulls.String           `db:"currency_value" json:"currencyValue, omitempty" schema:"currency-value"`
+		DateRangeValue    types.NullDateRange    `db:"date_range_value" json:"dateRangeValue" schema:"date-range-value"`
+		ReferenceType     nulls.String           `db:"reference_type" json:"referenceType, omitempty" schema:"reference-type"`
+		ReferenceID       nulls.String           `db:"reference_id" json:"referenceID, omitempty" schema:"reference-id"`
+		ListValue         types.JSONList         `db:"list_value" json:"listValue" schema:"list-value"`
+		Distance          *float64               `db:"distance" json:"distance,omitempty"`
+		ValueType         nulls.String           `db:"value_type" json:"valueType, omitempty" schema:"value-type"`
+		Position          nulls.Int64            `db:"position" json:"position, omitempty"`
+		PropertiesSetID   nulls.String           `db:"properties_set_id" json:"propertiesSetID, omitempty" schema:"properties-set-id"`
 		AuditableModel
 		ValidableDate
 	}
@@ -183,22 +244,373 @@ type (
 		ValidableDate
 	}
 
+	// Listing - Listing model
+	Listing struct {
+		IdentifiableModel
+		Price          nulls.Float64     `db:"price" json:"price, omitempty" schema:"price"`
+		Currency       nulls.String      `db:"currency" json:"currency, omitempty" schema:"currency"`
+		Operation      nulls.String      `db:"operation" json:"operation, omitempty" schema:"operation"`
+		Status         nulls.String      `db:"status" json:"status, omitempty" schema:"status"`
+		Address        nulls.String      `db:"address" json:"address, omitempty" schema:"address"`
+		Footprint      types.NullPolygon `db:"footprint" json:"footprint" schema:"footprint"`
+		OrganizationID nulls.String      `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AnnotableModel
+		GeolocalizableModel
+		AuditableModel
+		ValidableDate
+		Stats *ListingStats `db:"-" json:"stats,omitempty"`
+	}
+
+	// ListingHistory - Listing price and status change, entries are never updated.
+	ListingHistory struct {
+		IdentifiableModel
+		ListingID      nulls.String  `db:"listing_id" json:"listingID, omitempty" schema:"listing-id"`
+		Price          nulls.Float64 `db:"price" json:"price, omitempty" schema:"price"`
+		PreviousPrice  nulls.Float64 `db:"previous_price" json:"previousPrice, omitempty" schema:"previous-price"`
+		Currency       nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		Status         nulls.String  `db:"status" json:"status, omitempty" schema:"status"`
+		PreviousStatus nulls.String  `db:"previous_status" json:"previousStatus, omitempty" schema:"previous-status"`
+		UserID         nulls.String  `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		OrganizationID nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt      nulls.Time    `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
+	// Building - Building model
+	Building struct {
+		IdentifiableModel
+		Address        nulls.String `db:"address" json:"address, omitempty" schema:"address"`
+		Floors         nulls.Int64  `db:"floors" json:"floors, omitempty" schema:"floors"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AnnotableModel
+		GeolocalizableModel
+		AuditableModel
+		ValidableDate
+	}
+
+	// Unit - Unit model
+	Unit struct {
+		IdentifiableModel
+		Kind           nulls.String  `db:"kind" json:"kind, omitempty" schema:"kind"`
+		Floor          nulls.Int64   `db:"floor" json:"floor, omitempty" schema:"floor"`
+		Area           nulls.Float64 `db:"area" json:"area, omitempty" schema:"area"`
+		Share          nulls.Float64 `db:"share" json:"share, omitempty" schema:"share"`
+		Status         nulls.String  `db:"status" json:"status, omitempty" schema:"status"`
+		BuildingID     nulls.String  `db:"building_id" json:"buildingID, omitempty" schema:"building-id"`
+		OrganizationID nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AnnotableModel
+		AuditableModel
+		ValidableDate
+	}
+
+	// BuildingOccupancy - BuildingOccupancy model
+	BuildingOccupancy struct {
+		BuildingID    string  `db:"building_id" json:"buildingID"`
+		Units         int64   `db:"units" json:"units"`
+		Occupied      int64   `db:"occupied" json:"occupied"`
+		Vacant        int64   `db:"vacant" json:"vacant"`
+		Area          float64 `db:"area" json:"area"`
+		OccupiedArea  float64 `db:"occupied_area" json:"occupiedArea"`
+		Shares        float64 `db:"shares" json:"shares"`
+		OccupancyRate float64 `db:"occupancy_rate" json:"occupancyRate"`
+	}
+
+	// Lease - Lease model
+	Lease struct {
+		IdentifiableModel
+		EndsAt           nulls.Time    `db:"ends_at" json:"endsAt, omitempty" schema:"ends-at"`
+		RentAmount       nulls.Float64 `db:"rent_amount" json:"rentAmount, omitempty" schema:"rent-amount"`
+		Deposit          nulls.Float64 `db:"deposit" json:"deposit, omitempty" schema:"deposit"`
+		Currency         nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		PaymentDay       nulls.Int64   `db:"payment_day" json:"paymentDay, omitempty" schema:"payment-day"`
+		IndexationRate   nulls.Float64 `db:"indexation_rate" json:"indexationRate, omitempty" schema:"indexation-rate"`
+		IndexationPeriod nulls.Int64   `db:"indexation_period" json:"indexationPeriod, omitempty" schema:"indexation-period"`
+		LateFeeAmount    nulls.Float64 `db:"late_fee_amount" json:"lateFeeAmount, omitempty" schema:"late-fee-amount"`
+		LateFeeRate      nulls.Float64 `db:"late_fee_rate" json:"lateFeeRate, omitempty" schema:"late-fee-rate"`
+		GraceDays        nulls.Int64   `db:"grace_days" json:"graceDays, omitempty" schema:"grace-days"`
+		Status           nulls.String  `db:"status" json:"status, omitempty" schema:"status"`
+		UnitID           nulls.String  `db:"unit_id" json:"unitID, omitempty" schema:"unit-id"`
+		OrganizationID   nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		TenantIDs        []string      `db:"-" json:"tenantIDs, omitempty" schema:"-"`
+		AnnotableModel
+		AuditableModel
+		ValidableDate
+	}
+
+	// Account - Account model
+	Account struct {
+		IdentifiableModel
+		Code           nulls.String `db:"code" json:"code, omitempty" schema:"code"`
+		Kind           nulls.String `db:"kind" json:"kind, omitempty" schema:"kind"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// JournalEntry - JournalEntry model
+	JournalEntry struct {
+		IdentifiableModel
+		Kind           nulls.String `db:"kind" json:"kind, omitempty" schema:"kind"`
+		PostedAt       nulls.Time   `db:"posted_at" json:"postedAt, omitempty" schema:"posted-at"`
+		ReversalOf     nulls.String `db:"reversal_of" json:"reversalOf, omitempty" schema:"reversal-of"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		Postings       []Posting    `db:"-" json:"postings, omitempty"`
+		AuditableModel
+	}
+
+	// Posting - Posting model
+	Posting struct {
+		IdentifiableModel
+		JournalEntryID nulls.String  `db:"journal_entry_id" json:"journalEntryID, omitempty" schema:"journal-entry-id"`
+		AccountID      nulls.String  `db:"account_id" json:"accountID, omitempty" schema:"account-id"`
+		Debit          nulls.Float64 `db:"debit" json:"debit" schema:"debit"`
+		Credit         nulls.Float64 `db:"credit" json:"credit" schema:"credit"`
+		Currency       nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		LeaseID        nulls.String  `db:"lease_id" json:"leaseID, omitempty" schema:"lease-id"`
+		UnitID         nulls.String  `db:"unit_id" json:"unitID, omitempty" schema:"unit-id"`
+		InvoiceID      nulls.String  `db:"invoice_id" json:"invoiceID, omitempty" schema:"invoice-id"`
+		OrganizationID nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt      nulls.Time    `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
+	// Invoice - Invoice model
+	Invoice struct {
+		IdentifiableModel
+		Kind              nulls.String  `db:"kind" json:"kind, omitempty" schema:"kind"`
+		InstallmentNumber nulls.Int64   `db:"installment_number" json:"installmentNumber, omitempty" schema:"installment-number"`
+		PeriodStart       nulls.Time    `db:"period_start" json:"periodStart, omitempty" schema:"period-start"`
+		PeriodEnd         nulls.Time    `db:"period_end" json:"periodEnd, omitempty" schema:"period-end"`
+		DueDate           nulls.Time    `db:"due_date" json:"dueDate, omitempty" schema:"due-date"`
+		Amount            nulls.Float64 `db:"amount" json:"amount, omitempty" schema:"amount"`
+		Balance           nulls.Float64 `db:"balance" json:"balance" schema:"balance"`
+		Currency          nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		Status            nulls.String  `db:"status" json:"status, omitempty" schema:"status"`
+		JournalEntryID    nulls.String  `db:"journal_entry_id" json:"journalEntryID, omitempty" schema:"journal-entry-id"`
+		LeaseID           nulls.String  `db:"lease_id" json:"leaseID, omitempty" schema:"lease-id"`
+		UnitID            nulls.String  `db:"unit_id" json:"unitID, omitempty" schema:"unit-id"`
+		OrganizationID    nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// BankStatement - BankStatement model
+	BankStatement struct {
+		IdentifiableModel
+		Format         nulls.String      `db:"format" json:"format, omitempty" schema:"format"`
+		AccountNumber  nulls.String      `db:"account_number" json:"accountNumber, omitempty" schema:"account-number"`
+		Currency       nulls.String      `db:"currency" json:"currency, omitempty" schema:"currency"`
+		OpeningBalance nulls.Float64     `db:"opening_balance" json:"openingBalance, omitempty" schema:"opening-balance"`
+		ClosingBalance nulls.Float64     `db:"closing_balance" json:"closingBalance, omitempty" schema:"closing-balance"`
+		StatementDate  nulls.Time        `db:"statement_date" json:"statementDate, omitempty" schema:"statement-date"`
+		OrganizationID nulls.String      `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		Transactions   []BankTransaction `db:"-" json:"transactions, omitempty"`
+		AuditableModel
+	}
+
+	// BankTransaction - BankTransaction model
+	BankTransaction struct {
+		IdentifiableModel
+		BankStatementID nulls.String  `db:"bank_statement_id" json:"bankStatementID, omitempty" schema:"bank-statement-id"`
+		BookedAt        nulls.Time    `db:"booked_at" json:"bookedAt, omitempty" schema:"booked-at"`
+		Amount          nulls.Float64 `db:"amount" json:"amount" schema:"amount"`
+		Currency        nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		Reference       nulls.String  `db:"reference" json:"reference, omitempty" schema:"reference"`
+		BankReference   nulls.String  `db:"bank_reference" json:"bankReference, omitempty" schema:"bank-reference"`
+		PayerName       nulls.String  `db:"payer_name" json:"payerName, omitempty" schema:"payer-name"`
+		PayerAccount    nulls.String  `db:"payer_account" json:"payerAccount, omitempty" schema:"payer-account"`
+		Fingerprint     nulls.String  `db:"fingerprint" json:"-"`
+		Status          nulls.String  `db:"status" json:"status, omitempty" schema:"status"`
+		InvoiceID       nulls.String  `db:"invoice_id" json:"invoiceID, omitempty" schema:"invoice-id"`
+		JournalEntryID  nulls.String  `db:"journal_entry_id" json:"journalEntryID, omitempty" schema:"journal-entry-id"`
+		OrganizationID  nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		Matches         []BankMatch   `db:"-" json:"matches, omitempty"`
+		AuditableModel
+	}
+
+	// BankMatch - BankMatch model
+	BankMatch struct {
+		IdentifiableModel
+		BankTransactionID nulls.String `db:"bank_transaction_id" json:"bankTransactionID, omitempty" schema:"bank-transaction-id"`
+		InvoiceID         nulls.String `db:"invoice_id" json:"invoiceID, omitempty" schema:"invoice-id"`
+		Score             nulls.Int64  `db:"score" json:"score" schema:"score"`
+		Reasons           nulls.String `db:"reasons" json:"reasons, omitempty" schema:"reasons"`
+		OrganizationID    nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt         nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
+	// MaintenanceRequest - MaintenanceRequest model
+	MaintenanceRequest struct {
+		IdentifiableModel
+		Priority       nulls.String      `db:"priority" json:"priority, omitempty" schema:"priority"`
+		Status         nulls.String      `db:"status" json:"status, omitempty" schema:"status"`
+		BuildingID     nulls.String      `db:"building_id" json:"buildingID, omitempty" schema:"building-id"`
+		UnitID         nulls.String      `db:"unit_id" json:"unitID, omitempty" schema:"unit-id"`
+		ReportedBy     nulls.String      `db:"reported_by" json:"reportedBy, omitempty" schema:"reported-by"`
+		OrganizationID nulls.String      `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		WorkOrders     []WorkOrder       `db:"-" json:"workOrders, omitempty"`
+		Costs          []MaintenanceCost `db:"-" json:"costs, omitempty"`
+		AuditableModel
+	}
+
+	// WorkOrder - WorkOrder model
+	WorkOrder struct {
+		IdentifiableModel
+		Status                 nulls.String  `db:"status" json:"status, omitempty" schema:"status"`
+		MaintenanceRequestID   nulls.String  `db:"maintenance_request_id" json:"maintenanceRequestID, omitempty" schema:"maintenance-request-id"`
+		AssigneeUserID         nulls.String  `db:"assignee_user_id" json:"assigneeUserID, omitempty" schema:"assignee-user-id"`
+		AssigneeOrganizationID nulls.String  `db:"assignee_organization_id" json:"assigneeOrganizationID, omitempty" schema:"assignee-organization-id"`
+		ScheduledAt            nulls.Time    `db:"scheduled_at" json:"scheduledAt, omitempty" schema:"scheduled-at"`
+		CompletedAt            nulls.Time    `db:"completed_at" json:"completedAt, omitempty" schema:"completed-at"`
+		EstimatedCost          nulls.Float64 `db:"estimated_cost" json:"estimatedCost, omitempty" schema:"estimated-cost"`
+		ActualCost             nulls.Float64 `db:"actual_cost" json:"actualCost, omitempty" schema:"actual-cost"`
+		Currency               nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		OrganizationID         nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// MaintenanceComment - MaintenanceComment model
+	MaintenanceComment struct {
+		IdentifiableModel
+		Body                 nulls.String `db:"body" json:"body, omitempty" schema:"body"`
+		MaintenanceRequestID nulls.String `db:"maintenance_request_id" json:"maintenanceRequestID, omitempty" schema:"maintenance-request-id"`
+		UserID               nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		OrganizationID       nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt            nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
+	// MaintenancePhoto - MaintenancePhoto model
+	MaintenancePhoto struct {
+		IdentifiableModel
+		ContentType          nulls.String `db:"content_type" json:"contentType, omitempty" schema:"content-type"`
+		Size                 nulls.Int64  `db:"size" json:"size, omitempty" schema:"size"`
+		Base64               nulls.String `db:"data" json:"base64, omitempty" schema:"base-64"`
+		MaintenanceRequestID nulls.String `db:"maintenance_request_id" json:"maintenanceRequestID, omitempty" schema:"maintenance-request-id"`
+		UserID               nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		OrganizationID       nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt            nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
+	// AvailabilityWindow - AvailabilityWindow model
+	AvailabilityWindow struct {
+		IdentifiableModel
+		AgentID        nulls.String `db:"agent_id" json:"agentID, omitempty" schema:"agent-id"`
+		StartsAt       nulls.Time   `db:"starts_at" json:"startsAt, omitempty" schema:"starts-at"`
+		EndsAt         nulls.Time   `db:"ends_at" json:"endsAt, omitempty" schema:"ends-at"`
+		SlotMinutes    nulls.Int64  `db:"slot_minutes" json:"slotMinutes, omitempty" schema:"slot-minutes"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// Viewing - Viewing model
+	Viewing struct {
+		IdentifiableModel
+		ListingID            nulls.String `db:"listing_id" json:"listingID, omitempty" schema:"listing-id"`
+		AgentID              nulls.String `db:"agent_id" json:"agentID, omitempty" schema:"agent-id"`
+		AvailabilityWindowID nulls.String `db:"availability_window_id" json:"availabilityWindowID, omitempty" schema:"availability-window-id"`
+		ProspectUserID       nulls.String `db:"prospect_user_id" json:"prospectUserID, omitempty" schema:"prospect-user-id"`
+		ProspectEmail        nulls.String `db:"prospect_email" json:"prospectEmail, omitempty" schema:"prospect-email"`
+		ProspectPhone        nulls.String `db:"prospect_phone" json:"prospectPhone, omitempty" schema:"prospect-phone"`
+		StartsAt             nulls.Time   `db:"starts_at" json:"startsAt, omitempty" schema:"starts-at"`
+		EndsAt               nulls.Time   `db:"ends_at" json:"endsAt, omitempty" schema:"ends-at"`
+		Status               nulls.String `db:"status" json:"status, omitempty" schema:"status"`
+		OrganizationID       nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// LeadStage - LeadStage model
+	LeadStage struct {
+		IdentifiableModel
+		Position       nulls.Int64  `db:"position" json:"position, omitempty" schema:"position"`
+		Outcome        nulls.String `db:"outcome" json:"outcome, omitempty" schema:"outcome"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// Lead - Lead model
+	Lead struct {
+		IdentifiableModel
+		Email          nulls.String  `db:"email" json:"email, omitempty" schema:"email"`
+		Phone          nulls.String  `db:"phone" json:"phone, omitempty" schema:"phone"`
+		Source         nulls.String  `db:"source" json:"source, omitempty" schema:"source"`
+		Value          nulls.Float64 `db:"value" json:"value, omitempty" schema:"value"`
+		Currency       nulls.String  `db:"currency" json:"currency, omitempty" schema:"currency"`
+		ListingID      nulls.String  `db:"listing_id" json:"listingID, omitempty" schema:"listing-id"`
+		StageID        nulls.String  `db:"stage_id" json:"stageID, omitempty" schema:"stage-id"`
+		AssigneeID     nulls.String  `db:"assignee_id" json:"assigneeID, omitempty" schema:"assignee-id"`
+		StageChangedAt nulls.Time    `db:"stage_changed_at" json:"stageChangedAt, omitempty" schema:"-"`
+		OrganizationID nulls.String  `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// LeadActivity - LeadActivity model
+	LeadActivity struct {
+		IdentifiableModel
+		Kind           nulls.String `db:"kind" json:"kind, omitempty" schema:"kind"`
+		Body           nulls.String `db:"body" json:"body, omitempty" schema:"body"`
+		LeadID         nulls.String `db:"lead_id" json:"leadID, omitempty" schema:"lead-id"`
+		FromStageID    nulls.String `db:"from_stage_id" json:"fromStageID, omitempty" schema:"from-stage-id"`
+		ToStageID      nulls.String `db:"to_stage_id" json:"toStageID, omitempty" schema:"to-stage-id"`
+		UserID         nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		DueAt          nulls.Time   `db:"due_at" json:"dueAt, omitempty" schema:"due-at"`
+		DoneAt         nulls.Time   `db:"done_at" json:"doneAt, omitempty" schema:"done-at"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt      nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
+	// Document - Document model
+	Document struct {
+		IdentifiableModel
+		Kind           nulls.String `db:"kind" json:"kind, omitempty" schema:"kind"`
+		HolderType     nulls.String `db:"holder_type" json:"holderType, omitempty" schema:"holder-type"`
+		HolderID       nulls.String `db:"holder_id" json:"holderID, omitempty" schema:"holder-id"`
+		Version        nulls.Int64  `db:"version" json:"version, omitempty" schema:"-"`
+		FileName       nulls.String `db:"file_name" json:"fileName, omitempty" schema:"-"`
+		ContentType    nulls.String `db:"content_type" json:"contentType, omitempty" schema:"-"`
+		Size           nulls.Int64  `db:"size" json:"size, omitempty" schema:"-"`
+		Checksum       nulls.String `db:"checksum" json:"checksum, omitempty" schema:"-"`
+		RetainUntil    nulls.Time   `db:"retain_until" json:"retainUntil, omitempty" schema:"retain-until"`
+		LegalHold      nulls.Bool   `db:"legal_hold" json:"legalHold, omitempty" schema:"legal-hold"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		AuditableModel
+	}
+
+	// DocumentVersion - DocumentVersion model
+	DocumentVersion struct {
+		IdentifiableModel
+		DocumentID     nulls.String `db:"document_id" json:"documentID, omitempty" schema:"document-id"`
+		Version        nulls.Int64  `db:"version" json:"version, omitempty" schema:"version"`
+		FileName       nulls.String `db:"file_name" json:"fileName, omitempty" schema:"file-name"`
+		ContentType    nulls.String `db:"content_type" json:"contentType, omitempty" schema:"content-type"`
+		Size           nulls.Int64  `db:"size" json:"size, omitempty" schema:"size"`
+		Checksum       nulls.String `db:"checksum" json:"checksum, omitempty" schema:"checksum"`
+		StorageKey     nulls.String `db:"storage_key" json:"-" schema:"-"`
+		UserID         nulls.String `db:"user_id" json:"userID, omitempty" schema:"user-id"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		CreatedAt      nulls.Time   `db:"created_at" json:"createdAt, omitempty" schema:"-"`
+	}
+
 	// Album - Album model
 	Album struct {
-		ID          nulls.Int64  `db:"id" json:"id, omitempty"`
-		Name        nulls.String `db:"name" json:"name"`
-		Description nulls.String `db:"description" json:"description"`
+		IdentifiableModel
+		OwnerType      nulls.String `db:"owner_type" json:"ownerType, omitempty" schema:"owner-type"`
+		OwnerID        nulls.String `db:"owner_id" json:"ownerID, omitempty" schema:"owner-id"`
+		CoverImageID   nulls.String `db:"cover_image_id" json:"coverImageID, omitempty" schema:"cover-image-id"`
+		OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
 		AuditableModel
 	}
 
 	// Image - Image model
 	Image struct {
-		ID              nulls.Int64  `db:"id" json:"id, omitempty"`
-		Name            nulls.String `db:"name" json:"name"`
-		Description     nulls.String `db:"description" json:"description"`
-		Base64          nulls.String `db:"base-64" json:"base64" schema:"base-64"`
-		ThumbnailBase64 nulls.String `db:"thumbnail-base-64" json:"thumbnailBase64" schema:"thumbnail-base-64"`
-		Geolocation     `json:"geolocation"`
+		IdentifiableModel
+		Caption         nulls.String `db:"caption" json:"caption, omitempty" schema:"caption"`
+		Position        nulls.Int64  `db:"position" json:"position, omitempty" schema:"position"`
+		ContentType     nulls.String `db:"content_type" json:"contentType, omitempty" schema:"content-type"`
+		Size            nulls.Int64  `db:"size" json:"size, omitempty" schema:"size"`
+		Width           nulls.Int64  `db:"width" json:"width, omitempty" schema:"width"`
+		Height          nulls.Int64  `db:"height" json:"height, omitempty" schema:"height"`
+		Base64          nulls.String `db:"data" json:"base64, omitempty" schema:"base-64"`
+		MediumBase64    nulls.String `db:"medium_data" json:"mediumBase64, omitempty" schema:"medium-base-64"`
+		ThumbnailBase64 nulls.String `db:"thumbnail_data" json:"thumbnailBase64, omitempty" schema:"thumbnail-base-64"`
+		AlbumID         nulls.String `db:"album_id" json:"albumID, omitempty" schema:"album-id"`
+		OrganizationID  nulls.String `db:"organization_id" json:"organizationID, omitempty" schema:"organization-id"`
+		Geolocation     `db:"-" json:"geolocation"`
 		AuditableModel
 	}
 
@@ -24,14 +24,21 @@
 package models
 
 import (
-	"bytes"
 	"encoding/json"
-	"image"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/markbates/pop/nulls"
 )
 
+const (
+	// ProfileAvatarImage - Profile avatar image name.
+	ProfileAvatarImage = "avatar"
+	// ProfileHeaderImage - Profile header image name.
+	ProfileHeaderImage = "header"
+)
+
 // SetOwner - Set user.
 func (profile *Profile) SetOwner(user User) {
 	profile.UserID = user.ID
@@ -65,21 +72,20 @@ func (profile *Profile) GenAvatarBase64() {
 	}
 }
 
-// AvatarAsPNG - Get a PNG representation of avatar.
-func (profile *Profile) AvatarAsPNG() (png []byte, err error) {
-	imgBytes, err := base64Decode(profile.AvatarBase64)
-	if err != nil {
-		return png, err
-	}
-	img, _, err := image.Decode(bytes.NewReader(imgBytes))
-	if err != nil {
-		return png, err
-	}
-	png, err = pngEncode(img)
-	if err != nil {
-		return png, err
-	}
-	return png, nil
+// AvatarData - Returns avatar image kept in database before the blob store.
+func (profile *Profile) AvatarData() ([]byte, error) {
+	return base64Decode(profile.AvatarBase64)
+}
+
+// ProfileImageKey - Base blob store key of a profile image renditions,
+// a new key per upload lets clients cache images until their URL changes.
+func ProfileImageKey(profileID, name string) string {
+	return fmt.Sprintf("profiles/%s/%s_%d", profileID, name, time.Now().UnixNano()/int64(time.Millisecond))
+}
+
+// IsLegacyImageURI - Returns true for images stored as filesystem paths before the blob store.
+func IsLegacyImageURI(uri string) bool {
+	return strings.HasPrefix(uri, "/")
 }
 
 // MarshalJSON - Custom MarshalJSON function.
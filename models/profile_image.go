@@ -0,0 +1,285 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"path"
+)
+
+const (
+	// ProfileImageLarge - Large profile image rendition.
+	ProfileImageLarge = "large"
+	// ProfileImageMedium - Medium profile image rendition.
+	ProfileImageMedium = "medium"
+	// ProfileImageSmall - Small profile image rendition.
+	ProfileImageSmall = "small"
+	// ProfileImageMaxSize - Max uploaded avatar or header size in bytes.
+	ProfileImageMaxSize = 5 << 20
+	// ProfileImageMaxSide - Max uploaded avatar or header width and height.
+	ProfileImageMaxSide = 6000
+	// ProfileImageMinSide - Min uploaded avatar or header width and height.
+	ProfileImageMinSide = 32
+	// exifOrientationTag - EXIF tag holding how the image has to be turned to be shown upright.
+	exifOrientationTag = 0x0112
+)
+
+type (
+	// profileRendition - Name and dimensions of a generated profile image.
+	profileRendition struct {
+		name   string
+		width  int
+		height int
+	}
+)
+
+var (
+	// ErrImageTooSmall - Image is below min dimensions.
+	ErrImageTooSmall = errors.New("image is too small")
+	// Avatars are square
+	avatarRenditions = []profileRendition{
+		{ProfileImageLarge, 512, 512},
+		{ProfileImageMedium, 128, 128},
+		{ProfileImageSmall, 48, 48},
+	}
+	// Headers are 3:1 banners
+	headerRenditions = []profileRendition{
+		{ProfileImageLarge, 1500, 500},
+		{ProfileImageSmall, 600, 200},
+	}
+)
+
+// ProfileImageRenditions - Validates an uploaded avatar or header and returns its renditions, JPEG encoded, by name.
+// Images are turned upright following their EXIF orientation and center cropped, no metadata is kept.
+func ProfileImageRenditions(name string, data []byte) (map[string][]byte, error) {
+	if len(data) == 0 {
+		return nil, image.ErrFormat
+	}
+	if len(data) > ProfileImageMaxSize {
+		return nil, ErrImageTooBig
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "jpeg", "png", "gif":
+	default:
+		return nil, image.ErrFormat
+	}
+	if config.Width > ProfileImageMaxSide || config.Height > ProfileImageMaxSide {
+		return nil, ErrImageTooBig
+	}
+	if config.Width < ProfileImageMinSide || config.Height < ProfileImageMinSide {
+		return nil, ErrImageTooSmall
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format == "jpeg" {
+		src = orient(src, exifOrientation(data))
+	}
+	renditions := map[string][]byte{}
+	for _, rendition := range profileRenditions(name) {
+		region := crop(src.Bounds(), rendition.width, rendition.height)
+		encoded, err := jpegEncode(scale(src, region, rendition.width, rendition.height))
+		if err != nil {
+			return nil, err
+		}
+		renditions[rendition.name] = encoded
+	}
+	return renditions, nil
+}
+
+// ProfileImageRenditionNames - Returns the rendition names of an avatar or header.
+func ProfileImageRenditionNames(name string) []string {
+	names := []string{}
+	for _, rendition := range profileRenditions(name) {
+		names = append(names, rendition.name)
+	}
+	return names
+}
+
+// ProfileImageRenditionKey - Blob store key of a rendition of the profile image stored under base key.
+// Images stored before renditions were generated have a single key, with extension, for all of them.
+func ProfileImageRenditionKey(base, rendition string) string {
+	if path.Ext(base) != "" {
+		return base
+	}
+	return base + "/" + rendition + ".jpg"
+}
+
+// ProfileImageSide - Returns the side of an avatar rendition, large if unknown.
+func ProfileImageSide(rendition string) int {
+	for _, r := range avatarRenditions {
+		if r.name == rendition {
+			return r.width
+		}
+	}
+	return avatarRenditions[0].width
+}
+
+// Identicon - Returns a PNG avatar of side size with a symmetric pattern and color derived from seed.
+func Identicon(seed string, size int) ([]byte, error) {
+	sum := sha256.Sum256([]byte(seed))
+	fg := color.RGBA{60 + sum[0]%140, 60 + sum[1]%140, 60 + sum[2]%140, 0xff}
+	bg := color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	// 5x5 cells mirrored around the center column, half a cell of margin
+	cell := size * 2 / 11
+	offset := (size - 5*cell) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, bg)
+			col, row := (x-offset)/cell, (y-offset)/cell
+			if x < offset || y < offset || col > 4 || row > 4 {
+				continue
+			}
+			if col > 2 {
+				col = 4 - col
+			}
+			if sum[3+row*3+col]&1 == 1 {
+				img.Set(x, y, fg)
+			}
+		}
+	}
+	return pngEncode(img)
+}
+
+func profileRenditions(name string) []profileRendition {
+	if name == ProfileHeaderImage {
+		return headerRenditions
+	}
+	return avatarRenditions
+}
+
+// crop - Returns the centered region of bounds with the aspect ratio of width x height.
+func crop(bounds image.Rectangle, width, height int) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+	if w*height > h*width {
+		w = h * width / height
+	} else {
+		h = w * height / width
+	}
+	x := bounds.Min.X + (bounds.Dx()-w)/2
+	y := bounds.Min.Y + (bounds.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// exifOrientation - Returns the EXIF orientation of JPEG data, 1 (upright) if missing.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		// Start of scan, no more metadata
+		if marker == 0xda || length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		pos = pos + 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation - Returns the orientation tag of the first IFD of a TIFF header.
+func tiffOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orient - Flips and rotates src as EXIF orientation requires to show it upright.
+func orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return src
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			sx, sy := x, y
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
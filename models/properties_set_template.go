@@ -0,0 +1,245 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// PropertiesSetTemplateResourceTag - Tag of the organization resource whose permissions grant templates management.
+	PropertiesSetTemplateResourceTag = "properties-set-templates"
+)
+
+// Validate - Validates template and its property templates.
+func (template *PropertiesSetTemplate) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	if template.Name.String == "" {
+		errs["name"] = "is required"
+	}
+	names := make(map[string]bool)
+	for i, propertyTemplate := range template.PropertyTemplates {
+		prefix := fmt.Sprintf("propertyTemplates[%d].", i)
+		errs.Merge(prefix, propertyTemplate.Validate())
+		name := propertyTemplate.Name.String
+		if names[name] {
+			errs[prefix+"name"] = "is duplicated"
+		}
+		names[name] = true
+	}
+	return errs
+}
+
+// PropertyTemplate - Returns the property template declared with name.
+func (template *PropertiesSetTemplate) PropertyTemplate(name string) (PropertyTemplate, bool) {
+	for _, propertyTemplate := range template.PropertyTemplates {
+		if propertyTemplate.Name.String == name {
+			return propertyTemplate, true
+		}
+	}
+	return PropertyTemplate{}, false
+}
+
+// Validate - Validates property template declaration.
+func (propertyTemplate *PropertyTemplate) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	if propertyTemplate.Name.String == "" {
+		errs["name"] = "is required"
+	}
+	valueType := propertyTemplate.ValueType.String
+	if _, ok := PropertyValueFields[valueType]; !ok {
+		errs["valueType"] = fmt.Sprintf("unknown value type '%s'", valueType)
+	}
+	if propertyTemplate.MinValue.Valid && propertyTemplate.MaxValue.Valid &&
+		propertyTemplate.MinValue.Float64 > propertyTemplate.MaxValue.Float64 {
+		errs["minValue"] = "must not be greater than maxValue"
+	}
+	switch valueType {
+	case PropertyValueTypeString, PropertyValueTypeList:
+	case PropertyValueTypeEnum:
+		if len(propertyTemplate.AllowedValues) == 0 {
+			errs["allowedValues"] = "is required for enum values"
+		}
+	default:
+		if len(propertyTemplate.AllowedValues) > 0 {
+			errs["allowedValues"] = "only allowed for string, enum and list values"
+		}
+	}
+	return errs
+}
+
+// ValidateProperty - Validates a Property value against the template.
+func (propertyTemplate *PropertyTemplate) ValidateProperty(property *Property) ValidationErrors {
+	errs := ValidationErrors{}
+	valueType := propertyTemplate.ValueType.String
+	if property.ValueType.String != valueType {
+		errs["valueType"] = fmt.Sprintf("must be '%s'", valueType)
+		return errs
+	}
+	field := PropertyValueFields[valueType]
+	if !property.HasValue() {
+		if propertyTemplate.Required.Bool {
+			errs[field] = "is required"
+		}
+		return errs
+	}
+	errs.Merge("", property.Validate())
+	switch valueType {
+	case PropertyValueTypeEnum:
+		if !propertyTemplate.isAllowed(property.StringValue.String) {
+			errs[field] = fmt.Sprintf("must be one of: %s", strings.Join(propertyTemplate.AllowedValues, ", "))
+		}
+	case PropertyValueTypeMoney:
+		if msg := propertyTemplate.checkRange(property.MoneyValue.Float64, ""); msg != "" {
+			errs[field] = msg
+		}
+	case PropertyValueTypeList:
+		items := property.ListValue.Strings()
+		if len(propertyTemplate.AllowedValues) > 0 {
+			for _, item := range items {
+				if !propertyTemplate.isAllowed(item) {
+					errs[field] = fmt.Sprintf("items must be one of: %s", strings.Join(propertyTemplate.AllowedValues, ", "))
+					break
+				}
+			}
+		}
+		if _, ok := errs[field]; !ok {
+			if msg := propertyTemplate.checkRange(float64(len(property.ListValue)), " items"); msg != "" {
+				errs[field] = msg
+			}
+		}
+	case PropertyValueTypeString:
+		value := property.StringValue.String
+		if len(propertyTemplate.AllowedValues) > 0 && !propertyTemplate.isAllowed(value) {
+			errs[field] = fmt.Sprintf("must be one of: %s", strings.Join(propertyTemplate.AllowedValues, ", "))
+		} else if msg := propertyTemplate.checkRange(float64(len(value)), " characters long"); msg != "" {
+			errs[field] = msg
+		}
+	case PropertyValueTypeInt:
+		if msg := propertyTemplate.checkRange(float64(property.IntValue.Int64), ""); msg != "" {
+			errs[field] = msg
+		}
+	case PropertyValueTypeFloat:
+		if msg := propertyTemplate.checkRange(property.FloatValue.Float64, ""); msg != "" {
+			errs[field] = msg
+		}
+	}
+	return errs
+}
+
+func (propertyTemplate *PropertyTemplate) isAllowed(value string) bool {
+	for _, allowed := range propertyTemplate.AllowedValues {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (propertyTemplate *PropertyTemplate) checkRange(value float64, suffix string) string {
+	if propertyTemplate.MinValue.Valid && value < propertyTemplate.MinValue.Float64 {
+		return fmt.Sprintf("must be at least %v%s", propertyTemplate.MinValue.Float64, suffix)
+	}
+	if propertyTemplate.MaxValue.Valid && value > propertyTemplate.MaxValue.Float64 {
+		return fmt.Sprintf("must be at most %v%s", propertyTemplate.MaxValue.Float64, suffix)
+	}
+	return ""
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (template *PropertiesSetTemplate) MarshalJSON() ([]byte, error) {
+	type Alias PropertiesSetTemplate
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(template),
+		StartedAt: template.StartedAt.Time.Unix(),
+		CreatedAt: template.CreatedAt.Time.Unix(),
+		UpdatedAt: template.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (template *PropertiesSetTemplate) UnmarshalJSON(data []byte) error {
+	type Alias PropertiesSetTemplate
+	aux := &struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(template),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	template.StartedAt = nulls.Time{Time: time.Unix(aux.StartedAt, 0)}
+	template.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	template.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (propertyTemplate *PropertyTemplate) MarshalJSON() ([]byte, error) {
+	type Alias PropertyTemplate
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(propertyTemplate),
+		StartedAt: propertyTemplate.StartedAt.Time.Unix(),
+		CreatedAt: propertyTemplate.CreatedAt.Time.Unix(),
+		UpdatedAt: propertyTemplate.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (propertyTemplate *PropertyTemplate) UnmarshalJSON(data []byte) error {
+	type Alias PropertyTemplate
+	aux := &struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(propertyTemplate),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	propertyTemplate.StartedAt = nulls.Time{Time: time.Unix(aux.StartedAt, 0)}
+	propertyTemplate.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	propertyTemplate.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
@@ -25,11 +25,115 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/markbates/pop/nulls"
 )
 
+// Property value types.
+const (
+	PropertyValueTypeString       = "s"
+	PropertyValueTypeInt          = "i"
+	PropertyValueTypeFloat        = "f"
+	PropertyValueTypeBoolean      = "b"
+	PropertyValueTypeTimestamp    = "t"
+	PropertyValueTypeGeolocation  = "g"
+	PropertyValueTypePolygon      = "p"
+	PropertyValueTypeMultiPolygon = "m"
+	PropertyValueTypeLineString   = "l"
+	PropertyValueTypeEnum         = "e"
+	PropertyValueTypeMoney        = "c"
+	PropertyValueTypeDateRange    = "r"
+	PropertyValueTypeReference    = "x"
+	PropertyValueTypeList         = "a"
+)
+
+// PropertyValueFields - JSON field holding the value for each value type.
+var PropertyValueFields = map[string]string{
+	PropertyValueTypeString:       "stringValue",
+	PropertyValueTypeInt:          "intValue",
+	PropertyValueTypeFloat:        "floatValue",
+	PropertyValueTypeBoolean:      "booleanValue",
+	PropertyValueTypeTimestamp:    "timestampValue",
+	PropertyValueTypeGeolocation:  "geolocationValue",
+	PropertyValueTypePolygon:      "polygonValue",
+	PropertyValueTypeMultiPolygon: "multipolygonValue",
+	PropertyValueTypeLineString:   "linestringValue",
+	PropertyValueTypeEnum:         "stringValue",
+	PropertyValueTypeMoney:        "moneyValue",
+	PropertyValueTypeDateRange:    "dateRangeValue",
+	PropertyValueTypeReference:    "referenceID",
+	PropertyValueTypeList:         "listValue",
+}
+
+// HasValue - True if the value column matching the value type is set.
+func (property *Property) HasValue() bool {
+	switch property.ValueType.String {
+	case PropertyValueTypeString, PropertyValueTypeEnum:
+		return property.StringValue.Valid && property.StringValue.String != ""
+	case PropertyValueTypeInt:
+		return property.IntValue.Valid
+	case PropertyValueTypeFloat:
+		return property.FloatValue.Valid
+	case PropertyValueTypeBoolean:
+		return property.BooleanValue.Valid
+	case PropertyValueTypeTimestamp:
+		return property.TimestampValue.Valid
+	case PropertyValueTypeGeolocation:
+		return property.GeolocationValue.Valid
+	case PropertyValueTypePolygon:
+		return property.PolygonValue.Valid
+	case PropertyValueTypeMultiPolygon:
+		return property.MultiPolygonValue.Valid
+	case PropertyValueTypeLineString:
+		return property.LineStringValue.Valid
+	case PropertyValueTypeMoney:
+		return property.MoneyValue.Valid
+	case PropertyValueTypeDateRange:
+		return property.DateRangeValue.Valid
+	case PropertyValueTypeReference:
+		return property.ReferenceID.Valid && property.ReferenceID.String != ""
+	case PropertyValueTypeList:
+		return property.ListValue != nil
+	}
+	return false
+}
+
+// Validate - Checks value consistency for composite value types.
+func (property *Property) Validate() ValidationErrors {
+	errs := ValidationErrors{}
+	valueType := property.ValueType.String
+	if valueType == "" {
+		return errs
+	}
+	if _, ok := PropertyValueFields[valueType]; !ok {
+		errs["valueType"] = fmt.Sprintf("unknown value type '%s'", valueType)
+		return errs
+	}
+	if !property.HasValue() {
+		return errs
+	}
+	switch valueType {
+	case PropertyValueTypeMoney:
+		if len(property.CurrencyValue.String) != 3 {
+			errs["currencyValue"] = "must be a three letter currency code"
+		}
+	case PropertyValueTypeDateRange:
+		if !property.DateRangeValue.DateRange.IsValid() {
+			errs["dateRangeValue"] = "from must not be after to"
+		}
+	case PropertyValueTypeReference:
+		if property.ReferenceType.String == "" {
+			errs["referenceType"] = "is required"
+		}
+		if len(property.ReferenceID.String) != 36 {
+			errs["referenceID"] = "must be a valid id"
+		}
+	}
+	return errs
+}
+
 // MarshalJSON - Custom MarshalJSON function.
 func (property *Property) MarshalJSON() ([]byte, error) {
 	type Alias Property
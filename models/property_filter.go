@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import "github.com/markbates/pop/nulls"
+
+// PropertyFilter - Property value filter.
+// Min and Max are applied over numeric values (int, float and money).
+// Date matches date ranges containing it.
+// Contains matches lists including all of its items.
+type PropertyFilter struct {
+	Name          string
+	ValueType     string
+	Value         string
+	Min           nulls.Float64
+	Max           nulls.Float64
+	Currency      string
+	Date          nulls.Time
+	ReferenceType string
+	ReferenceID   string
+	Contains      []string
+}
+
+// IsEmpty - True if no value condition was set.
+func (f *PropertyFilter) IsEmpty() bool {
+	return f.Name == "" && f.ValueType == "" && f.Value == "" &&
+		!f.Min.Valid && !f.Max.Valid && f.Currency == "" && !f.Date.Valid &&
+		f.ReferenceType == "" && f.ReferenceID == "" && len(f.Contains) == 0
+}
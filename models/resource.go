@@ -30,6 +30,20 @@ import (
 	"github.com/markbates/pop/nulls"
 )
 
+const (
+	// OrganizationResourceTag - Tag of the organization resource whose permissions grant updating and deleting the organization.
+	OrganizationResourceTag = "organization"
+	// AccessControlResourceTag - Tag of the organization resource whose permissions grant managing resources, permissions and roles.
+	AccessControlResourceTag = "access-control"
+	// ListingResourceTag - Tag of the organization resource whose permissions grant listings management.
+	ListingResourceTag = "listings"
+	// BuildingResourceTag - Tag of the organization resource whose permissions grant buildings management.
+	BuildingResourceTag = "buildings"
+	// PermissionCacheResourceTag - Tag of the resource whose permissions grant reading the permission cache metrics.
+	// Not organization scoped: a grant in any organization is enough, tags are only provisioned by operators.
+	PermissionCacheResourceTag = "permission-cache"
+)
+
 // GenTag - Generates Resource's tag based on last8 digits of its ID.
 func (resource *Resource) GenTag() {
 	if len(resource.ID.String) == 36 {
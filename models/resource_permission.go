@@ -30,6 +30,44 @@ import (
 	"github.com/markbates/pop/nulls"
 )
 
+const (
+	// ActionRead - Read resource entities.
+	ActionRead = "read"
+	// ActionCreate - Create resource entities.
+	ActionCreate = "create"
+	// ActionUpdate - Update resource entities.
+	ActionUpdate = "update"
+	// ActionDelete - Delete resource entities.
+	ActionDelete = "delete"
+	// ActionManage - Any action over the resource.
+	ActionManage = "manage"
+)
+
+// Actions - Actions a resource permission can grant.
+var Actions = []string{ActionRead, ActionCreate, ActionUpdate, ActionDelete, ActionManage}
+
+// IsAction - True if name is an action a resource permission can grant.
+func IsAction(name string) bool {
+	for _, action := range Actions {
+		if action == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDefaults - Resource permissions grant any action if none is set.
+func (resourcePermission *ResourcePermission) SetDefaults() {
+	if resourcePermission.Action.String == "" {
+		resourcePermission.Action = ToNullsString(ActionManage)
+	}
+}
+
+// IsValid - Returns true if the granted action is known.
+func (resourcePermission *ResourcePermission) IsValid() bool {
+	return IsAction(resourcePermission.Action.String)
+}
+
 // MarshalJSON - Custom MarshalJSON function.
 func (resourcePermission *ResourcePermission) MarshalJSON() ([]byte, error) {
 	type Alias ResourcePermission
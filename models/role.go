@@ -71,3 +71,15 @@ func (role *Role) UnmarshalJSON(data []byte) error {
 	role.UpdatedAt = nulls.Time{Time: tu}
 	return nil
 }
+
+// MarshalJSON - Custom MarshalJSON function.
+func (parent RoleParent) MarshalJSON() ([]byte, error) {
+	type Alias RoleParent
+	return json.Marshal(&struct {
+		Alias
+		CreatedAt int64 `json:"createdAt"`
+	}{
+		Alias:     (Alias)(parent),
+		CreatedAt: parent.CreatedAt.Time.Unix(),
+	})
+}
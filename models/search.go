@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import "github.com/markbates/pop/nulls"
+
+// Search document types.
+const (
+	SearchTypeUser         = "user"
+	SearchTypeOrganization = "organization"
+	SearchTypeListing      = "listing"
+	SearchTypeResource     = "resource"
+)
+
+// SearchTypes - Searchable document types.
+var SearchTypes = []string{SearchTypeUser, SearchTypeOrganization, SearchTypeListing, SearchTypeResource}
+
+// SearchQuery - Full text search query.
+// Types limits results to some document types, all of them if empty.
+// OrganizationID limits results to documents of an Organization if set.
+// UserID is the user searching, results are limited to documents they can see.
+type SearchQuery struct {
+	Text           string
+	Types          []string
+	OrganizationID string
+	UserID         string
+	Limit          int
+	Offset         int
+}
+
+// SearchResult - Full text search match.
+// Snippet includes matching terms highlighted.
+type SearchResult struct {
+	ID             string       `db:"id" json:"id"`
+	Type           string       `db:"document_type" json:"type"`
+	OrganizationID nulls.String `db:"organization_id" json:"organizationID, omitempty"`
+	Title          nulls.String `db:"title" json:"title"`
+	Snippet        string       `db:"snippet" json:"snippet"`
+	Rank           float64      `db:"rank" json:"rank"`
+}
+
+// IsSearchType - True if name is a searchable document type.
+func IsSearchType(name string) bool {
+	for _, searchType := range SearchTypes {
+		if searchType == name {
+			return true
+		}
+	}
+	return false
+}
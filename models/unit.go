@@ -0,0 +1,113 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// UnitKindFlat - Dwelling unit.
+	UnitKindFlat = "flat"
+	// UnitKindGarage - Garage or parking space.
+	UnitKindGarage = "garage"
+	// UnitKindStorage - Storage room.
+	UnitKindStorage = "storage"
+	// UnitKindCommercial - Office or retail unit.
+	UnitKindCommercial = "commercial"
+	// UnitStatusVacant - Unit available.
+	UnitStatusVacant = "vacant"
+	// UnitStatusOccupied - Unit occupied by owner or tenant.
+	UnitStatusOccupied = "occupied"
+	// UnitStatusUnavailable - Unit not available (i.e.: under renovation).
+	UnitStatusUnavailable = "unavailable"
+)
+
+// SetDefaults - Default values for units before creation.
+func (unit *Unit) SetDefaults() {
+	if unit.Kind.String == "" {
+		unit.Kind = ToNullsString(UnitKindFlat)
+	}
+	if unit.Status.String == "" {
+		unit.Status = ToNullsString(UnitStatusVacant)
+	}
+}
+
+// IsValid - Returns true if kind, status, area and share hold valid values.
+// Share is the unit fraction of the building common property (0 to 1).
+func (unit *Unit) IsValid() bool {
+	switch unit.Kind.String {
+	case UnitKindFlat, UnitKindGarage, UnitKindStorage, UnitKindCommercial:
+	default:
+		return false
+	}
+	switch unit.Status.String {
+	case UnitStatusVacant, UnitStatusOccupied, UnitStatusUnavailable:
+	default:
+		return false
+	}
+	if unit.Area.Float64 < 0 {
+		return false
+	}
+	return unit.Share.Float64 >= 0 && unit.Share.Float64 <= 1
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (unit *Unit) MarshalJSON() ([]byte, error) {
+	type Alias Unit
+	return json.Marshal(&struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(unit),
+		StartedAt: unit.StartedAt.Time.Unix(),
+		CreatedAt: unit.CreatedAt.Time.Unix(),
+		UpdatedAt: unit.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (unit *Unit) UnmarshalJSON(data []byte) error {
+	type Alias Unit
+	aux := &struct {
+		*Alias
+		StartedAt int64 `json:"startedAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(unit),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	unit.StartedAt = nulls.Time{Time: time.Unix(aux.StartedAt, 0)}
+	unit.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	unit.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	// AppointmentResourceTag - Tag of the organization resource whose permissions grant viewings management.
+	AppointmentResourceTag = "appointments"
+	// ViewingStatusScheduled - Viewing booked by a prospect.
+	ViewingStatusScheduled = "scheduled"
+	// ViewingStatusCancelled - Viewing cancelled, its slot is free again.
+	ViewingStatusCancelled = "cancelled"
+	// ViewingSlotMinutes - Default viewing length.
+	ViewingSlotMinutes = 30
+	// ViewingMaxSlotMinutes - Max viewing length.
+	ViewingMaxSlotMinutes = 240
+	// ViewingSlotsDays - Default days ahead covered by free slots queries.
+	ViewingSlotsDays = 14
+)
+
+type (
+	// ViewingSlot - Free viewing slot of an agent.
+	ViewingSlot struct {
+		AgentID  string `json:"agentID"`
+		StartsAt int64  `json:"startsAt"`
+		EndsAt   int64  `json:"endsAt"`
+	}
+)
+
+// SetDefaults - Default values for availability windows before creation.
+func (window *AvailabilityWindow) SetDefaults() {
+	if window.SlotMinutes.Int64 == 0 {
+		window.SlotMinutes = ToNullsInt64(ViewingSlotMinutes)
+	}
+}
+
+// IsValid - Returns true if window has an agent and is long enough to hold at least a slot.
+func (window *AvailabilityWindow) IsValid() bool {
+	if window.AgentID.String == "" || !window.StartsAt.Valid || !window.EndsAt.Valid {
+		return false
+	}
+	if window.SlotMinutes.Int64 <= 0 || window.SlotMinutes.Int64 > ViewingMaxSlotMinutes {
+		return false
+	}
+	return !window.StartsAt.Time.Add(window.SlotDuration()).After(window.EndsAt.Time)
+}
+
+// SlotDuration - Length of the viewings booked in the window.
+func (window *AvailabilityWindow) SlotDuration() time.Duration {
+	return time.Duration(window.SlotMinutes.Int64) * time.Minute
+}
+
+// Fits - Returns true if a viewing starting at the time fits in the window.
+func (window *AvailabilityWindow) Fits(startsAt time.Time) bool {
+	if startsAt.Before(window.StartsAt.Time) {
+		return false
+	}
+	return !startsAt.Add(window.SlotDuration()).After(window.EndsAt.Time)
+}
+
+// Slots - Returns the free slots of the window within a period.
+// Slots overlapping scheduled viewings of the agent or of the listing are skipped.
+func (window *AvailabilityWindow) Slots(from, to time.Time, listingID string, booked []Viewing) []ViewingSlot {
+	slots := []ViewingSlot{}
+	duration := window.SlotDuration()
+	for start := window.StartsAt.Time; window.Fits(start); start = start.Add(duration) {
+		end := start.Add(duration)
+		if start.Before(from) || end.After(to) {
+			continue
+		}
+		free := true
+		for _, viewing := range booked {
+			if viewing.AgentID.String != window.AgentID.String && viewing.ListingID.String != listingID {
+				continue
+			}
+			if viewing.Overlaps(start, end) {
+				free = false
+				break
+			}
+		}
+		if free {
+			slots = append(slots, ViewingSlot{AgentID: window.AgentID.String, StartsAt: start.Unix(), EndsAt: end.Unix()})
+		}
+	}
+	return slots
+}
+
+// SetDefaults - Default values for viewings before booking.
+func (viewing *Viewing) SetDefaults() {
+	viewing.Status = ToNullsString(ViewingStatusScheduled)
+}
+
+// IsValid - Returns true if viewing has a listing, a start time and prospect contact data.
+func (viewing *Viewing) IsValid() bool {
+	if viewing.ListingID.String == "" || !viewing.StartsAt.Valid {
+		return false
+	}
+	if viewing.Name.String == "" || (viewing.ProspectEmail.String == "" && viewing.ProspectPhone.String == "") {
+		return false
+	}
+	return viewing.Status.String == ViewingStatusScheduled || viewing.Status.String == ViewingStatusCancelled
+}
+
+// Overlaps - Returns true if a scheduled viewing overlaps the period.
+func (viewing *Viewing) Overlaps(startsAt, endsAt time.Time) bool {
+	if viewing.Status.String == ViewingStatusCancelled {
+		return false
+	}
+	return viewing.StartsAt.Time.Before(endsAt) && startsAt.Before(viewing.EndsAt.Time)
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (window *AvailabilityWindow) MarshalJSON() ([]byte, error) {
+	type Alias AvailabilityWindow
+	return json.Marshal(&struct {
+		*Alias
+		StartsAt  int64 `json:"startsAt"`
+		EndsAt    int64 `json:"endsAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(window),
+		StartsAt:  window.StartsAt.Time.Unix(),
+		EndsAt:    window.EndsAt.Time.Unix(),
+		CreatedAt: window.CreatedAt.Time.Unix(),
+		UpdatedAt: window.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (window *AvailabilityWindow) UnmarshalJSON(data []byte) error {
+	type Alias AvailabilityWindow
+	aux := &struct {
+		*Alias
+		StartsAt  nulls.Int64 `json:"startsAt"`
+		EndsAt    nulls.Int64 `json:"endsAt"`
+		CreatedAt int64       `json:"createdAt"`
+		UpdatedAt int64       `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(window),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.StartsAt.Valid {
+		window.StartsAt = ToNullsTime(time.Unix(aux.StartsAt.Int64, 0))
+	}
+	if aux.EndsAt.Valid {
+		window.EndsAt = ToNullsTime(time.Unix(aux.EndsAt.Int64, 0))
+	}
+	window.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	window.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
+
+// MarshalJSON - Custom MarshalJSON function.
+func (viewing *Viewing) MarshalJSON() ([]byte, error) {
+	type Alias Viewing
+	return json.Marshal(&struct {
+		*Alias
+		StartsAt  int64 `json:"startsAt"`
+		EndsAt    int64 `json:"endsAt"`
+		CreatedAt int64 `json:"createdAt"`
+		UpdatedAt int64 `json:"updatedAt"`
+	}{
+		Alias:     (*Alias)(viewing),
+		StartsAt:  viewing.StartsAt.Time.Unix(),
+		EndsAt:    viewing.EndsAt.Time.Unix(),
+		CreatedAt: viewing.CreatedAt.Time.Unix(),
+		UpdatedAt: viewing.UpdatedAt.Time.Unix(),
+	})
+}
+
+// UnmarshalJSON - Custom UnmarshalJSON function.
+func (viewing *Viewing) UnmarshalJSON(data []byte) error {
+	type Alias Viewing
+	aux := &struct {
+		*Alias
+		StartsAt  nulls.Int64 `json:"startsAt"`
+		EndsAt    nulls.Int64 `json:"endsAt"`
+		CreatedAt int64       `json:"createdAt"`
+		UpdatedAt int64       `json:"updatedAt"`
+	}{
+		Alias: (*Alias)(viewing),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.StartsAt.Valid {
+		viewing.StartsAt = ToNullsTime(time.Unix(aux.StartsAt.Int64, 0))
+	}
+	if aux.EndsAt.Valid {
+		viewing.EndsAt = ToNullsTime(time.Unix(aux.EndsAt.Int64, 0))
+	}
+	viewing.CreatedAt = nulls.Time{Time: time.Unix(aux.CreatedAt, 0)}
+	viewing.UpdatedAt = nulls.Time{Time: time.Unix(aux.UpdatedAt, 0)}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"fmt"
+
+	"github.com/adrianpk/fundacja/cache"
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+const accessControlEntryInsertSQL = "INSERT INTO access_control_entries (id, name, description, entity_type, entity_id, user_id, role_id, action, organization_id, created_by, created_at) VALUES (:id, :name, :description, :entity_type, :entity_id, :user_id, :role_id, :action, :organization_id, :created_by, :created_at)"
+
+// AccessControlEntryRepository - AccessControlEntry repository manager.
+type AccessControlEntryRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeAccessControlEntryRepository - AccessControlEntryRepository constructor.
+func MakeAccessControlEntryRepository() (AccessControlEntryRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return AccessControlEntryRepository{}, err
+	}
+	return AccessControlEntryRepository{DB: db}, nil
+}
+
+// GetAll - GetAll AccessControlEntries from an Organization in repo.
+func (repo *AccessControlEntryRepository) GetAll(orgid string) ([]models.AccessControlEntry, error) {
+	entries := []models.AccessControlEntry{}
+	err := repo.DB.Select(&entries, "SELECT * FROM access_control_entries WHERE organization_id = $1 ORDER BY entity_type ASC, created_at ASC", orgid)
+	return entries, err
+}
+
+// GetByEntity - AccessControlEntries granting access to an entity of an Organization.
+func (repo *AccessControlEntryRepository) GetByEntity(orgid, entityType, entityID string) ([]models.AccessControlEntry, error) {
+	entries := []models.AccessControlEntry{}
+	err := repo.DB.Select(&entries, "SELECT * FROM access_control_entries WHERE organization_id = $1 AND entity_type = $2 AND entity_id = $3 ORDER BY created_at ASC", orgid, entityType, entityID)
+	return entries, err
+}
+
+// Create - Persists an AccessControlEntry in repo.
+// The entity and the grantee must belong to the organization of the entry.
+func (repo *AccessControlEntryRepository) Create(entry *models.AccessControlEntry) error {
+	if !models.IsEntityType(entry.EntityType.String) {
+		return models.ValidationErrors{"entityType": "unknown entity type"}
+	}
+	entry.SetID()
+	entry.CreatedAt = models.NullsNowTime()
+	tx := repo.DB.MustBegin()
+	// Entity in organization, entity types are whitelisted table names
+	var exists bool
+	entitySQL := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND organization_id = $2)", entry.EntityType.String)
+	err := tx.Get(&exists, entitySQL, entry.EntityID.String, entry.OrganizationID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !exists {
+		tx.Rollback()
+		return models.ValidationErrors{"entityID": "entity not found in organization"}
+	}
+	// Role in organization
+	if entry.RoleID.String != "" {
+		err = tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM roles WHERE id = $1 AND organization_id = $2)", entry.RoleID.String, entry.OrganizationID.String)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if !exists {
+			tx.Rollback()
+			return models.ValidationErrors{"roleID": "role not found in organization"}
+		}
+	}
+	_, err = tx.NamedExec(accessControlEntryInsertSQL, entry)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	cache.Decisions.Invalidate()
+	return err
+}
+
+// GetFromOrganization - Retrive an AccessControlEntry in repo by its ID and Organization ID.
+func (repo *AccessControlEntryRepository) GetFromOrganization(id, orgid string) (models.AccessControlEntry, error) {
+	entry := models.AccessControlEntry{}
+	err := repo.DB.Get(&entry, "SELECT * FROM access_control_entries WHERE id = $1 AND organization_id = $2", id, orgid)
+	return entry, err
+}
+
+// Delete - Deletes an AccessControlEntry from database.
+func (repo *AccessControlEntryRepository) Delete(id string) error {
+	_, err := repo.DB.Exec("DELETE FROM access_control_entries WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	cache.Decisions.Invalidate()
+	return nil
+}
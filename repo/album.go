@@ -0,0 +1,285 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+const (
+	albumInsertSQL = "INSERT INTO albums (id, name, description, owner_type, owner_id, cover_image_id, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :owner_type, :owner_id, :cover_image_id, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	imageInsertSQL = "INSERT INTO images (id, name, description, caption, position, content_type, size, width, height, data, medium_data, thumbnail_data, album_id, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :caption, :position, :content_type, :size, :width, :height, :data, :medium_data, :thumbnail_data, :album_id, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	// Image data is only selected when a rendition is requested
+	imageSelectSQL = "SELECT id, name, description, caption, position, content_type, size, width, height, album_id, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at FROM images"
+)
+
+// AlbumRepository - Album and Image repository manager.
+type AlbumRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeAlbumRepository - AlbumRepository constructor.
+func MakeAlbumRepository() (AlbumRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return AlbumRepository{}, err
+	}
+	return AlbumRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Albums from an Organization in repo.
+func (repo *AlbumRepository) GetAll(orgID string) ([]models.Album, error) {
+	albums := []models.Album{}
+	err := repo.DB.Select(&albums, "SELECT * FROM albums WHERE organization_id = $1 ORDER BY created_at ASC", orgID)
+	return albums, err
+}
+
+// GetAllByOwner - GetAll Albums attached to a listing, building or the Organization itself.
+func (repo *AlbumRepository) GetAllByOwner(orgID string, ownerType string, ownerID string) ([]models.Album, error) {
+	albums := []models.Album{}
+	err := repo.DB.Select(&albums, "SELECT * FROM albums WHERE organization_id = $1 AND owner_type = $2 AND owner_id = $3 ORDER BY created_at ASC", orgID, ownerType, ownerID)
+	return albums, err
+}
+
+// Get - Retrive an Album in repo by its ID.
+func (repo *AlbumRepository) Get(id string) (models.Album, error) {
+	album := models.Album{}
+	err := repo.DB.Get(&album, "SELECT * FROM albums WHERE id = $1", id)
+	if err != nil {
+		return album, err
+	}
+	return album, nil
+}
+
+// GetFromOrganization - Retrive an Album in repo by its ID and Organization ID.
+func (repo *AlbumRepository) GetFromOrganization(id string, orgID string) (models.Album, error) {
+	album := models.Album{}
+	err := repo.DB.Get(&album, "SELECT * FROM albums WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return album, err
+	}
+	return album, nil
+}
+
+// Create - Persists an Album in repo.
+func (repo *AlbumRepository) Create(album *models.Album) error {
+	album.SetID()
+	album.SetCreationValues()
+	// Cover is set uploading or selecting images
+	album.CoverImageID.Valid = false
+	_, err := repo.DB.NamedExec(albumInsertSQL, album)
+	return err
+}
+
+// Update - Update an Album in repo.
+func (repo *AlbumRepository) Update(album *models.Album) error {
+	// Update audit values
+	album.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(album.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := AlbumChanges(album, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE albums SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", album.ID.String))
+	//logger.Debug(query.String())
+	_, err = repo.DB.NamedExec(query.String(), album)
+	return err
+}
+
+// Delete - Deletes an Album and its Images from database.
+func (repo *AlbumRepository) Delete(id string) error {
+	_, err := repo.DB.Exec("DELETE FROM albums WHERE id = $1", id)
+	return err
+}
+
+// DeleteFromOrganization - Deletes Album from database if it belongs to the Organization.
+func (repo *AlbumRepository) DeleteFromOrganization(id string, orgID string) error {
+	_, err := repo.GetFromOrganization(id, orgID)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// SetCover - Sets an Image of the Album as its cover.
+func (repo *AlbumRepository) SetCover(id string, imageID string) error {
+	_, err := repo.GetImage(imageID, id)
+	if err != nil {
+		return err
+	}
+	_, err = repo.DB.Exec("UPDATE albums SET cover_image_id = $1, updated_at = NOW() WHERE id = $2", imageID, id)
+	return err
+}
+
+// GetImages - Retrieve the Images of an Album in order, without their data.
+func (repo *AlbumRepository) GetImages(id string) ([]models.Image, error) {
+	images := []models.Image{}
+	err := repo.DB.Select(&images, imageSelectSQL+" WHERE album_id = $1 ORDER BY position ASC", id)
+	return images, err
+}
+
+// GetImage - Retrive an Image of an Album without its data.
+func (repo *AlbumRepository) GetImage(imageID string, id string) (models.Image, error) {
+	image := models.Image{}
+	err := repo.DB.Get(&image, imageSelectSQL+" WHERE id = $1 AND album_id = $2", imageID, id)
+	return image, err
+}
+
+// GetImageData - Retrive an Image of an Album including its renditions data.
+func (repo *AlbumRepository) GetImageData(imageID string, id string) (models.Image, error) {
+	image := models.Image{}
+	err := repo.DB.Get(&image, "SELECT * FROM images WHERE id = $1 AND album_id = $2", imageID, id)
+	return image, err
+}
+
+// AddImage - Persists a processed Image at the end of its Album.
+// First image of an Album becomes its cover.
+func (repo *AlbumRepository) AddImage(image *models.Image) error {
+	tx := repo.DB.MustBegin()
+	// Concurrent uploads to the same album are serialized
+	album := models.Album{}
+	err := tx.Get(&album, "SELECT * FROM albums WHERE id = $1 FOR UPDATE", image.AlbumID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Get(&image.Position, "SELECT COALESCE(MAX(position), 0) + 1 FROM images WHERE album_id = $1", image.AlbumID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	image.SetID()
+	image.SetCreationValues()
+	_, err = tx.NamedExec(imageInsertSQL, image)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !album.CoverImageID.Valid {
+		_, err = tx.Exec("UPDATE albums SET cover_image_id = $1 WHERE id = $2", image.ID.String, album.ID.String)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateImage - Update name, description and caption of an Image.
+func (repo *AlbumRepository) UpdateImage(image *models.Image) error {
+	image.SetUpdateValues()
+	_, err := repo.DB.NamedExec("UPDATE images SET name = :name, description = :description, caption = :caption, updated_at = :updated_at WHERE id = :id AND album_id = :album_id", image)
+	return err
+}
+
+// DeleteImage - Deletes an Image from database, closing the gap in its Album order.
+// If the image was the cover the first remaining one takes its place.
+func (repo *AlbumRepository) DeleteImage(imageID string, id string) error {
+	image, err := repo.GetImage(imageID, id)
+	if err != nil {
+		return err
+	}
+	tx := repo.DB.MustBegin()
+	_, err = tx.Exec("DELETE FROM images WHERE id = $1", imageID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("UPDATE images SET position = position - 1 WHERE album_id = $1 AND position > $2", id, image.Position.Int64)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("UPDATE albums SET cover_image_id = (SELECT id FROM images WHERE album_id = $1 ORDER BY position ASC LIMIT 1) WHERE id = $1 AND cover_image_id IS NULL", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReorderImages - Sets the order of the Images of an Album.
+// Order must include every image of the album once.
+func (repo *AlbumRepository) ReorderImages(id string, imageIDs []string) error {
+	tx := repo.DB.MustBegin()
+	current := []string{}
+	err := tx.Select(&current, "SELECT id FROM images WHERE album_id = $1 FOR UPDATE", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !sameImages(current, imageIDs) {
+		tx.Rollback()
+		return models.ValidationErrors{"order": "order must include every album image once"}
+	}
+	for i, imageID := range imageIDs {
+		_, err = tx.Exec("UPDATE images SET position = $1 WHERE id = $2", i+1, imageID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func sameImages(current []string, ids []string) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	pending := make(map[string]bool)
+	for _, id := range current {
+		pending[id] = true
+	}
+	for _, id := range ids {
+		if !pending[id] {
+			return false
+		}
+		delete(pending, id)
+	}
+	return true
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	bankStatementInsertSQL   = "INSERT INTO bank_statements (id, name, description, format, account_number, currency, opening_balance, closing_balance, statement_date, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :format, :account_number, :currency, :opening_balance, :closing_balance, :statement_date, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	bankTransactionInsertSQL = "INSERT INTO bank_transactions (id, name, description, bank_statement_id, booked_at, amount, currency, reference, bank_reference, payer_name, payer_account, fingerprint, status, invoice_id, journal_entry_id, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :bank_statement_id, :booked_at, :amount, :currency, :reference, :bank_reference, :payer_name, :payer_account, :fingerprint, :status, :invoice_id, :journal_entry_id, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at) ON CONFLICT (organization_id, fingerprint) DO NOTHING"
+	bankTransactionUpdateSQL = "UPDATE bank_transactions SET status = :status, invoice_id = :invoice_id, journal_entry_id = :journal_entry_id, updated_at = :updated_at WHERE id = :id"
+	bankMatchInsertSQL       = "INSERT INTO bank_matches (id, name, description, bank_transaction_id, invoice_id, score, reasons, organization_id, created_at) VALUES (:id, :name, :description, :bank_transaction_id, :invoice_id, :score, :reasons, :organization_id, :created_at)"
+	tenantNamesSQL           = "SELECT lt.lease_id, COALESCE(u.first_name, '') AS first_name, COALESCE(u.last_name, '') AS last_name FROM lease_tenants lt INNER JOIN users u ON u.id = lt.user_id INNER JOIN leases l ON l.id = lt.lease_id WHERE l.organization_id = $1"
+)
+
+// BankStatementRepository - BankStatement repository manager.
+type BankStatementRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeBankStatementRepository - BankStatementRepository constructor.
+func MakeBankStatementRepository() (BankStatementRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return BankStatementRepository{}, err
+	}
+	return BankStatementRepository{DB: db}, nil
+}
+
+// GetAll - GetAll BankStatements from an Organization in repo, latest first.
+func (repo *BankStatementRepository) GetAll(orgID string) ([]models.BankStatement, error) {
+	statements := []models.BankStatement{}
+	err := repo.DB.Select(&statements, "SELECT * FROM bank_statements WHERE organization_id = $1 ORDER BY statement_date DESC NULLS LAST, created_at DESC", orgID)
+	return statements, err
+}
+
+// GetFromOrganization - Retrive a BankStatement and its transactions in repo by its ID and Organization ID.
+func (repo *BankStatementRepository) GetFromOrganization(id string, orgID string) (models.BankStatement, error) {
+	statement := models.BankStatement{}
+	err := repo.DB.Get(&statement, "SELECT * FROM bank_statements WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return statement, err
+	}
+	err = repo.DB.Select(&statement.Transactions, "SELECT * FROM bank_transactions WHERE bank_statement_id = $1 ORDER BY booked_at ASC, created_at ASC", id)
+	return statement, err
+}
+
+// GetTransactions - GetAll BankTransactions from an Organization in repo.
+func (repo *BankStatementRepository) GetTransactions(orgID string) ([]models.BankTransaction, error) {
+	transactions := []models.BankTransaction{}
+	err := repo.DB.Select(&transactions, "SELECT * FROM bank_transactions WHERE organization_id = $1 ORDER BY booked_at DESC, created_at DESC", orgID)
+	return transactions, err
+}
+
+// GetTransactionsByStatus - GetAll BankTransactions from an Organization in repo having a status, with their suggested matches.
+func (repo *BankStatementRepository) GetTransactionsByStatus(orgID string, status string) ([]models.BankTransaction, error) {
+	transactions := []models.BankTransaction{}
+	err := repo.DB.Select(&transactions, "SELECT * FROM bank_transactions WHERE organization_id = $1 AND status = $2 ORDER BY booked_at DESC, created_at DESC", orgID, status)
+	if err != nil {
+		return transactions, err
+	}
+	for i := range transactions {
+		transactions[i].Matches, err = repo.GetMatches(transactions[i].ID.String)
+		if err != nil {
+			return transactions, err
+		}
+	}
+	return transactions, nil
+}
+
+// GetTransaction - Retrive a BankTransaction and its suggested matches in repo by its ID and Organization ID.
+func (repo *BankStatementRepository) GetTransaction(id string, orgID string) (models.BankTransaction, error) {
+	transaction := models.BankTransaction{}
+	err := repo.DB.Get(&transaction, "SELECT * FROM bank_transactions WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.Matches, err = repo.GetMatches(id)
+	return transaction, err
+}
+
+// GetMatches - Retrieve the suggested matches of a BankTransaction, best first.
+func (repo *BankStatementRepository) GetMatches(transactionID string) ([]models.BankMatch, error) {
+	matches := []models.BankMatch{}
+	err := repo.DB.Select(&matches, "SELECT * FROM bank_matches WHERE bank_transaction_id = $1 ORDER BY score DESC", transactionID)
+	return matches, err
+}
+
+// Import - Persists a parsed statement and reconciles its transactions with open invoices.
+// Transactions already imported from another statement are skipped.
+func (repo *BankStatementRepository) Import(statement *models.BankStatement, createdBy nulls.String) error {
+	tx := repo.DB.MustBegin()
+	statement.SetID()
+	statement.SetCreationValues()
+	statement.CreatedBy = createdBy
+	_, err := tx.NamedExec(bankStatementInsertSQL, statement)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	imported := []models.BankTransaction{}
+	for _, transaction := range statement.Transactions {
+		transaction.SetID()
+		transaction.SetCreationValues()
+		transaction.CreatedBy = createdBy
+		transaction.BankStatementID = statement.ID
+		transaction.OrganizationID = statement.OrganizationID
+		res, err := tx.NamedExec(bankTransactionInsertSQL, &transaction)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if inserted > 0 {
+			imported = append(imported, transaction)
+		}
+	}
+	err = reconcileTransactions(tx, imported, statement.OrganizationID.String, createdBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	statement.Transactions = imported
+	return tx.Commit()
+}
+
+// Match - Posts a transaction as a payment of an invoice chosen on review.
+func (repo *BankStatementRepository) Match(id string, orgID string, invoiceID string, createdBy nulls.String) (models.BankTransaction, error) {
+	tx := repo.DB.MustBegin()
+	transaction := models.BankTransaction{}
+	err := tx.Get(&transaction, "SELECT * FROM bank_transactions WHERE id = $1 AND organization_id = $2 FOR UPDATE", id, orgID)
+	if err != nil {
+		tx.Rollback()
+		return transaction, err
+	}
+	if transaction.IsReconciled() {
+		tx.Rollback()
+		return transaction, models.ValidationErrors{"status": "transaction is already reconciled"}
+	}
+	invoice := models.Invoice{}
+	err = tx.Get(&invoice, "SELECT * FROM invoices WHERE id = $1 AND organization_id = $2 FOR UPDATE", invoiceID, orgID)
+	if err != nil {
+		tx.Rollback()
+		return transaction, err
+	}
+	if invoice.Currency.String != transaction.Currency.String {
+		tx.Rollback()
+		return transaction, models.ValidationErrors{"currency": "does not match invoice currency"}
+	}
+	err = postBankPayment(tx, &transaction, &invoice, createdBy)
+	if err != nil {
+		tx.Rollback()
+		return transaction, err
+	}
+	err = tx.Commit()
+	return transaction, err
+}
+
+// Ignore - Dismisses a transaction not related to any invoice.
+func (repo *BankStatementRepository) Ignore(id string, orgID string) error {
+	tx := repo.DB.MustBegin()
+	transaction := models.BankTransaction{}
+	err := tx.Get(&transaction, "SELECT * FROM bank_transactions WHERE id = $1 AND organization_id = $2 FOR UPDATE", id, orgID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if transaction.Status.String == models.BankTransactionStatusMatched {
+		tx.Rollback()
+		return models.ValidationErrors{"status": "transaction is already posted"}
+	}
+	transaction.Status = models.ToNullsString(models.BankTransactionStatusIgnored)
+	transaction.SetUpdateValues()
+	_, err = tx.NamedExec(bankTransactionUpdateSQL, &transaction)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// reconcileTransactions - Posts unambiguous matches and stores suggestions for the rest.
+func reconcileTransactions(tx *sqlx.Tx, transactions []models.BankTransaction, orgID string, createdBy nulls.String) error {
+	invoices := []models.Invoice{}
+	err := tx.Select(&invoices, "SELECT * FROM invoices WHERE organization_id = $1 AND status IN ($2, $3) ORDER BY due_date ASC FOR UPDATE", orgID, models.InvoiceStatusOpen, models.InvoiceStatusPartiallyPaid)
+	if err != nil {
+		return err
+	}
+	tenants := []models.TenantName{}
+	err = tx.Select(&tenants, tenantNamesSQL, orgID)
+	if err != nil {
+		return err
+	}
+	for i := range transactions {
+		transaction := &transactions[i]
+		if transaction.Status.String != models.BankTransactionStatusUnmatched {
+			continue
+		}
+		matches := transaction.Candidates(invoices, tenants)
+		if match, ok := models.AutoMatch(matches); ok {
+			for j := range invoices {
+				if invoices[j].ID.String == match.InvoiceID.String {
+					err = postBankPayment(tx, transaction, &invoices[j], createdBy)
+					break
+				}
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		for j := range matches {
+			matches[j].SetID()
+			matches[j].CreatedAt = transaction.CreatedAt
+			_, err = tx.NamedExec(bankMatchInsertSQL, &matches[j])
+			if err != nil {
+				return err
+			}
+		}
+		transaction.Matches = matches
+		transaction.Status = models.ToNullsString(models.BankTransactionStatusSuggested)
+		_, err = tx.NamedExec(bankTransactionUpdateSQL, transaction)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postBankPayment - Posts a transaction as a payment of a locked invoice and marks it matched.
+// Invoice balance and status are updated so that later transactions see the payment.
+func postBankPayment(tx *sqlx.Tx, transaction *models.BankTransaction, invoice *models.Invoice, createdBy nulls.String) error {
+	payment := transaction.Payment()
+	entry, err := payInvoice(tx, invoice, payment, createdBy)
+	if err != nil {
+		return err
+	}
+	invoice.Balance = models.ToNullsFoat64(models.RoundAmount(invoice.Balance.Float64 - payment.Amount))
+	invoice.UpdateStatus()
+	transaction.Status = models.ToNullsString(models.BankTransactionStatusMatched)
+	transaction.InvoiceID = invoice.ID
+	transaction.JournalEntryID = entry.ID
+	transaction.SetUpdateValues()
+	_, err = tx.NamedExec(bankTransactionUpdateSQL, transaction)
+	return err
+}
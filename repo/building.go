@@ -0,0 +1,190 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+// BuildingRepository - Building repository manager.
+type BuildingRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeBuildingRepository - BuildingRepository constructor.
+func MakeBuildingRepository() (BuildingRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return BuildingRepository{}, err
+	}
+	return BuildingRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Buildings from an Organization in repo.
+func (repo *BuildingRepository) GetAll(orgid string) ([]models.Building, error) {
+	buildings := []models.Building{}
+	err := repo.DB.Select(&buildings, "SELECT * FROM buildings WHERE organization_id = $1 ORDER BY name ASC", orgid)
+	return buildings, err
+}
+
+// Create - Persists a Building in repo.
+func (repo *BuildingRepository) Create(building *models.Building) error {
+	building.SetID()
+	building.SetCreationValues()
+	tx := repo.DB.MustBegin()
+	buildingInsertSQL := "INSERT INTO buildings (id, name, description, address, floors, organization_id, annotations, geolocation, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :address, :floors, :organization_id, :annotations, :geolocation, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	_, err := tx.NamedExec(buildingInsertSQL, building)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get - Retrive a Building in repo by its ID.
+func (repo *BuildingRepository) Get(id string) (models.Building, error) {
+	building := models.Building{}
+	err := repo.DB.Get(&building, "SELECT * FROM buildings WHERE id = $1", id)
+	if err != nil {
+		return building, err
+	}
+	return building, nil
+}
+
+// GetFromOrganization - Retrive a Building in repo by its ID and Organization ID.
+func (repo *BuildingRepository) GetFromOrganization(id string, orgid string) (models.Building, error) {
+	building := models.Building{}
+	err := repo.DB.Get(&building, "SELECT * FROM buildings WHERE id = $1 AND organization_id = $2", id, orgid)
+	if err != nil {
+		return building, err
+	}
+	return building, nil
+}
+
+// GetByNameInOrganization - Retrive a Building in repo by its name and Organization ID.
+func (repo *BuildingRepository) GetByNameInOrganization(name string, orgid string) (models.Building, error) {
+	building := models.Building{}
+	err := repo.DB.Get(&building, "SELECT * FROM buildings WHERE name = $1 AND organization_id = $2", name, orgid)
+	if err != nil {
+		return building, err
+	}
+	return building, nil
+}
+
+// GetPropertiesSets - Retrieve the PropertiesSets holding the attributes of a Building.
+func (repo *BuildingRepository) GetPropertiesSets(id string) ([]models.PropertiesSet, error) {
+	propertiesSets := []models.PropertiesSet{}
+	err := repo.DB.Select(&propertiesSets, "SELECT * FROM properties_sets WHERE holder_id = $1 ORDER BY position ASC, name ASC", id)
+	return propertiesSets, err
+}
+
+// GetOccupancy - Aggregate units occupancy of a Building.
+// Occupancy rate is the ratio of occupied units to all units.
+func (repo *BuildingRepository) GetOccupancy(id string) (models.BuildingOccupancy, error) {
+	occupancy := models.BuildingOccupancy{}
+	err := repo.DB.Get(&occupancy, `SELECT b.id AS building_id,
+	 COUNT(u.id) AS units,
+	 COUNT(u.id) FILTER (WHERE u.status = $2) AS occupied,
+	 COUNT(u.id) FILTER (WHERE u.status = $3) AS vacant,
+	 COALESCE(SUM(u.area), 0) AS area,
+	 COALESCE(SUM(u.area) FILTER (WHERE u.status = $2), 0) AS occupied_area,
+	 COALESCE(SUM(u.share), 0) AS shares,
+	 COALESCE(COUNT(u.id) FILTER (WHERE u.status = $2)::float / NULLIF(COUNT(u.id), 0), 0) AS occupancy_rate
+	 FROM buildings b LEFT JOIN units u ON u.building_id = b.id
+	 WHERE b.id = $1
+	 GROUP BY b.id`, id, models.UnitStatusOccupied, models.UnitStatusVacant)
+	return occupancy, err
+}
+
+// Update - Update a building in repo.
+func (repo *BuildingRepository) Update(building *models.Building) error {
+	// Update audit values
+	building.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(building.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := BuildingChanges(building, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE buildings SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", building.ID.String))
+	//logger.Debug(query.String())
+	tx := repo.DB.MustBegin()
+	_, err = tx.NamedExec(query.String(), building)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
+
+// Delete - Deletes building, its units and their properties sets from database.
+func (repo *BuildingRepository) Delete(id string) error {
+	tx := repo.DB.MustBegin()
+	holders := "holder_id = $1 OR holder_id IN (SELECT id FROM units WHERE building_id = $1)"
+	tx.MustExec(fmt.Sprintf("DELETE FROM properties WHERE properties_set_id IN (SELECT id FROM properties_sets WHERE %s)", holders), id)
+	tx.MustExec(fmt.Sprintf("DELETE FROM properties_sets WHERE %s", holders), id)
+	tx.MustExec("DELETE FROM units WHERE building_id = $1", id)
+	tx.MustExec("DELETE FROM albums WHERE owner_type = 'building' AND owner_id = $1", id)
+	tx.MustExec("DELETE FROM buildings WHERE id = $1", id)
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteFromOrganization - Deletes building from database if it belongs to the Organization.
+func (repo *BuildingRepository) DeleteFromOrganization(id string, orgid string) error {
+	_, err := repo.GetFromOrganization(id, orgid)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/models"
+)
+
+// AlbumChanges - Creates a map ([string]interface{}) including al changing field.
+func AlbumChanges(album *models.Album, reference models.Album) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != album.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != album.Description.String {
+		changes["description"] = ":description"
+	}
+	if reference.IsActive.Bool != album.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != album.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != album.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
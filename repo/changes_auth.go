@@ -105,15 +105,7 @@ func ProfileChanges(profile *models.Profile, reference models.Profile) map[strin
 			changes["anniversary_date"] = ":anniversary_date"
 		}
 	}
-	if len(profile.Avatar.ByteSlice) > 0 && !reflect.DeepEqual(reference.Avatar.ByteSlice, profile.Avatar.ByteSlice) {
-		changes["avatar"] = ":avatar"
-	}
-	if reference.AvatarURI.String != profile.AvatarURI.String {
-		changes["avatar_uri"] = ":avatar_uri"
-	}
-	if reference.HeaderURI.String != profile.HeaderURI.String {
-		changes["header_uri"] = ":header_uri"
-	}
+	// Avatar and header are only changed through SaveProfileAvatar and SaveProfileHeader
 	if !reflect.DeepEqual(reference.Annotations, profile.Annotations) {
 		if isJSON(profile.Annotations.String()) {
 			changes["annotations"] = ":annotations"
@@ -174,8 +166,10 @@ func OrganizationChanges(organization *models.Organization, reference models.Org
 			changes["updated_at"] = ":updated_at"
 		}
 	}
-	if reference.UserID != organization.UserID {
-		changes["user_id"] = ":user_id"
+	if reference.SearchLanguage.String != organization.SearchLanguage.String {
+		if organization.SearchLanguage.String != "" {
+			changes["search_language"] = ":search_language"
+		}
 	}
 	return changes
 }
@@ -287,6 +281,9 @@ func ResourcePermissionChanges(resourcePermission *models.ResourcePermission, re
 	if resourcePermission.PermissionID.String != "" && reference.PermissionID != resourcePermission.PermissionID {
 		changes["permission_id"] = ":permission_id"
 	}
+	if resourcePermission.Action.String != "" && reference.Action != resourcePermission.Action {
+		changes["action"] = ":action"
+	}
 	return changes
 }
 
@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"reflect"
+
+	"github.com/adrianpk/fundacja/models"
+)
+
+// BuildingChanges - Creates a map ([string]interface{}) including al changing field.
+func BuildingChanges(building *models.Building, reference models.Building) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != building.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != building.Description.String {
+		changes["description"] = ":description"
+	}
+	if reference.Address.String != building.Address.String {
+		changes["address"] = ":address"
+	}
+	if reference.Floors.Int64 != building.Floors.Int64 {
+		changes["floors"] = ":floors"
+	}
+	if !reflect.DeepEqual(reference.Annotations, building.Annotations) {
+		if isJSON(building.Annotations.String()) {
+			changes["annotations"] = ":annotations"
+		}
+	}
+	if reference.Geolocation.Point.String() != building.Geolocation.Point.String() {
+		changes["geolocation"] = ":geolocation"
+	}
+	if reference.IsActive.Bool != building.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != building.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != building.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
+
+// UnitChanges - Creates a map ([string]interface{}) including al changing field.
+func UnitChanges(unit *models.Unit, reference models.Unit) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != unit.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != unit.Description.String {
+		changes["description"] = ":description"
+	}
+	if unit.Kind.String != "" && reference.Kind.String != unit.Kind.String {
+		changes["kind"] = ":kind"
+	}
+	if reference.Floor != unit.Floor {
+		changes["floor"] = ":floor"
+	}
+	if reference.Area != unit.Area {
+		changes["area"] = ":area"
+	}
+	if reference.Share != unit.Share {
+		changes["share"] = ":share"
+	}
+	if unit.Status.String != "" && reference.Status.String != unit.Status.String {
+		changes["status"] = ":status"
+	}
+	if !reflect.DeepEqual(reference.Annotations, unit.Annotations) {
+		if isJSON(unit.Annotations.String()) {
+			changes["annotations"] = ":annotations"
+		}
+	}
+	if reference.IsActive.Bool != unit.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != unit.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != unit.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
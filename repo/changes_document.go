@@ -0,0 +1,57 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/models"
+)
+
+// DocumentChanges - Creates a map ([string]interface{}) including al changing field.
+func DocumentChanges(document *models.Document, reference models.Document) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != document.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != document.Description.String {
+		changes["description"] = ":description"
+	}
+	if document.Kind.String != "" && reference.Kind.String != document.Kind.String {
+		changes["kind"] = ":kind"
+	}
+	if document.RetainUntil.Valid && !reference.RetainUntil.Time.Equal(document.RetainUntil.Time) {
+		changes["retain_until"] = ":retain_until"
+	}
+	if document.LegalHold.Valid && reference.LegalHold.Bool != document.LegalHold.Bool {
+		changes["legal_hold"] = ":legal_hold"
+	}
+	if reference.IsActive.Bool != document.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.UpdatedAt.Time != document.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/models"
+)
+
+// LeadStageChanges - Creates a map ([string]interface{}) including al changing field.
+func LeadStageChanges(stage *models.LeadStage, reference models.LeadStage) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != stage.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != stage.Description.String {
+		changes["description"] = ":description"
+	}
+	if stage.Position.Int64 != 0 && reference.Position.Int64 != stage.Position.Int64 {
+		changes["position"] = ":position"
+	}
+	if stage.Outcome.String != "" && reference.Outcome.String != stage.Outcome.String {
+		changes["outcome"] = ":outcome"
+	}
+	if reference.IsActive.Bool != stage.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.UpdatedAt.Time != stage.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
+
+// LeadChanges - Creates a map ([string]interface{}) including al changing field.
+func LeadChanges(lead *models.Lead, reference models.Lead) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != lead.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != lead.Description.String {
+		changes["description"] = ":description"
+	}
+	if reference.Email.String != lead.Email.String {
+		changes["email"] = ":email"
+	}
+	if reference.Phone.String != lead.Phone.String {
+		changes["phone"] = ":phone"
+	}
+	if lead.Source.String != "" && reference.Source.String != lead.Source.String {
+		changes["source"] = ":source"
+	}
+	if reference.Value.Float64 != lead.Value.Float64 {
+		changes["value"] = ":value"
+	}
+	if reference.Currency.String != lead.Currency.String {
+		changes["currency"] = ":currency"
+	}
+	if reference.ListingID.String != lead.ListingID.String {
+		changes["listing_id"] = ":listing_id"
+	}
+	if reference.AssigneeID.String != lead.AssigneeID.String {
+		changes["assignee_id"] = ":assignee_id"
+	}
+	if reference.IsActive.Bool != lead.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != lead.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != lead.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"reflect"
+
+	"github.com/adrianpk/fundacja/models"
+)
+
+// LeaseChanges - Creates a map ([string]interface{}) including al changing field.
+func LeaseChanges(lease *models.Lease, reference models.Lease) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != lease.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != lease.Description.String {
+		changes["description"] = ":description"
+	}
+	if lease.StartedAt.Valid && !reference.StartedAt.Time.Equal(lease.StartedAt.Time) {
+		changes["started_at"] = ":started_at"
+	}
+	if lease.EndsAt.Valid && !reference.EndsAt.Time.Equal(lease.EndsAt.Time) {
+		changes["ends_at"] = ":ends_at"
+	}
+	if reference.RentAmount != lease.RentAmount {
+		changes["rent_amount"] = ":rent_amount"
+	}
+	if reference.Deposit != lease.Deposit {
+		changes["deposit"] = ":deposit"
+	}
+	if reference.Currency.String != lease.Currency.String {
+		changes["currency"] = ":currency"
+	}
+	if reference.PaymentDay != lease.PaymentDay {
+		changes["payment_day"] = ":payment_day"
+	}
+	if reference.IndexationRate != lease.IndexationRate {
+		changes["indexation_rate"] = ":indexation_rate"
+	}
+	if reference.IndexationPeriod != lease.IndexationPeriod {
+		changes["indexation_period"] = ":indexation_period"
+	}
+	if reference.LateFeeAmount != lease.LateFeeAmount {
+		changes["late_fee_amount"] = ":late_fee_amount"
+	}
+	if reference.LateFeeRate != lease.LateFeeRate {
+		changes["late_fee_rate"] = ":late_fee_rate"
+	}
+	if reference.GraceDays != lease.GraceDays {
+		changes["grace_days"] = ":grace_days"
+	}
+	if lease.Status.String != "" && reference.Status.String != lease.Status.String {
+		changes["status"] = ":status"
+	}
+	if !reflect.DeepEqual(reference.Annotations, lease.Annotations) {
+		if isJSON(lease.Annotations.String()) {
+			changes["annotations"] = ":annotations"
+		}
+	}
+	if reference.IsActive.Bool != lease.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != lease.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != lease.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/models"
+)
+
+// MaintenanceRequestChanges - Creates a map ([string]interface{}) including al changing field.
+func MaintenanceRequestChanges(request *models.MaintenanceRequest, reference models.MaintenanceRequest) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != request.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != request.Description.String {
+		changes["description"] = ":description"
+	}
+	if request.Priority.String != "" && reference.Priority.String != request.Priority.String {
+		changes["priority"] = ":priority"
+	}
+	if request.Status.String != "" && reference.Status.String != request.Status.String {
+		changes["status"] = ":status"
+	}
+	if reference.IsActive.Bool != request.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != request.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != request.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
+
+// WorkOrderChanges - Creates a map ([string]interface{}) including al changing field.
+func WorkOrderChanges(order *models.WorkOrder, reference models.WorkOrder) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != order.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != order.Description.String {
+		changes["description"] = ":description"
+	}
+	if order.Status.String != "" && reference.Status.String != order.Status.String {
+		changes["status"] = ":status"
+	}
+	if reference.AssigneeUserID.String != order.AssigneeUserID.String {
+		changes["assignee_user_id"] = ":assignee_user_id"
+	}
+	if reference.AssigneeOrganizationID.String != order.AssigneeOrganizationID.String {
+		changes["assignee_organization_id"] = ":assignee_organization_id"
+	}
+	if order.ScheduledAt.Valid && !reference.ScheduledAt.Time.Equal(order.ScheduledAt.Time) {
+		changes["scheduled_at"] = ":scheduled_at"
+	}
+	if order.CompletedAt.Valid && !reference.CompletedAt.Time.Equal(order.CompletedAt.Time) {
+		changes["completed_at"] = ":completed_at"
+	}
+	if reference.EstimatedCost != order.EstimatedCost {
+		changes["estimated_cost"] = ":estimated_cost"
+	}
+	if reference.ActualCost != order.ActualCost {
+		changes["actual_cost"] = ":actual_cost"
+	}
+	if reference.Currency.String != order.Currency.String {
+		changes["currency"] = ":currency"
+	}
+	if reference.IsActive.Bool != order.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != order.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != order.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
@@ -23,7 +23,11 @@
 
 package repo
 
-import "github.com/adrianpk/fundacja/models"
+import (
+	"reflect"
+
+	"github.com/adrianpk/fundacja/models"
+)
 
 // PropertiesSetChanges - Creates a map ([string]interface{}) including al changing field.
 func PropertiesSetChanges(propertiesSet *models.PropertiesSet, reference models.PropertiesSet) map[string]string {
@@ -40,6 +44,9 @@ func PropertiesSetChanges(propertiesSet *models.PropertiesSet, reference models.
 	if reference.HolderID.String != propertiesSet.HolderID.String {
 		changes["holder_id"] = ":holder_id"
 	}
+	if reference.TemplateID.String != propertiesSet.TemplateID.String {
+		changes["template_id"] = ":template_id"
+	}
 	if reference.IsActive.Bool != propertiesSet.IsActive.Bool {
 		changes["is_active"] = ":is_active"
 	}
@@ -78,6 +85,37 @@ func PropertyChanges(property *models.Property, reference models.Property) map[s
 	if reference.TimestampValue.Time != property.TimestampValue.Time {
 		changes["timestamp_value"] = ":timestamp_value"
 	}
+	if reference.GeolocationValue.Point.String() != property.GeolocationValue.Point.String() {
+		changes["geolocation_value"] = ":geolocation_value"
+	}
+	if reference.PolygonValue.Polygon.String() != property.PolygonValue.Polygon.String() {
+		changes["polygon_value"] = ":polygon_value"
+	}
+	if reference.MultiPolygonValue.MultiPolygon.String() != property.MultiPolygonValue.MultiPolygon.String() {
+		changes["multipolygon_value"] = ":multipolygon_value"
+	}
+	if reference.LineStringValue.LineString.String() != property.LineStringValue.LineString.String() {
+		changes["linestring_value"] = ":linestring_value"
+	}
+	if reference.MoneyValue != property.MoneyValue {
+		changes["money_value"] = ":money_value"
+	}
+	if reference.CurrencyValue.String != property.CurrencyValue.String {
+		changes["currency_value"] = ":currency_value"
+	}
+	if reference.DateRangeValue.DateRange.String() != property.DateRangeValue.DateRange.String() ||
+		reference.DateRangeValue.Valid != property.DateRangeValue.Valid {
+		changes["date_range_value"] = ":date_range_value"
+	}
+	if reference.ReferenceType.String != property.ReferenceType.String {
+		changes["reference_type"] = ":reference_type"
+	}
+	if reference.ReferenceID.String != property.ReferenceID.String {
+		changes["reference_id"] = ":reference_id"
+	}
+	if !reflect.DeepEqual(reference.ListValue, property.ListValue) {
+		changes["list_value"] = ":list_value"
+	}
 	if reference.ValueType.String != property.ValueType.String {
 		changes["value_type"] = ":value_type"
 	}
@@ -94,3 +132,26 @@ func PropertyChanges(property *models.Property, reference models.Property) map[s
 	}
 	return changes
 }
+
+// PropertiesSetTemplateChanges - Creates a map ([string]interface{}) including al changing field.
+func PropertiesSetTemplateChanges(template *models.PropertiesSetTemplate, reference models.PropertiesSetTemplate) map[string]string {
+	changes := make(map[string]string)
+	if reference.Name.String != template.Name.String {
+		changes["name"] = ":name"
+	}
+	if reference.Description.String != template.Description.String {
+		changes["description"] = ":description"
+	}
+	if reference.IsActive.Bool != template.IsActive.Bool {
+		changes["is_active"] = ":is_active"
+	}
+	if reference.IsLogicalDeleted.Bool != template.IsLogicalDeleted.Bool {
+		changes["is_logical_deleted"] = ":is_logical_deleted"
+	}
+	if reference.UpdatedAt.Time != template.UpdatedAt.Time {
+		if true {
+			changes["updated_at"] = ":updated_at"
+		}
+	}
+	return changes
+}
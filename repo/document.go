@@ -0,0 +1,219 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+const (
+	documentInsertSQL        = "INSERT INTO documents (id, name, description, kind, holder_type, holder_id, version, file_name, content_type, size, checksum, retain_until, legal_hold, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :kind, :holder_type, :holder_id, :version, :file_name, :content_type, :size, :checksum, :retain_until, :legal_hold, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	documentVersionInsertSQL = "INSERT INTO document_versions (id, name, description, document_id, version, file_name, content_type, size, checksum, storage_key, user_id, organization_id, created_at) VALUES (:id, :name, :description, :document_id, :version, :file_name, :content_type, :size, :checksum, :storage_key, :user_id, :organization_id, :created_at)"
+)
+
+// DocumentRepository - Document and DocumentVersion repository manager.
+type DocumentRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeDocumentRepository - DocumentRepository constructor.
+func MakeDocumentRepository() (DocumentRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return DocumentRepository{}, err
+	}
+	return DocumentRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Documents from an Organization in repo.
+func (repo *DocumentRepository) GetAll(orgID string) ([]models.Document, error) {
+	documents := []models.Document{}
+	err := repo.DB.Select(&documents, "SELECT * FROM documents WHERE organization_id = $1 ORDER BY created_at DESC", orgID)
+	return documents, err
+}
+
+// GetAllByHolder - GetAll Documents attached to a lease, unit or the Organization itself.
+func (repo *DocumentRepository) GetAllByHolder(orgID string, holderType string, holderID string) ([]models.Document, error) {
+	documents := []models.Document{}
+	err := repo.DB.Select(&documents, "SELECT * FROM documents WHERE organization_id = $1 AND holder_type = $2 AND holder_id = $3 ORDER BY created_at DESC", orgID, holderType, holderID)
+	return documents, err
+}
+
+// Get - Retrive a Document in repo by its ID.
+func (repo *DocumentRepository) Get(id string) (models.Document, error) {
+	document := models.Document{}
+	err := repo.DB.Get(&document, "SELECT * FROM documents WHERE id = $1", id)
+	if err != nil {
+		return document, err
+	}
+	return document, nil
+}
+
+// GetFromOrganization - Retrive a Document in repo by its ID and Organization ID.
+func (repo *DocumentRepository) GetFromOrganization(id string, orgID string) (models.Document, error) {
+	document := models.Document{}
+	err := repo.DB.Get(&document, "SELECT * FROM documents WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return document, err
+	}
+	return document, nil
+}
+
+// Create - Persists a Document and its first version, whose file is already stored.
+func (repo *DocumentRepository) Create(document *models.Document, version *models.DocumentVersion) error {
+	document.SetID()
+	document.SetCreationValues()
+	version.SetID()
+	version.DocumentID = document.ID
+	version.Version = models.ToNullsInt64(1)
+	version.OrganizationID = document.OrganizationID
+	version.CreatedAt = document.CreatedAt
+	document.SetFile(version)
+	tx := repo.DB.MustBegin()
+	_, err := tx.NamedExec(documentInsertSQL, document)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.NamedExec(documentVersionInsertSQL, version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddVersion - Persists a new version of a Document, whose file is already stored, and makes it the current one.
+func (repo *DocumentRepository) AddVersion(document *models.Document, version *models.DocumentVersion) error {
+	tx := repo.DB.MustBegin()
+	// Concurrent uploads of the same document are serialized
+	err := tx.Get(&version.Version, "SELECT version + 1 FROM documents WHERE id = $1 FOR UPDATE", document.ID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	version.SetID()
+	version.DocumentID = document.ID
+	version.OrganizationID = document.OrganizationID
+	version.CreatedAt = models.NullsNowTime()
+	_, err = tx.NamedExec(documentVersionInsertSQL, version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	document.SetFile(version)
+	document.UpdatedAt = version.CreatedAt
+	_, err = tx.NamedExec("UPDATE documents SET version = :version, file_name = :file_name, content_type = :content_type, size = :size, checksum = :checksum, updated_at = :updated_at WHERE id = :id", document)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetVersions - Returns the versions of a Document, latest first.
+func (repo *DocumentRepository) GetVersions(id string) ([]models.DocumentVersion, error) {
+	versions := []models.DocumentVersion{}
+	err := repo.DB.Select(&versions, "SELECT * FROM document_versions WHERE document_id = $1 ORDER BY version DESC", id)
+	return versions, err
+}
+
+// GetVersion - Retrive a version of a Document by its number.
+func (repo *DocumentRepository) GetVersion(id string, number int64) (models.DocumentVersion, error) {
+	version := models.DocumentVersion{}
+	err := repo.DB.Get(&version, "SELECT * FROM document_versions WHERE document_id = $1 AND version = $2", id, number)
+	return version, err
+}
+
+// Update - Update a Document in repo.
+// Retention can be extended but not shortened.
+func (repo *DocumentRepository) Update(document *models.Document) error {
+	// Update audit values
+	document.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(document.ID.String)
+	if err != nil {
+		return err
+	}
+	if reference.RetainUntil.Valid && (!document.RetainUntil.Valid || document.RetainUntil.Time.Before(reference.RetainUntil.Time)) {
+		return models.ValidationErrors{"retainUntil": "retention period cannot be shortened"}
+	}
+	// Customized query
+	changes := DocumentChanges(document, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE documents SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", document.ID.String))
+	//logger.Debug(query.String())
+	_, err = repo.DB.NamedExec(query.String(), document)
+	return err
+}
+
+// Delete - Deletes a Document and its versions from database if it is not retained.
+// Returns the storage keys of the deleted versions files.
+func (repo *DocumentRepository) Delete(id string, orgID string) ([]string, error) {
+	tx := repo.DB.MustBegin()
+	document := models.Document{}
+	err := tx.Get(&document, "SELECT * FROM documents WHERE id = $1 AND organization_id = $2 FOR UPDATE", id, orgID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if document.IsRetained(time.Now()) {
+		tx.Rollback()
+		return nil, models.ValidationErrors{"retainUntil": "document is retained"}
+	}
+	keys := []string{}
+	err = tx.Select(&keys, "SELECT storage_key FROM document_versions WHERE document_id = $1", id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	_, err = tx.Exec("DELETE FROM documents WHERE id = $1", id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return keys, tx.Commit()
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// facetScope - How properties relate to the rows of a faceted collection.
+type facetScope struct {
+	// from - Properties source, aliased as 'p'.
+	from string
+	// owner - Column holding the collection row ID.
+	owner string
+}
+
+var (
+	// holderFacetScope - Properties owned through any PropertiesSet of a holder.
+	holderFacetScope = facetScope{
+		from:  "properties p JOIN properties_sets ps ON ps.id = p.properties_set_id",
+		owner: "ps.holder_id",
+	}
+	// propertiesSetFacetScope - Properties of a PropertiesSet.
+	propertiesSetFacetScope = facetScope{
+		from:  "properties p",
+		owner: "p.properties_set_id",
+	}
+)
+
+// facetConditions - Compiles facet query conditions into EXISTS subqueries over 'table' rows.
+// Values are appended to 'args' and referenced as positional parameters.
+func facetConditions(scope facetScope, table string, query models.FacetQuery, args []interface{}) ([]string, []interface{}) {
+	conditions := []string{}
+	for _, condition := range query.Conditions {
+		args = append(args, condition.Name)
+		predicates := []string{fmt.Sprintf("p.name = $%d", len(args))}
+		var predicate string
+		switch condition.Kind {
+		case models.FacetConditionRange:
+			if condition.Min.Valid {
+				predicate, args = numericPredicate(">=", []float64{condition.Min.Float64}, args)
+				predicates = append(predicates, predicate)
+			}
+			if condition.Max.Valid {
+				predicate, args = numericPredicate("<=", []float64{condition.Max.Float64}, args)
+				predicates = append(predicates, predicate)
+			}
+		case models.FacetConditionNumber:
+			values := []float64{}
+			for _, value := range condition.Values {
+				v, err := strconv.ParseFloat(value, 64)
+				if err == nil {
+					values = append(values, v)
+				}
+			}
+			predicate, args = numericPredicate("=", values, args)
+			predicates = append(predicates, predicate)
+		case models.FacetConditionBoolean:
+			args = append(args, condition.Values[0] == "true")
+			predicates = append(predicates, fmt.Sprintf("p.boolean_value = $%d", len(args)))
+		default:
+			args = append(args, pq.Array(condition.Values))
+			predicates = append(predicates, fmt.Sprintf("p.string_value = ANY($%d::varchar[])", len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s = %s.id AND %s)",
+			scope.from, scope.owner, table, strings.Join(predicates, " AND ")))
+	}
+	return conditions, args
+}
+
+// numericPredicate - Compares each numeric value column against values.
+// Parameters are typed after each column so that (name, value) indexes can be used.
+// Bounds are rounded inwards for int values, non integral values never match them.
+func numericPredicate(op string, values []float64, args []interface{}) (string, []interface{}) {
+	ints := []int64{}
+	for _, v := range values {
+		switch {
+		case op == ">=":
+			ints = append(ints, int64(math.Ceil(v)))
+		case op == "<=":
+			ints = append(ints, int64(math.Floor(v)))
+		case v == math.Trunc(v):
+			ints = append(ints, int64(v))
+		}
+	}
+	predicates := []string{}
+	if len(ints) > 0 {
+		args = append(args, pq.Array(ints))
+		predicates = append(predicates, fmt.Sprintf("p.int_value %s ANY($%d::bigint[])", op, len(args)))
+	}
+	args = append(args, pq.Array(values))
+	predicates = append(predicates, fmt.Sprintf("p.float_value %s ANY($%d::real[])", op, len(args)))
+	args = append(args, pq.Array(values))
+	predicates = append(predicates, fmt.Sprintf("p.money_value %s ANY($%d::numeric[])", op, len(args)))
+	return "(" + strings.Join(predicates, " OR ") + ")", args
+}
+
+// facetCounts - Counts collection rows in 'ids' per value bucket of each requested facet.
+// Distinct values are ordered by count, numeric buckets by their lower bound.
+func facetCounts(db *sqlx.DB, scope facetScope, ids []string, requests []models.FacetRequest) ([]models.Facet, error) {
+	facets := []models.Facet{}
+	for _, request := range requests {
+		facet := models.Facet{Name: request.Name, Buckets: []models.FacetBucket{}}
+		args := []interface{}{request.Name, pq.Array(ids)}
+		value := "COALESCE(p.string_value, p.int_value::text, p.float_value::text, p.money_value::text, p.boolean_value::text)"
+		order := "count DESC, value ASC"
+		if request.Width > 0 {
+			args = append(args, request.Width)
+			number := fmt.Sprintf(numericValue, "p.")
+			value = fmt.Sprintf("(FLOOR(%s / $3) * $3)::text", number)
+			order = fmt.Sprintf("MIN(%s) ASC", number)
+		}
+		query := fmt.Sprintf("SELECT %s AS value, COUNT(DISTINCT %s) AS count FROM %s WHERE p.name = $1 AND %s = ANY($2::uuid[]) AND %s IS NOT NULL GROUP BY 1 ORDER BY %s;",
+			value, scope.owner, scope.from, scope.owner, value, order)
+		err := db.Select(&facet.Buckets, query, args...)
+		if err != nil {
+			return facets, err
+		}
+		facets = append(facets, facet)
+	}
+	return facets, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/fundacja/types"
+)
+
+// geoSelect - Builds a spatial query over a GEOGRAPHY column.
+// Rows are filtered by radius (ST_DWithin) and / or bounding box (ST_MakeEnvelope),
+// ordered by distance to the filter origin and returned with a 'distance' column in meters.
+// 'where' and 'args' contains additional conditions and their values.
+func geoSelect(table, column, where string, args []interface{}, filter types.GeoFilter) (string, []interface{}) {
+	origin := filter.Origin()
+	args = append(args, origin.Lng, origin.Lat)
+	point := fmt.Sprintf("ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography", len(args)-1, len(args))
+	conditions := []string{}
+	if where != "" {
+		conditions = append(conditions, where)
+	}
+	if filter.HasRadius() {
+		args = append(args, filter.Radius)
+		conditions = append(conditions, fmt.Sprintf("ST_DWithin(%s, %s, $%d)", column, point, len(args)))
+	}
+	if filter.BBoxValid {
+		b := filter.BBox
+		args = append(args, b.MinLng, b.MinLat, b.MaxLng, b.MaxLat)
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("%s && ST_MakeEnvelope($%d, $%d, $%d, $%d, 4326)::geography", column, n-3, n-2, n-1, n))
+	}
+	var query bytes.Buffer
+	query.WriteString(fmt.Sprintf("SELECT *, ST_Distance(%s, %s) AS distance FROM %s", column, point, table))
+	if len(conditions) > 0 {
+		query.WriteString(" WHERE ")
+		query.WriteString(strings.Join(conditions, " AND "))
+	}
+	query.WriteString(" ORDER BY distance ASC;")
+	return query.String(), args
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"time"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+	"github.com/markbates/pop/nulls"
+)
+
+const invoiceInsertSQL = "INSERT INTO invoices (id, name, description, kind, installment_number, period_start, period_end, due_date, amount, balance, currency, status, journal_entry_id, lease_id, unit_id, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :kind, :installment_number, :period_start, :period_end, :due_date, :amount, :balance, :currency, :status, :journal_entry_id, :lease_id, :unit_id, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+
+// InvoiceRepository - Invoice repository manager.
+type InvoiceRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeInvoiceRepository - InvoiceRepository constructor.
+func MakeInvoiceRepository() (InvoiceRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return InvoiceRepository{}, err
+	}
+	return InvoiceRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Invoices from an Organization in repo.
+func (repo *InvoiceRepository) GetAll(orgID string) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	err := repo.DB.Select(&invoices, "SELECT * FROM invoices WHERE organization_id = $1 ORDER BY due_date ASC, name ASC", orgID)
+	return invoices, err
+}
+
+// GetAllByStatus - GetAll Invoices from an Organization in repo having a status.
+func (repo *InvoiceRepository) GetAllByStatus(orgID string, status string) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	err := repo.DB.Select(&invoices, "SELECT * FROM invoices WHERE organization_id = $1 AND status = $2 ORDER BY due_date ASC, name ASC", orgID, status)
+	return invoices, err
+}
+
+// GetAllFromLease - GetAll Invoices of a Lease in repo.
+func (repo *InvoiceRepository) GetAllFromLease(leaseID string) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	err := repo.DB.Select(&invoices, "SELECT * FROM invoices WHERE lease_id = $1 ORDER BY due_date ASC, name ASC", leaseID)
+	return invoices, err
+}
+
+// Get - Retrive an Invoice in repo by its ID.
+func (repo *InvoiceRepository) Get(id string) (models.Invoice, error) {
+	invoice := models.Invoice{}
+	err := repo.DB.Get(&invoice, "SELECT * FROM invoices WHERE id = $1", id)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, nil
+}
+
+// GetFromOrganization - Retrive an Invoice in repo by its ID and Organization ID.
+func (repo *InvoiceRepository) GetFromOrganization(id string, orgID string) (models.Invoice, error) {
+	invoice := models.Invoice{}
+	err := repo.DB.Get(&invoice, "SELECT * FROM invoices WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, nil
+}
+
+// Generate - Issues the invoices of a Lease due until a date.
+// Rent invoices are issued for schedule installments due until then and not invoiced yet.
+// Late fee invoices are issued once per rent invoice still unpaid after due date plus grace days.
+func (repo *InvoiceRepository) Generate(lease *models.Lease, until time.Time, createdBy nulls.String) ([]models.Invoice, error) {
+	generated := []models.Invoice{}
+	tx := repo.DB.MustBegin()
+	// Serialize generation for the lease
+	tx.MustExec("SELECT id FROM leases WHERE id = $1 FOR UPDATE", lease.ID.String)
+	accounts, err := ledgerAccounts(tx, lease.OrganizationID.String, createdBy)
+	if err != nil {
+		tx.Rollback()
+		return generated, err
+	}
+	current := []models.Invoice{}
+	err = tx.Select(&current, "SELECT * FROM invoices WHERE lease_id = $1 AND status <> $2 ORDER BY due_date ASC", lease.ID.String, models.InvoiceStatusVoid)
+	if err != nil {
+		tx.Rollback()
+		return generated, err
+	}
+	invoiced := make(map[string]map[int64]bool)
+	invoiced[models.InvoiceKindRent] = make(map[int64]bool)
+	invoiced[models.InvoiceKindLateFee] = make(map[int64]bool)
+	rent := []models.Invoice{}
+	for _, invoice := range current {
+		invoiced[invoice.Kind.String][invoice.InstallmentNumber.Int64] = true
+		if invoice.Kind.String == models.InvoiceKindRent {
+			rent = append(rent, invoice)
+		}
+	}
+	// Rent
+	for _, installment := range lease.RentScheduleUntil(until) {
+		if invoiced[models.InvoiceKindRent][installment.Number] {
+			continue
+		}
+		invoice := models.InvoiceFromInstallment(lease, installment)
+		err = createInvoice(tx, &invoice, accounts, createdBy)
+		if err != nil {
+			tx.Rollback()
+			return generated, err
+		}
+		generated = append(generated, invoice)
+		rent = append(rent, invoice)
+	}
+	// Late fees
+	for _, invoice := range rent {
+		if !invoice.IsOverdue(until, lease.GraceDays.Int64) || invoiced[models.InvoiceKindLateFee][invoice.InstallmentNumber.Int64] {
+			continue
+		}
+		lateFee := models.LateFeeInvoice(lease, invoice, until)
+		if lateFee.Amount.Float64 <= 0 {
+			continue
+		}
+		err = createInvoice(tx, &lateFee, accounts, createdBy)
+		if err != nil {
+			tx.Rollback()
+			return generated, err
+		}
+		generated = append(generated, lateFee)
+	}
+	err = tx.Commit()
+	return generated, err
+}
+
+// Pay - Posts a payment for an Invoice.
+// Partial payments are allowed, payments over the outstanding balance are not.
+func (repo *InvoiceRepository) Pay(id string, orgID string, payment models.Payment, createdBy nulls.String) (models.JournalEntry, error) {
+	tx := repo.DB.MustBegin()
+	invoice := models.Invoice{}
+	err := tx.Get(&invoice, "SELECT * FROM invoices WHERE id = $1 AND organization_id = $2 FOR UPDATE", id, orgID)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+	entry, err := payInvoice(tx, &invoice, payment, createdBy)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+	err = tx.Commit()
+	return entry, err
+}
+
+// payInvoice - Validates a payment against a locked invoice, posts its entry and refreshes the invoice.
+func payInvoice(tx *sqlx.Tx, invoice *models.Invoice, payment models.Payment, createdBy nulls.String) (models.JournalEntry, error) {
+	errs := models.ValidationErrors{}
+	switch {
+	case invoice.Status.String == models.InvoiceStatusVoid || invoice.Status.String == models.InvoiceStatusPaid:
+		errs["status"] = "invoice is not payable"
+	case payment.Amount <= 0:
+		errs["amount"] = "must be greater than zero"
+	case models.RoundAmount(payment.Amount) > invoice.Balance.Float64:
+		errs["amount"] = "exceeds outstanding balance"
+	}
+	if len(errs) > 0 {
+		return models.JournalEntry{}, errs
+	}
+	accounts, err := ledgerAccounts(tx, invoice.OrganizationID.String, createdBy)
+	if err != nil {
+		return models.JournalEntry{}, err
+	}
+	entry := invoice.PaymentEntry(payment, accounts)
+	entry.CreatedBy = createdBy
+	err = postJournalEntry(tx, &entry)
+	if err != nil {
+		return models.JournalEntry{}, err
+	}
+	err = refreshInvoice(tx, invoice.ID.String)
+	return entry, err
+}
+
+// createInvoice - Persists an invoice and posts its issuing entry.
+func createInvoice(tx *sqlx.Tx, invoice *models.Invoice, accounts map[string]models.Account, createdBy nulls.String) error {
+	invoice.SetID()
+	invoice.SetCreationValues()
+	invoice.CreatedBy = createdBy
+	entry := invoice.IssueEntry(accounts)
+	entry.CreatedBy = createdBy
+	err := postJournalEntry(tx, &entry)
+	if err != nil {
+		return err
+	}
+	invoice.JournalEntryID = entry.ID
+	_, err = tx.NamedExec(invoiceInsertSQL, invoice)
+	return err
+}
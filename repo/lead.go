@@ -0,0 +1,438 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	leadStageInsertSQL    = "INSERT INTO lead_stages (id, name, description, position, outcome, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :position, :outcome, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	leadInsertSQL         = "INSERT INTO leads (id, name, description, email, phone, source, value, currency, listing_id, stage_id, assignee_id, stage_changed_at, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :email, :phone, :source, :value, :currency, :listing_id, :stage_id, :assignee_id, :stage_changed_at, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	leadActivityInsertSQL = "INSERT INTO lead_activities (id, name, description, kind, body, lead_id, from_stage_id, to_stage_id, user_id, due_at, done_at, organization_id, created_at) VALUES (:id, :name, :description, :kind, :body, :lead_id, :from_stage_id, :to_stage_id, :user_id, :due_at, :done_at, :organization_id, :created_at)"
+	leadFunnelSQL         = "SELECT s.id AS stage_id, COALESCE(s.name, '') AS name, s.position, s.outcome, COUNT(l.id) AS leads, COALESCE(SUM(l.value), 0) AS value FROM lead_stages s LEFT JOIN leads l ON l.stage_id = s.id WHERE s.organization_id = $1 GROUP BY s.id ORDER BY s.position ASC"
+)
+
+// LeadRepository - Lead, LeadStage and LeadActivity repository manager.
+type LeadRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeLeadRepository - LeadRepository constructor.
+func MakeLeadRepository() (LeadRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return LeadRepository{}, err
+	}
+	return LeadRepository{DB: db}, nil
+}
+
+// GetStages - Returns the pipeline stages of an Organization in order.
+func (repo *LeadRepository) GetStages(orgID string) ([]models.LeadStage, error) {
+	stages := []models.LeadStage{}
+	err := repo.DB.Select(&stages, "SELECT * FROM lead_stages WHERE organization_id = $1 ORDER BY position ASC", orgID)
+	return stages, err
+}
+
+// GetStage - Retrive a LeadStage in repo by its ID and Organization ID.
+func (repo *LeadRepository) GetStage(id string, orgID string) (models.LeadStage, error) {
+	stage := models.LeadStage{}
+	err := repo.DB.Get(&stage, "SELECT * FROM lead_stages WHERE id = $1 AND organization_id = $2", id, orgID)
+	return stage, err
+}
+
+// CreateStage - Persists a LeadStage in repo.
+// Stages without position are appended to the pipeline.
+func (repo *LeadRepository) CreateStage(stage *models.LeadStage) error {
+	tx := repo.DB.MustBegin()
+	_, err := leadStages(tx, stage.OrganizationID.String, stage.CreatedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if stage.Position.Int64 == 0 {
+		err = tx.Get(&stage.Position, "SELECT COALESCE(MAX(position), 0) + 1 FROM lead_stages WHERE organization_id = $1", stage.OrganizationID.String)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	stage.SetID()
+	stage.SetCreationValues()
+	_, err = tx.NamedExec(leadStageInsertSQL, stage)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateStage - Update a LeadStage in repo.
+func (repo *LeadRepository) UpdateStage(stage *models.LeadStage) error {
+	// Update audit values
+	stage.SetUpdateValues()
+	// Current state
+	reference, err := repo.GetStage(stage.ID.String, stage.OrganizationID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := LeadStageChanges(stage, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE lead_stages SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", stage.ID.String))
+	//logger.Debug(query.String())
+	_, err = repo.DB.NamedExec(query.String(), stage)
+	return err
+}
+
+// DeleteStage - Deletes a LeadStage from database if no lead is at it.
+func (repo *LeadRepository) DeleteStage(id string, orgID string) error {
+	_, err := repo.GetStage(id, orgID)
+	if err != nil {
+		return err
+	}
+	inUse := false
+	err = repo.DB.Get(&inUse, "SELECT EXISTS (SELECT 1 FROM leads WHERE stage_id = $1)", id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return models.ValidationErrors{"stage": "stage has leads, move them first"}
+	}
+	_, err = repo.DB.Exec("DELETE FROM lead_stages WHERE id = $1 AND organization_id = $2", id, orgID)
+	return err
+}
+
+// GetAll - GetAll Leads from an Organization in repo, latest first.
+func (repo *LeadRepository) GetAll(orgID string) ([]models.Lead, error) {
+	leads := []models.Lead{}
+	err := repo.DB.Select(&leads, "SELECT * FROM leads WHERE organization_id = $1 ORDER BY created_at DESC", orgID)
+	return leads, err
+}
+
+// GetAllByStage - GetAll Leads from an Organization at a stage.
+func (repo *LeadRepository) GetAllByStage(orgID string, stageID string) ([]models.Lead, error) {
+	leads := []models.Lead{}
+	err := repo.DB.Select(&leads, "SELECT * FROM leads WHERE organization_id = $1 AND stage_id = $2 ORDER BY stage_changed_at ASC", orgID, stageID)
+	return leads, err
+}
+
+// GetAllByAssignee - GetAll Leads from an Organization assigned to an agent.
+func (repo *LeadRepository) GetAllByAssignee(orgID string, assigneeID string) ([]models.Lead, error) {
+	leads := []models.Lead{}
+	err := repo.DB.Select(&leads, "SELECT * FROM leads WHERE organization_id = $1 AND assignee_id = $2 ORDER BY created_at DESC", orgID, assigneeID)
+	return leads, err
+}
+
+// Get - Retrive a Lead in repo by its ID.
+func (repo *LeadRepository) Get(id string) (models.Lead, error) {
+	lead := models.Lead{}
+	err := repo.DB.Get(&lead, "SELECT * FROM leads WHERE id = $1", id)
+	if err != nil {
+		return lead, err
+	}
+	return lead, nil
+}
+
+// GetFromOrganization - Retrive a Lead in repo by its ID and Organization ID.
+func (repo *LeadRepository) GetFromOrganization(id string, orgID string) (models.Lead, error) {
+	lead := models.Lead{}
+	err := repo.DB.Get(&lead, "SELECT * FROM leads WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return lead, err
+	}
+	return lead, nil
+}
+
+// Create - Persists a Lead in repo recording its entry in the pipeline.
+// Leads without stage enter at the first one, organizations without pipeline get the default one.
+func (repo *LeadRepository) Create(lead *models.Lead) error {
+	tx := repo.DB.MustBegin()
+	stages, err := leadStages(tx, lead.OrganizationID.String, lead.CreatedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if lead.StageID.String == "" {
+		lead.StageID = stages[0].ID
+	} else if !hasLeadStage(stages, lead.StageID.String) {
+		tx.Rollback()
+		return models.ValidationErrors{"stageID": "unknown stage"}
+	}
+	lead.SetID()
+	lead.SetCreationValues()
+	lead.StageChangedAt = lead.CreatedAt
+	_, err = tx.NamedExec(leadInsertSQL, lead)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = addLeadActivity(tx, lead, models.LeadActivityStageChange, lead.CreatedBy, func(activity *models.LeadActivity) {
+		activity.ToStageID = lead.StageID
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if lead.AssigneeID.String != "" {
+		err = addLeadActivity(tx, lead, models.LeadActivityAssignment, lead.CreatedBy, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Update - Update a Lead in repo, recording assignee changes.
+// Stage can only be changed moving the lead.
+func (repo *LeadRepository) Update(lead *models.Lead, userID nulls.String) error {
+	// Update audit values
+	lead.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(lead.ID.String)
+	if err != nil {
+		return err
+	}
+	lead.StageID = reference.StageID
+	// Customized query
+	changes := LeadChanges(lead, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE leads SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", lead.ID.String))
+	//logger.Debug(query.String())
+	tx := repo.DB.MustBegin()
+	_, err = tx.NamedExec(query.String(), lead)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, ok := changes["assignee_id"]; ok {
+		err = addLeadActivity(tx, lead, models.LeadActivityAssignment, userID, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Move - Moves a Lead to another stage of its Organization pipeline, recording the change.
+func (repo *LeadRepository) Move(lead *models.Lead, stageID string, note string, userID nulls.String) (models.LeadActivity, error) {
+	activity := models.LeadActivity{}
+	stage, err := repo.GetStage(stageID, lead.OrganizationID.String)
+	if err != nil {
+		return activity, models.ValidationErrors{"stageID": "unknown stage"}
+	}
+	if stage.ID.String == lead.StageID.String {
+		return activity, models.ValidationErrors{"stageID": "lead is already at the stage"}
+	}
+	from := lead.StageID
+	lead.StageID = stage.ID
+	lead.StageChangedAt = models.NullsNowTime()
+	lead.UpdatedAt = lead.StageChangedAt
+	tx := repo.DB.MustBegin()
+	// Concurrent moves of the same lead are serialized
+	res, err := tx.NamedExec("UPDATE leads SET stage_id = :stage_id, stage_changed_at = :stage_changed_at, updated_at = :updated_at WHERE id = :id AND stage_id = '"+from.String+"'", lead)
+	if err != nil {
+		tx.Rollback()
+		return activity, err
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return activity, err
+	}
+	if moved == 0 {
+		tx.Rollback()
+		return activity, models.ValidationErrors{"stageID": "lead stage changed meanwhile"}
+	}
+	err = addLeadActivity(tx, lead, models.LeadActivityStageChange, userID, func(a *models.LeadActivity) {
+		a.FromStageID = from
+		a.ToStageID = stage.ID
+		a.Body = models.ToNullsString(note)
+		activity = *a
+	})
+	if err != nil {
+		tx.Rollback()
+		return activity, err
+	}
+	return activity, tx.Commit()
+}
+
+// Delete - Deletes a Lead and its activities from database.
+func (repo *LeadRepository) Delete(id string) error {
+	_, err := repo.DB.Exec("DELETE FROM leads WHERE id = $1", id)
+	return err
+}
+
+// DeleteFromOrganization - Deletes Lead from database if it belongs to the Organization.
+func (repo *LeadRepository) DeleteFromOrganization(id string, orgID string) error {
+	_, err := repo.GetFromOrganization(id, orgID)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// GetActivities - Returns the history of a Lead, oldest first.
+func (repo *LeadRepository) GetActivities(leadID string) ([]models.LeadActivity, error) {
+	activities := []models.LeadActivity{}
+	err := repo.DB.Select(&activities, "SELECT * FROM lead_activities WHERE lead_id = $1 ORDER BY created_at ASC", leadID)
+	return activities, err
+}
+
+// GetActivity - Retrive a LeadActivity in repo by its ID and Lead ID.
+func (repo *LeadRepository) GetActivity(id string, leadID string) (models.LeadActivity, error) {
+	activity := models.LeadActivity{}
+	err := repo.DB.Get(&activity, "SELECT * FROM lead_activities WHERE id = $1 AND lead_id = $2", id, leadID)
+	return activity, err
+}
+
+// AddActivity - Persists a note, call, email or reminder on a Lead.
+func (repo *LeadRepository) AddActivity(activity *models.LeadActivity) error {
+	activity.SetID()
+	activity.CreatedAt = models.NullsNowTime()
+	activity.DoneAt = nulls.Time{}
+	_, err := repo.DB.NamedExec(leadActivityInsertSQL, activity)
+	return err
+}
+
+// CompleteReminder - Sets a pending reminder as done.
+func (repo *LeadRepository) CompleteReminder(activity *models.LeadActivity) error {
+	if !activity.IsPending() {
+		return models.ValidationErrors{"activity": "activity is not a pending reminder"}
+	}
+	activity.DoneAt = models.NullsNowTime()
+	_, err := repo.DB.NamedExec("UPDATE lead_activities SET done_at = :done_at WHERE id = :id", activity)
+	return err
+}
+
+// GetDueReminders - Returns the pending reminders of an Organization due until a time, oldest first.
+// If an agent is given only reminders set by the agent or on leads assigned to it are returned.
+func (repo *LeadRepository) GetDueReminders(orgID string, until time.Time, agentID string) ([]models.LeadActivity, error) {
+	reminders := []models.LeadActivity{}
+	query := "SELECT la.* FROM lead_activities la INNER JOIN leads l ON l.id = la.lead_id WHERE la.organization_id = $1 AND la.kind = $2 AND la.done_at IS NULL AND la.due_at <= $3"
+	var err error
+	if agentID != "" {
+		err = repo.DB.Select(&reminders, query+" AND (la.user_id = $4 OR l.assignee_id = $4) ORDER BY la.due_at ASC", orgID, models.LeadActivityReminder, until, agentID)
+	} else {
+		err = repo.DB.Select(&reminders, query+" ORDER BY la.due_at ASC", orgID, models.LeadActivityReminder, until)
+	}
+	return reminders, err
+}
+
+// GetFunnel - Returns leads count and value at every stage of an Organization pipeline.
+func (repo *LeadRepository) GetFunnel(orgID string) ([]models.FunnelStage, error) {
+	funnel := []models.FunnelStage{}
+	err := repo.DB.Select(&funnel, leadFunnelSQL, orgID)
+	return funnel, err
+}
+
+// leadStages - Returns organization pipeline stages in order, creating the default ones if missing.
+func leadStages(tx *sqlx.Tx, orgID string, createdBy nulls.String) ([]models.LeadStage, error) {
+	stages := []models.LeadStage{}
+	err := tx.Select(&stages, "SELECT * FROM lead_stages WHERE organization_id = $1 ORDER BY position ASC", orgID)
+	if err != nil || len(stages) > 0 {
+		return stages, err
+	}
+	for _, stage := range models.DefaultLeadStages() {
+		stage.SetID()
+		stage.SetCreationValues()
+		stage.OrganizationID = models.ToNullsString(orgID)
+		stage.CreatedBy = createdBy
+		_, err = tx.NamedExec(leadStageInsertSQL, &stage)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// addLeadActivity - Records a system activity on a lead, set can complete its values.
+func addLeadActivity(tx *sqlx.Tx, lead *models.Lead, kind string, userID nulls.String, set func(*models.LeadActivity)) error {
+	activity := models.LeadActivity{
+		Kind:           models.ToNullsString(kind),
+		LeadID:         lead.ID,
+		UserID:         userID,
+		OrganizationID: lead.OrganizationID,
+	}
+	activity.SetID()
+	activity.CreatedAt = models.NullsNowTime()
+	if kind == models.LeadActivityAssignment {
+		activity.Body = lead.AssigneeID
+	}
+	if set != nil {
+		set(&activity)
+	}
+	_, err := tx.NamedExec(leadActivityInsertSQL, &activity)
+	return err
+}
+
+func hasLeadStage(stages []models.LeadStage, id string) bool {
+	for _, stage := range stages {
+		if stage.ID.String == id {
+			return true
+		}
+	}
+	return false
+}
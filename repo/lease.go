@@ -0,0 +1,246 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+// LeaseRepository - Lease repository manager.
+type LeaseRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeLeaseRepository - LeaseRepository constructor.
+func MakeLeaseRepository() (LeaseRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return LeaseRepository{}, err
+	}
+	return LeaseRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Leases from an Organization in repo.
+func (repo *LeaseRepository) GetAll(orgID string) ([]models.Lease, error) {
+	leases := []models.Lease{}
+	err := repo.DB.Select(&leases, "SELECT * FROM leases WHERE organization_id = $1 ORDER BY started_at DESC, name ASC", orgID)
+	return leases, err
+}
+
+// GetAllFromUnit - GetAll Leases of a Unit in repo.
+func (repo *LeaseRepository) GetAllFromUnit(unitID string) ([]models.Lease, error) {
+	leases := []models.Lease{}
+	err := repo.DB.Select(&leases, "SELECT * FROM leases WHERE unit_id = $1 ORDER BY started_at DESC, name ASC", unitID)
+	return leases, err
+}
+
+// Create - Persists a Lease and its tenants in repo.
+// Lease start date is kept if provided.
+func (repo *LeaseRepository) Create(lease *models.Lease) error {
+	startedAt := lease.StartedAt
+	lease.SetID()
+	lease.SetCreationValues()
+	if startedAt.Valid {
+		lease.StartedAt = startedAt
+	}
+	tx := repo.DB.MustBegin()
+	leaseInsertSQL := "INSERT INTO leases (id, name, description, ends_at, rent_amount, deposit, currency, payment_day, indexation_rate, indexation_period, late_fee_amount, late_fee_rate, grace_days, status, unit_id, organization_id, annotations, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :ends_at, :rent_amount, :deposit, :currency, :payment_day, :indexation_rate, :indexation_period, :late_fee_amount, :late_fee_rate, :grace_days, :status, :unit_id, :organization_id, :annotations, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	_, err := tx.NamedExec(leaseInsertSQL, lease)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, userID := range lease.TenantIDs {
+		_, err = tx.Exec("INSERT INTO lease_tenants (lease_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", lease.ID.String, userID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if lease.Status.String == models.LeaseStatusActive {
+		tx.MustExec("UPDATE units SET status = $1 WHERE id = $2", models.UnitStatusOccupied, lease.UnitID.String)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get - Retrive a Lease in repo by its ID.
+func (repo *LeaseRepository) Get(id string) (models.Lease, error) {
+	lease := models.Lease{}
+	err := repo.DB.Get(&lease, "SELECT * FROM leases WHERE id = $1", id)
+	if err != nil {
+		return lease, err
+	}
+	return lease, nil
+}
+
+// GetFromOrganization - Retrive a Lease in repo by its ID and Organization ID.
+func (repo *LeaseRepository) GetFromOrganization(id string, orgID string) (models.Lease, error) {
+	lease := models.Lease{}
+	err := repo.DB.Get(&lease, "SELECT * FROM leases WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return lease, err
+	}
+	return lease, nil
+}
+
+// GetByNameInOrganization - Retrive a Lease in repo by its name and Organization ID.
+func (repo *LeaseRepository) GetByNameInOrganization(name string, orgID string) (models.Lease, error) {
+	lease := models.Lease{}
+	err := repo.DB.Get(&lease, "SELECT * FROM leases WHERE name = $1 AND organization_id = $2", name, orgID)
+	if err != nil {
+		return lease, err
+	}
+	return lease, nil
+}
+
+// GetTenants - Retrieve the Users renting under a Lease.
+func (repo *LeaseRepository) GetTenants(id string) ([]models.User, error) {
+	users := []models.User{}
+	err := repo.DB.Select(&users, "SELECT users.* FROM users INNER JOIN lease_tenants ON lease_tenants.user_id = users.id WHERE lease_tenants.lease_id = $1 ORDER BY users.username ASC", id)
+	return users, err
+}
+
+// AddTenant - Adds a User to the tenants of a Lease.
+func (repo *LeaseRepository) AddTenant(id string, userID string) error {
+	_, err := repo.DB.Exec("INSERT INTO lease_tenants (lease_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", id, userID)
+	return err
+}
+
+// RemoveTenant - Removes a User from the tenants of a Lease.
+func (repo *LeaseRepository) RemoveTenant(id string, userID string) error {
+	_, err := repo.DB.Exec("DELETE FROM lease_tenants WHERE lease_id = $1 AND user_id = $2", id, userID)
+	return err
+}
+
+// IsUnitTenant - Returns true if user is tenant of an active lease of the unit.
+func (repo *LeaseRepository) IsUnitTenant(unitID string, userID string) (bool, error) {
+	isTenant := false
+	err := repo.DB.Get(&isTenant, "SELECT EXISTS (SELECT 1 FROM leases l INNER JOIN lease_tenants lt ON lt.lease_id = l.id WHERE l.unit_id = $1 AND lt.user_id = $2 AND l.status = $3)", unitID, userID, models.LeaseStatusActive)
+	return isTenant, err
+}
+
+// IsLeaseTenant - Returns true if user is tenant of the lease.
+func (repo *LeaseRepository) IsLeaseTenant(id string, userID string) (bool, error) {
+	isTenant := false
+	err := repo.DB.Get(&isTenant, "SELECT EXISTS (SELECT 1 FROM lease_tenants WHERE lease_id = $1 AND user_id = $2)", id, userID)
+	return isTenant, err
+}
+
+// Update - Update a lease in repo.
+// Leased unit status follows lease status changes.
+func (repo *LeaseRepository) Update(lease *models.Lease) error {
+	// Update audit values
+	lease.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(lease.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := LeaseChanges(lease, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE leases SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", lease.ID.String))
+	//logger.Debug(query.String())
+	tx := repo.DB.MustBegin()
+	_, err = tx.NamedExec(query.String(), lease)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, ok := changes["status"]; ok {
+		switch lease.Status.String {
+		case models.LeaseStatusActive:
+			tx.MustExec("UPDATE units SET status = $1 WHERE id = $2", models.UnitStatusOccupied, reference.UnitID.String)
+		case models.LeaseStatusTerminated, models.LeaseStatusExpired:
+			tx.MustExec(vacateUnitSQL, models.UnitStatusVacant, reference.UnitID.String, models.LeaseStatusActive)
+		}
+	}
+	err = tx.Commit()
+	return err
+}
+
+// Expire - Sets as expired the active leases of an organization that reached their end date.
+func (repo *LeaseRepository) Expire(orgID string) error {
+	_, err := repo.DB.Exec(expireLeasesSQL, models.LeaseStatusExpired, orgID, models.LeaseStatusActive, models.UnitStatusVacant)
+	return err
+}
+
+// Delete - Deletes lease and its tenants from database.
+func (repo *LeaseRepository) Delete(id string) error {
+	tx := repo.DB.MustBegin()
+	tx.MustExec("DELETE FROM lease_tenants WHERE lease_id = $1", id)
+	tx.MustExec("DELETE FROM leases WHERE id = $1", id)
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteFromOrganization - Deletes lease from database if it belongs to the Organization.
+func (repo *LeaseRepository) DeleteFromOrganization(id string, orgID string) error {
+	_, err := repo.GetFromOrganization(id, orgID)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// vacateUnitSQL - Sets unit as vacant unless another active lease still holds it.
+const vacateUnitSQL = "UPDATE units SET status = $1 WHERE id = $2 AND NOT EXISTS (SELECT 1 FROM leases WHERE unit_id = $2 AND status = $3)"
+
+// expireLeasesSQL - Expires overdue active leases and sets their units as vacant.
+// Units statement sees leases as they were before the update, hence the end date check.
+const expireLeasesSQL = `WITH expired AS (
+  UPDATE leases SET status = $1, updated_at = now()
+  WHERE organization_id = $2 AND status = $3 AND ends_at <= now()
+  RETURNING unit_id)
+UPDATE units SET status = $4
+WHERE id IN (SELECT unit_id FROM expired)
+AND NOT EXISTS (SELECT 1 FROM leases WHERE leases.unit_id = units.id AND leases.status = $3 AND (leases.ends_at IS NULL OR leases.ends_at > now()))`
@@ -0,0 +1,291 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+	"github.com/markbates/pop/nulls"
+)
+
+const (
+	accountInsertSQL      = "INSERT INTO accounts (id, name, description, code, kind, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :code, :kind, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at) ON CONFLICT (organization_id, code) DO NOTHING"
+	journalEntryInsertSQL = "INSERT INTO journal_entries (id, name, description, kind, posted_at, reversal_of, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :kind, :posted_at, :reversal_of, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	postingInsertSQL      = "INSERT INTO postings (id, name, description, journal_entry_id, account_id, debit, credit, currency, lease_id, unit_id, invoice_id, organization_id, created_at) VALUES (:id, :name, :description, :journal_entry_id, :account_id, :debit, :credit, :currency, :lease_id, :unit_id, :invoice_id, :organization_id, :created_at)"
+	// statementSQL - Receivables postings with their entry data, filtered by caller.
+	statementSQL = `SELECT je.posted_at, p.journal_entry_id, je.kind, je.name, p.invoice_id, COALESCE(p.currency, '') AS currency, p.debit, p.credit
+	 FROM postings p
+	 INNER JOIN journal_entries je ON je.id = p.journal_entry_id
+	 INNER JOIN accounts a ON a.id = p.account_id
+	 WHERE a.code = $1 AND p.organization_id = $2 AND `
+	statementOrderSQL = " ORDER BY je.posted_at ASC, je.created_at ASC"
+	// standingPaymentsSQL - True if the invoice has payments not reversed.
+	standingPaymentsSQL = `SELECT EXISTS (SELECT 1 FROM journal_entries je
+	 INNER JOIN postings p ON p.journal_entry_id = je.id
+	 WHERE p.invoice_id = $1 AND je.kind = $2
+	 AND NOT EXISTS (SELECT 1 FROM journal_entries r WHERE r.reversal_of = je.id))`
+)
+
+// LedgerRepository - Ledger repository manager.
+type LedgerRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeLedgerRepository - LedgerRepository constructor.
+func MakeLedgerRepository() (LedgerRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return LedgerRepository{}, err
+	}
+	return LedgerRepository{DB: db}, nil
+}
+
+// GetAccounts - GetAll Accounts from an Organization in repo.
+func (repo *LedgerRepository) GetAccounts(orgID string) ([]models.Account, error) {
+	accounts := []models.Account{}
+	err := repo.DB.Select(&accounts, "SELECT * FROM accounts WHERE organization_id = $1 ORDER BY code ASC", orgID)
+	return accounts, err
+}
+
+// GetJournalEntries - GetAll JournalEntries from an Organization in repo, latest first.
+func (repo *LedgerRepository) GetJournalEntries(orgID string) ([]models.JournalEntry, error) {
+	entries := []models.JournalEntry{}
+	err := repo.DB.Select(&entries, "SELECT * FROM journal_entries WHERE organization_id = $1 ORDER BY posted_at DESC, created_at DESC", orgID)
+	return entries, err
+}
+
+// GetJournalEntry - Retrive a JournalEntry and its postings in repo by its ID and Organization ID.
+func (repo *LedgerRepository) GetJournalEntry(id string, orgID string) (models.JournalEntry, error) {
+	entry := models.JournalEntry{}
+	err := repo.DB.Get(&entry, "SELECT * FROM journal_entries WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return entry, err
+	}
+	entry.Postings, err = repo.GetPostings(id)
+	return entry, err
+}
+
+// GetPostings - Retrieve the Postings of a JournalEntry.
+func (repo *LedgerRepository) GetPostings(entryID string) ([]models.Posting, error) {
+	postings := []models.Posting{}
+	err := repo.DB.Select(&postings, "SELECT * FROM postings WHERE journal_entry_id = $1 ORDER BY debit DESC, credit ASC", entryID)
+	return postings, err
+}
+
+// Reverse - Posts an entry cancelling a previous one.
+// Ledger is immutable: this is the only way to correct a posted entry.
+func (repo *LedgerRepository) Reverse(id string, orgID string, createdBy nulls.String) (models.JournalEntry, error) {
+	tx := repo.DB.MustBegin()
+	entry := models.JournalEntry{}
+	err := tx.Get(&entry, "SELECT * FROM journal_entries WHERE id = $1 AND organization_id = $2 FOR UPDATE", id, orgID)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+	if entry.Kind.String == models.JournalEntryKindReversal {
+		tx.Rollback()
+		return models.JournalEntry{}, models.ValidationErrors{"kind": "reversal entries can't be reversed"}
+	}
+	var reversed bool
+	err = tx.Get(&reversed, "SELECT EXISTS (SELECT 1 FROM journal_entries WHERE reversal_of = $1)", id)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+	if reversed {
+		tx.Rollback()
+		return models.JournalEntry{}, models.ValidationErrors{"id": "entry already reversed"}
+	}
+	err = tx.Select(&entry.Postings, "SELECT * FROM postings WHERE journal_entry_id = $1", id)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+	// Issued invoices with payments posted can't be voided
+	if entry.Kind.String == models.JournalEntryKindInvoice {
+		err = checkNoStandingPayments(tx, entry.Postings)
+		if err != nil {
+			tx.Rollback()
+			return models.JournalEntry{}, err
+		}
+	}
+	reversal := entry.Reversal()
+	reversal.CreatedBy = createdBy
+	err = postJournalEntry(tx, &reversal)
+	if err != nil {
+		tx.Rollback()
+		return models.JournalEntry{}, err
+	}
+	// Invoices follow their postings
+	refreshed := make(map[string]bool)
+	for _, posting := range reversal.Postings {
+		invoiceID := posting.InvoiceID.String
+		if invoiceID == "" || refreshed[invoiceID] {
+			continue
+		}
+		err = refreshInvoice(tx, invoiceID)
+		if err != nil {
+			tx.Rollback()
+			return models.JournalEntry{}, err
+		}
+		refreshed[invoiceID] = true
+	}
+	err = tx.Commit()
+	return reversal, err
+}
+
+// GetUnitStatement - Receivables statement of a Unit.
+func (repo *LedgerRepository) GetUnitStatement(unitID string, orgID string) (models.Statement, error) {
+	lines := []models.StatementLine{}
+	err := repo.DB.Select(&lines, statementSQL+"p.unit_id = $3"+statementOrderSQL, models.AccountReceivable, orgID, unitID)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	return models.MakeStatement(models.StatementHolderUnit, unitID, lines), nil
+}
+
+// GetTenantStatement - Receivables statement of a User for all the leases where it is tenant.
+func (repo *LedgerRepository) GetTenantStatement(userID string, orgID string) (models.Statement, error) {
+	lines := []models.StatementLine{}
+	err := repo.DB.Select(&lines, statementSQL+"p.lease_id IN (SELECT lease_id FROM lease_tenants WHERE user_id = $3)"+statementOrderSQL, models.AccountReceivable, orgID, userID)
+	if err != nil {
+		return models.Statement{}, err
+	}
+	return models.MakeStatement(models.StatementHolderTenant, userID, lines), nil
+}
+
+// ledgerAccounts - Returns organization accounts by code, creating the default ones if missing.
+func ledgerAccounts(tx *sqlx.Tx, orgID string, createdBy nulls.String) (map[string]models.Account, error) {
+	accounts := []models.Account{}
+	err := tx.Select(&accounts, "SELECT * FROM accounts WHERE organization_id = $1", orgID)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]models.Account)
+	for _, account := range accounts {
+		byCode[account.Code.String] = account
+	}
+	for _, account := range models.DefaultAccounts() {
+		if _, ok := byCode[account.Code.String]; ok {
+			continue
+		}
+		account.SetID()
+		account.SetCreationValues()
+		account.OrganizationID = models.ToNullsString(orgID)
+		account.CreatedBy = createdBy
+		_, err = tx.NamedExec(accountInsertSQL, &account)
+		if err != nil {
+			return nil, err
+		}
+		byCode[account.Code.String] = account
+	}
+	return byCode, nil
+}
+
+// postJournalEntry - Persists a balanced entry and its postings.
+func postJournalEntry(tx *sqlx.Tx, entry *models.JournalEntry) error {
+	if !entry.IsBalanced() {
+		return models.ValidationErrors{"postings": "debits and credits don't balance"}
+	}
+	entry.SetID()
+	entry.SetCreationValues()
+	if !entry.PostedAt.Valid {
+		entry.PostedAt = entry.CreatedAt
+	}
+	_, err := tx.NamedExec(journalEntryInsertSQL, entry)
+	if err != nil {
+		return err
+	}
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		posting.ID = models.ToNullsString("")
+		posting.SetID()
+		posting.JournalEntryID = entry.ID
+		posting.OrganizationID = entry.OrganizationID
+		posting.CreatedAt = entry.CreatedAt
+		_, err = tx.NamedExec(postingInsertSQL, posting)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoStandingPayments - Returns a validation error if any invoice of the postings has payments not reversed.
+// Invoices are locked so no payment can be posted until the transaction ends.
+func checkNoStandingPayments(tx *sqlx.Tx, postings []models.Posting) error {
+	checked := make(map[string]bool)
+	for _, posting := range postings {
+		invoiceID := posting.InvoiceID.String
+		if invoiceID == "" || checked[invoiceID] {
+			continue
+		}
+		_, err := tx.Exec("SELECT id FROM invoices WHERE id = $1 FOR UPDATE", invoiceID)
+		if err != nil {
+			return err
+		}
+		var paid bool
+		err = tx.Get(&paid, standingPaymentsSQL, invoiceID, models.JournalEntryKindPayment)
+		if err != nil {
+			return err
+		}
+		if paid {
+			return models.ValidationErrors{"id": "invoice has payments posted, reverse them first"}
+		}
+		checked[invoiceID] = true
+	}
+	return nil
+}
+
+// refreshInvoice - Updates invoice balance and status from its receivables postings.
+// Invoices whose issuing entry was reversed are void.
+func refreshInvoice(tx *sqlx.Tx, invoiceID string) error {
+	invoice := models.Invoice{}
+	err := tx.Get(&invoice, "SELECT * FROM invoices WHERE id = $1 FOR UPDATE", invoiceID)
+	if err != nil {
+		return err
+	}
+	var balance float64
+	err = tx.Get(&balance, "SELECT COALESCE(SUM(p.debit - p.credit), 0) FROM postings p INNER JOIN accounts a ON a.id = p.account_id WHERE p.invoice_id = $1 AND a.code = $2", invoiceID, models.AccountReceivable)
+	if err != nil {
+		return err
+	}
+	var voided bool
+	if invoice.JournalEntryID.String != "" {
+		err = tx.Get(&voided, "SELECT EXISTS (SELECT 1 FROM journal_entries WHERE reversal_of = $1)", invoice.JournalEntryID.String)
+		if err != nil {
+			return err
+		}
+	}
+	invoice.Balance = models.ToNullsFoat64(models.RoundAmount(balance))
+	invoice.UpdateStatus()
+	if voided {
+		invoice.Status = models.ToNullsString(models.InvoiceStatusVoid)
+	}
+	invoice.SetUpdateValues()
+	_, err = tx.NamedExec("UPDATE invoices SET balance = :balance, status = :status, updated_at = :updated_at WHERE id = :id", &invoice)
+	return err
+}
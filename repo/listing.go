@@ -0,0 +1,336 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/types"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/markbates/pop/nulls"
+)
+
+const listingHistoryInsertSQL = "INSERT INTO listing_history (id, name, description, listing_id, price, previous_price, currency, status, previous_status, user_id, organization_id, created_at) VALUES (:id, :name, :description, :listing_id, :price, :previous_price, :currency, :status, :previous_status, :user_id, :organization_id, :created_at)"
+
+// ListingRepository - Listing repository manager.
+type ListingRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeListingRepository - ListingRepository constructor.
+func MakeListingRepository() (ListingRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return ListingRepository{}, err
+	}
+	return ListingRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Listings from an Organization in repo.
+func (repo *ListingRepository) GetAll(orgid string) ([]models.Listing, error) {
+	listings := []models.Listing{}
+	err := repo.DB.Select(&listings, "SELECT * FROM listings WHERE organization_id = $1 ORDER BY name ASC", orgid)
+	return listings, err
+}
+
+// GetAllByLocation - Get Listings from an Organization in repo matching a spatial filter ordered by distance.
+func (repo *ListingRepository) GetAllByLocation(orgid string, filter types.GeoFilter) ([]models.Listing, error) {
+	listings := []models.Listing{}
+	query, args := geoSelect("listings", "geolocation", "organization_id = $1", []interface{}{orgid}, filter)
+	err := repo.DB.Select(&listings, query, args...)
+	return listings, err
+}
+
+// GetAllFaceted - Get Listings from an Organization in repo owning properties that match the facet query.
+// Results are ordered by distance if a spatial filter is set.
+func (repo *ListingRepository) GetAllFaceted(orgid string, query models.FacetQuery, geoFilter types.GeoFilter) ([]models.Listing, error) {
+	listings := []models.Listing{}
+	conditions, args := facetConditions(holderFacetScope, "listings", query, []interface{}{orgid})
+	where := strings.Join(append([]string{"organization_id = $1"}, conditions...), " AND ")
+	if !geoFilter.IsEmpty() {
+		stmt, args := geoSelect("listings", "geolocation", where, args, geoFilter)
+		err := repo.DB.Select(&listings, stmt, args...)
+		return listings, err
+	}
+	stmt := fmt.Sprintf("SELECT * FROM listings WHERE %s ORDER BY name ASC", where)
+	err := repo.DB.Select(&listings, stmt, args...)
+	return listings, err
+}
+
+// GetFacets - Count listings per value bucket of requested properties.
+func (repo *ListingRepository) GetFacets(listings []models.Listing, requests []models.FacetRequest) ([]models.Facet, error) {
+	return facetCounts(repo.DB, holderFacetScope, listingIDs(listings), requests)
+}
+
+// Create - Persists a Listing in repo recording its initial price and status.
+func (repo *ListingRepository) Create(listing *models.Listing) error {
+	listing.SetID()
+	listing.SetCreationValues()
+	tx := repo.DB.MustBegin()
+	listingInsertSQL := "INSERT INTO listings (id, name, description, price, currency, operation, status, address, footprint, organization_id, annotations, geolocation, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :price, :currency, :operation, :status, :address, :footprint, :organization_id, :annotations, :geolocation, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	_, err := tx.NamedExec(listingInsertSQL, listing)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = addListingHistory(tx, listing, nil, listing.CreatedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get - Retrive a Listing in repo by its ID.
+func (repo *ListingRepository) Get(id string) (models.Listing, error) {
+	listing := models.Listing{}
+	err := repo.DB.Get(&listing, "SELECT * FROM listings WHERE id = $1", id)
+	if err != nil {
+		return listing, err
+	}
+	return listing, nil
+}
+
+// GetFromOrganization - Retrive a Listing in repo by its ID and Organization ID.
+func (repo *ListingRepository) GetFromOrganization(id string, orgid string) (models.Listing, error) {
+	listing := models.Listing{}
+	err := repo.DB.Get(&listing, "SELECT * FROM listings WHERE id = $1 AND organization_id = $2", id, orgid)
+	if err != nil {
+		return listing, err
+	}
+	return listing, nil
+}
+
+// GetByNameInOrganization - Retrive a Listing in repo by its name and Organization ID.
+func (repo *ListingRepository) GetByNameInOrganization(name string, orgid string) (models.Listing, error) {
+	listing := models.Listing{}
+	err := repo.DB.Get(&listing, "SELECT * FROM listings WHERE name = $1 AND organization_id = $2", name, orgid)
+	if err != nil {
+		return listing, err
+	}
+	return listing, nil
+}
+
+// GetPropertiesSets - Retrieve the PropertiesSets holding the attributes of a Listing.
+func (repo *ListingRepository) GetPropertiesSets(id string) ([]models.PropertiesSet, error) {
+	propertiesSets := []models.PropertiesSet{}
+	err := repo.DB.Select(&propertiesSets, "SELECT * FROM properties_sets WHERE holder_id = $1 ORDER BY position ASC, name ASC", id)
+	return propertiesSets, err
+}
+
+// Update - Update a listing in repo, recording price and status changes.
+func (repo *ListingRepository) Update(listing *models.Listing, userID nulls.String) error {
+	// Update audit values
+	listing.SetUpdateValues()
+	tx := repo.DB.MustBegin()
+	// Current state, concurrent updates are serialized to keep history consistent
+	reference := models.Listing{}
+	err := tx.Get(&reference, "SELECT * FROM listings WHERE id = $1 FOR UPDATE", listing.ID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// Customized query
+	changes := ListingChanges(listing, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE listings SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", listing.ID.String))
+	//logger.Debug(query.String())
+	_, err = tx.NamedExec(query.String(), listing)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if listing.HasHistoryChanges(reference) {
+		err = addListingHistory(tx, listing, &reference, userID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	err = tx.Commit()
+	return err
+}
+
+// Delete - Deletes listing and its properties sets from database.
+func (repo *ListingRepository) Delete(id string) error {
+	tx := repo.DB.MustBegin()
+	tx.MustExec("DELETE FROM properties WHERE properties_set_id IN (SELECT id FROM properties_sets WHERE holder_id = $1)", id)
+	tx.MustExec("DELETE FROM properties_sets WHERE holder_id = $1", id)
+	tx.MustExec("DELETE FROM albums WHERE owner_type = 'listing' AND owner_id = $1", id)
+	tx.MustExec("DELETE FROM listings WHERE id = $1", id)
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteFromOrganization - Deletes listing and its properties sets from database.
+func (repo *ListingRepository) DeleteFromOrganization(id string, orgid string) error {
+	_, err := repo.GetFromOrganization(id, orgid)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// GetHistory - Returns the price and status changes of a Listing, oldest first.
+func (repo *ListingRepository) GetHistory(id string) ([]models.ListingHistory, error) {
+	history := []models.ListingHistory{}
+	err := repo.DB.Select(&history, "SELECT * FROM listing_history WHERE listing_id = $1 ORDER BY created_at ASC", id)
+	return history, err
+}
+
+// GetStats - Returns the market statistics of a Listing.
+func (repo *ListingRepository) GetStats(id string) (models.ListingStats, error) {
+	stats := models.ListingStats{}
+	err := repo.DB.Get(&stats, "SELECT * FROM listing_stats WHERE listing_id = $1", id)
+	return stats, err
+}
+
+// SetStats - Sets the market statistics of listings.
+func (repo *ListingRepository) SetStats(listings []models.Listing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+	stats := []models.ListingStats{}
+	err := repo.DB.Select(&stats, "SELECT * FROM listing_stats WHERE listing_id = ANY($1::uuid[])", pq.Array(listingIDs(listings)))
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.ListingStats, len(stats))
+	for i := range stats {
+		byID[stats[i].ListingID] = &stats[i]
+	}
+	for i := range listings {
+		listings[i].Stats = byID[listings[i].ID.String]
+	}
+	return nil
+}
+
+// FilterByStats - Returns the listings whose market statistics match the filter, keeping their order.
+func (repo *ListingRepository) FilterByStats(listings []models.Listing, filter models.ListingStatsFilter) ([]models.Listing, error) {
+	if filter.IsEmpty() || len(listings) == 0 {
+		return listings, nil
+	}
+	conditions, args := listingStatsConditions(filter, []interface{}{pq.Array(listingIDs(listings))})
+	stmt := fmt.Sprintf("SELECT listing_id FROM listing_stats WHERE %s", strings.Join(append([]string{"listing_id = ANY($1::uuid[])"}, conditions...), " AND "))
+	ids := []string{}
+	err := repo.DB.Select(&ids, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	matching := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		matching[id] = true
+	}
+	filtered := []models.Listing{}
+	for _, listing := range listings {
+		if matching[listing.ID.String] {
+			filtered = append(filtered, listing)
+		}
+	}
+	return filtered, nil
+}
+
+// addListingHistory - Records listing current price and status, previous values are taken from reference if any.
+func addListingHistory(tx *sqlx.Tx, listing *models.Listing, reference *models.Listing, userID nulls.String) error {
+	history := models.ListingHistory{
+		ListingID:      listing.ID,
+		Price:          listing.Price,
+		Currency:       listing.Currency,
+		Status:         listing.Status,
+		UserID:         userID,
+		OrganizationID: listing.OrganizationID,
+	}
+	if reference != nil {
+		history.PreviousPrice = reference.Price
+		history.PreviousStatus = reference.Status
+		history.OrganizationID = reference.OrganizationID
+	}
+	history.SetID()
+	history.CreatedAt = models.NullsNowTime()
+	_, err := tx.NamedExec(listingHistoryInsertSQL, &history)
+	return err
+}
+
+func listingStatsConditions(filter models.ListingStatsFilter, args []interface{}) ([]string, []interface{}) {
+	conditions := []string{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.MinDaysOnMarket.Valid {
+		add("days_on_market >= $%d", filter.MinDaysOnMarket.Int64)
+	}
+	if filter.MaxDaysOnMarket.Valid {
+		add("days_on_market <= $%d", filter.MaxDaysOnMarket.Int64)
+	}
+	if filter.PriceReduced.Valid {
+		if filter.PriceReduced.Bool {
+			conditions = append(conditions, "price_reductions > 0")
+		} else {
+			conditions = append(conditions, "price_reductions = 0")
+		}
+	}
+	if filter.MinPriceChange.Valid {
+		add("price_change >= $%d", filter.MinPriceChange.Float64)
+	}
+	if filter.MaxPriceChange.Valid {
+		add("price_change <= $%d", filter.MaxPriceChange.Float64)
+	}
+	return conditions, args
+}
+
+func listingIDs(listings []models.Listing) []string {
+	ids := make([]string, len(listings))
+	for i, listing := range listings {
+		ids[i] = listing.ID.String
+	}
+	return ids
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+const (
+	maintenanceRequestInsertSQL = "INSERT INTO maintenance_requests (id, name, description, priority, status, building_id, unit_id, reported_by, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :priority, :status, :building_id, :unit_id, :reported_by, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	workOrderInsertSQL          = "INSERT INTO work_orders (id, name, description, status, maintenance_request_id, assignee_user_id, assignee_organization_id, scheduled_at, completed_at, estimated_cost, actual_cost, currency, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :status, :maintenance_request_id, :assignee_user_id, :assignee_organization_id, :scheduled_at, :completed_at, :estimated_cost, :actual_cost, :currency, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	maintenanceCommentInsertSQL = "INSERT INTO maintenance_comments (id, name, description, body, maintenance_request_id, user_id, organization_id, created_at) VALUES (:id, :name, :description, :body, :maintenance_request_id, :user_id, :organization_id, :created_at)"
+	maintenancePhotoInsertSQL   = "INSERT INTO maintenance_photos (id, name, description, content_type, size, data, maintenance_request_id, user_id, organization_id, created_at) VALUES (:id, :name, :description, :content_type, :size, :data, :maintenance_request_id, :user_id, :organization_id, :created_at)"
+	// maintenanceAssigneeSQL - Work orders of a request assigned to a user, directly or through an organization the user owns.
+	maintenanceAssigneeSQL = "SELECT 1 FROM work_orders wo LEFT JOIN organizations o ON o.id = wo.assignee_organization_id WHERE wo.maintenance_request_id = mr.id AND (wo.assignee_user_id = $2 OR o.user_id = $2)"
+	// requestStatusSQL - Moves a request to a new status only from the expected one.
+	requestStatusSQL = "UPDATE maintenance_requests SET status = $1, updated_at = now() WHERE id = $2 AND status = $3"
+	// requestDoneSQL - Sets an in progress request as done once none of its work orders is pending.
+	requestDoneSQL = "UPDATE maintenance_requests SET status = $1, updated_at = now() WHERE id = $2 AND status = $3 AND NOT EXISTS (SELECT 1 FROM work_orders WHERE maintenance_request_id = $2 AND status IN ($4, $5))"
+)
+
+// MaintenanceRequestRepository - MaintenanceRequest repository manager.
+type MaintenanceRequestRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeMaintenanceRequestRepository - MaintenanceRequestRepository constructor.
+func MakeMaintenanceRequestRepository() (MaintenanceRequestRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return MaintenanceRequestRepository{}, err
+	}
+	return MaintenanceRequestRepository{DB: db}, nil
+}
+
+// GetAll - GetAll MaintenanceRequests from an Organization in repo, latest first.
+func (repo *MaintenanceRequestRepository) GetAll(orgID string) ([]models.MaintenanceRequest, error) {
+	requests := []models.MaintenanceRequest{}
+	err := repo.DB.Select(&requests, "SELECT * FROM maintenance_requests WHERE organization_id = $1 ORDER BY created_at DESC", orgID)
+	return requests, err
+}
+
+// GetAllByStatus - GetAll MaintenanceRequests from an Organization in repo having a status.
+func (repo *MaintenanceRequestRepository) GetAllByStatus(orgID string, status string) ([]models.MaintenanceRequest, error) {
+	requests := []models.MaintenanceRequest{}
+	err := repo.DB.Select(&requests, "SELECT * FROM maintenance_requests WHERE organization_id = $1 AND status = $2 ORDER BY created_at DESC", orgID, status)
+	return requests, err
+}
+
+// GetAllForUser - GetAll MaintenanceRequests from an Organization reported by or assigned to a User.
+func (repo *MaintenanceRequestRepository) GetAllForUser(orgID string, userID string) ([]models.MaintenanceRequest, error) {
+	requests := []models.MaintenanceRequest{}
+	query := fmt.Sprintf("SELECT * FROM maintenance_requests mr WHERE mr.organization_id = $1 AND (mr.reported_by = $2 OR EXISTS (%s)) ORDER BY mr.created_at DESC", maintenanceAssigneeSQL)
+	err := repo.DB.Select(&requests, query, orgID, userID)
+	return requests, err
+}
+
+// IsAssignee - Returns true if any of the request work orders is assigned to the User.
+func (repo *MaintenanceRequestRepository) IsAssignee(id string, userID string) (bool, error) {
+	isAssignee := false
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM maintenance_requests mr WHERE mr.id = $1 AND EXISTS (%s))", maintenanceAssigneeSQL)
+	err := repo.DB.Get(&isAssignee, query, id, userID)
+	return isAssignee, err
+}
+
+// Create - Persists a MaintenanceRequest in repo.
+func (repo *MaintenanceRequestRepository) Create(request *models.MaintenanceRequest) error {
+	request.SetID()
+	request.SetCreationValues()
+	_, err := repo.DB.NamedExec(maintenanceRequestInsertSQL, request)
+	return err
+}
+
+// Get - Retrive a MaintenanceRequest in repo by its ID.
+func (repo *MaintenanceRequestRepository) Get(id string) (models.MaintenanceRequest, error) {
+	request := models.MaintenanceRequest{}
+	err := repo.DB.Get(&request, "SELECT * FROM maintenance_requests WHERE id = $1", id)
+	if err != nil {
+		return request, err
+	}
+	return request, nil
+}
+
+// GetFromOrganization - Retrive a MaintenanceRequest, its work orders and costs in repo by its ID and Organization ID.
+func (repo *MaintenanceRequestRepository) GetFromOrganization(id string, orgID string) (models.MaintenanceRequest, error) {
+	request := models.MaintenanceRequest{}
+	err := repo.DB.Get(&request, "SELECT * FROM maintenance_requests WHERE id = $1 AND organization_id = $2", id, orgID)
+	if err != nil {
+		return request, err
+	}
+	err = repo.DB.Select(&request.WorkOrders, "SELECT * FROM work_orders WHERE maintenance_request_id = $1 ORDER BY created_at ASC", id)
+	if err != nil {
+		return request, err
+	}
+	request.SumCosts()
+	return request, nil
+}
+
+// Update - Update a MaintenanceRequest in repo.
+func (repo *MaintenanceRequestRepository) Update(request *models.MaintenanceRequest) error {
+	// Update audit values
+	request.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(request.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := MaintenanceRequestChanges(request, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE maintenance_requests SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", request.ID.String))
+	//logger.Debug(query.String())
+	_, err = repo.DB.NamedExec(query.String(), request)
+	return err
+}
+
+// Delete - Deletes MaintenanceRequest, its work orders, comments and photos from database.
+func (repo *MaintenanceRequestRepository) Delete(id string) error {
+	_, err := repo.DB.Exec("DELETE FROM maintenance_requests WHERE id = $1", id)
+	return err
+}
+
+// DeleteFromOrganization - Deletes MaintenanceRequest from database if it belongs to the Organization.
+func (repo *MaintenanceRequestRepository) DeleteFromOrganization(id string, orgID string) error {
+	_, err := repo.Get(id)
+	if err != nil {
+		return err
+	}
+	_, err = repo.DB.Exec("DELETE FROM maintenance_requests WHERE id = $1 AND organization_id = $2", id, orgID)
+	return err
+}
+
+// GetComments - Retrieve the comments of a MaintenanceRequest, oldest first.
+func (repo *MaintenanceRequestRepository) GetComments(id string) ([]models.MaintenanceComment, error) {
+	comments := []models.MaintenanceComment{}
+	err := repo.DB.Select(&comments, "SELECT * FROM maintenance_comments WHERE maintenance_request_id = $1 ORDER BY created_at ASC", id)
+	return comments, err
+}
+
+// AddComment - Persists a comment on a MaintenanceRequest.
+func (repo *MaintenanceRequestRepository) AddComment(comment *models.MaintenanceComment) error {
+	comment.SetID()
+	comment.CreatedAt = models.NullsNowTime()
+	_, err := repo.DB.NamedExec(maintenanceCommentInsertSQL, comment)
+	return err
+}
+
+// GetPhotos - Retrieve the photos of a MaintenanceRequest without their data.
+func (repo *MaintenanceRequestRepository) GetPhotos(id string) ([]models.MaintenancePhoto, error) {
+	photos := []models.MaintenancePhoto{}
+	err := repo.DB.Select(&photos, "SELECT id, name, description, content_type, size, NULL AS data, maintenance_request_id, user_id, organization_id, created_at FROM maintenance_photos WHERE maintenance_request_id = $1 ORDER BY created_at ASC", id)
+	return photos, err
+}
+
+// GetPhoto - Retrive a photo of a MaintenanceRequest including its data.
+func (repo *MaintenanceRequestRepository) GetPhoto(photoID string, id string) (models.MaintenancePhoto, error) {
+	photo := models.MaintenancePhoto{}
+	err := repo.DB.Get(&photo, "SELECT * FROM maintenance_photos WHERE id = $1 AND maintenance_request_id = $2", photoID, id)
+	return photo, err
+}
+
+// AddPhoto - Persists a decoded photo of a MaintenanceRequest.
+func (repo *MaintenanceRequestRepository) AddPhoto(photo *models.MaintenancePhoto) error {
+	photo.SetID()
+	photo.CreatedAt = models.NullsNowTime()
+	_, err := repo.DB.NamedExec(maintenancePhotoInsertSQL, photo)
+	return err
+}
+
+// DeletePhoto - Deletes a photo of a MaintenanceRequest from database.
+func (repo *MaintenanceRequestRepository) DeletePhoto(photoID string, id string) error {
+	_, err := repo.GetPhoto(photoID, id)
+	if err != nil {
+		return err
+	}
+	_, err = repo.DB.Exec("DELETE FROM maintenance_photos WHERE id = $1 AND maintenance_request_id = $2", photoID, id)
+	return err
+}
+
+// WorkOrderRepository - WorkOrder repository manager.
+type WorkOrderRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeWorkOrderRepository - WorkOrderRepository constructor.
+func MakeWorkOrderRepository() (WorkOrderRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return WorkOrderRepository{}, err
+	}
+	return WorkOrderRepository{DB: db}, nil
+}
+
+// GetAll - GetAll WorkOrders of a MaintenanceRequest in repo.
+func (repo *WorkOrderRepository) GetAll(requestID string) ([]models.WorkOrder, error) {
+	orders := []models.WorkOrder{}
+	err := repo.DB.Select(&orders, "SELECT * FROM work_orders WHERE maintenance_request_id = $1 ORDER BY created_at ASC", requestID)
+	return orders, err
+}
+
+// Get - Retrive a WorkOrder in repo by its ID.
+func (repo *WorkOrderRepository) Get(id string) (models.WorkOrder, error) {
+	order := models.WorkOrder{}
+	err := repo.DB.Get(&order, "SELECT * FROM work_orders WHERE id = $1", id)
+	if err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+// GetFromRequest - Retrive a WorkOrder in repo by its ID and MaintenanceRequest ID.
+func (repo *WorkOrderRepository) GetFromRequest(id string, requestID string) (models.WorkOrder, error) {
+	order := models.WorkOrder{}
+	err := repo.DB.Get(&order, "SELECT * FROM work_orders WHERE id = $1 AND maintenance_request_id = $2", id, requestID)
+	if err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+// Create - Persists a WorkOrder in repo.
+// A triaged request becomes assigned with its first work order.
+func (repo *WorkOrderRepository) Create(order *models.WorkOrder) error {
+	order.SetID()
+	order.SetCreationValues()
+	tx := repo.DB.MustBegin()
+	_, err := tx.NamedExec(workOrderInsertSQL, order)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(requestStatusSQL, models.MaintenanceStatusAssigned, order.MaintenanceRequestID.String, models.MaintenanceStatusTriaged)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update - Update a WorkOrder in repo.
+// Request status follows its work orders: it gets in progress when one starts
+// and done when none is left pending.
+func (repo *WorkOrderRepository) Update(order *models.WorkOrder) error {
+	// Update audit values
+	order.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(order.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := WorkOrderChanges(order, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE work_orders SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", order.ID.String))
+	//logger.Debug(query.String())
+	tx := repo.DB.MustBegin()
+	_, err = tx.NamedExec(query.String(), order)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, ok := changes["status"]; ok {
+		requestID := reference.MaintenanceRequestID.String
+		switch order.Status.String {
+		case models.WorkOrderStatusInProgress:
+			_, err = tx.Exec(requestStatusSQL, models.MaintenanceStatusInProgress, requestID, models.MaintenanceStatusAssigned)
+		case models.WorkOrderStatusCompleted, models.WorkOrderStatusCancelled:
+			_, err = tx.Exec(requestDoneSQL, models.MaintenanceStatusDone, requestID, models.MaintenanceStatusInProgress, models.WorkOrderStatusOpen, models.WorkOrderStatusInProgress)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
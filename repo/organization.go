@@ -27,12 +27,24 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/types"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // Import pq without side effects
 )
 
+// organizationPartySQL - True if user owns the organization, holds a role in it, rents under one of its leases,
+// reported one of its maintenance requests, is assigned one of its work orders or booked one of its viewings.
+const organizationPartySQL = `SELECT EXISTS (SELECT 1 FROM organizations WHERE id = $1 AND user_id = $2)
+ OR EXISTS (SELECT 1 FROM user_roles WHERE organization_id = $1 AND user_id = $2)
+ OR EXISTS (SELECT 1 FROM lease_tenants lt INNER JOIN leases l ON l.id = lt.lease_id WHERE l.organization_id = $1 AND lt.user_id = $2)
+ OR EXISTS (SELECT 1 FROM maintenance_requests WHERE organization_id = $1 AND reported_by = $2)
+ OR EXISTS (SELECT 1 FROM work_orders wo LEFT JOIN organizations vendor ON vendor.id = wo.assignee_organization_id
+   WHERE wo.organization_id = $1 AND (wo.assignee_user_id = $2 OR vendor.user_id = $2))
+ OR EXISTS (SELECT 1 FROM viewings WHERE organization_id = $1 AND prospect_user_id = $2)`
+
 // OrganizationRepository - Organization repository manager.
 type OrganizationRepository struct {
 	DB *sqlx.DB
@@ -54,12 +66,20 @@ func (repo *OrganizationRepository) GetAll() ([]models.Organization, error) {
 	return organizations, err
 }
 
+// GetAllByLocation - Get Organizations in repo matching a spatial filter ordered by distance.
+func (repo *OrganizationRepository) GetAllByLocation(filter types.GeoFilter) ([]models.Organization, error) {
+	organizations := []models.Organization{}
+	query, args := geoSelect("organizations", "geolocation", "", []interface{}{}, filter)
+	err := repo.DB.Select(&organizations, query, args...)
+	return organizations, err
+}
+
 // Create - Persists a Organization in repo.
 func (repo *OrganizationRepository) Create(organization *models.Organization) error {
 	organization.SetID()
 	organization.SetCreationValues()
 	tx := repo.DB.MustBegin()
-	organizationInsertSQL := "INSERT INTO organizations (id, name, description, user_username, user_id, geolocation, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :user_username, :user_id, :geolocation, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	organizationInsertSQL := "INSERT INTO organizations (id, name, description, user_username, user_id, search_language, geolocation, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :user_username, :user_id, :search_language, :geolocation, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
 	_, err := tx.NamedExec(organizationInsertSQL, organization)
 	if err != nil {
 		return err
@@ -91,6 +111,21 @@ func (repo *OrganizationRepository) GetByName(name string) (models.Organization,
 	return u, nil
 }
 
+// IsMember - Returns true if user owns the Organization or holds a role in it.
+func (repo *OrganizationRepository) IsMember(id string, userID string) (bool, error) {
+	isMember := false
+	err := repo.DB.Get(&isMember, "SELECT EXISTS (SELECT 1 FROM organizations WHERE id = $1 AND user_id = $2) OR EXISTS (SELECT 1 FROM user_roles WHERE organization_id = $1 AND user_id = $2)", id, userID)
+	return isMember, err
+}
+
+// IsParty - Returns true if user is a member of the Organization or deals with it
+// as tenant, maintenance reporter or assignee or viewing prospect.
+func (repo *OrganizationRepository) IsParty(id string, userID string) (bool, error) {
+	isParty := false
+	err := repo.DB.Get(&isParty, organizationPartySQL, id, userID)
+	return isParty, err
+}
+
 // Update - Update a organization in repo.
 func (repo *OrganizationRepository) Update(organization *models.Organization) error {
 	// Update password and audit values
@@ -126,6 +161,7 @@ func (repo *OrganizationRepository) Update(organization *models.Organization) er
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	return err
 }
 
@@ -135,6 +171,7 @@ func (repo *OrganizationRepository) Delete(id string) error {
 	organizationDeleteSQL := fmt.Sprintf("DELETE FROM organizations WHERE id = '%s'", id)
 	_ = tx.MustExec(organizationDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
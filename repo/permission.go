@@ -27,6 +27,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
@@ -34,6 +35,10 @@ import (
 	_ "github.com/lib/pq" // Import pq without side effects
 )
 
+// userRolesSQL - Roles of the User given as first param along with the roles they inherit from.
+// Inherited roles are scoped to the organization of the assignment, parents always belong to the same one.
+const userRolesSQL = "WITH RECURSIVE user_role_ids(role_id, organization_id) AS (SELECT role_id, organization_id FROM user_roles WHERE user_id = $1 UNION SELECT role_parents.parent_id, user_role_ids.organization_id FROM role_parents INNER JOIN user_role_ids ON role_parents.role_id = user_role_ids.role_id) "
+
 // PermissionRepository - Permission repository manager.
 type PermissionRepository struct {
 	DB *sqlx.DB
@@ -136,34 +141,26 @@ func (repo *PermissionRepository) Delete(id string) error {
 	permissionDeleteSQL := fmt.Sprintf("DELETE FROM permissions WHERE id = '%s'", id)
 	_ = tx.MustExec(permissionDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// GetUserPermissionIDs - Returns an array of Permissions IDs that are assigned to some User
+// GetUserPermissionIDs - Returns an array of Permissions IDs that are assigned to some User,
+// directly through its roles or inherited from their parent roles.
 func (repo *PermissionRepository) GetUserPermissionIDs(userID string) ([]string, error) {
 	permisisons := []string{}
 	var query bytes.Buffer
-	query.WriteString("SELECT permissions.id FROM permissions INNER JOIN role_permissions ")
-	query.WriteString("ON permissions.id = role_permissions.permission_id ")
-	query.WriteString("INNER JOIN user_roles ")
-	query.WriteString("ON role_permissions.role_id = user_roles.role_id ")
-	query.WriteString("INNER JOIN users ")
-	query.WriteString("ON user_roles.user_id = users.id ")
-	query.WriteString(fmt.Sprintf("WHERE users.id = '%s';", userID))
-	logger.Debugf("Query: %s", query.String())
-	tx := repo.DB.MustBegin()
-	err := repo.DB.Select(&permisisons, query.String())
+	query.WriteString(userRolesSQL)
+	query.WriteString("SELECT DISTINCT role_permissions.permission_id FROM role_permissions INNER JOIN user_role_ids ")
+	query.WriteString("ON role_permissions.role_id = user_role_ids.role_id")
+	err := repo.DB.Select(&permisisons, query.String(), userID)
 	if err != nil {
 		logger.Dump(err)
 		return permisisons, err
 	}
-	err = tx.Commit()
-	if err != nil {
-		return permisisons, err
-	}
 	return permisisons, nil
 }
 
@@ -193,41 +190,55 @@ func (repo *PermissionRepository) GetEnablingPermissionIDs(resourceIDorTag strin
 	return permisisons, nil
 }
 
-// SELECT count(permissions.id) FROM permissions INNER JOIN resource_permissions ON permissions.id = resource_permissions.permission_id INNER JOIN resources ON resources.id = resource_permissions.resource_id WHERE resources.tag = 'f254cfe4' AND permissions.id IN (SELECT permissions.id FROM permissions INNER JOIN role_permissions ON permissions.id = role_permissions.permission_id INNER JOIN user_roles ON role_permissions.role_id = user_roles.role_id INNER JOIN users ON user_roles.user_id = users.id WHERE users.id = '5958b185-8150-4aae-b53f-0c44771ddec5')
-
-// HasPermission - Returns true if user has at least one permission over a resource.
-func (repo *PermissionRepository) HasPermission(resourceIDorTag, userID string) (bool, error) {
-	hasPermission := false
+// Can - Returns true if user has, through its roles or their parent roles, a permission granting an action over a resource.
+// Permissions granting manage allow any action.
+// If an organization ID is given the resource and the user roles must belong to that organization.
+func (repo *PermissionRepository) Can(userID, action, resourceIDorTag, orgID string) (bool, error) {
+	can := false
 	var query bytes.Buffer
-	query.WriteString("SELECT COUNT(permissions.id) > 0 FROM permissions INNER JOIN resource_permissions ")
-	query.WriteString("ON permissions.id = resource_permissions.permission_id ")
-	query.WriteString("INNER JOIN resources ")
+	query.WriteString(userRolesSQL)
+	query.WriteString("SELECT EXISTS (SELECT 1 FROM resources INNER JOIN resource_permissions ")
 	query.WriteString("ON resources.id = resource_permissions.resource_id ")
+	query.WriteString("INNER JOIN role_permissions ")
+	query.WriteString("ON resource_permissions.permission_id = role_permissions.permission_id ")
+	query.WriteString("INNER JOIN user_role_ids ")
+	query.WriteString("ON role_permissions.role_id = user_role_ids.role_id ")
 	if len(resourceIDorTag) == 36 {
-		query.WriteString(fmt.Sprintf("WHERE resources.id = %s ", resourceIDorTag))
+		query.WriteString("WHERE resources.id::text = $2 ")
 	} else {
-		query.WriteString(fmt.Sprintf("WHERE resources.tag = '%s' ", resourceIDorTag))
+		query.WriteString("WHERE resources.tag = $2 ")
 	}
-	query.WriteString("AND permissions.id ")
-	query.WriteString("IN (SELECT permissions.id FROM permissions INNER JOIN role_permissions ")
-	query.WriteString("ON permissions.id = role_permissions.permission_id ")
-	query.WriteString("INNER JOIN user_roles ")
-	query.WriteString("ON role_permissions.role_id = user_roles.role_id ")
-	query.WriteString("INNER JOIN users ")
-	query.WriteString("ON user_roles.user_id = users.id ")
-	query.WriteString(fmt.Sprintf("WHERE users.id = '%s')", userID))
+	query.WriteString("AND ($3 = '' OR (resources.organization_id::text = $3 AND user_role_ids.organization_id::text = $3)) ")
+	query.WriteString("AND resource_permissions.action IN ($4, $5))")
+	err := repo.DB.Get(&can, query.String(), userID, resourceIDorTag, orgID, action, models.ActionManage)
+	return can, err
+}
 
-	logger.Debugf("Query: %s", query.String())
+// CanAccess - Returns true if user has been granted, directly or through its roles or their parent roles,
+// an action over a single entity of an organization.
+// Entries granting manage allow any action.
+func (repo *PermissionRepository) CanAccess(userID, action, entityType, entityID, orgID string) (bool, error) {
+	can := false
+	var query bytes.Buffer
+	query.WriteString(userRolesSQL)
+	query.WriteString("SELECT EXISTS (SELECT 1 FROM access_control_entries ")
+	query.WriteString("WHERE entity_type = $2 AND entity_id::text = $3 AND organization_id::text = $4 ")
+	query.WriteString("AND action IN ($5, $6) ")
+	query.WriteString("AND (user_id = $1 OR role_id IN (SELECT role_id FROM user_role_ids WHERE organization_id::text = $4)))")
+	err := repo.DB.Get(&can, query.String(), userID, entityType, entityID, orgID, action, models.ActionManage)
+	return can, err
+}
 
-	tx := repo.DB.MustBegin()
-	err := repo.DB.Get(&hasPermission, query.String())
-	if err != nil {
-		logger.Dump(err)
-		return false, err
-	}
-	err = tx.Commit()
-	if err != nil {
-		return false, err
-	}
-	return hasPermission, nil
+// GetGrantedEntityIDs - Returns the IDs of the entities of some type over which user has been granted an action,
+// directly or through its roles or their parent roles.
+func (repo *PermissionRepository) GetGrantedEntityIDs(userID, action, entityType, orgID string) ([]string, error) {
+	ids := []string{}
+	var query bytes.Buffer
+	query.WriteString(userRolesSQL)
+	query.WriteString("SELECT DISTINCT entity_id FROM access_control_entries ")
+	query.WriteString("WHERE entity_type = $2 AND organization_id::text = $3 ")
+	query.WriteString("AND action IN ($4, $5) ")
+	query.WriteString("AND (user_id = $1 OR role_id IN (SELECT role_id FROM user_role_ids WHERE organization_id::text = $3))")
+	err := repo.DB.Select(&ids, query.String(), userID, entityType, orgID, action, models.ActionManage)
+	return ids, err
 }
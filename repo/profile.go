@@ -27,15 +27,16 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"time"
 
-	"github.com/adrianpk/fundacja/bootstrap"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/storage"
+	"github.com/adrianpk/fundacja/types"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // Import pq without side effects
+	"github.com/markbates/pop/nulls"
 )
 
 // ProfileRepository - Profile repository manager.
@@ -59,6 +60,14 @@ func (repo *ProfileRepository) GetAll() ([]models.Profile, error) {
 	return profiles, err
 }
 
+// GetAllByLocation - Get Profiles in repo matching a spatial filter ordered by distance.
+func (repo *ProfileRepository) GetAllByLocation(filter types.GeoFilter) ([]models.Profile, error) {
+	profiles := []models.Profile{}
+	query, args := geoSelect("profiles", "geolocation", "", []interface{}{}, filter)
+	err := repo.DB.Select(&profiles, query, args...)
+	return profiles, err
+}
+
 // Create - Persists a Profile in repo.
 func (repo *ProfileRepository) Create(profile *models.Profile) error {
 	profile.SetID()
@@ -159,54 +168,161 @@ func (repo *ProfileRepository) DeleteByUserID(userID string) error {
 	return nil
 }
 
-// SaveProfileAvatar - Store profile's avatar.
-func (repo *ProfileRepository) SaveProfileAvatar(profile *models.Profile) error {
-	path := fmt.Sprintf("%s/public/users/%s/profile", bootstrap.BaseDir, profile.ID.String)
-	assurePath(path)
-	uri := fmt.Sprintf("%s/avatar_%d.png", path, timestamp())
-	if png, ok := profile.AvatarAsPNG(); ok == nil {
-		defaultFilePermission := os.FileMode(int(0777))
-		err := ioutil.WriteFile(uri, png, defaultFilePermission)
+// SaveProfileAvatar - Store profile's avatar renditions generated from uploaded data.
+func (repo *ProfileRepository) SaveProfileAvatar(profile *models.Profile, data []byte) error {
+	return repo.saveProfileImage(profile, models.ProfileAvatarImage, data)
+}
+
+// SaveProfileHeader - Store profile's header image renditions generated from uploaded data.
+func (repo *ProfileRepository) SaveProfileHeader(profile *models.Profile, data []byte) error {
+	return repo.saveProfileImage(profile, models.ProfileHeaderImage, data)
+}
+
+// DeleteProfileAvatar - Delete profile's avatar.
+func (repo *ProfileRepository) DeleteProfileAvatar(profile *models.Profile) error {
+	return repo.deleteProfileImage(profile, models.ProfileAvatarImage)
+}
+
+// DeleteProfileHeader - Delete profile's header image.
+func (repo *ProfileRepository) DeleteProfileHeader(profile *models.Profile) error {
+	return repo.deleteProfileImage(profile, models.ProfileHeaderImage)
+}
+
+// MoveLegacyImages - Moves avatars kept in database and images kept as filesystem paths to the blob store.
+// Returns the number of profiles moved, failing ones are logged and left as they are.
+func (repo *ProfileRepository) MoveLegacyImages() (int, error) {
+	profiles := []models.Profile{}
+	err := repo.DB.Select(&profiles, "SELECT * FROM profiles WHERE octet_length(avatar) > 0 OR avatar_uri LIKE '/%' OR header_uri LIKE '/%'")
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	for i := range profiles {
+		profile := &profiles[i]
+		profile.GenAvatarBase64()
+		err = nil
+		if len(profile.Avatar.ByteSlice) > 0 {
+			var data []byte
+			data, err = profile.AvatarData()
+			if err == nil {
+				err = repo.SaveProfileAvatar(profile, data)
+			}
+		} else if models.IsLegacyImageURI(profile.AvatarURI.String) {
+			err = repo.moveLegacyImage(profile, models.ProfileAvatarImage, profile.AvatarURI.String)
+		}
+		if err == nil && models.IsLegacyImageURI(profile.HeaderURI.String) {
+			err = repo.moveLegacyImage(profile, models.ProfileHeaderImage, profile.HeaderURI.String)
+		}
 		if err != nil {
-			return err
+			logger.Errorf("Cannot move images of profile %s: %s", profile.ID.String, err.Error())
+			continue
 		}
-		profile.AvatarURI = models.ToNullsString(uri)
-		repo.Update(profile)
+		moved = moved + 1
 	}
-	return nil
+	return moved, nil
 }
 
-// DeleteProfileAvatar - Delete profile's avatar.
-func (repo *ProfileRepository) DeleteProfileAvatar(profile *models.Profile) error {
-	err := os.Remove(profile.AvatarURI.String)
+func (repo *ProfileRepository) moveLegacyImage(profile *models.Profile, image, path string) error {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		logger.Errorf("Cannot delete %s", profile.AvatarURI.String)
 		return err
 	}
-	profile.AvatarURI = models.ToNullsString("")
-	err = repo.Update(profile)
+	return repo.saveProfileImage(profile, image, data)
+}
+
+// saveProfileImage - Stores image renditions under a new key replacing the previous ones.
+func (repo *ProfileRepository) saveProfileImage(profile *models.Profile, image string, data []byte) error {
+	renditions, err := models.ProfileImageRenditions(image, data)
 	if err != nil {
-		logger.Errorf("Error deleting profile's avatar for user %s", profile.UserID.String)
 		return err
 	}
+	store, err := storage.GetStore()
+	if err != nil {
+		return err
+	}
+	key := models.ProfileImageKey(profile.ID.String, image)
+	for name, rendition := range renditions {
+		_, err = store.Put(models.ProfileImageRenditionKey(key, name), bytes.NewReader(rendition))
+		if err != nil {
+			deleteProfileImageBlobs(store, image, key)
+			return err
+		}
+	}
+	previous, err := repo.setProfileImage(profile, image, models.ToNullsString(key))
+	if err != nil {
+		deleteProfileImageBlobs(store, image, key)
+		return err
+	}
+	deleteProfileImageBlobs(store, image, previous)
 	return nil
 }
 
-func storeProfileHeader(profile models.Profile) error {
+// deleteProfileImage - Removes an image key and its blobs.
+func (repo *ProfileRepository) deleteProfileImage(profile *models.Profile, image string) error {
+	previous, err := repo.setProfileImage(profile, image, nulls.String{})
+	if err != nil {
+		logger.Errorf("Error deleting profile's %s for user %s", image, profile.UserID.String)
+		return err
+	}
+	store, err := storage.GetStore()
+	if err != nil {
+		return err
+	}
+	deleteProfileImageBlobs(store, image, previous)
 	return nil
 }
 
-func assurePath(path string) error {
-	defaultFilePermission := os.FileMode(int(0777))
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return os.MkdirAll(path, defaultFilePermission)
+// deleteProfileImageBlobs - Removes the renditions stored under a key, failures are only logged.
+func deleteProfileImageBlobs(store storage.BlobStore, image, key string) {
+	if key == "" || models.IsLegacyImageURI(key) {
+		return
+	}
+	deleted := map[string]bool{}
+	for _, name := range models.ProfileImageRenditionNames(image) {
+		rendition := models.ProfileImageRenditionKey(key, name)
+		if deleted[rendition] {
+			continue
+		}
+		deleted[rendition] = true
+		err := store.Delete(rendition)
+		if err != nil {
+			logger.Errorf("Cannot delete %s", rendition)
+		}
 	}
-	return nil
 }
 
-func timestamp() int64 {
-	now := time.Now()
-	nanos := now.UnixNano()
-	millis := nanos / 1000000
-	return millis
+// setProfileImage - Updates the key of a profile image returning the previous one,
+// avatars kept in database are dropped once they have a key.
+func (repo *ProfileRepository) setProfileImage(profile *models.Profile, image string, key nulls.String) (string, error) {
+	column := "header_uri"
+	if image == models.ProfileAvatarImage {
+		column = "avatar_uri"
+	}
+	tx := repo.DB.MustBegin()
+	var previous nulls.String
+	err := tx.Get(&previous, fmt.Sprintf("SELECT %s FROM profiles WHERE id = $1 FOR UPDATE", column), profile.ID.String)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	query := fmt.Sprintf("UPDATE profiles SET %s = $1, updated_at = $2 WHERE id = $3", column)
+	if image == models.ProfileAvatarImage {
+		query = "UPDATE profiles SET avatar_uri = $1, avatar = NULL, updated_at = $2 WHERE id = $3"
+	}
+	_, err = tx.Exec(query, key, time.Now(), profile.ID.String)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return "", err
+	}
+	if image == models.ProfileAvatarImage {
+		profile.AvatarURI = key
+		profile.Avatar = nulls.ByteSlice{}
+	} else {
+		profile.HeaderURI = key
+	}
+	return previous.String, nil
 }
@@ -26,6 +26,7 @@ package repo
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
@@ -54,12 +55,31 @@ func (repo *PropertiesSetRepository) GetAll(holderID string) ([]models.Propertie
 	return propertiesSets, err
 }
 
+// GetAllFaceted - Get PropertiesSets of a holder in repo including properties that match the facet query.
+func (repo *PropertiesSetRepository) GetAllFaceted(holderID string, query models.FacetQuery) ([]models.PropertiesSet, error) {
+	propertiesSets := []models.PropertiesSet{}
+	conditions, args := facetConditions(propertiesSetFacetScope, "properties_sets", query, []interface{}{holderID})
+	where := strings.Join(append([]string{"holder_id = $1"}, conditions...), " AND ")
+	stmt := fmt.Sprintf("SELECT * FROM properties_sets WHERE %s ORDER BY name ASC", where)
+	err := repo.DB.Select(&propertiesSets, stmt, args...)
+	return propertiesSets, err
+}
+
+// GetFacets - Count PropertiesSets per value bucket of requested properties.
+func (repo *PropertiesSetRepository) GetFacets(propertiesSets []models.PropertiesSet, requests []models.FacetRequest) ([]models.Facet, error) {
+	ids := make([]string, len(propertiesSets))
+	for i, propertiesSet := range propertiesSets {
+		ids[i] = propertiesSet.ID.String
+	}
+	return facetCounts(repo.DB, propertiesSetFacetScope, ids, requests)
+}
+
 // Create - Persists a PropertiesSet in repo.
 func (repo *PropertiesSetRepository) Create(propertiesSet *models.PropertiesSet) error {
 	propertiesSet.SetID()
 	propertiesSet.SetCreationValues()
 	tx := repo.DB.MustBegin()
-	propertiesSetInsertSQL := "INSERT INTO properties_sets (id, name, description, geolocation, created_by, is_active, is_logical_deleted, created_at, updated_at, organization_id) VALUES (:id, :name, :description, :geolocation, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at, :organization_id)"
+	propertiesSetInsertSQL := "INSERT INTO properties_sets (id, name, description, position, holder_id, template_id, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :position, :holder_id, :template_id, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
 	_, err := tx.NamedExec(propertiesSetInsertSQL, propertiesSet)
 	if err != nil {
 		return err
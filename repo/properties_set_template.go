@@ -0,0 +1,214 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+const propertyTemplateInsertSQL = "INSERT INTO property_templates (id, name, description, value_type, required, min_value, max_value, allowed_values, unit, position, properties_set_template_id, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :value_type, :required, :min_value, :max_value, :allowed_values, :unit, :position, :properties_set_template_id, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+
+// PropertiesSetTemplateRepository - PropertiesSetTemplate repository manager.
+type PropertiesSetTemplateRepository struct {
+	DB *sqlx.DB
+}
+
+// MakePropertiesSetTemplateRepository - PropertiesSetTemplateRepository constructor.
+func MakePropertiesSetTemplateRepository() (PropertiesSetTemplateRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return PropertiesSetTemplateRepository{}, err
+	}
+	return PropertiesSetTemplateRepository{DB: db}, nil
+}
+
+// GetAll - GetAll PropertiesSetTemplates available to an Organization in repo.
+// Templates without organization are available to all of them.
+func (repo *PropertiesSetTemplateRepository) GetAll(orgid string) ([]models.PropertiesSetTemplate, error) {
+	templates := []models.PropertiesSetTemplate{}
+	err := repo.DB.Select(&templates, "SELECT * FROM properties_set_templates WHERE organization_id = $1 OR organization_id IS NULL ORDER BY name ASC", orgid)
+	if err != nil {
+		return templates, err
+	}
+	for i := range templates {
+		templates[i].PropertyTemplates, err = repo.GetPropertyTemplates(templates[i].ID.String)
+		if err != nil {
+			return templates, err
+		}
+	}
+	return templates, nil
+}
+
+// Create - Persists a PropertiesSetTemplate and its PropertyTemplates in repo.
+func (repo *PropertiesSetTemplateRepository) Create(template *models.PropertiesSetTemplate) error {
+	template.SetID()
+	template.SetCreationValues()
+	tx := repo.DB.MustBegin()
+	templateInsertSQL := "INSERT INTO properties_set_templates (id, name, description, organization_id, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :organization_id, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	_, err := tx.NamedExec(templateInsertSQL, template)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = repo.insertPropertyTemplates(tx, template)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get - Retrive a PropertiesSetTemplate in repo by its ID.
+func (repo *PropertiesSetTemplateRepository) Get(id string) (models.PropertiesSetTemplate, error) {
+	template := models.PropertiesSetTemplate{}
+	err := repo.DB.Get(&template, "SELECT * FROM properties_set_templates WHERE id = $1", id)
+	if err != nil {
+		return template, err
+	}
+	template.PropertyTemplates, err = repo.GetPropertyTemplates(id)
+	return template, err
+}
+
+// GetFromOrganization - Retrive a PropertiesSetTemplate available to an Organization by its ID.
+func (repo *PropertiesSetTemplateRepository) GetFromOrganization(id string, orgid string) (models.PropertiesSetTemplate, error) {
+	template := models.PropertiesSetTemplate{}
+	err := repo.DB.Get(&template, "SELECT * FROM properties_set_templates WHERE id = $1 AND (organization_id = $2 OR organization_id IS NULL)", id, orgid)
+	if err != nil {
+		return template, err
+	}
+	template.PropertyTemplates, err = repo.GetPropertyTemplates(id)
+	return template, err
+}
+
+// GetByNameInOrganization - Retrive a PropertiesSetTemplate available to an Organization by its name.
+func (repo *PropertiesSetTemplateRepository) GetByNameInOrganization(name string, orgid string) (models.PropertiesSetTemplate, error) {
+	template := models.PropertiesSetTemplate{}
+	err := repo.DB.Get(&template, "SELECT * FROM properties_set_templates WHERE name = $1 AND (organization_id = $2 OR organization_id IS NULL) ORDER BY organization_id NULLS LAST LIMIT 1", name, orgid)
+	if err != nil {
+		return template, err
+	}
+	template.PropertyTemplates, err = repo.GetPropertyTemplates(template.ID.String)
+	return template, err
+}
+
+// GetPropertyTemplates - Retrive PropertyTemplates declared by a PropertiesSetTemplate.
+func (repo *PropertiesSetTemplateRepository) GetPropertyTemplates(id string) ([]models.PropertyTemplate, error) {
+	propertyTemplates := []models.PropertyTemplate{}
+	err := repo.DB.Select(&propertyTemplates, "SELECT * FROM property_templates WHERE properties_set_template_id = $1 ORDER BY position ASC, name ASC", id)
+	return propertyTemplates, err
+}
+
+// GetPropertyTemplate - Retrive a PropertyTemplate by its PropertiesSetTemplate ID and name.
+func (repo *PropertiesSetTemplateRepository) GetPropertyTemplate(id, name string) (models.PropertyTemplate, error) {
+	propertyTemplate := models.PropertyTemplate{}
+	err := repo.DB.Get(&propertyTemplate, "SELECT * FROM property_templates WHERE properties_set_template_id = $1 AND name = $2", id, name)
+	return propertyTemplate, err
+}
+
+// Update - Update a PropertiesSetTemplate in repo.
+// PropertyTemplates are replaced when present.
+func (repo *PropertiesSetTemplateRepository) Update(template *models.PropertiesSetTemplate) error {
+	// Update audit values
+	template.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(template.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := PropertiesSetTemplateChanges(template, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE properties_set_templates SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", template.ID.String))
+	tx := repo.DB.MustBegin()
+	_, err = tx.NamedExec(query.String(), template)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if template.PropertyTemplates != nil {
+		_, err = tx.Exec("DELETE FROM property_templates WHERE properties_set_template_id = $1", template.ID.String)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = repo.insertPropertyTemplates(tx, template)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete - Deletes PropertiesSetTemplate and its PropertyTemplates from database.
+func (repo *PropertiesSetTemplateRepository) Delete(id string) error {
+	tx := repo.DB.MustBegin()
+	tx.MustExec("DELETE FROM property_templates WHERE properties_set_template_id = $1", id)
+	tx.MustExec("DELETE FROM properties_set_templates WHERE id = $1", id)
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (repo *PropertiesSetTemplateRepository) insertPropertyTemplates(tx *sqlx.Tx, template *models.PropertiesSetTemplate) error {
+	for i := range template.PropertyTemplates {
+		propertyTemplate := &template.PropertyTemplates[i]
+		propertyTemplate.ID = models.ToNullsString("")
+		propertyTemplate.SetID()
+		propertyTemplate.SetCreationValues()
+		propertyTemplate.CreatedBy = template.CreatedBy
+		propertyTemplate.PropertiesSetTemplateID = template.ID
+		if !propertyTemplate.Position.Valid {
+			propertyTemplate.Position = models.ToNullsInt64(int64(i))
+		}
+		_, err := tx.NamedExec(propertyTemplateInsertSQL, propertyTemplate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -25,10 +25,13 @@ package repo
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/types"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // Import pq without side effects
 )
@@ -54,13 +57,41 @@ func (repo *PropertyRepository) GetAll(propsetID string) ([]models.Property, err
 	return properties, err
 }
 
+// GetAllByLocation - Get Properties from a PropertiesSet in repo matching a spatial filter ordered by distance.
+func (repo *PropertyRepository) GetAllByLocation(propsetID string, filter types.GeoFilter) ([]models.Property, error) {
+	properties := []models.Property{}
+	query, args := geoSelect("properties", "geolocation_value", "properties_set_id = $1", []interface{}{propsetID}, filter)
+	err := repo.DB.Select(&properties, query, args...)
+	return properties, err
+}
+
+// GetAllFiltered - Get Properties from a PropertiesSet in repo matching value and spatial filters.
+func (repo *PropertyRepository) GetAllFiltered(propsetID string, filter models.PropertyFilter, geoFilter types.GeoFilter) ([]models.Property, error) {
+	properties := []models.Property{}
+	conditions, args := propertyFilterConditions("", filter, []interface{}{propsetID})
+	where := strings.Join(append([]string{"properties_set_id = $1"}, conditions...), " AND ")
+	if !geoFilter.IsEmpty() {
+		query, args := geoSelect("properties", "geolocation_value", where, args, geoFilter)
+		err := repo.DB.Select(&properties, query, args...)
+		return properties, err
+	}
+	query := fmt.Sprintf("SELECT * FROM properties WHERE %s ORDER BY name ASC", where)
+	err := repo.DB.Select(&properties, query, args...)
+	return properties, err
+}
+
 // Create - Persists a Property in repo.
 func (repo *PropertyRepository) Create(property *models.Property) error {
+	// Template
+	err := repo.Validate(property)
+	if err != nil {
+		return err
+	}
 	property.SetID()
 	property.SetCreationValues()
 	tx := repo.DB.MustBegin()
-	propertyInsertSQL := "INSERT INTO properties (id, name, description, string_value, int_value, float_value, boolean_value, timestamp_value, geolocation_value, value_type, position, properties_set_id, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :string_value, :int_value, :float_value, :boolean_value, :timestamp_value, :geolocation_value, :value_type, :position, :properties_set_id, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
-	_, err := tx.NamedExec(propertyInsertSQL, property)
+	propertyInsertSQL := "INSERT INTO properties (id, name, description, string_value, int_value, float_value, boolean_value, timestamp_value, geolocation_value, polygon_value, multipolygon_value, linestring_value, money_value, currency_value, date_range_value, reference_type, reference_id, list_value, value_type, position, properties_set_id, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :string_value, :int_value, :float_value, :boolean_value, :timestamp_value, :geolocation_value, :polygon_value, :multipolygon_value, :linestring_value, :money_value, :currency_value, :date_range_value, :reference_type, :reference_id, :list_value, :value_type, :position, :properties_set_id, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	_, err = tx.NamedExec(propertyInsertSQL, property)
 	if err != nil {
 		return err
 	}
@@ -100,6 +131,12 @@ func (repo *PropertyRepository) Update(property *models.Property) error {
 	if err != nil {
 		return err
 	}
+	// Template of the stored set, properties don't move between sets
+	property.PropertiesSetID = reference.PropertiesSetID
+	err = repo.Validate(property)
+	if err != nil {
+		return err
+	}
 	// Customized query
 	changes := PropertyChanges(property, reference)
 	number := len(changes)
@@ -129,6 +166,44 @@ func (repo *PropertyRepository) Update(property *models.Property) error {
 	return err
 }
 
+// Validate - Validates a Property against its PropertiesSet template.
+// Properties in sets without template are only checked for value consistency.
+// Returns models.ValidationErrors if property violates the template.
+func (repo *PropertyRepository) Validate(property *models.Property) error {
+	var templateID sql.NullString
+	err := repo.DB.Get(&templateID, "SELECT template_id FROM properties_sets WHERE id = $1", property.PropertiesSetID.String)
+	if err == sql.ErrNoRows {
+		return models.ValidationErrors{"propertiesSetID": "properties set does not exist"}
+	}
+	if err != nil {
+		return err
+	}
+	if !templateID.Valid {
+		errs := property.Validate()
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+	propertyTemplate := models.PropertyTemplate{}
+	err = repo.DB.Get(&propertyTemplate, "SELECT * FROM property_templates WHERE properties_set_template_id = $1 AND name = $2", templateID.String, property.Name.String)
+	if err == sql.ErrNoRows {
+		return models.ValidationErrors{"name": fmt.Sprintf("'%s' is not declared in template", property.Name.String)}
+	}
+	if err != nil {
+		return err
+	}
+	// Value type defaults to template one
+	if property.ValueType.String == "" {
+		property.ValueType = propertyTemplate.ValueType
+	}
+	errs := propertyTemplate.ValidateProperty(property)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 // Delete - Deletes property from database.
 func (repo *PropertyRepository) Delete(id string) error {
 	tx := repo.DB.MustBegin()
@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/models"
+)
+
+// propertyFilterConditions - Builds SQL conditions over properties columns for filter.
+// 'alias' prefixes column names (i.e.: "p." or "").
+// Values are appended to 'args' and referenced as positional parameters.
+func propertyFilterConditions(alias string, filter models.PropertyFilter, args []interface{}) ([]string, []interface{}) {
+	conditions := []string{}
+	add := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, alias, len(args)))
+	}
+	if filter.Name != "" {
+		add("%sname = $%d", filter.Name)
+	}
+	if filter.ValueType != "" {
+		add("%svalue_type = $%d", filter.ValueType)
+	}
+	if filter.Value != "" {
+		add("%sstring_value = $%d", filter.Value)
+	}
+	if filter.Min.Valid {
+		add(numericValue+" >= $%[2]d", filter.Min.Float64)
+	}
+	if filter.Max.Valid {
+		add(numericValue+" <= $%[2]d", filter.Max.Float64)
+	}
+	if filter.Currency != "" {
+		add("%scurrency_value = $%d", filter.Currency)
+	}
+	if filter.Date.Valid {
+		add("%sdate_range_value @> $%d::date", filter.Date.Time.Format("2006-01-02"))
+	}
+	if filter.ReferenceType != "" {
+		add("%sreference_type = $%d", filter.ReferenceType)
+	}
+	if filter.ReferenceID != "" {
+		add("%sreference_id = $%d", filter.ReferenceID)
+	}
+	if len(filter.Contains) > 0 {
+		items, _ := json.Marshal(filter.Contains)
+		add("%slist_value @> $%d::jsonb", string(items))
+	}
+	return conditions, args
+}
+
+// numericValue - Numeric value of a property whatever its numeric value type.
+const numericValue = "COALESCE(%[1]sint_value::float, %[1]sfloat_value::float, %[1]smoney_value::float)"
@@ -27,6 +27,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/jmoiron/sqlx"
@@ -65,6 +66,7 @@ func (repo *ResourceRepository) Create(resource *models.Resource) error {
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -126,6 +128,7 @@ func (repo *ResourceRepository) Update(resource *models.Resource) error {
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	return err
 }
 
@@ -135,6 +138,7 @@ func (repo *ResourceRepository) Delete(id string) error {
 	resourceDeleteSQL := fmt.Sprintf("DELETE FROM resources WHERE id = '%s'", id)
 	_ = tx.MustExec(resourceDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
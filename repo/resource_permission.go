@@ -27,6 +27,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/jmoiron/sqlx"
@@ -59,12 +60,14 @@ func (repo *ResourcePermissionRepository) Create(resourcePermission *models.Reso
 	resourcePermission.SetID()
 	resourcePermission.SetCreationValues()
 	tx := repo.DB.MustBegin()
-	resourcePermissionInsertSQL := "INSERT INTO resource_permissions (id, name, description, geolocation, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at, organization_id, resource_id, permission:_id) VALUES (:id, :name, :description, :geolocation, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at, :organization_id, :resource_id, :permission_id)"
+	resourcePermissionInsertSQL := "INSERT INTO resource_permissions (id, name, description, created_by, is_active, is_logical_deleted, created_at, updated_at, organization_id, resource_id, permission_id, action) VALUES (:id, :name, :description, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at, :organization_id, :resource_id, :permission_id, :action)"
 	_, err := tx.NamedExec(resourcePermissionInsertSQL, resourcePermission)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -136,6 +139,7 @@ func (repo *ResourcePermissionRepository) Update(resourcePermission *models.Reso
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	return err
 }
 
@@ -145,6 +149,7 @@ func (repo *ResourcePermissionRepository) Delete(id string) error {
 	resourcePermissionDeleteSQL := fmt.Sprintf("DELETE FROM resource_permissions WHERE id = '%s'", id)
 	_ = tx.MustExec(resourcePermissionDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -157,6 +162,7 @@ func (repo *ResourcePermissionRepository) DeleteFromOrganization(id string, orgi
 	resourcePermissionDeleteSQL := fmt.Sprintf("DELETE FROM resource_permissions WHERE id = '%s' AND organization_id = '%s'", id, orgid)
 	_ = tx.MustExec(resourcePermissionDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -25,14 +25,25 @@ package repo
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // Import pq without side effect
 )
 
+const (
+	roleParentInsertSQL = "INSERT INTO role_parents (id, name, description, role_id, parent_id, organization_id, created_by, created_at) VALUES (:id, :name, :description, :role_id, :parent_id, :organization_id, :created_by, :created_at)"
+	// Advisory lock key class, hierarchy changes within an organization are serialized.
+	roleLockClass = 3
+	// roleAncestorsSQL - Role given as first param along with all the roles it inherits from.
+	// UNION discards repeated rows so the recursion ends even over a cyclic hierarchy.
+	roleAncestorsSQL = "WITH RECURSIVE ancestors(role_id) AS (SELECT $1::uuid UNION SELECT role_parents.parent_id FROM role_parents INNER JOIN ancestors ON role_parents.role_id = ancestors.role_id) "
+)
+
 // RoleRepository - Role repository manager.
 type RoleRepository struct {
 	DB *sqlx.DB
@@ -81,6 +92,13 @@ func (repo *RoleRepository) Get(id string) (models.Role, error) {
 	return u, nil
 }
 
+// GetFromOrganization - Retrive a Role in repo by its ID and Organization ID.
+func (repo *RoleRepository) GetFromOrganization(id, orgid string) (models.Role, error) {
+	role := models.Role{}
+	err := repo.DB.Get(&role, "SELECT * FROM roles WHERE id = $1 AND organization_id = $2", id, orgid)
+	return role, err
+}
+
 // GetByRoleName - Retrive a Role in repo by its rolename.
 func (repo *RoleRepository) GetByName(name string) (models.Role, error) {
 	u := models.Role{}
@@ -135,8 +153,105 @@ func (repo *RoleRepository) Delete(id string) error {
 	roleDeleteSQL := fmt.Sprintf("DELETE FROM roles WHERE id = '%s'", id)
 	_ = tx.MustExec(roleDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// GetParents - Roles a Role directly inherits from.
+func (repo *RoleRepository) GetParents(id string) ([]models.Role, error) {
+	roles := []models.Role{}
+	err := repo.DB.Select(&roles, "SELECT roles.* FROM roles INNER JOIN role_parents ON roles.id = role_parents.parent_id WHERE role_parents.role_id = $1 ORDER BY roles.name ASC", id)
+	return roles, err
+}
+
+// AddParent - Makes a Role inherit from another one of the same Organization.
+// Returns validation errors if the parent is not in the organization, is already a parent
+// or if the new link would close a cycle in the hierarchy.
+func (repo *RoleRepository) AddParent(parent *models.RoleParent) error {
+	if parent.RoleID.String == parent.ParentID.String {
+		return models.ValidationErrors{"parentID": "a role can't inherit from itself"}
+	}
+	parent.SetID()
+	parent.CreatedAt = models.NullsNowTime()
+	tx := repo.DB.MustBegin()
+	// Two concurrent links could each pass the cycle check and close a cycle together.
+	_, err := tx.Exec(lockSQL, roleLockClass, parent.OrganizationID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// Both roles in the organization
+	var count int
+	err = tx.Get(&count, "SELECT COUNT(*) FROM roles WHERE id IN ($1, $2) AND organization_id = $3", parent.RoleID.String, parent.ParentID.String, parent.OrganizationID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if count != 2 {
+		tx.Rollback()
+		return models.ValidationErrors{"parentID": "parent role not found in organization"}
+	}
+	// Already a parent
+	var exists bool
+	err = tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM role_parents WHERE role_id = $1 AND parent_id = $2)", parent.RoleID.String, parent.ParentID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if exists {
+		tx.Rollback()
+		return models.ValidationErrors{"parentID": "already a parent of this role"}
+	}
+	// The role can't be an ancestor of its new parent
+	var cycle bool
+	err = tx.Get(&cycle, roleAncestorsSQL+"SELECT EXISTS (SELECT 1 FROM ancestors WHERE role_id = $2)", parent.ParentID.String, parent.RoleID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if cycle {
+		tx.Rollback()
+		return models.ValidationErrors{"parentID": "parent role inherits from this role"}
+	}
+	_, err = tx.NamedExec(roleParentInsertSQL, parent)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	cache.Decisions.Invalidate()
+	return err
+}
+
+// RemoveParent - Removes a parent from a Role.
+func (repo *RoleRepository) RemoveParent(id, parentID string) error {
+	result, err := repo.DB.Exec("DELETE FROM role_parents WHERE role_id = $1 AND parent_id = $2", id, parentID)
+	if err != nil {
+		return err
+	}
+	cache.Decisions.Invalidate()
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetEffectivePermissions - Permissions granted to a Role, directly or inherited from its ancestors.
+func (repo *RoleRepository) GetEffectivePermissions(id string) ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	var query bytes.Buffer
+	query.WriteString(roleAncestorsSQL)
+	query.WriteString("SELECT * FROM permissions WHERE id IN ")
+	query.WriteString("(SELECT role_permissions.permission_id FROM role_permissions INNER JOIN ancestors ")
+	query.WriteString("ON role_permissions.role_id = ancestors.role_id) ")
+	query.WriteString("ORDER BY name ASC")
+	err := repo.DB.Select(&permissions, query.String(), id)
+	return permissions, err
+}
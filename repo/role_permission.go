@@ -27,6 +27,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/jmoiron/sqlx"
@@ -65,6 +66,7 @@ func (repo *RolePermissionRepository) Create(rolePermission *models.RolePermissi
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -136,6 +138,7 @@ func (repo *RolePermissionRepository) Update(rolePermission *models.RolePermissi
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	return err
 }
 
@@ -145,6 +148,7 @@ func (repo *RolePermissionRepository) Delete(id string) error {
 	rolePermissionDeleteSQL := fmt.Sprintf("DELETE FROM role_permissions WHERE id = '%s'", id)
 	_ = tx.MustExec(rolePermissionDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -157,6 +161,7 @@ func (repo *RolePermissionRepository) DeleteFromOrganization(id string, orgid st
 	rolePermissionDeleteSQL := fmt.Sprintf("DELETE FROM role_permissions WHERE id = '%s' AND organization_id = '%s'", id, orgid)
 	_ = tx.MustExec(rolePermissionDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// searchSQL - Documents matching a text query, visible to the user.
+// The text query is built once per configured language so the GIN index on vector is used.
+// Users see organizations, published listings and every document of the organizations they belong to,
+// users only if they share one of them.
+const searchSQL = `WITH member_organizations AS (
+ SELECT id FROM organizations WHERE user_id = $6
+ UNION SELECT organization_id FROM user_roles WHERE user_id = $6),
+ queries AS (
+ SELECT language, plainto_tsquery(language, $1) AS query
+ FROM (SELECT 'simple'::regconfig AS language UNION SELECT search_config(search_language) FROM organizations) languages)
+ SELECT d.id, d.document_type, d.organization_id, d.title,
+ ts_rank_cd(d.vector, q.query) AS rank,
+ ts_headline(d.language, concat_ws(' ', d.title, d.body), q.query, 'StartSel=<em>, StopSel=</em>, MaxFragments=2, MaxWords=24, MinWords=8') AS snippet
+ FROM queries q
+ INNER JOIN search_documents d ON d.language = q.language AND d.vector @@ q.query
+ LEFT JOIN listings l ON d.document_type = 'listing' AND l.id = d.id
+ WHERE d.document_type = ANY($2::varchar[])
+ AND ($3 = '' OR d.organization_id::text = $3)
+ AND (d.organization_id IN (SELECT id FROM member_organizations)
+ OR d.document_type = 'organization'
+ OR (d.document_type = 'listing' AND l.status = 'published')
+ OR (d.document_type = 'user' AND (d.id = $6
+ OR d.id IN (SELECT user_id FROM user_roles WHERE organization_id IN (SELECT id FROM member_organizations))
+ OR d.id IN (SELECT user_id FROM organizations WHERE id IN (SELECT id FROM member_organizations)))))
+ ORDER BY rank DESC, d.title ASC
+ LIMIT $4 OFFSET $5;`
+
+// SearchRepository - Full text search repository manager.
+type SearchRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeSearchRepository - SearchRepository constructor.
+func MakeSearchRepository() (SearchRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return SearchRepository{}, err
+	}
+	return SearchRepository{DB: db}, nil
+}
+
+// Search - Get documents matching the query ordered by rank.
+// Query text is stemmed using each document language.
+// Results are limited to documents the user of the query can see.
+func (repo *SearchRepository) Search(query models.SearchQuery) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+	types := query.Types
+	if len(types) == 0 {
+		types = models.SearchTypes
+	}
+	err := repo.DB.Select(&results, searchSQL, query.Text, pq.Array(types), query.OrganizationID, query.Limit, query.Offset, query.UserID)
+	return results, err
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+// UnitRepository - Unit repository manager.
+type UnitRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeUnitRepository - UnitRepository constructor.
+func MakeUnitRepository() (UnitRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return UnitRepository{}, err
+	}
+	return UnitRepository{DB: db}, nil
+}
+
+// GetAll - GetAll Units from a Building in repo.
+func (repo *UnitRepository) GetAll(buildingID string) ([]models.Unit, error) {
+	units := []models.Unit{}
+	err := repo.DB.Select(&units, "SELECT * FROM units WHERE building_id = $1 ORDER BY floor ASC, name ASC", buildingID)
+	return units, err
+}
+
+// Create - Persists a Unit in repo.
+func (repo *UnitRepository) Create(unit *models.Unit) error {
+	unit.SetID()
+	unit.SetCreationValues()
+	tx := repo.DB.MustBegin()
+	unitInsertSQL := "INSERT INTO units (id, name, description, kind, floor, area, share, status, building_id, organization_id, annotations, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :kind, :floor, :area, :share, :status, :building_id, :organization_id, :annotations, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	_, err := tx.NamedExec(unitInsertSQL, unit)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get - Retrive a Unit in repo by its ID.
+func (repo *UnitRepository) Get(id string) (models.Unit, error) {
+	unit := models.Unit{}
+	err := repo.DB.Get(&unit, "SELECT * FROM units WHERE id = $1", id)
+	if err != nil {
+		return unit, err
+	}
+	return unit, nil
+}
+
+// GetFromBuilding - Retrive a Unit in repo by its ID and Building ID.
+func (repo *UnitRepository) GetFromBuilding(id string, buildingID string) (models.Unit, error) {
+	unit := models.Unit{}
+	err := repo.DB.Get(&unit, "SELECT * FROM units WHERE id = $1 AND building_id = $2", id, buildingID)
+	if err != nil {
+		return unit, err
+	}
+	return unit, nil
+}
+
+// GetByNameInBuilding - Retrive a Unit in repo by its name and Building ID.
+func (repo *UnitRepository) GetByNameInBuilding(name string, buildingID string) (models.Unit, error) {
+	unit := models.Unit{}
+	err := repo.DB.Get(&unit, "SELECT * FROM units WHERE name = $1 AND building_id = $2", name, buildingID)
+	if err != nil {
+		return unit, err
+	}
+	return unit, nil
+}
+
+// GetPropertiesSets - Retrieve the PropertiesSets holding the attributes of a Unit.
+func (repo *UnitRepository) GetPropertiesSets(id string) ([]models.PropertiesSet, error) {
+	propertiesSets := []models.PropertiesSet{}
+	err := repo.DB.Select(&propertiesSets, "SELECT * FROM properties_sets WHERE holder_id = $1 ORDER BY position ASC, name ASC", id)
+	return propertiesSets, err
+}
+
+// Update - Update a unit in repo.
+func (repo *UnitRepository) Update(unit *models.Unit) error {
+	// Update audit values
+	unit.SetUpdateValues()
+	// Current state
+	reference, err := repo.Get(unit.ID.String)
+	if err != nil {
+		return err
+	}
+	// Customized query
+	changes := UnitChanges(unit, reference)
+	number := len(changes)
+	pos := 0
+	last := number < 2
+	var query bytes.Buffer
+	query.WriteString("UPDATE units SET ")
+	for field, structField := range changes {
+		var partial string
+		if last {
+			partial = fmt.Sprintf("%v = %v ", field, structField)
+		} else {
+			partial = fmt.Sprintf("%v = %v, ", field, structField)
+		}
+		query.WriteString(partial)
+		pos = pos + 1
+		last = pos == number-1
+	}
+	query.WriteString(fmt.Sprintf("WHERE id = '%s';", unit.ID.String))
+	//logger.Debug(query.String())
+	tx := repo.DB.MustBegin()
+	_, err = tx.NamedExec(query.String(), unit)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
+
+// Delete - Deletes unit and its properties sets from database.
+func (repo *UnitRepository) Delete(id string) error {
+	tx := repo.DB.MustBegin()
+	tx.MustExec("DELETE FROM properties WHERE properties_set_id IN (SELECT id FROM properties_sets WHERE holder_id = $1)", id)
+	tx.MustExec("DELETE FROM properties_sets WHERE holder_id = $1", id)
+	tx.MustExec("DELETE FROM units WHERE id = $1", id)
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteFromBuilding - Deletes unit from database if it belongs to the Building.
+func (repo *UnitRepository) DeleteFromBuilding(id string, buildingID string) error {
+	_, err := repo.GetFromBuilding(id, buildingID)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
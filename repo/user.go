@@ -32,6 +32,7 @@ import (
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/logger"
 	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/types"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // Import pq without side effect
 )
@@ -57,6 +58,14 @@ func (repo *UserRepository) GetAll() ([]models.User, error) {
 	return users, err
 }
 
+// GetAllByLocation - Get Users in repo matching a spatial filter ordered by distance.
+func (repo *UserRepository) GetAllByLocation(filter types.GeoFilter) ([]models.User, error) {
+	users := []models.User{}
+	query, args := geoSelect("users", "geolocation", "", []interface{}{}, filter)
+	err := repo.DB.Select(&users, query, args...)
+	return users, err
+}
+
 // Create - Persists a User in repo.
 func (repo *UserRepository) Create(user *models.User) error {
 	user.SetID()
@@ -27,6 +27,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/db"
 	"github.com/adrianpk/fundacja/models"
 	"github.com/jmoiron/sqlx"
@@ -65,6 +66,7 @@ func (repo *UserRoleRepository) Create(userRole *models.UserRole) error {
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -136,6 +138,7 @@ func (repo *UserRoleRepository) Update(userRole *models.UserRole) error {
 		return err
 	}
 	err = tx.Commit()
+	cache.Decisions.Invalidate()
 	return err
 }
 
@@ -145,6 +148,7 @@ func (repo *UserRoleRepository) Delete(id string) error {
 	userRoleDeleteSQL := fmt.Sprintf("DELETE FROM user_roles WHERE id = '%s'", id)
 	_ = tx.MustExec(userRoleDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -157,6 +161,7 @@ func (repo *UserRoleRepository) DeleteFromOrganization(id string, orgid string)
 	userRoleDeleteSQL := fmt.Sprintf("DELETE FROM user_roles WHERE id = '%s' AND organization_id = '%s'", id, orgid)
 	_ = tx.MustExec(userRoleDeleteSQL)
 	err := tx.Commit()
+	cache.Decisions.Invalidate()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,249 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package repo
+
+import (
+	"sort"
+	"time"
+
+	"github.com/adrianpk/fundacja/db"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // Import pq without side effects
+)
+
+const (
+	availabilityWindowInsertSQL = "INSERT INTO availability_windows (id, name, description, agent_id, starts_at, ends_at, slot_minutes, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :agent_id, :starts_at, :ends_at, :slot_minutes, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	viewingInsertSQL            = "INSERT INTO viewings (id, name, description, listing_id, agent_id, availability_window_id, prospect_user_id, prospect_email, prospect_phone, starts_at, ends_at, status, organization_id, started_at, created_by, is_active, is_logical_deleted, created_at, updated_at) VALUES (:id, :name, :description, :listing_id, :agent_id, :availability_window_id, :prospect_user_id, :prospect_email, :prospect_phone, :starts_at, :ends_at, :status, :organization_id, :started_at, :created_by, :is_active, :is_logical_deleted, :created_at, :updated_at)"
+	// Advisory lock key classes, bookings of the same agent or listing are serialized.
+	agentLockClass   = 1
+	listingLockClass = 2
+	lockSQL          = "SELECT pg_advisory_xact_lock($1, hashtext($2))"
+	// viewingConflictSQL - Scheduled viewings of the agent or of the listing overlapping a period.
+	viewingConflictSQL = "SELECT EXISTS (SELECT 1 FROM viewings WHERE status = $1 AND starts_at < $2 AND ends_at > $3 AND (agent_id = $4 OR listing_id = $5))"
+)
+
+// ViewingRepository - Viewing and AvailabilityWindow repository manager.
+type ViewingRepository struct {
+	DB *sqlx.DB
+}
+
+// MakeViewingRepository - ViewingRepository constructor.
+func MakeViewingRepository() (ViewingRepository, error) {
+	db, err := db.GetDbx()
+	if err != nil {
+		return ViewingRepository{}, err
+	}
+	return ViewingRepository{DB: db}, nil
+}
+
+// GetWindows - GetAll AvailabilityWindows from an Organization in repo ending after a time.
+func (repo *ViewingRepository) GetWindows(orgID string, from time.Time) ([]models.AvailabilityWindow, error) {
+	windows := []models.AvailabilityWindow{}
+	err := repo.DB.Select(&windows, "SELECT * FROM availability_windows WHERE organization_id = $1 AND ends_at > $2 ORDER BY starts_at ASC, agent_id ASC", orgID, from)
+	return windows, err
+}
+
+// GetAgentWindows - GetAll AvailabilityWindows of an agent in an Organization ending after a time.
+func (repo *ViewingRepository) GetAgentWindows(orgID string, agentID string, from time.Time) ([]models.AvailabilityWindow, error) {
+	windows := []models.AvailabilityWindow{}
+	err := repo.DB.Select(&windows, "SELECT * FROM availability_windows WHERE organization_id = $1 AND agent_id = $2 AND ends_at > $3 ORDER BY starts_at ASC", orgID, agentID, from)
+	return windows, err
+}
+
+// GetWindow - Retrive an AvailabilityWindow in repo by its ID and Organization ID.
+func (repo *ViewingRepository) GetWindow(id string, orgID string) (models.AvailabilityWindow, error) {
+	window := models.AvailabilityWindow{}
+	err := repo.DB.Get(&window, "SELECT * FROM availability_windows WHERE id = $1 AND organization_id = $2", id, orgID)
+	return window, err
+}
+
+// CreateWindow - Persists an AvailabilityWindow in repo.
+// Windows of the same agent can't overlap.
+func (repo *ViewingRepository) CreateWindow(window *models.AvailabilityWindow) error {
+	window.SetID()
+	window.SetCreationValues()
+	tx := repo.DB.MustBegin()
+	_, err := tx.Exec(lockSQL, agentLockClass, window.AgentID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	overlaps := false
+	err = tx.Get(&overlaps, "SELECT EXISTS (SELECT 1 FROM availability_windows WHERE agent_id = $1 AND starts_at < $2 AND ends_at > $3)", window.AgentID.String, window.EndsAt.Time, window.StartsAt.Time)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if overlaps {
+		tx.Rollback()
+		return models.ValidationErrors{"startsAt": "window overlaps another window of the agent"}
+	}
+	_, err = tx.NamedExec(availabilityWindowInsertSQL, window)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteWindow - Deletes an AvailabilityWindow from database, booked viewings are kept.
+func (repo *ViewingRepository) DeleteWindow(id string, orgID string) error {
+	_, err := repo.GetWindow(id, orgID)
+	if err != nil {
+		return err
+	}
+	_, err = repo.DB.Exec("DELETE FROM availability_windows WHERE id = $1 AND organization_id = $2", id, orgID)
+	return err
+}
+
+// GetAll - GetAll Viewings from an Organization in repo.
+func (repo *ViewingRepository) GetAll(orgID string) ([]models.Viewing, error) {
+	viewings := []models.Viewing{}
+	err := repo.DB.Select(&viewings, "SELECT * FROM viewings WHERE organization_id = $1 ORDER BY starts_at ASC", orgID)
+	return viewings, err
+}
+
+// GetAllFromAgent - GetAll Viewings of an agent in an Organization.
+func (repo *ViewingRepository) GetAllFromAgent(orgID string, agentID string) ([]models.Viewing, error) {
+	viewings := []models.Viewing{}
+	err := repo.DB.Select(&viewings, "SELECT * FROM viewings WHERE organization_id = $1 AND agent_id = $2 ORDER BY starts_at ASC", orgID, agentID)
+	return viewings, err
+}
+
+// GetAllFromListing - GetAll Viewings of a Listing in an Organization.
+func (repo *ViewingRepository) GetAllFromListing(orgID string, listingID string) ([]models.Viewing, error) {
+	viewings := []models.Viewing{}
+	err := repo.DB.Select(&viewings, "SELECT * FROM viewings WHERE organization_id = $1 AND listing_id = $2 ORDER BY starts_at ASC", orgID, listingID)
+	return viewings, err
+}
+
+// GetAllFromProspect - GetAll Viewings requested by a User in an Organization.
+func (repo *ViewingRepository) GetAllFromProspect(orgID string, userID string) ([]models.Viewing, error) {
+	viewings := []models.Viewing{}
+	err := repo.DB.Select(&viewings, "SELECT * FROM viewings WHERE organization_id = $1 AND prospect_user_id = $2 ORDER BY starts_at ASC", orgID, userID)
+	return viewings, err
+}
+
+// GetScheduled - GetAll scheduled Viewings from an Organization overlapping a period.
+func (repo *ViewingRepository) GetScheduled(orgID string, from, to time.Time) ([]models.Viewing, error) {
+	viewings := []models.Viewing{}
+	err := repo.DB.Select(&viewings, "SELECT * FROM viewings WHERE organization_id = $1 AND status = $2 AND starts_at < $3 AND ends_at > $4 ORDER BY starts_at ASC", orgID, models.ViewingStatusScheduled, to, from)
+	return viewings, err
+}
+
+// GetFromOrganization - Retrive a Viewing in repo by its ID and Organization ID.
+func (repo *ViewingRepository) GetFromOrganization(id string, orgID string) (models.Viewing, error) {
+	viewing := models.Viewing{}
+	err := repo.DB.Get(&viewing, "SELECT * FROM viewings WHERE id = $1 AND organization_id = $2", id, orgID)
+	return viewing, err
+}
+
+// Book - Persists a Viewing in the first availability window holding its start time
+// whose agent and listing are free during the slot.
+// An agent can be requested setting the viewing AgentID.
+// Bookings are serialized by listing and agent so overlapping requests can't both succeed.
+func (repo *ViewingRepository) Book(viewing *models.Viewing) error {
+	startsAt := viewing.StartsAt.Time
+	tx := repo.DB.MustBegin()
+	_, err := tx.Exec(lockSQL, listingLockClass, viewing.ListingID.String)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	// Candidate windows
+	windows := []models.AvailabilityWindow{}
+	if viewing.AgentID.String != "" {
+		err = tx.Select(&windows, "SELECT * FROM availability_windows WHERE organization_id = $1 AND agent_id = $2 AND starts_at <= $3 AND ends_at > $3 ORDER BY starts_at ASC", viewing.OrganizationID.String, viewing.AgentID.String, startsAt)
+	} else {
+		err = tx.Select(&windows, "SELECT * FROM availability_windows WHERE organization_id = $1 AND starts_at <= $2 AND ends_at > $2 ORDER BY starts_at ASC, agent_id ASC", viewing.OrganizationID.String, startsAt)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	candidates := []models.AvailabilityWindow{}
+	for _, window := range windows {
+		if window.Fits(startsAt) {
+			candidates = append(candidates, window)
+		}
+	}
+	if len(candidates) == 0 {
+		tx.Rollback()
+		return models.ValidationErrors{"startsAt": "no agent is available at the requested time"}
+	}
+	// Agents are locked in a stable order to avoid deadlocks between bookings
+	agents := []string{}
+	for _, window := range candidates {
+		agents = append(agents, window.AgentID.String)
+	}
+	sort.Strings(agents)
+	for i, agentID := range agents {
+		if i > 0 && agents[i-1] == agentID {
+			continue
+		}
+		_, err = tx.Exec(lockSQL, agentLockClass, agentID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, window := range candidates {
+		endsAt := startsAt.Add(window.SlotDuration())
+		conflict := false
+		err = tx.Get(&conflict, viewingConflictSQL, models.ViewingStatusScheduled, endsAt, startsAt, window.AgentID.String, viewing.ListingID.String)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if conflict {
+			continue
+		}
+		viewing.SetID()
+		viewing.SetCreationValues()
+		viewing.AgentID = window.AgentID
+		viewing.AvailabilityWindowID = window.ID
+		viewing.StartsAt = models.ToNullsTime(startsAt)
+		viewing.EndsAt = models.ToNullsTime(endsAt)
+		_, err = tx.NamedExec(viewingInsertSQL, viewing)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	}
+	tx.Rollback()
+	return models.ValidationErrors{"startsAt": "requested slot overlaps an existing viewing"}
+}
+
+// Cancel - Sets a scheduled Viewing as cancelled, freeing its slot.
+func (repo *ViewingRepository) Cancel(viewing *models.Viewing) error {
+	if viewing.Status.String != models.ViewingStatusScheduled {
+		return models.ValidationErrors{"status": "viewing is not scheduled"}
+	}
+	viewing.Status = models.ToNullsString(models.ViewingStatusCancelled)
+	viewing.SetUpdateValues()
+	_, err := repo.DB.NamedExec("UPDATE viewings SET status = :status, updated_at = :updated_at WHERE id = :id", viewing)
+	return err
+}
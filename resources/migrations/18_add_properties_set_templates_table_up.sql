@@ -0,0 +1,72 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE properties_set_templates
+(id UUID PRIMARY KEY,
+ name VARCHAR(128),
+ description VARCHAR(255) NULL,
+ organization_id UUID NULL,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE properties_set_templates
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE TABLE property_templates
+(id UUID PRIMARY KEY,
+ name VARCHAR(128),
+ description VARCHAR(255) NULL,
+ value_type VARCHAR(1),
+ required BOOLEAN NULL,
+ min_value FLOAT NULL,
+ max_value FLOAT NULL,
+ allowed_values TEXT[] NULL,
+ unit VARCHAR(32) NULL,
+ position SMALLINT NULL,
+ properties_set_template_id UUID,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE property_templates
+ ADD CONSTRAINT properties_set_template_id_fkey
+ FOREIGN KEY (properties_set_template_id)
+ REFERENCES properties_set_templates
+ ON DELETE CASCADE;
+
+ALTER TABLE properties_sets
+ ADD COLUMN template_id UUID NULL;
+
+ALTER TABLE properties_sets
+ ADD CONSTRAINT template_id_fkey
+ FOREIGN KEY (template_id)
+ REFERENCES properties_set_templates
+ ON DELETE SET NULL;
@@ -0,0 +1,34 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+ALTER TABLE properties
+ ADD COLUMN money_value NUMERIC(14,2) NULL,
+ ADD COLUMN currency_value VARCHAR(3) NULL,
+ ADD COLUMN date_range_value DATERANGE NULL,
+ ADD COLUMN reference_type VARCHAR(32) NULL,
+ ADD COLUMN reference_id UUID NULL,
+ ADD COLUMN list_value JSONB NULL;
+
+CREATE INDEX properties_date_range_value_idx ON properties USING GIST (date_range_value);
+CREATE INDEX properties_list_value_idx ON properties USING GIN (list_value);
+CREATE INDEX properties_reference_idx ON properties (reference_type, reference_id);
@@ -0,0 +1,43 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+DROP TRIGGER IF EXISTS properties_search_documents ON properties;
+DROP TRIGGER IF EXISTS listings_search_documents ON listings;
+DROP TRIGGER IF EXISTS resources_search_documents ON resources;
+DROP TRIGGER IF EXISTS organizations_search_documents ON organizations;
+DROP TRIGGER IF EXISTS users_search_documents ON users;
+
+DROP FUNCTION IF EXISTS search_documents_properties_trigger();
+DROP FUNCTION IF EXISTS search_documents_trigger();
+DROP FUNCTION IF EXISTS index_listing(UUID);
+DROP FUNCTION IF EXISTS index_resource(UUID);
+DROP FUNCTION IF EXISTS index_organization(UUID);
+DROP FUNCTION IF EXISTS index_user(UUID);
+DROP FUNCTION IF EXISTS index_search_document(UUID, VARCHAR, UUID, REGCONFIG, TEXT, TEXT);
+DROP FUNCTION IF EXISTS organization_search_config(UUID);
+DROP FUNCTION IF EXISTS search_config(TEXT);
+
+DROP TABLE IF EXISTS search_documents;
+
+ALTER TABLE organizations
+ DROP COLUMN IF EXISTS search_language;
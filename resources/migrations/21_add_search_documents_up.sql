@@ -0,0 +1,150 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+-- Full text search
+-- Searchable entities are indexed in search_documents by triggers.
+-- Listings and resources are stemmed using their organization search language.
+
+ALTER TABLE organizations
+ ADD COLUMN search_language VARCHAR(32) NULL DEFAULT 'simple';
+
+CREATE TABLE search_documents
+(id UUID PRIMARY KEY,
+ document_type VARCHAR(16) NOT NULL,
+ organization_id UUID NULL,
+ language REGCONFIG NOT NULL DEFAULT 'simple',
+ title TEXT NULL,
+ body TEXT NULL,
+ vector TSVECTOR NOT NULL,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+CREATE INDEX search_documents_vector_idx ON search_documents USING GIN (vector);
+CREATE INDEX search_documents_type_idx ON search_documents (document_type, organization_id);
+
+-- Text search configuration for language name, 'simple' if not available.
+CREATE OR REPLACE FUNCTION search_config(name TEXT) RETURNS REGCONFIG AS $$
+ SELECT COALESCE((SELECT oid::regconfig FROM pg_ts_config WHERE cfgname = name), 'simple'::regconfig);
+$$ LANGUAGE SQL STABLE;
+
+-- Text search configuration for organization.
+CREATE OR REPLACE FUNCTION organization_search_config(org UUID) RETURNS REGCONFIG AS $$
+ SELECT search_config((SELECT search_language FROM organizations WHERE id = org));
+$$ LANGUAGE SQL STABLE;
+
+CREATE OR REPLACE FUNCTION index_search_document(doc_id UUID, doc_type VARCHAR, org UUID, lang REGCONFIG, doc_title TEXT, doc_body TEXT) RETURNS VOID AS $$
+BEGIN
+ INSERT INTO search_documents (id, document_type, organization_id, language, title, body, vector, updated_at)
+ VALUES (doc_id, doc_type, org, lang, doc_title, doc_body,
+  setweight(to_tsvector(lang, COALESCE(doc_title, '')), 'A') || setweight(to_tsvector(lang, COALESCE(doc_body, '')), 'B'),
+  now())
+ ON CONFLICT (id) DO UPDATE SET
+  document_type = EXCLUDED.document_type,
+  organization_id = EXCLUDED.organization_id,
+  language = EXCLUDED.language,
+  title = EXCLUDED.title,
+  body = EXCLUDED.body,
+  vector = EXCLUDED.vector,
+  updated_at = EXCLUDED.updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION index_user(user_id UUID) RETURNS VOID AS $$
+ SELECT index_search_document(u.id, 'user', NULL, 'simple'::regconfig,
+  concat_ws(' ', u.first_name, u.middle_names, u.last_name),
+  u.username)
+ FROM users u WHERE u.id = user_id;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION index_organization(org UUID) RETURNS VOID AS $$
+ SELECT index_search_document(o.id, 'organization', o.id, search_config(o.search_language),
+  o.name,
+  o.description)
+ FROM organizations o WHERE o.id = org;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION index_resource(resource_id UUID) RETURNS VOID AS $$
+ SELECT index_search_document(r.id, 'resource', r.organization_id, organization_search_config(r.organization_id),
+  r.name,
+  concat_ws(' ', r.description, r.tag))
+ FROM resources r WHERE r.id = resource_id;
+$$ LANGUAGE SQL;
+
+-- Listing body includes string values of its properties.
+CREATE OR REPLACE FUNCTION index_listing(listing_id UUID) RETURNS VOID AS $$
+ SELECT index_search_document(l.id, 'listing', l.organization_id, organization_search_config(l.organization_id),
+  l.name,
+  concat_ws(' ', l.description, l.address,
+   (SELECT string_agg(p.string_value, ' ') FROM properties p JOIN properties_sets ps ON ps.id = p.properties_set_id WHERE ps.holder_id = l.id)))
+ FROM listings l WHERE l.id = listing_id;
+$$ LANGUAGE SQL;
+
+CREATE OR REPLACE FUNCTION search_documents_trigger() RETURNS TRIGGER AS $$
+BEGIN
+ IF TG_OP = 'DELETE' THEN
+  DELETE FROM search_documents WHERE id = OLD.id;
+  RETURN OLD;
+ END IF;
+ CASE TG_TABLE_NAME
+  WHEN 'users' THEN PERFORM index_user(NEW.id);
+  WHEN 'organizations' THEN
+   PERFORM index_organization(NEW.id);
+   IF TG_OP = 'UPDATE' AND NEW.search_language IS DISTINCT FROM OLD.search_language THEN
+    PERFORM index_listing(id) FROM listings WHERE organization_id = NEW.id;
+    PERFORM index_resource(id) FROM resources WHERE organization_id = NEW.id;
+   END IF;
+  WHEN 'resources' THEN PERFORM index_resource(NEW.id);
+  WHEN 'listings' THEN PERFORM index_listing(NEW.id);
+ END CASE;
+ RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Reindex listing holding a changed property.
+CREATE OR REPLACE FUNCTION search_documents_properties_trigger() RETURNS TRIGGER AS $$
+BEGIN
+ IF TG_OP IN ('UPDATE', 'DELETE') THEN
+  PERFORM index_listing(ps.holder_id) FROM properties_sets ps WHERE ps.id = OLD.properties_set_id;
+ END IF;
+ IF TG_OP IN ('INSERT', 'UPDATE') THEN
+  PERFORM index_listing(ps.holder_id) FROM properties_sets ps WHERE ps.id = NEW.properties_set_id;
+ END IF;
+ RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_search_documents AFTER INSERT OR UPDATE OR DELETE ON users
+ FOR EACH ROW EXECUTE PROCEDURE search_documents_trigger();
+CREATE TRIGGER organizations_search_documents AFTER INSERT OR UPDATE OR DELETE ON organizations
+ FOR EACH ROW EXECUTE PROCEDURE search_documents_trigger();
+CREATE TRIGGER resources_search_documents AFTER INSERT OR UPDATE OR DELETE ON resources
+ FOR EACH ROW EXECUTE PROCEDURE search_documents_trigger();
+CREATE TRIGGER listings_search_documents AFTER INSERT OR UPDATE OR DELETE ON listings
+ FOR EACH ROW EXECUTE PROCEDURE search_documents_trigger();
+CREATE TRIGGER properties_search_documents AFTER INSERT OR UPDATE OR DELETE ON properties
+ FOR EACH ROW EXECUTE PROCEDURE search_documents_properties_trigger();
+
+-- Index current rows
+SELECT index_user(id) FROM users;
+SELECT index_organization(id) FROM organizations;
+SELECT index_resource(id) FROM resources;
+SELECT index_listing(id) FROM listings;
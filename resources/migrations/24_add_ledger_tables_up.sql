@@ -0,0 +1,181 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+ALTER TABLE leases
+ ADD COLUMN late_fee_amount NUMERIC(14,2) NULL,
+ ADD COLUMN late_fee_rate NUMERIC(7,4) NULL,
+ ADD COLUMN grace_days INTEGER NULL;
+
+CREATE TABLE accounts
+(id UUID PRIMARY KEY,
+ name VARCHAR(128),
+ description TEXT NULL,
+ code VARCHAR(16),
+ kind VARCHAR(16),
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE,
+ UNIQUE (organization_id, code));
+
+ALTER TABLE accounts
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE TABLE journal_entries
+(id UUID PRIMARY KEY,
+ name VARCHAR(255),
+ description TEXT NULL,
+ kind VARCHAR(16),
+ posted_at TIMESTAMP WITH TIME ZONE,
+ reversal_of UUID NULL UNIQUE,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE journal_entries
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+ALTER TABLE journal_entries
+ ADD CONSTRAINT reversal_of_fkey
+ FOREIGN KEY (reversal_of)
+ REFERENCES journal_entries
+ ON DELETE CASCADE;
+
+CREATE INDEX journal_entries_organization_id_idx ON journal_entries (organization_id, posted_at);
+
+CREATE TABLE invoices
+(id UUID PRIMARY KEY,
+ name VARCHAR(64),
+ description TEXT NULL,
+ kind VARCHAR(16),
+ installment_number INTEGER,
+ period_start TIMESTAMP WITH TIME ZONE NULL,
+ period_end TIMESTAMP WITH TIME ZONE NULL,
+ due_date TIMESTAMP WITH TIME ZONE,
+ amount NUMERIC(14,2),
+ balance NUMERIC(14,2),
+ currency VARCHAR(3) NULL,
+ status VARCHAR(16),
+ journal_entry_id UUID NULL,
+ lease_id UUID,
+ unit_id UUID,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE invoices
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+-- One rent and one late fee invoice per lease installment, voided ones excepted.
+CREATE UNIQUE INDEX invoices_installment_idx ON invoices (lease_id, kind, installment_number) WHERE status <> 'void';
+CREATE INDEX invoices_organization_id_idx ON invoices (organization_id, status);
+
+CREATE TABLE postings
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ journal_entry_id UUID,
+ account_id UUID,
+ debit NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+ credit NUMERIC(14,2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+ currency VARCHAR(3) NULL,
+ lease_id UUID NULL,
+ unit_id UUID NULL,
+ invoice_id UUID NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE,
+ CHECK ((debit = 0) <> (credit = 0)));
+
+ALTER TABLE postings
+ ADD CONSTRAINT journal_entry_id_fkey
+ FOREIGN KEY (journal_entry_id)
+ REFERENCES journal_entries
+ ON DELETE CASCADE;
+
+ALTER TABLE postings
+ ADD CONSTRAINT account_id_fkey
+ FOREIGN KEY (account_id)
+ REFERENCES accounts
+ ON DELETE CASCADE;
+
+CREATE INDEX postings_journal_entry_id_idx ON postings (journal_entry_id);
+CREATE INDEX postings_invoice_id_idx ON postings (invoice_id);
+CREATE INDEX postings_unit_id_idx ON postings (unit_id);
+CREATE INDEX postings_lease_id_idx ON postings (lease_id);
+
+-- Journal entries and postings are immutable, corrections are made through reversing entries.
+-- Deletes cascading from organizations (trigger depth > 1) are allowed.
+CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+    RETURN OLD;
+  END IF;
+  RAISE EXCEPTION '% on % not allowed: ledger records are immutable', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable
+ BEFORE UPDATE OR DELETE ON journal_entries
+ FOR EACH ROW EXECUTE PROCEDURE ledger_immutable();
+
+CREATE TRIGGER postings_immutable
+ BEFORE UPDATE OR DELETE ON postings
+ FOR EACH ROW EXECUTE PROCEDURE ledger_immutable();
+
+-- Debits and credits of a journal entry must balance for every currency when transaction commits.
+CREATE OR REPLACE FUNCTION journal_entry_balanced() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM postings
+             WHERE journal_entry_id = NEW.journal_entry_id
+             GROUP BY currency
+             HAVING SUM(debit) <> SUM(credit)) THEN
+    RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+ AFTER INSERT ON postings
+ DEFERRABLE INITIALLY DEFERRED
+ FOR EACH ROW EXECUTE PROCEDURE journal_entry_balanced();
@@ -0,0 +1,110 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE bank_statements
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ format VARCHAR(16),
+ account_number VARCHAR(64) NULL,
+ currency VARCHAR(3) NULL,
+ opening_balance NUMERIC(14,2) NULL,
+ closing_balance NUMERIC(14,2) NULL,
+ statement_date TIMESTAMP WITH TIME ZONE NULL,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE bank_statements
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE TABLE bank_transactions
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ bank_statement_id UUID,
+ booked_at TIMESTAMP WITH TIME ZONE,
+ amount NUMERIC(14,2),
+ currency VARCHAR(3) NULL,
+ reference TEXT NULL,
+ bank_reference VARCHAR(128) NULL,
+ payer_name VARCHAR(255) NULL,
+ payer_account VARCHAR(64) NULL,
+ fingerprint VARCHAR(40),
+ status VARCHAR(16),
+ invoice_id UUID NULL,
+ journal_entry_id UUID NULL,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE,
+ UNIQUE (organization_id, fingerprint));
+
+ALTER TABLE bank_transactions
+ ADD CONSTRAINT bank_statement_id_fkey
+ FOREIGN KEY (bank_statement_id)
+ REFERENCES bank_statements
+ ON DELETE CASCADE;
+
+ALTER TABLE bank_transactions
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX bank_transactions_status_idx ON bank_transactions (organization_id, status);
+
+CREATE TABLE bank_matches
+(id UUID PRIMARY KEY,
+ name VARCHAR(64) NULL,
+ description TEXT NULL,
+ bank_transaction_id UUID,
+ invoice_id UUID,
+ score INTEGER,
+ reasons VARCHAR(64) NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE bank_matches
+ ADD CONSTRAINT bank_transaction_id_fkey
+ FOREIGN KEY (bank_transaction_id)
+ REFERENCES bank_transactions
+ ON DELETE CASCADE;
+
+ALTER TABLE bank_matches
+ ADD CONSTRAINT invoice_id_fkey
+ FOREIGN KEY (invoice_id)
+ REFERENCES invoices
+ ON DELETE CASCADE;
+
+CREATE INDEX bank_matches_bank_transaction_id_idx ON bank_matches (bank_transaction_id);
@@ -0,0 +1,159 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE maintenance_requests
+(id UUID PRIMARY KEY,
+ name VARCHAR(255),
+ description TEXT NULL,
+ priority VARCHAR(16),
+ status VARCHAR(16),
+ building_id UUID,
+ unit_id UUID NULL,
+ reported_by UUID NULL,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE maintenance_requests
+ ADD CONSTRAINT building_id_fkey
+ FOREIGN KEY (building_id)
+ REFERENCES buildings
+ ON DELETE CASCADE;
+
+ALTER TABLE maintenance_requests
+ ADD CONSTRAINT unit_id_fkey
+ FOREIGN KEY (unit_id)
+ REFERENCES units
+ ON DELETE CASCADE;
+
+ALTER TABLE maintenance_requests
+ ADD CONSTRAINT reported_by_fkey
+ FOREIGN KEY (reported_by)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+ALTER TABLE maintenance_requests
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX maintenance_requests_organization_id_idx ON maintenance_requests (organization_id, status);
+CREATE INDEX maintenance_requests_reported_by_idx ON maintenance_requests (reported_by);
+
+CREATE TABLE work_orders
+(id UUID PRIMARY KEY,
+ name VARCHAR(255),
+ description TEXT NULL,
+ status VARCHAR(16),
+ maintenance_request_id UUID,
+ assignee_user_id UUID NULL,
+ assignee_organization_id UUID NULL,
+ scheduled_at TIMESTAMP WITH TIME ZONE NULL,
+ completed_at TIMESTAMP WITH TIME ZONE NULL,
+ estimated_cost NUMERIC(14,2) NULL,
+ actual_cost NUMERIC(14,2) NULL,
+ currency VARCHAR(3) NULL,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE work_orders
+ ADD CONSTRAINT maintenance_request_id_fkey
+ FOREIGN KEY (maintenance_request_id)
+ REFERENCES maintenance_requests
+ ON DELETE CASCADE;
+
+ALTER TABLE work_orders
+ ADD CONSTRAINT assignee_user_id_fkey
+ FOREIGN KEY (assignee_user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+ALTER TABLE work_orders
+ ADD CONSTRAINT assignee_organization_id_fkey
+ FOREIGN KEY (assignee_organization_id)
+ REFERENCES organizations
+ ON DELETE SET NULL;
+
+CREATE INDEX work_orders_maintenance_request_id_idx ON work_orders (maintenance_request_id);
+CREATE INDEX work_orders_assignee_user_id_idx ON work_orders (assignee_user_id);
+CREATE INDEX work_orders_assignee_organization_id_idx ON work_orders (assignee_organization_id);
+
+CREATE TABLE maintenance_comments
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ body TEXT,
+ maintenance_request_id UUID,
+ user_id UUID NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE maintenance_comments
+ ADD CONSTRAINT maintenance_request_id_fkey
+ FOREIGN KEY (maintenance_request_id)
+ REFERENCES maintenance_requests
+ ON DELETE CASCADE;
+
+ALTER TABLE maintenance_comments
+ ADD CONSTRAINT user_id_fkey
+ FOREIGN KEY (user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+CREATE INDEX maintenance_comments_maintenance_request_id_idx ON maintenance_comments (maintenance_request_id, created_at);
+
+CREATE TABLE maintenance_photos
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ content_type VARCHAR(32),
+ size INTEGER,
+ data TEXT,
+ maintenance_request_id UUID,
+ user_id UUID NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE maintenance_photos
+ ADD CONSTRAINT maintenance_request_id_fkey
+ FOREIGN KEY (maintenance_request_id)
+ REFERENCES maintenance_requests
+ ON DELETE CASCADE;
+
+ALTER TABLE maintenance_photos
+ ADD CONSTRAINT user_id_fkey
+ FOREIGN KEY (user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+CREATE INDEX maintenance_photos_maintenance_request_id_idx ON maintenance_photos (maintenance_request_id);
@@ -0,0 +1,108 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE availability_windows
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ agent_id UUID,
+ starts_at TIMESTAMP WITH TIME ZONE,
+ ends_at TIMESTAMP WITH TIME ZONE,
+ slot_minutes INTEGER,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE availability_windows
+ ADD CONSTRAINT agent_id_fkey
+ FOREIGN KEY (agent_id)
+ REFERENCES users
+ ON DELETE CASCADE;
+
+ALTER TABLE availability_windows
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX availability_windows_organization_id_idx ON availability_windows (organization_id, starts_at);
+CREATE INDEX availability_windows_agent_id_idx ON availability_windows (agent_id, starts_at);
+
+CREATE TABLE viewings
+(id UUID PRIMARY KEY,
+ name VARCHAR(255),
+ description TEXT NULL,
+ listing_id UUID,
+ agent_id UUID,
+ availability_window_id UUID NULL,
+ prospect_user_id UUID NULL,
+ prospect_email VARCHAR(255) NULL,
+ prospect_phone VARCHAR(32) NULL,
+ starts_at TIMESTAMP WITH TIME ZONE,
+ ends_at TIMESTAMP WITH TIME ZONE,
+ status VARCHAR(16),
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE viewings
+ ADD CONSTRAINT listing_id_fkey
+ FOREIGN KEY (listing_id)
+ REFERENCES listings
+ ON DELETE CASCADE;
+
+ALTER TABLE viewings
+ ADD CONSTRAINT agent_id_fkey
+ FOREIGN KEY (agent_id)
+ REFERENCES users
+ ON DELETE CASCADE;
+
+ALTER TABLE viewings
+ ADD CONSTRAINT availability_window_id_fkey
+ FOREIGN KEY (availability_window_id)
+ REFERENCES availability_windows
+ ON DELETE SET NULL;
+
+ALTER TABLE viewings
+ ADD CONSTRAINT prospect_user_id_fkey
+ FOREIGN KEY (prospect_user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+ALTER TABLE viewings
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX viewings_agent_id_idx ON viewings (agent_id, starts_at);
+CREATE INDEX viewings_listing_id_idx ON viewings (listing_id, starts_at);
+CREATE INDEX viewings_prospect_user_id_idx ON viewings (prospect_user_id);
@@ -0,0 +1,122 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE lead_stages
+(id UUID PRIMARY KEY,
+ name VARCHAR(64),
+ description TEXT NULL,
+ position INTEGER,
+ outcome VARCHAR(8),
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE lead_stages
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX lead_stages_organization_id_idx ON lead_stages (organization_id, position);
+
+CREATE TABLE leads
+(id UUID PRIMARY KEY,
+ name VARCHAR(255),
+ description TEXT NULL,
+ email VARCHAR(255) NULL,
+ phone VARCHAR(32) NULL,
+ source VARCHAR(16),
+ value NUMERIC(14,2) NULL,
+ currency VARCHAR(3) NULL,
+ listing_id UUID NULL,
+ stage_id UUID,
+ assignee_id UUID NULL,
+ stage_changed_at TIMESTAMP WITH TIME ZONE,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE leads
+ ADD CONSTRAINT listing_id_fkey
+ FOREIGN KEY (listing_id)
+ REFERENCES listings
+ ON DELETE SET NULL;
+
+ALTER TABLE leads
+ ADD CONSTRAINT stage_id_fkey
+ FOREIGN KEY (stage_id)
+ REFERENCES lead_stages
+ ON DELETE RESTRICT;
+
+ALTER TABLE leads
+ ADD CONSTRAINT assignee_id_fkey
+ FOREIGN KEY (assignee_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+ALTER TABLE leads
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX leads_organization_id_idx ON leads (organization_id, stage_id);
+CREATE INDEX leads_assignee_id_idx ON leads (assignee_id);
+
+CREATE TABLE lead_activities
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ kind VARCHAR(16),
+ body TEXT NULL,
+ lead_id UUID,
+ from_stage_id UUID NULL,
+ to_stage_id UUID NULL,
+ user_id UUID NULL,
+ due_at TIMESTAMP WITH TIME ZONE NULL,
+ done_at TIMESTAMP WITH TIME ZONE NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE lead_activities
+ ADD CONSTRAINT lead_id_fkey
+ FOREIGN KEY (lead_id)
+ REFERENCES leads
+ ON DELETE CASCADE;
+
+ALTER TABLE lead_activities
+ ADD CONSTRAINT user_id_fkey
+ FOREIGN KEY (user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+CREATE INDEX lead_activities_lead_id_idx ON lead_activities (lead_id, created_at);
+CREATE INDEX lead_activities_reminders_idx ON lead_activities (organization_id, due_at) WHERE kind = 'reminder' AND done_at IS NULL;
@@ -0,0 +1,81 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE documents
+(id UUID PRIMARY KEY,
+ name VARCHAR(255),
+ description TEXT NULL,
+ kind VARCHAR(16),
+ holder_type VARCHAR(16),
+ holder_id UUID,
+ version INTEGER,
+ file_name VARCHAR(255),
+ content_type VARCHAR(64),
+ size BIGINT,
+ checksum VARCHAR(64),
+ retain_until TIMESTAMP WITH TIME ZONE NULL,
+ legal_hold BOOLEAN,
+ organization_id UUID,
+ started_at TIMESTAMP WITH TIME ZONE,
+ created_by UUID NULL,
+ is_active BOOLEAN,
+ is_logical_deleted BOOLEAN,
+ created_at TIMESTAMP WITH TIME ZONE,
+ updated_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE documents
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX documents_holder_idx ON documents (organization_id, holder_type, holder_id);
+
+CREATE TABLE document_versions
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ document_id UUID,
+ version INTEGER,
+ file_name VARCHAR(255),
+ content_type VARCHAR(64),
+ size BIGINT,
+ checksum VARCHAR(64),
+ storage_key VARCHAR(255),
+ user_id UUID NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE document_versions
+ ADD CONSTRAINT document_id_fkey
+ FOREIGN KEY (document_id)
+ REFERENCES documents
+ ON DELETE CASCADE;
+
+ALTER TABLE document_versions
+ ADD CONSTRAINT user_id_fkey
+ FOREIGN KEY (user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX document_versions_version_idx ON document_versions (document_id, version);
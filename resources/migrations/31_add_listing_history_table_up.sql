@@ -0,0 +1,80 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+CREATE TABLE listing_history
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ listing_id UUID,
+ price NUMERIC(14,2) NULL,
+ previous_price NUMERIC(14,2) NULL,
+ currency VARCHAR(3) NULL,
+ status VARCHAR(16),
+ previous_status VARCHAR(16) NULL,
+ user_id UUID NULL,
+ organization_id UUID,
+ created_at TIMESTAMP WITH TIME ZONE);
+
+ALTER TABLE listing_history
+ ADD CONSTRAINT listing_id_fkey
+ FOREIGN KEY (listing_id)
+ REFERENCES listings
+ ON DELETE CASCADE;
+
+ALTER TABLE listing_history
+ ADD CONSTRAINT user_id_fkey
+ FOREIGN KEY (user_id)
+ REFERENCES users
+ ON DELETE SET NULL;
+
+CREATE INDEX listing_history_listing_idx ON listing_history (listing_id, created_at);
+
+-- History entries are never rewritten, only removed along with their listing.
+CREATE OR REPLACE FUNCTION listing_history_append_only() RETURNS TRIGGER AS $$
+BEGIN
+ RAISE EXCEPTION 'listing_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER listing_history_append_only
+ BEFORE UPDATE ON listing_history
+ FOR EACH ROW EXECUTE PROCEDURE listing_history_append_only();
+
+-- Market statistics per listing.
+-- Days on market are counted from first publication until closing, or until now if still open.
+CREATE VIEW listing_stats AS
+ SELECT l.id AS listing_id,
+  h.listed_at,
+  COALESCE(FLOOR(EXTRACT(EPOCH FROM (CASE WHEN l.status = 'closed' THEN COALESCE(h.closed_at, now()) ELSE now() END) - h.listed_at) / 86400), 0)::BIGINT AS days_on_market,
+  COALESCE(h.price_reductions, 0)::BIGINT AS price_reductions,
+  h.initial_price,
+  CASE WHEN h.initial_price > 0 THEN ROUND((l.price - h.initial_price) * 100 / h.initial_price, 2) ELSE 0 END::FLOAT8 AS price_change
+ FROM listings l
+ LEFT JOIN
+ (SELECT listing_id,
+   MIN(created_at) FILTER (WHERE status = 'published') AS listed_at,
+   MAX(created_at) FILTER (WHERE status = 'closed') AS closed_at,
+   COUNT(*) FILTER (WHERE price < previous_price) AS price_reductions,
+   (ARRAY_AGG(price ORDER BY created_at ASC) FILTER (WHERE price IS NOT NULL))[1] AS initial_price
+  FROM listing_history
+  GROUP BY listing_id) h ON h.listing_id = l.id;
@@ -0,0 +1,55 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+-- Parent roles a role inherits permissions from, both within the same organization.
+CREATE TABLE role_parents
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ role_id UUID,
+ parent_id UUID,
+ organization_id UUID,
+ created_by UUID NULL,
+ created_at TIMESTAMP WITH TIME ZONE,
+ CONSTRAINT role_parents_not_self CHECK (role_id <> parent_id));
+
+ALTER TABLE role_parents
+ ADD CONSTRAINT role_id_fkey
+ FOREIGN KEY (role_id)
+ REFERENCES roles
+ ON DELETE CASCADE;
+
+ALTER TABLE role_parents
+ ADD CONSTRAINT parent_id_fkey
+ FOREIGN KEY (parent_id)
+ REFERENCES roles
+ ON DELETE CASCADE;
+
+ALTER TABLE role_parents
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX role_parents_role_parent_idx ON role_parents (role_id, parent_id);
+CREATE INDEX role_parents_parent_idx ON role_parents (parent_id);
@@ -0,0 +1,76 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+-- Record level grants, an action over a single entity granted to a user or to a role.
+-- Entity types are the tags of the resources the entities belong to.
+CREATE TABLE access_control_entries
+(id UUID PRIMARY KEY,
+ name VARCHAR(255) NULL,
+ description TEXT NULL,
+ entity_type VARCHAR(32),
+ entity_id UUID,
+ user_id UUID NULL,
+ role_id UUID NULL,
+ action VARCHAR(16) NOT NULL DEFAULT 'manage',
+ organization_id UUID,
+ created_by UUID NULL,
+ created_at TIMESTAMP WITH TIME ZONE,
+ CONSTRAINT access_control_entries_grantee CHECK ((user_id IS NULL) <> (role_id IS NULL)));
+
+ALTER TABLE access_control_entries
+ ADD CONSTRAINT user_id_fkey
+ FOREIGN KEY (user_id)
+ REFERENCES users
+ ON DELETE CASCADE;
+
+ALTER TABLE access_control_entries
+ ADD CONSTRAINT role_id_fkey
+ FOREIGN KEY (role_id)
+ REFERENCES roles
+ ON DELETE CASCADE;
+
+ALTER TABLE access_control_entries
+ ADD CONSTRAINT organization_id_fkey
+ FOREIGN KEY (organization_id)
+ REFERENCES organizations
+ ON DELETE CASCADE;
+
+CREATE INDEX access_control_entries_entity_idx ON access_control_entries (entity_type, entity_id);
+CREATE INDEX access_control_entries_user_idx ON access_control_entries (user_id, entity_type);
+CREATE INDEX access_control_entries_role_idx ON access_control_entries (role_id, entity_type);
+
+-- Entries are removed along with the entity they grant access to.
+CREATE OR REPLACE FUNCTION delete_access_control_entries() RETURNS TRIGGER AS $$
+BEGIN
+ DELETE FROM access_control_entries WHERE entity_type = TG_ARGV[0] AND entity_id = OLD.id;
+ RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER listings_delete_access_control_entries
+ AFTER DELETE ON listings
+ FOR EACH ROW EXECUTE PROCEDURE delete_access_control_entries('listings');
+
+CREATE TRIGGER buildings_delete_access_control_entries
+ AFTER DELETE ON buildings
+ FOR EACH ROW EXECUTE PROCEDURE delete_access_control_entries('buildings');
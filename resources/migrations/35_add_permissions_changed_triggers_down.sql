@@ -0,0 +1,33 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+DROP TRIGGER IF EXISTS user_roles_permissions_changed ON user_roles;
+DROP TRIGGER IF EXISTS role_permissions_permissions_changed ON role_permissions;
+DROP TRIGGER IF EXISTS resource_permissions_permissions_changed ON resource_permissions;
+DROP TRIGGER IF EXISTS role_parents_permissions_changed ON role_parents;
+DROP TRIGGER IF EXISTS access_control_entries_permissions_changed ON access_control_entries;
+DROP TRIGGER IF EXISTS roles_permissions_changed ON roles;
+DROP TRIGGER IF EXISTS permissions_permissions_changed ON permissions;
+DROP TRIGGER IF EXISTS resources_permissions_changed ON resources;
+DROP TRIGGER IF EXISTS organizations_permissions_changed ON organizations;
+DROP FUNCTION IF EXISTS notify_permissions_changed();
@@ -0,0 +1,66 @@
+-- Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+--
+-- MIT License
+--
+-- Permission is hereby granted, free of charge, to any person obtaining
+-- a copy of this software and associated documentation files (the
+-- "Software"), to deal in the Software without restriction, including
+-- without limitation the rights to use, copy, modify, merge, publish,
+-- distribute, sublicense, and/or sell copies of the Software, and to
+-- permit persons to whom the Software is furnished to do so, subject to
+-- the following conditions:
+--
+-- The above copyright notice and this permission notice shall be
+-- included in all copies or substantial portions of the Software.
+--
+-- THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+-- EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+-- MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+-- NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+-- LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+-- OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+-- WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+-- Notifies application instances that authorization decisions they cached may be stale.
+CREATE OR REPLACE FUNCTION notify_permissions_changed() RETURNS TRIGGER AS $$
+BEGIN
+ PERFORM pg_notify('permissions_changed', TG_TABLE_NAME);
+ RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER user_roles_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON user_roles
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER role_permissions_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON role_permissions
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER resource_permissions_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON resource_permissions
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER role_parents_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON role_parents
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER access_control_entries_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON access_control_entries
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER roles_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON roles
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER permissions_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON permissions
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER resources_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON resources
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
+
+CREATE TRIGGER organizations_permissions_changed
+ AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON organizations
+ FOR EACH STATEMENT EXECUTE PROCEDURE notify_permissions_changed();
@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPIAppointmentRouter - Initialize API router for viewing appointments.
+func InitAPIAppointmentRouter() *mux.Router {
+	// Paths
+	appointmentPath := "/api/v1/organizations/{organization}/appointments"
+	// Router
+	appointmentRouter := apiV1Router.PathPrefix(appointmentPath).Subrouter()
+	// Availability
+	appointmentRouter.Handle("/availability", AllowParty(models.AppointmentResourceTag, api.GetAvailabilityWindows)).Methods("GET")
+	appointmentRouter.Handle("/availability", AllowParty(models.AppointmentResourceTag, api.CreateAvailabilityWindow)).Methods("POST")
+	appointmentRouter.Handle("/availability/{window}", AllowParty(models.AppointmentResourceTag, api.DeleteAvailabilityWindow)).Methods("DELETE")
+	// Open to any signed-in user: prospects look up slots and book
+	// viewings before having any tie with the organization. Handlers
+	// limit users not taking part in it to published listings.
+	appointmentRouter.HandleFunc("/slots", api.GetViewingSlots).Methods("GET")
+	// Viewings
+	appointmentRouter.Handle("/viewings", AllowParty(models.AppointmentResourceTag, api.GetViewings)).Methods("GET")
+	appointmentRouter.HandleFunc("/viewings", api.BookViewing).Methods("POST") // Open, see slots
+	appointmentRouter.Handle("/viewings/{viewing}", AllowParty(models.AppointmentResourceTag, api.GetViewing)).Methods("GET")
+	appointmentRouter.Handle("/viewings/{viewing}/cancel", AllowParty(models.AppointmentResourceTag, api.CancelViewing)).Methods("POST")
+	// Calendar feeds
+	appointmentRouter.Handle("/agents/{agent}/viewings.ics", AllowParty(models.AppointmentResourceTag, api.GetAgentViewingsCalendar)).Methods("GET")
+	appointmentRouter.Handle("/listings/{listing}/viewings.ics", AllowParty(models.AppointmentResourceTag, api.GetListingViewingsCalendar)).Methods("GET")
+	return appointmentRouter
+}
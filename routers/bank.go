@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPIBankRouter - Initialize API router for bank statements.
+func InitAPIBankRouter() *mux.Router {
+	// Paths
+	bankPath := "/api/v1/organizations/{organization}/bank"
+	// Router
+	bankRouter := apiV1Router.PathPrefix(bankPath).Subrouter()
+	// Statements
+	bankRouter.Handle("/statements", Allow(models.BankResourceTag, api.GetBankStatements)).Methods("GET")
+	bankRouter.Handle("/statements", Allow(models.BankResourceTag, api.ImportBankStatement)).Methods("POST")
+	bankRouter.Handle("/statements/{statement}", Allow(models.BankResourceTag, api.GetBankStatement)).Methods("GET")
+	// Transactions
+	bankRouter.Handle("/transactions", Allow(models.BankResourceTag, api.GetBankTransactions)).Methods("GET")
+	bankRouter.Handle("/transactions/{transaction}", Allow(models.BankResourceTag, api.GetBankTransaction)).Methods("GET")
+	bankRouter.Handle("/transactions/{transaction}/match", Allow(models.BankResourceTag, api.MatchBankTransaction)).Methods("POST")
+	bankRouter.Handle("/transactions/{transaction}/ignore", Allow(models.BankResourceTag, api.IgnoreBankTransaction)).Methods("POST")
+	return bankRouter
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPICRMRouter - Initialize API router for leads pipeline.
+func InitAPICRMRouter() *mux.Router {
+	// Paths
+	crmPath := "/api/v1/organizations/{organization}/crm"
+	// Router
+	crmRouter := apiV1Router.PathPrefix(crmPath).Subrouter()
+	// Stages
+	crmRouter.Handle("/stages", AllowParty(models.CRMResourceTag, api.GetLeadStages)).Methods("GET")
+	crmRouter.Handle("/stages", AllowParty(models.CRMResourceTag, api.CreateLeadStage)).Methods("POST")
+	crmRouter.Handle("/stages/{stage}", AllowParty(models.CRMResourceTag, api.UpdateLeadStage)).Methods("PUT")
+	crmRouter.Handle("/stages/{stage}", AllowParty(models.CRMResourceTag, api.DeleteLeadStage)).Methods("DELETE")
+	// Leads
+	crmRouter.Handle("/leads", AllowParty(models.CRMResourceTag, api.GetLeads)).Methods("GET")
+	crmRouter.Handle("/leads", AllowParty(models.CRMResourceTag, api.CreateLead)).Methods("POST")
+	crmRouter.Handle("/leads/{lead}", AllowParty(models.CRMResourceTag, api.GetLead)).Methods("GET")
+	crmRouter.Handle("/leads/{lead}", AllowParty(models.CRMResourceTag, api.UpdateLead)).Methods("PUT")
+	crmRouter.Handle("/leads/{lead}", AllowParty(models.CRMResourceTag, api.DeleteLead)).Methods("DELETE")
+	crmRouter.Handle("/leads/{lead}/move", AllowParty(models.CRMResourceTag, api.MoveLead)).Methods("POST")
+	// Activities
+	crmRouter.Handle("/leads/{lead}/activities", AllowParty(models.CRMResourceTag, api.GetLeadActivities)).Methods("GET")
+	crmRouter.Handle("/leads/{lead}/activities", AllowParty(models.CRMResourceTag, api.CreateLeadActivity)).Methods("POST")
+	crmRouter.Handle("/leads/{lead}/activities/{activity}/done", AllowParty(models.CRMResourceTag, api.CompleteLeadReminder)).Methods("POST")
+	crmRouter.Handle("/reminders", AllowParty(models.CRMResourceTag, api.GetLeadReminders)).Methods("GET")
+	// Reports
+	crmRouter.Handle("/funnel", AllowParty(models.CRMResourceTag, api.GetLeadFunnel)).Methods("GET")
+	return crmRouter
+}
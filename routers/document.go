@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPIDocumentRouter - Initialize API router for documents.
+func InitAPIDocumentRouter() *mux.Router {
+	// Paths
+	documentPath := "/api/v1/organizations/{organization}/documents"
+	// Router
+	documentRouter := apiV1Router.PathPrefix(documentPath).Subrouter()
+	// Documents
+	documentRouter.Handle("", AllowParty(models.DocumentResourceTag, api.GetDocuments)).Methods("GET")
+	documentRouter.Handle("", AllowParty(models.DocumentResourceTag, api.CreateDocument)).Methods("POST")
+	documentRouter.Handle("/{document}", AllowParty(models.DocumentResourceTag, api.GetDocument)).Methods("GET")
+	documentRouter.Handle("/{document}", AllowParty(models.DocumentResourceTag, api.UpdateDocument)).Methods("PUT")
+	documentRouter.Handle("/{document}", AllowParty(models.DocumentResourceTag, api.DeleteDocument)).Methods("DELETE")
+	documentRouter.Handle("/{document}/download", AllowParty(models.DocumentResourceTag, api.DownloadDocument)).Methods("GET")
+	// Versions
+	documentRouter.Handle("/{document}/versions", AllowParty(models.DocumentResourceTag, api.GetDocumentVersions)).Methods("GET")
+	documentRouter.Handle("/{document}/versions", AllowParty(models.DocumentResourceTag, api.AddDocumentVersion)).Methods("POST")
+	documentRouter.Handle("/{document}/versions/{version:[0-9]+}/download", AllowParty(models.DocumentResourceTag, api.DownloadDocumentVersion)).Methods("GET")
+	return documentRouter
+}
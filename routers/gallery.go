@@ -0,0 +1,56 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPIGalleryRouter - Initialize API router for photo galleries.
+func InitAPIGalleryRouter() *mux.Router {
+	// Paths
+	albumPath := "/api/v1/organizations/{organization}/albums"
+	// Router
+	albumRouter := apiV1Router.PathPrefix(albumPath).Subrouter()
+	// Albums
+	// Reads are checked by the handlers: users taking part in the organization see every album,
+	// others only those of the organization and its published listings.
+	albumRouter.HandleFunc("", api.GetAlbums).Methods("GET")
+	albumRouter.Handle("", Allow(models.GalleryResourceTag, api.CreateAlbum)).Methods("POST")
+	albumRouter.HandleFunc("/{album}", api.GetAlbum).Methods("GET")
+	albumRouter.Handle("/{album}", Allow(models.GalleryResourceTag, api.UpdateAlbum)).Methods("PUT")
+	albumRouter.Handle("/{album}", Allow(models.GalleryResourceTag, api.DeleteAlbum)).Methods("DELETE")
+	albumRouter.Handle("/{album}/order", Allow(models.GalleryResourceTag, api.ReorderAlbumImages)).Methods("PUT")
+	// Images
+	albumRouter.Handle("/{album}/images", Allow(models.GalleryResourceTag, api.UploadAlbumImages)).Methods("POST")
+	albumRouter.HandleFunc("/{album}/images/{image}", api.GetAlbumImage).Methods("GET")
+	albumRouter.Handle("/{album}/images/{image}", Allow(models.GalleryResourceTag, api.UpdateAlbumImage)).Methods("PUT")
+	albumRouter.Handle("/{album}/images/{image}", Allow(models.GalleryResourceTag, api.DeleteAlbumImage)).Methods("DELETE")
+	albumRouter.Handle("/{album}/images/{image}/cover", Allow(models.GalleryResourceTag, api.SetAlbumCover)).Methods("POST")
+	albumRouter.HandleFunc("/{album}/images/{image}/{rendition:original|medium|thumbnail}", api.GetAlbumImageRendition).Methods("GET")
+	return albumRouter
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPILeaseRouter - Initialize API router for leases.
+func InitAPILeaseRouter() *mux.Router {
+	// Paths
+	leasePath := "/api/v1/organizations/{organization}/leases"
+	// Router
+	leaseRouter := apiV1Router.PathPrefix(leasePath).Subrouter()
+	// Resource
+	leaseRouter.Handle("", Allow(models.LeaseResourceTag, api.GetLeases)).Methods("GET")
+	leaseRouter.Handle("", Allow(models.LeaseResourceTag, api.CreateLease)).Methods("POST")
+	leaseRouter.Handle("/{lease}", AllowParty(models.LeaseResourceTag, api.GetLease)).Methods("GET")
+	leaseRouter.Handle("/{lease}", Allow(models.LeaseResourceTag, api.UpdateLease)).Methods("PUT")
+	leaseRouter.Handle("/{lease}", Allow(models.LeaseResourceTag, api.DeleteLease)).Methods("DELETE")
+	// Rent schedule
+	leaseRouter.Handle("/{lease}/schedule", AllowParty(models.LeaseResourceTag, api.GetLeaseSchedule)).Methods("GET")
+	// Tenants
+	leaseRouter.Handle("/{lease}/tenants", AllowParty(models.LeaseResourceTag, api.GetLeaseTenants)).Methods("GET")
+	leaseRouter.Handle("/{lease}/tenants/{user}", Allow(models.LeaseResourceTag, api.AddLeaseTenant)).Methods("POST")
+	leaseRouter.Handle("/{lease}/tenants/{user}", Allow(models.LeaseResourceTag, api.RemoveLeaseTenant)).Methods("DELETE")
+	// Invoices
+	leaseRouter.Handle("/{lease}/invoices", AllowParty(models.LeaseResourceTag, api.GetLeaseInvoices)).Methods("GET")
+	leaseRouter.Handle("/{lease}/invoices", Allow(models.LeaseResourceTag, api.GenerateLeaseInvoices)).Methods("POST")
+	return leaseRouter
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPILedgerRouter - Initialize API router for ledger.
+func InitAPILedgerRouter() *mux.Router {
+	// Paths
+	ledgerPath := "/api/v1/organizations/{organization}/ledger"
+	// Router
+	ledgerRouter := apiV1Router.PathPrefix(ledgerPath).Subrouter()
+	// Accounts
+	ledgerRouter.Handle("/accounts", Allow(models.LedgerResourceTag, api.GetAccounts)).Methods("GET")
+	// Journal entries
+	ledgerRouter.Handle("/journal-entries", Allow(models.LedgerResourceTag, api.GetJournalEntries)).Methods("GET")
+	ledgerRouter.Handle("/journal-entries/{entry}", Allow(models.LedgerResourceTag, api.GetJournalEntry)).Methods("GET")
+	ledgerRouter.Handle("/journal-entries/{entry}/reversal", Allow(models.LedgerResourceTag, api.ReverseJournalEntry)).Methods("POST")
+	// Invoices
+	ledgerRouter.Handle("/invoices", Allow(models.LedgerResourceTag, api.GetInvoices)).Methods("GET")
+	ledgerRouter.Handle("/invoices/{invoice}", AllowParty(models.LedgerResourceTag, api.GetInvoice)).Methods("GET")
+	ledgerRouter.Handle("/invoices/{invoice}/payments", Allow(models.LedgerResourceTag, api.PayInvoice)).Methods("POST")
+	// Statements
+	ledgerRouter.Handle("/statements/units/{unit}", Allow(models.LedgerResourceTag, api.GetUnitStatement)).Methods("GET")
+	ledgerRouter.Handle("/statements/tenants/{user}", AllowParty(models.LedgerResourceTag, api.GetTenantStatement)).Methods("GET")
+	return ledgerRouter
+}
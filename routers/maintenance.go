@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package routers
+
+import (
+	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
+
+	"github.com/gorilla/mux"
+)
+
+// InitAPIMaintenanceRouter - Initialize API router for maintenance requests and work orders.
+func InitAPIMaintenanceRouter() *mux.Router {
+	// Paths
+	maintenancePath := "/api/v1/organizations/{organization}/maintenance"
+	// Router
+	maintenanceRouter := apiV1Router.PathPrefix(maintenancePath).Subrouter()
+	// Requests
+	maintenanceRouter.Handle("/requests", AllowParty(models.MaintenanceResourceTag, api.GetMaintenanceRequests)).Methods("GET")
+	maintenanceRouter.Handle("/requests", AllowParty(models.MaintenanceResourceTag, api.CreateMaintenanceRequest)).Methods("POST")
+	maintenanceRouter.Handle("/requests/{request}", AllowParty(models.MaintenanceResourceTag, api.GetMaintenanceRequest)).Methods("GET")
+	maintenanceRouter.Handle("/requests/{request}", AllowParty(models.MaintenanceResourceTag, api.UpdateMaintenanceRequest)).Methods("PUT")
+	maintenanceRouter.Handle("/requests/{request}", AllowParty(models.MaintenanceResourceTag, api.DeleteMaintenanceRequest)).Methods("DELETE")
+	// Work orders
+	maintenanceRouter.Handle("/requests/{request}/work-orders", AllowParty(models.MaintenanceResourceTag, api.GetWorkOrders)).Methods("GET")
+	maintenanceRouter.Handle("/requests/{request}/work-orders", AllowParty(models.MaintenanceResourceTag, api.CreateWorkOrder)).Methods("POST")
+	maintenanceRouter.Handle("/requests/{request}/work-orders/{order}", AllowParty(models.MaintenanceResourceTag, api.UpdateWorkOrder)).Methods("PUT")
+	// Comments
+	maintenanceRouter.Handle("/requests/{request}/comments", AllowParty(models.MaintenanceResourceTag, api.GetMaintenanceComments)).Methods("GET")
+	maintenanceRouter.Handle("/requests/{request}/comments", AllowParty(models.MaintenanceResourceTag, api.CreateMaintenanceComment)).Methods("POST")
+	// Photos
+	maintenanceRouter.Handle("/requests/{request}/photos", AllowParty(models.MaintenanceResourceTag, api.GetMaintenancePhotos)).Methods("GET")
+	maintenanceRouter.Handle("/requests/{request}/photos", AllowParty(models.MaintenanceResourceTag, api.CreateMaintenancePhoto)).Methods("POST")
+	maintenanceRouter.Handle("/requests/{request}/photos/{photo}", AllowParty(models.MaintenanceResourceTag, api.GetMaintenancePhoto)).Methods("GET")
+	maintenanceRouter.Handle("/requests/{request}/photos/{photo}", AllowParty(models.MaintenanceResourceTag, api.DeleteMaintenancePhoto)).Methods("DELETE")
+	return maintenanceRouter
+}
@@ -25,6 +25,7 @@ package routers
 
 import (
 	"github.com/adrianpk/fundacja/api"
+	"github.com/adrianpk/fundacja/models"
 
 	"github.com/gorilla/mux"
 )
@@ -38,44 +39,86 @@ func InitAPIOrganizationRouter() *mux.Router {
 	// Resource
 	organizationAPIRouter.HandleFunc("", api.GetOrganizations).Methods("GET")
 	organizationAPIRouter.HandleFunc("", api.CreateOrganization).Methods("POST")
+	// Checked by the handler, organizations can be addressed by name
 	organizationAPIRouter.HandleFunc("/{organization}", api.GetOrganization).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}", api.UpdateOrganization).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}", api.DeleteOrganization).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}", Allow(models.OrganizationResourceTag, api.UpdateOrganization)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}", Allow(models.OrganizationResourceTag, api.DeleteOrganization)).Methods("DELETE")
 	// Resource
-	organizationAPIRouter.HandleFunc("/{organization}/resources", api.GetResources).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/resources", api.CreateResource).Methods("POST")
-	organizationAPIRouter.HandleFunc("/{organization}/resources/{resource}", api.GetResource).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/resources/{resource}", api.UpdateResource).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}/resources/{resource}", api.DeleteResource).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/resources", Allow(models.AccessControlResourceTag, api.GetResources)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/resources", Allow(models.AccessControlResourceTag, api.CreateResource)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/resources/{resource}", Allow(models.AccessControlResourceTag, api.GetResource)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/resources/{resource}", Allow(models.AccessControlResourceTag, api.UpdateResource)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/resources/{resource}", Allow(models.AccessControlResourceTag, api.DeleteResource)).Methods("DELETE")
 	// Resource
-	organizationAPIRouter.HandleFunc("/{organization}/permissions", api.GetPermissions).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/permissions", api.CreatePermission).Methods("POST")
-	organizationAPIRouter.HandleFunc("/{organization}/permissions/{permission}", api.GetPermission).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/permissions/{permission}", api.UpdatePermission).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}/permissions/{permission}", api.DeletePermission).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/permissions", Allow(models.AccessControlResourceTag, api.GetPermissions)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/permissions", Allow(models.AccessControlResourceTag, api.CreatePermission)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/permissions/{permission}", Allow(models.AccessControlResourceTag, api.GetPermission)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/permissions/{permission}", Allow(models.AccessControlResourceTag, api.UpdatePermission)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/permissions/{permission}", Allow(models.AccessControlResourceTag, api.DeletePermission)).Methods("DELETE")
 	// Resource
-	organizationAPIRouter.HandleFunc("/{organization}/resource-permissions", api.GetResourcePermissions).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/resource-permissions", api.CreateResourcePermission).Methods("POST")
-	organizationAPIRouter.HandleFunc("/{organization}/resource-permissions/{resource-permission}", api.GetResourcePermission).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/resource-permissions/{resource-permission}", api.UpdateResourcePermission).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}/resource-permissions/{resource-permission}", api.DeleteResourcePermission).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/resource-permissions", Allow(models.AccessControlResourceTag, api.GetResourcePermissions)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/resource-permissions", Allow(models.AccessControlResourceTag, api.CreateResourcePermission)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/resource-permissions/{resource-permission}", Allow(models.AccessControlResourceTag, api.GetResourcePermission)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/resource-permissions/{resource-permission}", Allow(models.AccessControlResourceTag, api.UpdateResourcePermission)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/resource-permissions/{resource-permission}", Allow(models.AccessControlResourceTag, api.DeleteResourcePermission)).Methods("DELETE")
 	// Resource
-	organizationAPIRouter.HandleFunc("/{organization}/roles", api.GetRoles).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/roles", api.CreateRole).Methods("POST")
-	organizationAPIRouter.HandleFunc("/{organization}/roles/{role}", api.GetRole).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/roles/{role}", api.UpdateRole).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}/roles/{role}", api.DeleteRole).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/roles", Allow(models.AccessControlResourceTag, api.GetRoles)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/roles", Allow(models.AccessControlResourceTag, api.CreateRole)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}", Allow(models.AccessControlResourceTag, api.GetRole)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}", Allow(models.AccessControlResourceTag, api.UpdateRole)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}", Allow(models.AccessControlResourceTag, api.DeleteRole)).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}/parents", Allow(models.AccessControlResourceTag, api.GetRoleParents)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}/parents", Allow(models.AccessControlResourceTag, api.AddRoleParent)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}/parents/{parent}", Allow(models.AccessControlResourceTag, api.DeleteRoleParent)).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/roles/{role}/permissions", Allow(models.AccessControlResourceTag, api.GetRoleEffectivePermissions)).Methods("GET")
 	// Resource
-	organizationAPIRouter.HandleFunc("/{organization}/role-permissions", api.GetRolePermissions).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/role-permissions", api.CreateRolePermission).Methods("POST")
-	organizationAPIRouter.HandleFunc("/{organization}/role-permissions/{role-permission}", api.GetRolePermission).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/role-permissions/{role-permission}", api.UpdateRolePermission).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}/role-permissions/{role-permission}", api.DeleteRolePermission).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/role-permissions", Allow(models.AccessControlResourceTag, api.GetRolePermissions)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/role-permissions", Allow(models.AccessControlResourceTag, api.CreateRolePermission)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/role-permissions/{role-permission}", Allow(models.AccessControlResourceTag, api.GetRolePermission)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/role-permissions/{role-permission}", Allow(models.AccessControlResourceTag, api.UpdateRolePermission)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/role-permissions/{role-permission}", Allow(models.AccessControlResourceTag, api.DeleteRolePermission)).Methods("DELETE")
 	// Resource
-	organizationAPIRouter.HandleFunc("/{organization}/user-roles", api.GetUserRoles).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/user-roles", api.CreateUserRole).Methods("POST")
-	organizationAPIRouter.HandleFunc("/{organization}/user-roles/{user-role}", api.GetUserRole).Methods("GET")
-	organizationAPIRouter.HandleFunc("/{organization}/user-roles/{user-role}", api.UpdateUserRole).Methods("PUT")
-	organizationAPIRouter.HandleFunc("/{organization}/user-roles/{user-role}", api.DeleteUserRole).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/user-roles", Allow(models.AccessControlResourceTag, api.GetUserRoles)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/user-roles", Allow(models.AccessControlResourceTag, api.CreateUserRole)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/user-roles/{user-role}", Allow(models.AccessControlResourceTag, api.GetUserRole)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/user-roles/{user-role}", Allow(models.AccessControlResourceTag, api.UpdateUserRole)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/user-roles/{user-role}", Allow(models.AccessControlResourceTag, api.DeleteUserRole)).Methods("DELETE")
+	// Resource
+	organizationAPIRouter.Handle("/{organization}/access-control-entries", Allow(models.AccessControlResourceTag, api.GetAccessControlEntries)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/access-control-entries", Allow(models.AccessControlResourceTag, api.CreateAccessControlEntry)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/access-control-entries/{access-control-entry}", Allow(models.AccessControlResourceTag, api.GetAccessControlEntry)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/access-control-entries/{access-control-entry}", Allow(models.AccessControlResourceTag, api.DeleteAccessControlEntry)).Methods("DELETE")
+	// Resource
+	organizationAPIRouter.Handle("/{organization}/listings", Allow(models.ListingResourceTag, api.GetListings)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/listings", Allow(models.ListingResourceTag, api.CreateListing)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/listings/{listing}", Allow(models.ListingResourceTag, api.GetListing)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/listings/{listing}", Allow(models.ListingResourceTag, api.UpdateListing)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/listings/{listing}", Allow(models.ListingResourceTag, api.DeleteListing)).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/listings/{listing}/properties-sets", Allow(models.ListingResourceTag, api.GetListingPropertiesSets)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/listings/{listing}/properties-sets", Allow(models.ListingResourceTag, api.CreateListingPropertiesSet)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/listings/{listing}/history", Allow(models.ListingResourceTag, api.GetListingHistory)).Methods("GET")
+	// Resource
+	organizationAPIRouter.Handle("/{organization}/buildings", Allow(models.BuildingResourceTag, api.GetBuildings)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings", Allow(models.BuildingResourceTag, api.CreateBuilding)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}", Allow(models.BuildingResourceTag, api.GetBuilding)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}", Allow(models.BuildingResourceTag, api.UpdateBuilding)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}", Allow(models.BuildingResourceTag, api.DeleteBuilding)).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/occupancy", Allow(models.BuildingResourceTag, api.GetBuildingOccupancy)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/properties-sets", Allow(models.BuildingResourceTag, api.GetBuildingPropertiesSets)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/properties-sets", Allow(models.BuildingResourceTag, api.CreateBuildingPropertiesSet)).Methods("POST")
+	// Resource
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units", Allow(models.BuildingResourceTag, api.GetUnits)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units", Allow(models.BuildingResourceTag, api.CreateUnit)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units/{unit}", Allow(models.BuildingResourceTag, api.GetUnit)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units/{unit}", Allow(models.BuildingResourceTag, api.UpdateUnit)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units/{unit}", Allow(models.BuildingResourceTag, api.DeleteUnit)).Methods("DELETE")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units/{unit}/properties-sets", Allow(models.BuildingResourceTag, api.GetUnitPropertiesSets)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/buildings/{building}/units/{unit}/properties-sets", Allow(models.BuildingResourceTag, api.CreateUnitPropertiesSet)).Methods("POST")
+	// Resource
+	organizationAPIRouter.Handle("/{organization}/properties-set-templates", AllowParty(models.PropertiesSetTemplateResourceTag, api.GetPropertiesSetTemplates)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/properties-set-templates", Allow(models.PropertiesSetTemplateResourceTag, api.CreatePropertiesSetTemplate)).Methods("POST")
+	organizationAPIRouter.Handle("/{organization}/properties-set-templates/{template}", AllowParty(models.PropertiesSetTemplateResourceTag, api.GetPropertiesSetTemplate)).Methods("GET")
+	organizationAPIRouter.Handle("/{organization}/properties-set-templates/{template}", Allow(models.PropertiesSetTemplateResourceTag, api.UpdatePropertiesSetTemplate)).Methods("PUT")
+	organizationAPIRouter.Handle("/{organization}/properties-set-templates/{template}", Allow(models.PropertiesSetTemplateResourceTag, api.DeletePropertiesSetTemplate)).Methods("DELETE")
 	return organizationAPIRouter
 }
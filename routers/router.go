@@ -29,6 +29,7 @@ import (
 
 	"github.com/adrianpk/fundacja/bootstrap"
 	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/services"
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 )
@@ -49,6 +50,7 @@ func GetRouter(config bootstrap.Configuration) *mux.Router {
 	InitSubRouters()
 	InitAPIV1Router()
 	InitAPIV1SubRouters()
+	InitBlobRouter()
 	InitPublicFilesystem(config.GetPublicDir())
 	return appRouter
 }
@@ -78,11 +80,22 @@ func InitAPIV1Router() {
 // InitAPIV1SubRouters - Initialize API subrouters.
 func InitAPIV1SubRouters() {
 	InitAPIUserRouter()
+	// Leases, ledger, bank, maintenance, appointments, CRM, galleries and documents are nested in organization paths, register them first.
+	InitAPILeaseRouter()
+	InitAPILedgerRouter()
+	InitAPIBankRouter()
+	InitAPIMaintenanceRouter()
+	InitAPIAppointmentRouter()
+	InitAPICRMRouter()
+	InitAPIGalleryRouter()
+	InitAPIDocumentRouter()
 	InitAPIOrganizationRouter()
 	InitAPIPropertiesSetRouter()
 	InitAPIPropertyRouter()
 	InitAPIPlanSubscriptionRouter()
 	InitAPIPlanRouter()
+	InitAPISearchRouter()
+	InitAPIPermissionCacheRouter()
 }
 
 // InitSignupAndLoginRouter - Get a router for API calls.
@@ -133,6 +146,29 @@ func InitPublicFilesystems(publicDir string) {
 	appRouter.Handle(publicPath, fsServer)
 }
 
+// Allow - Wraps a handler so that it is only reached by users allowed to perform
+// the action the request method maps to over the resource tagged resourceTag.
+func Allow(resourceTag string, handler http.HandlerFunc) http.Handler {
+	return AllowAction(resourceTag, "", handler)
+}
+
+// AllowAction - Like Allow but requires a given action whatever the request method is.
+func AllowAction(resourceTag, action string, handler http.HandlerFunc) http.Handler {
+	return negroni.New(
+		negroni.HandlerFunc(services.AuthorizeAction(resourceTag, action)),
+		negroni.Wrap(handler),
+	)
+}
+
+// AllowParty - Like Allow but also lets through users taking part in the organization
+// without a permission over the resource, for handlers that decide what each party can do.
+func AllowParty(resourceTag string, handler http.HandlerFunc) http.Handler {
+	return negroni.New(
+		negroni.HandlerFunc(services.AuthorizeParty(resourceTag)),
+		negroni.Wrap(handler),
+	)
+}
+
 // NewRouter - Creates a new mux.router.
 func NewRouter() *mux.Router {
 	r := mux.NewRouter()
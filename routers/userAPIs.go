@@ -47,5 +47,6 @@ func InitAPIUserRouter() *mux.Router {
 	userAPIRouter.HandleFunc("/{user}/profile", api.DeleteUserProfile).Methods("DELETE")
 	// Resource
 	userAPIRouter.HandleFunc("/{user}/profile/avatar", api.HandleAvatar)
+	userAPIRouter.HandleFunc("/{user}/profile/header", api.HandleHeader)
 	return userAPIRouter
 }
@@ -24,46 +24,222 @@
 package services
 
 import (
-	//"database/sql"
-	//httpcontext "github.com/gorilla/context"
-	//"github.com/jmoiron/sqlx"
+	"database/sql"
+	"net/http"
+	"sync"
 
+	"github.com/adrianpk/fundacja/app"
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
 
 	_ "github.com/lib/pq" // Import pq without side effects
 
 	"github.com/adrianpk/fundacja/repo"
 )
 
-// IsAllowed - Returns true if user has at leeast one enabling permission over some resource.
-// Panic if not.
-func IsAllowed(resourceIDOrTag, userID string) {
-	hasPermission := HasPermission(resourceIDOrTag, userID)
+var (
+	sharedPermissionRepo       repo.PermissionRepository
+	sharedPermissionRepoErr    error
+	sharedPermissionRepoOnce   sync.Once
+	sharedOrganizationRepo     repo.OrganizationRepository
+	sharedOrganizationRepoErr  error
+	sharedOrganizationRepoOnce sync.Once
+)
+
+// entityVars - Path variables holding the ID of the entity of each entity type.
+var entityVars = map[string]string{
+	models.ListingResourceTag:  "listing",
+	models.BuildingResourceTag: "building",
+}
+
+// Authorize - Returns a middleware that lets the request through only if the session user
+// can perform the action its HTTP method maps to over the resource tagged resourceTag, responds 403 otherwise.
+// In organization paths the check is scoped to the organization in the path.
+// Paths of a single entity are also let through if the user has been granted the action over that entity.
+func Authorize(resourceTag string) negroni.HandlerFunc {
+	return AuthorizeAction(resourceTag, "")
+}
+
+// AuthorizeAction - Like Authorize but checks a given action instead of the one mapped from the HTTP method.
+func AuthorizeAction(resourceTag, action string) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		claims, ok := r.Context().Value(bootstrap.UserCtxKey).(bootstrap.AppClaims)
+		if !ok {
+			app.ShowError(w, app.ErrUnauthorized, app.ErrNotLoggedIn, http.StatusUnauthorized)
+			return
+		}
+		requested := action
+		if requested == "" {
+			requested = MethodAction(r.Method)
+		}
+		allowed, err := CanAccess(claims.UserID, requested, resourceTag, mux.Vars(r))
+		if err != nil {
+			app.ShowError(w, app.ErrRequestProcessing, err, http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			app.ShowError(w, app.ErrForbidden, app.ErrUnauthorized, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// AuthorizeParty - Like Authorize but also lets through, whatever their permissions, users
+// taking part in the organization in the path: members, tenants, maintenance reporters and assignees
+// and viewing prospects. Handlers restrict what each of them can reach.
+func AuthorizeParty(resourceTag string) negroni.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		claims, ok := r.Context().Value(bootstrap.UserCtxKey).(bootstrap.AppClaims)
+		if !ok {
+			app.ShowError(w, app.ErrUnauthorized, app.ErrNotLoggedIn, http.StatusUnauthorized)
+			return
+		}
+		vars := mux.Vars(r)
+		allowed, err := CanAccess(claims.UserID, MethodAction(r.Method), resourceTag, vars)
+		if err == nil && !allowed {
+			allowed, err = IsOrganizationParty(claims.UserID, vars["organization"])
+		}
+		if err != nil {
+			app.ShowError(w, app.ErrRequestProcessing, err, http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			app.ShowError(w, app.ErrForbidden, app.ErrUnauthorized, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// IsOrganizationParty - Returns true if user is a member of the organization or deals with it
+// as tenant, maintenance reporter or assignee or viewing prospect.
+// Not cached: these ties change with business data, not with roles and permissions.
+func IsOrganizationParty(userID, orgID string) (bool, error) {
+	if len(orgID) != 36 {
+		return false, nil
+	}
+	organizationRepo, err := organizationRepository()
+	if err != nil {
+		return false, err
+	}
+	return organizationRepo.IsParty(orgID, userID)
+}
+
+// MethodAction - Returns the action an HTTP method performs by default.
+// Unknown methods require managing the resource.
+func MethodAction(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.ActionRead
+	case http.MethodPost:
+		return models.ActionCreate
+	case http.MethodPut, http.MethodPatch:
+		return models.ActionUpdate
+	case http.MethodDelete:
+		return models.ActionDelete
+	}
+	return models.ActionManage
+}
+
+// Can - Returns true if user can perform an action over some resource.
+// If an organization ID is given its owner can perform any action, other users need a permission
+// granted through their roles in that organization.
+// Decisions are cached until roles, permissions or grants change.
+func Can(userID, action, resourceIDOrTag, orgID string) (bool, error) {
+	key := cache.DecisionKey("resource", userID, action, resourceIDOrTag, orgID)
+	return cache.Decisions.Lookup(key, func() (bool, error) {
+		return canPerform(userID, action, resourceIDOrTag, orgID)
+	})
+}
+
+func canPerform(userID, action, resourceIDOrTag, orgID string) (bool, error) {
+	if orgID != "" {
+		isOwner, err := isOrganizationOwner(orgID, userID)
+		if err != nil || isOwner {
+			return isOwner, err
+		}
+	}
+	// Get repo
+	permissionRepo, err := permissionRepository()
+	if err != nil {
+		return false, err
+	}
+	// Select
+	can, err := permissionRepo.Can(userID, action, resourceIDOrTag, orgID)
 	logger.Debugf("User: %s", userID)
 	logger.Debugf("Resource: %s", resourceIDOrTag)
-	logger.Debugf("Allowed? %t", hasPermission)
-	if hasPermission {
-		return
+	logger.Debugf("Action: %s", action)
+	logger.Debugf("Allowed? %t", can)
+	return can, err
+}
+
+// CanAccess - Returns true if user can perform an action over the resource tagged resourceTag
+// or, lacking that, over the entity addressed by the request path variables.
+// Reading collections is allowed to users granted reading some of their entities,
+// handlers restrict the result to them through VisibleEntityIDs.
+func CanAccess(userID, action, resourceTag string, vars map[string]string) (bool, error) {
+	orgID := vars["organization"]
+	can, err := Can(userID, action, resourceTag, orgID)
+	if err != nil || can {
+		return can, err
 	}
-	panic("Forbidden")
+	entityVar, ok := entityVars[resourceTag]
+	if !ok || orgID == "" {
+		return false, nil
+	}
+	entityID := vars[entityVar]
+	if entityID == "" && action != models.ActionRead {
+		return false, nil
+	}
+	key := cache.DecisionKey("entity", userID, action, resourceTag, entityID, orgID)
+	return cache.Decisions.Lookup(key, func() (bool, error) {
+		return canAccessEntity(userID, action, resourceTag, entityID, orgID)
+	})
 }
 
-// HasPermission - Returns true if user has at least one permission over a resource.
-func HasPermission(resourceIDorTag, userID string) bool {
+// canAccessEntity - True if user has been granted an action over an entity
+// or, without entity ID, over any entity of its type.
+func canAccessEntity(userID, action, entityType, entityID, orgID string) (bool, error) {
+	// Get repo
+	permissionRepo, err := permissionRepository()
+	if err != nil {
+		return false, err
+	}
+	// Single entity
+	if entityID != "" {
+		return permissionRepo.CanAccess(userID, action, entityType, entityID, orgID)
+	}
+	// Collection
+	ids, err := permissionRepo.GetGrantedEntityIDs(userID, action, entityType, orgID)
+	return len(ids) > 0, err
+}
+
+// VisibleEntityIDs - Returns the IDs of the entities of some type in an organization user can read.
+// all is true if user can read every entity of that type.
+func VisibleEntityIDs(userID, entityType, orgID string) (ids []string, all bool, err error) {
+	all, err = Can(userID, models.ActionRead, entityType, orgID)
+	if err != nil || all {
+		return nil, all, err
+	}
 	// Get repo
-	permissionRepo, err := repo.MakePermissionRepository()
+	permissionRepo, err := permissionRepository()
 	if err != nil {
-		return false
+		return nil, false, err
 	}
 	// Select
-	hasPermission, _ := permissionRepo.HasPermission(resourceIDorTag, userID)
-	return hasPermission
+	ids, err = permissionRepo.GetGrantedEntityIDs(userID, models.ActionRead, entityType, orgID)
+	return ids, false, err
 }
 
 // GetUserPermissionsIDs - Returns an array of Permissions IDs that are assigned to some User
 func GetUserPermissionsIDs(userID string) []string {
 	// Get repo
-	permissionRepo, err := repo.MakePermissionRepository()
+	permissionRepo, err := permissionRepository()
 	if err != nil {
 		return []string{}
 	}
@@ -75,7 +251,7 @@ func GetUserPermissionsIDs(userID string) []string {
 // GetEnablingPermissionIDs - Returns an array of Permissions IDs that enable the use of some Resource
 func GetEnablingPermissionIDs(resourceIDorTag string) []string {
 	// Get repo
-	permissionRepo, err := repo.MakePermissionRepository()
+	permissionRepo, err := permissionRepository()
 	if err != nil {
 		return []string{}
 	}
@@ -83,3 +259,38 @@ func GetEnablingPermissionIDs(resourceIDorTag string) []string {
 	permissionIDs, _ := permissionRepo.GetEnablingPermissionIDs(resourceIDorTag)
 	return permissionIDs
 }
+
+// permissionRepository - Permission repository shared by authorization checks,
+// opening a connection pool per check would dominate request latency.
+func permissionRepository() (*repo.PermissionRepository, error) {
+	sharedPermissionRepoOnce.Do(func() {
+		sharedPermissionRepo, sharedPermissionRepoErr = repo.MakePermissionRepository()
+	})
+	return &sharedPermissionRepo, sharedPermissionRepoErr
+}
+
+// organizationRepository - Organization repository shared by ownership checks.
+func organizationRepository() (*repo.OrganizationRepository, error) {
+	sharedOrganizationRepoOnce.Do(func() {
+		sharedOrganizationRepo, sharedOrganizationRepoErr = repo.MakeOrganizationRepository()
+	})
+	return &sharedOrganizationRepo, sharedOrganizationRepoErr
+}
+
+func isOrganizationOwner(orgID, userID string) (bool, error) {
+	if len(orgID) != 36 {
+		return false, nil
+	}
+	organizationRepo, err := organizationRepository()
+	if err != nil {
+		return false, err
+	}
+	organization, err := organizationRepo.Get(orgID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return organization.UserID.String == userID, nil
+}
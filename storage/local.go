@@ -0,0 +1,169 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStore - BlobStore keeping blobs as files under a root directory.
+// Signed URLs point to URLBase, where the blob handler checks them with VerifySignature.
+type LocalStore struct {
+	Root       string
+	URLBase    string
+	SigningKey []byte
+}
+
+// MakeLocalStore - LocalStore constructor.
+func MakeLocalStore(config Config) (*LocalStore, error) {
+	err := os.MkdirAll(config.Root, 0750)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStore{Root: config.Root, URLBase: config.URLBase, SigningKey: config.SigningKey}, nil
+}
+
+// Put - Writes content to a temporary file renamed to its key path once complete,
+// readers never see partial blobs.
+func (store *LocalStore) Put(key string, r io.Reader) (int64, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0750)
+	if err != nil {
+		return 0, err
+	}
+	tmp, err := ioutil.TempFile(dir, ".upload-")
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(tmp, r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return size, nil
+}
+
+// Get - Opens the file stored under key.
+func (store *LocalStore) Get(key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+// Delete - Removes the file stored under key.
+func (store *LocalStore) Delete(key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SignedURL - Returns a blob handler URL carrying key expiration time and signature.
+func (store *LocalStore) SignedURL(key string, expiration time.Duration) (string, error) {
+	_, err := store.path(key)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiration).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", signature(store.SigningKey, key, expires))
+	return fmt.Sprintf("%s/%s?%s", strings.TrimSuffix(store.URLBase, "/"), escapeKey(key), query.Encode()), nil
+}
+
+// VerifySignature - Checks a signed URL key, expiration time and signature.
+func (store *LocalStore) VerifySignature(key, expires, sig string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrURLSignature
+	}
+	expected := signature(store.SigningKey, key, expires)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrURLSignature
+	}
+	if time.Now().Unix() > unix {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+// signature - HMAC-SHA256 of key and expiration time.
+func signature(signingKey []byte, key, expires string) string {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// escapeKey - Escapes key segments keeping separators.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// path - Returns the file path of a key, keys cannot point outside the root directory.
+func (store *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(store.Root, clean), nil
+}
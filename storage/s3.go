@@ -0,0 +1,336 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3Service         = "s3"
+	s3DateFormat      = "20060102T150405Z"
+	s3ShortDateFormat = "20060102"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// Longest lifetime accepted for presigned URLs
+	s3MaxURLExpiration = 7 * 24 * time.Hour
+	// Bytes used to detect blob content type
+	s3SniffLen = 512
+)
+
+// S3Store - BlobStore keeping blobs as objects of an S3 compatible service bucket.
+// Requests are signed with AWS Signature Version 4, PathStyle addressing is
+// required by most self hosted services.
+type S3Store struct {
+	Endpoint  *url.URL
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	Client    *http.Client
+}
+
+// MakeS3Store - S3Store constructor.
+func MakeS3Store(config Config) (*S3Store, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("invalid s3 store endpoint '%s' or bucket '%s'", config.Endpoint, config.Bucket)
+	}
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Store{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    config.Bucket,
+		AccessKey: config.AccessKey,
+		SecretKey: config.SecretKey,
+		PathStyle: config.PathStyle,
+		Client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Put - Uploads content as an object, its content type is detected from the first bytes.
+// Content is buffered since the service requires its length and checksum up front.
+func (store *S3Store) Put(key string, r io.Reader) (int64, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	sniff := content
+	if len(sniff) > s3SniffLen {
+		sniff = sniff[:s3SniffLen]
+	}
+	req, err := store.request("PUT", key, bytes.NewReader(content))
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = int64(len(content))
+	req.Header.Set("Content-Type", http.DetectContentType(sniff))
+	store.sign(req, hashHex(content), time.Now())
+	res, err := store.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, s3Error(res)
+	}
+	return int64(len(content)), nil
+}
+
+// Get - Downloads an object.
+func (store *S3Store) Get(key string) (io.ReadCloser, error) {
+	req, err := store.request("GET", key, nil)
+	if err != nil {
+		return nil, err
+	}
+	store.sign(req, hashHex(nil), time.Now())
+	res, err := store.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrBlobNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, s3Error(res)
+	}
+	return res.Body, nil
+}
+
+// Delete - Removes an object.
+func (store *S3Store) Delete(key string) error {
+	req, err := store.request("DELETE", key, nil)
+	if err != nil {
+		return err
+	}
+	store.sign(req, hashHex(nil), time.Now())
+	res, err := store.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return s3Error(res)
+}
+
+// SignedURL - Returns a presigned GET URL, responses can be cached by clients until expiration.
+func (store *S3Store) SignedURL(key string, expiration time.Duration) (string, error) {
+	return store.presign(key, expiration, time.Now())
+}
+
+// presign - Returns a presigned GET URL for key valid from now until expiration.
+func (store *S3Store) presign(key string, expiration time.Duration, now time.Time) (string, error) {
+	u, err := store.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	if expiration > s3MaxURLExpiration {
+		expiration = s3MaxURLExpiration
+	}
+	now = now.UTC()
+	scope := store.scope(now)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", store.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(s3DateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiration/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	query.Set("response-cache-control", fmt.Sprintf("private, max-age=%d", int64(expiration/time.Second)))
+	canonical := strings.Join([]string{
+		"GET",
+		canonicalPath(u.Path),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", store.signature(canonical, now))
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// request - Returns an unsigned request for an object.
+func (store *S3Store) request(method, key string, body io.Reader) (*http.Request, error) {
+	u, err := store.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawPath = canonicalPath(u.Path)
+	return req, nil
+}
+
+// sign - Adds Signature Version 4 authorization headers to a request.
+func (store *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3DateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(s3DateFormat),
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers = []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+		values["content-type"] = contentType
+	}
+	var canonicalHeaders bytes.Buffer
+	for _, header := range headers {
+		canonicalHeaders.WriteString(header + ":" + strings.TrimSpace(values[header]) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, store.AccessKey, store.scope(now), signedHeaders, store.signature(canonical, now)))
+}
+
+// signature - Signs a canonical request.
+func (store *S3Store) signature(canonical string, now time.Time) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3DateFormat),
+		store.scope(now),
+		hashHex([]byte(canonical)),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+store.SecretKey), now.Format(s3ShortDateFormat))
+	key = hmacSHA256(key, store.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope - Credential scope for a date.
+func (store *S3Store) scope(now time.Time) string {
+	return fmt.Sprintf("%s/%s/%s/aws4_request", now.Format(s3ShortDateFormat), store.Region, s3Service)
+}
+
+// objectURL - Returns the URL of the object stored under key.
+func (store *S3Store) objectURL(key string) (*url.URL, error) {
+	if key == "" || strings.Contains(key, "..") {
+		return nil, ErrInvalidKey
+	}
+	u := *store.Endpoint
+	base := strings.TrimSuffix(u.Path, "/")
+	if store.PathStyle {
+		u.Path = base + "/" + store.Bucket + "/" + strings.TrimPrefix(key, "/")
+	} else {
+		u.Host = store.Bucket + "." + u.Host
+		u.Path = base + "/" + strings.TrimPrefix(key, "/")
+	}
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u, nil
+}
+
+// canonicalPath - URI encodes each path segment.
+func canonicalPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery - URI encodes query parameters sorted by name.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode - Percent encodes all but unreserved characters.
+func uriEncode(value string) string {
+	var encoded bytes.Buffer
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// s3Error - Returns an error from a service response.
+func s3Error(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3 store: %s: %s", res.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// LocalDriver - Blobs stored in local filesystem.
+	LocalDriver = "local"
+	// S3Driver - Blobs stored in an S3 compatible service.
+	S3Driver = "s3"
+	// DefaultURLExpiration - Lifetime of signed URLs when not configured.
+	DefaultURLExpiration = 15 * time.Minute
+)
+
+type (
+	// BlobStore - Binary objects store addressed by key.
+	BlobStore interface {
+		// Put - Stores the content read from r under key, replacing it if exists.
+		Put(key string, r io.Reader) (int64, error)
+		// Get - Returns a reader for the content stored under key, caller must close it.
+		Get(key string) (io.ReadCloser, error)
+		// Delete - Removes the content stored under key, missing keys are not an error.
+		Delete(key string) error
+		// SignedURL - Returns a URL granting read access to the content stored under key until expiration.
+		SignedURL(key string, expiration time.Duration) (string, error)
+	}
+
+	// Config - Blob store configuration parameters.
+	Config struct {
+		Driver string
+		// Local driver
+		Root       string
+		URLBase    string
+		SigningKey []byte
+		// S3 driver
+		Endpoint  string
+		Region    string
+		Bucket    string
+		AccessKey string
+		SecretKey string
+		PathStyle bool
+		// Signed URLs
+		URLExpiration time.Duration
+	}
+)
+
+var (
+	// StoreConfig holds the blob store configuration values.
+	StoreConfig Config
+	// ErrBlobNotFound - No content stored under key.
+	ErrBlobNotFound = errors.New("blob not found")
+	// ErrInvalidKey - Key is empty or points outside the store.
+	ErrInvalidKey = errors.New("invalid blob key")
+	// ErrUnknownDriver - Configured driver is not supported.
+	ErrUnknownDriver = errors.New("unknown blob store driver")
+	// ErrURLExpired - Signed URL is no longer valid.
+	ErrURLExpired = errors.New("signed url expired")
+	// ErrURLSignature - Signed URL signature does not match.
+	ErrURLSignature = errors.New("invalid signed url signature")
+)
+
+// GetStore - Returns the configured BlobStore.
+func GetStore() (BlobStore, error) {
+	switch StoreConfig.Driver {
+	case LocalDriver, "":
+		return MakeLocalStore(StoreConfig)
+	case S3Driver:
+		return MakeS3Store(StoreConfig)
+	}
+	return nil, ErrUnknownDriver
+}
+
+// URLExpiration - Returns configured signed URLs lifetime.
+func URLExpiration() time.Duration {
+	if StoreConfig.URLExpiration > 0 {
+		return StoreConfig.URLExpiration
+	}
+	return DefaultURLExpiration
+}
@@ -36,6 +36,7 @@ import (
 	"testing"
 
 	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/cache"
 	"github.com/adrianpk/fundacja/handler"
 
 	testfixtures "gopkg.in/testfixtures.v2"
@@ -112,6 +113,8 @@ func (configurator *testBootstrap) PrepareTestDatabase() {
 	if err := TestBootstrap.Fixtures.Load(); err != nil {
 		log.Fatal(err)
 	}
+	// Fixtures are loaded with triggers disabled, change notifications are not sent
+	cache.Decisions.Invalidate()
 }
 
 func (configurator *testBootstrap) AuthorizeRequest(req *http.Request, user, username, role string) {
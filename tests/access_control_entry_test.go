@@ -0,0 +1,251 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/services"
+	"github.com/adrianpk/fundacja/testbootstrap"
+	"github.com/markbates/pop/nulls"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	role1            = "9b6869e4-f51a-4197-9608-f2898bd764d8"
+	editorRole       = "4d6f8a0c-2e4a-4c8e-8a0c-2e4a6c8e0a04"
+	listing1         = "7b1f3e57-3b41-4a8e-9f2a-6f0d2c1a9e11"
+	listing2         = "2e4c8d90-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+	listing3         = "9c2d4e6f-8a0b-4c1d-9e2f-3a4b5c6d7e03"
+	editorEntry      = "8a0c2e4a-6c8e-4a0c-9e4a-6c8e0a2c4e06"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func aclRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+	}
+	return res
+}
+
+func grantEntity(t *testing.T, userID, roleID, action, entityType, entityID string) {
+	entryRepo, err := repo.MakeAccessControlEntryRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	entry := models.AccessControlEntry{
+		EntityType:     models.ToNullsString(entityType),
+		EntityID:       models.ToNullsString(entityID),
+		UserID:         models.ToNullsString(userID),
+		RoleID:         models.ToNullsString(roleID),
+		Action:         models.ToNullsString(action),
+		OrganizationID: models.ToNullsString(organization1),
+	}
+	if userID == "" {
+		entry.UserID = nulls.String{}
+	}
+	if roleID == "" {
+		entry.RoleID = nulls.String{}
+	}
+	err = entryRepo.Create(&entry)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func responseIDs(t *testing.T, res *http.Response) []string {
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	ids := []string{}
+	for _, item := range body.Data {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestCreateAccessControlEntry(t *testing.T) {
+	logger.Debug("TestCreateAccessControlEntry...")
+	tbp.PrepareTestDatabase()
+	entryJSON := fmt.Sprintf(`{"data": {"entityType": "listings", "entityID": "%s", "userID": "%s", "action": "read"}}`, listing1, user2)
+	res := aclRequest(t, "POST", fmt.Sprintf("%s/%s/access-control-entries", organizationsURL, organization1), user1, "admin", entryJSON)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestCreateInvalidAccessControlEntry(t *testing.T) {
+	logger.Debug("TestCreateInvalidAccessControlEntry...")
+	tbp.PrepareTestDatabase()
+	entriesURL := fmt.Sprintf("%s/%s/access-control-entries", organizationsURL, organization1)
+	entries := []string{
+		fmt.Sprintf(`{"data": {"entityType": "units", "entityID": "%s", "userID": "%s"}}`, listing1, user2),
+		fmt.Sprintf(`{"data": {"entityType": "listings", "entityID": "%s", "userID": "%s", "roleID": "%s"}}`, listing1, user2, role1),
+		fmt.Sprintf(`{"data": {"entityType": "listings", "entityID": "%s", "userID": "%s", "action": "approve"}}`, listing1, user2),
+		fmt.Sprintf(`{"data": {"entityType": "listings", "entityID": "%s", "userID": "%s"}}`, listing2, user2),
+	}
+	for _, entryJSON := range entries {
+		res := aclRequest(t, "POST", entriesURL, user1, "admin", entryJSON)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("Entry: %s, Status: %d | Expected: 400-StatusBadRequest", entryJSON, res.StatusCode)
+		}
+	}
+}
+
+func TestGetAccessControlEntriesByEntity(t *testing.T) {
+	logger.Debug("TestGetAccessControlEntriesByEntity...")
+	tbp.PrepareTestDatabase()
+	entriesURL := fmt.Sprintf("%s/%s/access-control-entries?entity-type=listings&entity-id=%s", organizationsURL, organization1, listing1)
+	res := aclRequest(t, "GET", entriesURL, user1, "admin", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	ids := responseIDs(t, res)
+	if len(ids) != 1 || ids[0] != editorEntry {
+		t.Errorf("Entries: %v | Expected: '%s'", ids, editorEntry)
+	}
+}
+
+func TestDeleteAccessControlEntry(t *testing.T) {
+	logger.Debug("TestDeleteAccessControlEntry...")
+	tbp.PrepareTestDatabase()
+	entryURL := fmt.Sprintf("%s/%s/access-control-entries/%s", organizationsURL, organization1, editorEntry)
+	res := aclRequest(t, "DELETE", entryURL, user1, "admin", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	res = aclRequest(t, "GET", entryURL, user1, "admin", "")
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+}
+
+func TestUserEntityGrant(t *testing.T) {
+	logger.Debug("TestUserEntityGrant...")
+	tbp.PrepareTestDatabase()
+	grantEntity(t, user2, "", models.ActionRead, models.ListingResourceTag, listing1)
+	listingsURL := fmt.Sprintf("%s/%s/listings", organizationsURL, organization1)
+	res := aclRequest(t, "GET", fmt.Sprintf("%s/%s", listingsURL, listing1), user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Granted status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+	res = aclRequest(t, "GET", fmt.Sprintf("%s/%s", listingsURL, listing3), user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Not granted status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+	res = aclRequest(t, "DELETE", fmt.Sprintf("%s/%s", listingsURL, listing1), user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Delete status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestCollectionIsFilteredByEntityGrants(t *testing.T) {
+	logger.Debug("TestCollectionIsFilteredByEntityGrants...")
+	tbp.PrepareTestDatabase()
+	grantEntity(t, user2, "", models.ActionRead, models.ListingResourceTag, listing1)
+	res := aclRequest(t, "GET", fmt.Sprintf("%s/%s/listings", organizationsURL, organization1), user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	ids := responseIDs(t, res)
+	if len(ids) != 1 || ids[0] != listing1 {
+		t.Errorf("Listings: %v | Expected: only '%s'", ids, listing1)
+	}
+	res = aclRequest(t, "GET", fmt.Sprintf("%s/%s/listings", organizationsURL, organization1), user1, "admin", "")
+	if ids := responseIDs(t, res); len(ids) < 2 {
+		t.Errorf("Listings: %v | Expected: every listing for the owner", ids)
+	}
+}
+
+func TestRoleEntityGrant(t *testing.T) {
+	logger.Debug("TestRoleEntityGrant...")
+	tbp.PrepareTestDatabase()
+	userRoleRepo, err := repo.MakeUserRoleRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	userRole := models.UserRole{
+		OrganizationID: models.ToNullsString(organization1),
+		UserID:         models.ToNullsString(user2),
+		RoleID:         models.ToNullsString(editorRole),
+	}
+	err = userRoleRepo.Create(&userRole)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cases := []struct {
+		action    string
+		listingID string
+		expected  bool
+	}{
+		{models.ActionUpdate, listing1, true},
+		{models.ActionDelete, listing1, false},
+		{models.ActionUpdate, listing3, false},
+	}
+	for _, c := range cases {
+		vars := map[string]string{"organization": organization1, "listing": c.listingID}
+		can, err := services.CanAccess(user2, c.action, models.ListingResourceTag, vars)
+		if err != nil || can != c.expected {
+			t.Errorf("Action: %s, Listing: %s, Can: %t, Error: %v | Expected: %t", c.action, c.listingID, can, err, c.expected)
+		}
+	}
+}
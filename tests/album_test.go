@@ -0,0 +1,284 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	listing1         = "7b1f3e57-3b41-4a8e-9f2a-6f0d2c1a9e11"
+	album1           = "1d3f5b7d-9f1b-4d3f-b5b7-9f1b3d5f7b01"
+	album2           = "1d3f5b7d-9f1b-4d3f-b5b7-9f1b3d5f7b02"
+	album3           = "1d3f5b7d-9f1b-4d3f-b5b7-9f1b3d5f7b03"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	image1           = "2e4a6c8e-0a2c-4e6a-8c0e-0a2c4e6a8c01"
+	image2           = "2e4a6c8e-0a2c-4e6a-8c0e-0a2c4e6a8c02"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func albumURL(orgid, id string) string {
+	return fmt.Sprintf("%s/%s/albums/%s", organizationsURL, orgid, id)
+}
+
+func albumRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func uploadImage(t *testing.T, userID, username string, width, height int) *http.Response {
+	src := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			src.Set(x, y, color.NRGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("files", "bedroom.png")
+	png.Encode(part, src)
+	writer.WriteField("caption", "Bedroom.")
+	writer.Close()
+	request, _ := http.NewRequest("POST", albumURL(organization1, album1)+"/images", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+type albumImages struct {
+	Data []struct {
+		ID       string `json:"id"`
+		Caption  string `json:"caption"`
+		Position int64  `json:"position"`
+		Width    int64  `json:"width"`
+	} `json:"data"`
+}
+
+func TestUploadAlbumImage(t *testing.T) {
+	logger.Debug("TestUploadAlbumImage...")
+	tbp.PrepareTestDatabase()
+	res := uploadImage(t, user1, "admin", 1600, 900)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body albumImages
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].Position != 3 || body.Data[0].Width != 1600 || body.Data[0].Caption != "Bedroom." {
+		t.Errorf("Images: %+v | Expected: 1600px wide 'Bedroom.' at position 3", body.Data)
+		return
+	}
+	// Renditions
+	renditions := map[string][2]int{"medium": {1024, 576}, "thumbnail": {240, 240}}
+	for rendition, size := range renditions {
+		url := fmt.Sprintf("%s/images/%s/%s", albumURL(organization1, album1), body.Data[0].ID, rendition)
+		res = albumRequest(t, "GET", url, user2, "user", "")
+		if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "image/jpeg" {
+			t.Errorf("Status: %d %s | Expected: 200-StatusOk image/jpeg", res.StatusCode, res.Header.Get("Content-Type"))
+			return
+		}
+		config, err := jpeg.DecodeConfig(res.Body)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if config.Width != size[0] || config.Height != size[1] {
+			t.Errorf("%s: %dx%d | Expected: %dx%d", rendition, config.Width, config.Height, size[0], size[1])
+		}
+	}
+}
+
+func TestUploadAlbumImageAsNonMember(t *testing.T) {
+	logger.Debug("TestUploadAlbumImageAsNonMember...")
+	tbp.PrepareTestDatabase()
+	res := uploadImage(t, user2, "user", 10, 10)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestCreateAlbumForForeignListing(t *testing.T) {
+	logger.Debug("TestCreateAlbumForForeignListing...")
+	tbp.PrepareTestDatabase()
+	albumJSON := `{"data": {"name": "Photos", "ownerType": "listing", "ownerID": "2e4c8d90-5a6b-4c7d-8e9f-0a1b2c3d4e5f"}}`
+	res := albumRequest(t, "POST", fmt.Sprintf("%s/%s/albums", organizationsURL, organization1), user1, "admin", albumJSON)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestReorderAlbumImages(t *testing.T) {
+	logger.Debug("TestReorderAlbumImages...")
+	tbp.PrepareTestDatabase()
+	url := albumURL(organization1, album1) + "/order"
+	// Incomplete order
+	res := albumRequest(t, "PUT", url, user1, "admin", fmt.Sprintf(`{"data": ["%s"]}`, image2))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+	res = albumRequest(t, "PUT", url, user1, "admin", fmt.Sprintf(`{"data": ["%s", "%s"]}`, image2, image1))
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	res = albumRequest(t, "GET", albumURL(organization1, album1), user1, "admin", "")
+	var body struct {
+		Images []struct {
+			ID string `json:"id"`
+		} `json:"images"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Images) != 2 || body.Images[0].ID != image2 {
+		t.Errorf("Images: %+v | Expected: '%s' first", body.Images, image2)
+	}
+}
+
+func TestDeleteAlbumCoverImage(t *testing.T) {
+	logger.Debug("TestDeleteAlbumCoverImage...")
+	tbp.PrepareTestDatabase()
+	res := albumRequest(t, "DELETE", albumURL(organization1, album1)+"/images/"+image1, user1, "admin", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	albumRepo, err := repo.MakeAlbumRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	album, err := albumRepo.Get(album1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if album.CoverImageID.String != image2 {
+		t.Errorf("Cover: '%s' | Expected: '%s'", album.CoverImageID.String, image2)
+	}
+	image, err := albumRepo.GetImage(image2, album1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if image.Position.Int64 != 1 {
+		t.Errorf("Position: %d | Expected: 1", image.Position.Int64)
+	}
+}
+
+func TestGetAlbumsAsNonParty(t *testing.T) {
+	logger.Debug("TestGetAlbumsAsNonParty...")
+	tbp.PrepareTestDatabase()
+	// user1 takes no part in organization2, only its own album is public
+	res := albumRequest(t, "GET", fmt.Sprintf("%s/%s/albums", organizationsURL, organization2), user1, "admin", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != album3 {
+		t.Errorf("Albums: %v | Expected: only %s", body.Data, album3)
+	}
+}
+
+func TestGetDraftListingAlbumAsNonParty(t *testing.T) {
+	logger.Debug("TestGetDraftListingAlbumAsNonParty...")
+	tbp.PrepareTestDatabase()
+	res := albumRequest(t, "GET", albumURL(organization2, album2), user1, "admin", "")
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+	res = albumRequest(t, "GET", albumURL(organization2, album2), user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Owner status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetOrganizationAlbumAsNonParty(t *testing.T) {
+	logger.Debug("TestGetOrganizationAlbumAsNonParty...")
+	tbp.PrepareTestDatabase()
+	res := albumRequest(t, "GET", albumURL(organization2, album3), user1, "admin", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
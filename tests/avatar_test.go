@@ -25,8 +25,13 @@ package tests
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"image"
+	_ "image/jpeg" // Required by "image" library.
+	"image/png"
 	"log"
 	"net/http"
 	"os"
@@ -37,6 +42,7 @@ import (
 
 	"github.com/adrianpk/fundacja/bootstrap"
 	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
 	"github.com/adrianpk/fundacja/testbootstrap"
 
 	_ "github.com/lib/pq"
@@ -113,6 +119,223 @@ func TestUploadAvatar(t *testing.T) {
 	}
 }
 
+func TestUploadAvatarToBlobStore(t *testing.T) {
+	logger.Debug("TestUploadAvatarToBlobStore...")
+	tbp.PrepareTestDatabase()
+	avatarJSON := fmt.Sprintf(`
+  {
+    "data": {
+      "profileID": "%s",
+      "base64": "%s"
+    }
+  }
+	`, profile1, sampleBase64Image())
+	tbp.Reader = strings.NewReader(avatarJSON)
+	request, _ := http.NewRequest("POST", fmt.Sprintf("%s/%s/profile/avatar", usersURL, user1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	// Verify
+	profileRepo, err := repo.MakeProfileRepository()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	profile, err := profileRepo.GetByUserID(user1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !strings.HasPrefix(profile.AvatarURI.String, "profiles/"+profile1+"/avatar_") || len(profile.Avatar.ByteSlice) > 0 {
+		t.Errorf("Avatar key: '%s' | Expected: blob store key and no database avatar", profile.AvatarURI.String)
+		return
+	}
+	// Signed URL
+	request, _ = http.NewRequest("GET", fmt.Sprintf("%s/%s/profile", usersURL, user1), nil)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	var body struct {
+		AvatarURL string `json:"avatarURL"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil || body.AvatarURL == "" {
+		t.Errorf("Avatar URL: '%s' | Expected: signed URL", body.AvatarURL)
+		return
+	}
+	res, err = http.Get(tbp.ServerInstance.URL + body.AvatarURL)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "image/jpeg" || res.Header.Get("ETag") == "" {
+		t.Errorf("Status: %d, type: '%s' | Expected: 200-StatusOK 'image/jpeg'", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	// Tampered signature
+	res, err = http.Get(tbp.ServerInstance.URL + strings.Replace(body.AvatarURL, "signature=", "signature=0", 1))
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func profileImageRequest(t *testing.T, method, url, data string, header map[string]string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func sampleImageJSON(base64Image string) string {
+	return fmt.Sprintf(`{"data": {"profileID": "%s", "base64": "%s"}}`, profile1, base64Image)
+}
+
+func TestGetAvatarRenditions(t *testing.T) {
+	logger.Debug("TestGetAvatarRenditions...")
+	tbp.PrepareTestDatabase()
+	avatarURL := fmt.Sprintf("%s/%s/profile/avatar", usersURL, user1)
+	res := profileImageRequest(t, "POST", avatarURL, sampleImageJSON(sampleBase64Image()), nil)
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	sides := map[string]int{"large": 512, "medium": 128, "small": 48}
+	for size, side := range sides {
+		res = profileImageRequest(t, "GET", avatarURL+"?size="+size, "", nil)
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+			return
+		}
+		config, format, err := image.DecodeConfig(res.Body)
+		if err != nil || format != "jpeg" || config.Width != side || config.Height != side {
+			t.Errorf("Rendition %s: %s %dx%d | Expected: jpeg %dx%d", size, format, config.Width, config.Height, side, side)
+		}
+		// Revalidation
+		etag := res.Header.Get("ETag")
+		res = profileImageRequest(t, "GET", avatarURL+"?size="+size, "", map[string]string{"If-None-Match": etag})
+		if etag == "" || res.StatusCode != http.StatusNotModified {
+			t.Errorf("Status: %d | Expected: 304-StatusNotModified", res.StatusCode)
+		}
+	}
+}
+
+func TestUploadInvalidAvatar(t *testing.T) {
+	logger.Debug("TestUploadInvalidAvatar...")
+	tbp.PrepareTestDatabase()
+	avatarURL := fmt.Sprintf("%s/%s/profile/avatar", usersURL, user1)
+	// Not an image
+	res := profileImageRequest(t, "POST", avatarURL, sampleImageJSON(base64.StdEncoding.EncodeToString([]byte("Not an image."))), nil)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+	// Too small
+	var buffer bytes.Buffer
+	png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+	res = profileImageRequest(t, "POST", avatarURL, sampleImageJSON(base64.StdEncoding.EncodeToString(buffer.Bytes())), nil)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetIdenticonAvatar(t *testing.T) {
+	logger.Debug("TestGetIdenticonAvatar...")
+	tbp.PrepareTestDatabase()
+	// Fixture profiles have no avatar
+	res := profileImageRequest(t, "GET", fmt.Sprintf("%s/%s/profile/avatar?size=medium", usersURL, user2), "", nil)
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+		return
+	}
+	config, format, err := image.DecodeConfig(res.Body)
+	if err != nil || format != "png" || config.Width != 128 {
+		t.Errorf("Identicon: %s %dx%d | Expected: png 128x128", format, config.Width, config.Height)
+	}
+	if !strings.HasPrefix(res.Header.Get("Cache-Control"), "public") {
+		t.Errorf("Cache-Control: '%s' | Expected: public", res.Header.Get("Cache-Control"))
+	}
+}
+
+func TestUploadAndDeleteHeader(t *testing.T) {
+	logger.Debug("TestUploadAndDeleteHeader...")
+	tbp.PrepareTestDatabase()
+	headerURL := fmt.Sprintf("%s/%s/profile/header", usersURL, user1)
+	res := profileImageRequest(t, "POST", headerURL, sampleImageJSON(sampleBase64Image()), nil)
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	res = profileImageRequest(t, "GET", headerURL+"?size=small", "", nil)
+	config, _, err := image.DecodeConfig(res.Body)
+	if err != nil || config.Width != 600 || config.Height != 200 {
+		t.Errorf("Header: %dx%d | Expected: 600x200", config.Width, config.Height)
+	}
+	res = profileImageRequest(t, "DELETE", headerURL, "", nil)
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	res = profileImageRequest(t, "GET", headerURL, "", nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+}
+
+func TestMoveLegacyAvatar(t *testing.T) {
+	logger.Debug("TestMoveLegacyAvatar...")
+	tbp.PrepareTestDatabase()
+	profileRepo, err := repo.MakeProfileRepository()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	profile, err := profileRepo.GetByUserID(user2)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	// Avatar kept in database
+	profile.SetAvatarAsBase64(sampleBase64Image())
+	_, err = profileRepo.DB.Exec("UPDATE profiles SET avatar = $1, avatar_uri = NULL WHERE id = $2", profile.Avatar, profile.ID.String)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	moved, err := profileRepo.MoveLegacyImages()
+	if err != nil || moved != 1 {
+		t.Errorf("Moved: %d | Expected: 1", moved)
+		return
+	}
+	profile, err = profileRepo.GetByUserID(user2)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !strings.HasPrefix(profile.AvatarURI.String, "profiles/"+profile2+"/avatar_") || len(profile.Avatar.ByteSlice) > 0 {
+		t.Errorf("Avatar key: '%s' | Expected: blob store key and no database avatar", profile.AvatarURI.String)
+	}
+}
+
 // func TestUpdateProfile(t *testing.T) {
 // 	logger.Debug("TestUpdateProfile...")
 // 	prepareTestDatabase()
@@ -0,0 +1,429 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	invoice1         = "9c3e5a7b-9d1f-4b3c-8e5a-7b9d1f3c5e01"
+	bankTransaction1 = "3a5b7c9d-1e2f-4a3b-8c5d-7e9f1a3b5c01"
+	bankTransaction2 = "3a5b7c9d-1e2f-4a3b-8c5d-7e9f1a3b5c02"
+	csvStatement     = "Date;Amount;Currency;Title;Payer\n2017-01-20;700,00;PLN;Rent Lease1/001;Clark Kent\n2017-01-21;-50,00;PLN;Bank fee;Bank\n"
+	mt940Statement   = `:20:STMT2
+:25:PL61109010140000071219812874
+:28C:2/1
+:60F:C170131PLN1700,00
+:61:1702030203C700,00NTRFNONREF//BR2001
+:86:166?00TRANSFER?20February?32John Doe
+:62F:C170228PLN2400,00
+-`
+	camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Id>STMT3</Id>
+      <CreDtTm>2017-01-31T18:00:00</CreDtTm>
+      <Acct><Id><IBAN>PL61109010140000071219812874</IBAN></Id><Ccy>PLN</Ccy></Acct>
+      <Ntry>
+        <Amt Ccy="PLN">300.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><Dt>2017-01-25</Dt></BookgDt>
+        <AcctSvcrRef>BR3001</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <RltdPties><Dbtr><Nm>Clark Kent</Nm></Dbtr></RltdPties>
+            <RmtInf><Ustrd>Lease1/001 partial</Ustrd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func bankURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/bank", organizationsURL, orgid)
+}
+
+type importedStatement struct {
+	Data struct {
+		ID           string `json:"id"`
+		Transactions []struct {
+			ID      string `json:"id"`
+			Status  string `json:"status"`
+			Matches []struct {
+				InvoiceID string `json:"invoiceID"`
+			} `json:"matches"`
+		} `json:"transactions"`
+	} `json:"data"`
+}
+
+func importStatement(t *testing.T, format, content string) (*http.Response, importedStatement) {
+	return importStatementAs(t, user1, format, content)
+}
+
+func importStatementAs(t *testing.T, userID, format, content string) (*http.Response, importedStatement) {
+	var body importedStatement
+	payload, _ := json.Marshal(map[string]interface{}{
+		"data": map[string]string{"format": format, "content": content},
+	})
+	request, _ := http.NewRequest("POST", bankURL(organization1)+"/statements", bytes.NewReader(payload))
+	tbp.AuthorizeRequest(request, userID, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode == http.StatusCreated {
+		err = json.NewDecoder(res.Body).Decode(&body)
+		if err != nil {
+			t.Error(err.Error())
+		}
+	}
+	return res, body
+}
+
+func verifyInvoice(t *testing.T, balance float64, status string) {
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	invoice, err := invoiceRepo.Get(invoice1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if invoice.Balance.Float64 != balance || invoice.Status.String != status {
+		t.Errorf("Balance: %.2f, status: '%s' | Expected: %.2f, '%s'", invoice.Balance.Float64, invoice.Status.String, balance, status)
+	}
+}
+
+func TestImportCSVStatementWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestImportCSVStatementWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	res, body := importStatement(t, models.BankFormatCSV, csvStatement)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	if len(body.Data.Transactions) != 2 {
+		t.Errorf("Transactions: %d | Expected: 2", len(body.Data.Transactions))
+		return
+	}
+	if body.Data.Transactions[0].Status != models.BankTransactionStatusMatched {
+		t.Errorf("Status: '%s' | Expected: '%s'", body.Data.Transactions[0].Status, models.BankTransactionStatusMatched)
+	}
+	if body.Data.Transactions[1].Status != models.BankTransactionStatusIgnored {
+		t.Errorf("Status: '%s' | Expected: '%s'", body.Data.Transactions[1].Status, models.BankTransactionStatusIgnored)
+	}
+	verifyInvoice(t, 0, models.InvoiceStatusPaid)
+	// Transactions are imported once
+	res, body = importStatement(t, models.BankFormatCSV, csvStatement)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	if len(body.Data.Transactions) != 0 {
+		t.Errorf("Transactions: %d | Expected: 0", len(body.Data.Transactions))
+	}
+}
+
+func TestImportMT940StatementSuggestsMatches(t *testing.T) {
+	logger.Debug("TestImportMT940StatementSuggestsMatches...")
+	tbp.PrepareTestDatabase()
+	res, body := importStatement(t, "", mt940Statement)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	if len(body.Data.Transactions) != 1 {
+		t.Errorf("Transactions: %d | Expected: 1", len(body.Data.Transactions))
+		return
+	}
+	transaction := body.Data.Transactions[0]
+	if transaction.Status != models.BankTransactionStatusSuggested || len(transaction.Matches) != 1 || transaction.Matches[0].InvoiceID != invoice1 {
+		t.Errorf("Status: '%s', matches: %d | Expected: '%s', 1 (%s)", transaction.Status, len(transaction.Matches), models.BankTransactionStatusSuggested, invoice1)
+	}
+	verifyInvoice(t, 700, models.InvoiceStatusPartiallyPaid)
+}
+
+func TestImportCAMT053StatementWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestImportCAMT053StatementWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	res, body := importStatement(t, models.BankFormatCAMT053, camtStatement)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	if len(body.Data.Transactions) != 1 || body.Data.Transactions[0].Status != models.BankTransactionStatusMatched {
+		t.Errorf("Transactions: %d | Expected: 1 matched", len(body.Data.Transactions))
+	}
+	verifyInvoice(t, 400, models.InvoiceStatusPartiallyPaid)
+}
+
+func TestImportCAMT053Reversal(t *testing.T) {
+	logger.Debug("TestImportCAMT053Reversal...")
+	tbp.PrepareTestDatabase()
+	// A returned payment is booked as a debit flagged as reversal
+	reversal := strings.Replace(camtStatement, "<CdtDbtInd>CRDT</CdtDbtInd>", "<CdtDbtInd>DBIT</CdtDbtInd>\n        <RvslInd>true</RvslInd>", 1)
+	res, body := importStatement(t, models.BankFormatCAMT053, reversal)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	if len(body.Data.Transactions) != 1 || body.Data.Transactions[0].Status != models.BankTransactionStatusIgnored {
+		t.Errorf("Transactions: %v | Expected: 1 ignored", body.Data.Transactions)
+	}
+	verifyInvoice(t, 700, models.InvoiceStatusPartiallyPaid)
+}
+
+func TestImportInvalidStatement(t *testing.T) {
+	logger.Debug("TestImportInvalidStatement...")
+	tbp.PrepareTestDatabase()
+	res, _ := importStatement(t, models.BankFormatCSV, "Payer;Title\nClark Kent;Rent\n")
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestImportStatementAsTenant(t *testing.T) {
+	logger.Debug("TestImportStatementAsTenant...")
+	tbp.PrepareTestDatabase()
+	res, _ := importStatementAs(t, user2, models.BankFormatCSV, csvStatement)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestGetBankTransactionsForReview(t *testing.T) {
+	logger.Debug("TestGetBankTransactionsForReview...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/transactions?status=%s", bankURL(organization1), models.BankTransactionStatusSuggested)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Matches []struct {
+				InvoiceID string `json:"invoiceID"`
+			} `json:"matches"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != bankTransaction1 || len(body.Data[0].Matches) != 1 {
+		t.Errorf("Transactions: %d | Expected: 1 (%s) with 1 match", len(body.Data), bankTransaction1)
+	}
+}
+
+func TestMatchBankTransactionWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestMatchBankTransactionWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	matchJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"invoiceID": "%s"
+		}
+	}
+	`, invoice1)
+	url := fmt.Sprintf("%s/transactions/%s/match", bankURL(organization1), bankTransaction1)
+	tbp.Reader = strings.NewReader(matchJSON)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	verifyInvoice(t, 0, models.InvoiceStatusPaid)
+	// Matched transactions cannot be posted again
+	tbp.Reader = strings.NewReader(matchJSON)
+	request, _ = http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestMatchBankTransactionAsTenant(t *testing.T) {
+	logger.Debug("TestMatchBankTransactionAsTenant...")
+	tbp.PrepareTestDatabase()
+	matchJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"invoiceID": "%s"
+		}
+	}
+	`, invoice1)
+	url := fmt.Sprintf("%s/transactions/%s/match", bankURL(organization1), bankTransaction1)
+	tbp.Reader = strings.NewReader(matchJSON)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+		return
+	}
+	verifyInvoice(t, 700, models.InvoiceStatusPartiallyPaid)
+}
+
+func TestIgnoreBankTransaction(t *testing.T) {
+	logger.Debug("TestIgnoreBankTransaction...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/transactions/%s/ignore", bankURL(organization1), bankTransaction2)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestIgnoreBankTransactionAsTenant(t *testing.T) {
+	logger.Debug("TestIgnoreBankTransactionAsTenant...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/transactions/%s/ignore", bankURL(organization1), bankTransaction2)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestMatchScoreReference(t *testing.T) {
+	logger.Debug("TestMatchScoreReference...")
+	transaction := models.BankTransaction{}
+	transaction.Reference = models.ToNullsString("Rent B/001, January")
+	transaction.Amount = models.ToNullsFoat64(100)
+	invoice := models.Invoice{}
+	invoice.Balance = models.ToNullsFoat64(700)
+	invoice.Name = models.ToNullsString("B/001")
+	score, _ := transaction.MatchScore(&invoice, nil)
+	if score != models.BankMatchScoreReference {
+		t.Errorf("Score: %d | Expected: %d", score, models.BankMatchScoreReference)
+	}
+}
+
+func TestMatchScoreReferenceWithPrefixedName(t *testing.T) {
+	logger.Debug("TestMatchScoreReferenceWithPrefixedName...")
+	transaction := models.BankTransaction{}
+	transaction.Reference = models.ToNullsString("Rent AB/001")
+	transaction.Amount = models.ToNullsFoat64(100)
+	invoice := models.Invoice{}
+	invoice.Balance = models.ToNullsFoat64(700)
+	invoice.Name = models.ToNullsString("B/001")
+	score, reasons := transaction.MatchScore(&invoice, nil)
+	if score != 0 {
+		t.Errorf("Score: %d, reasons: %v | Expected: 0", score, reasons)
+	}
+}
+
+func TestMatchScoreReferenceWithLateFeeName(t *testing.T) {
+	logger.Debug("TestMatchScoreReferenceWithLateFeeName...")
+	transaction := models.BankTransaction{}
+	transaction.Reference = models.ToNullsString("Late fee X/001/LF")
+	transaction.Amount = models.ToNullsFoat64(100)
+	invoice := models.Invoice{}
+	invoice.Balance = models.ToNullsFoat64(700)
+	invoice.Name = models.ToNullsString("X/001")
+	score, reasons := transaction.MatchScore(&invoice, nil)
+	if score != 0 {
+		t.Errorf("Score: %d, reasons: %v | Expected: 0", score, reasons)
+	}
+	invoice.Name = models.ToNullsString("X/001/LF")
+	score, _ = transaction.MatchScore(&invoice, nil)
+	if score != models.BankMatchScoreReference {
+		t.Errorf("Score: %d | Expected: %d", score, models.BankMatchScoreReference)
+	}
+}
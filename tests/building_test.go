@@ -0,0 +1,275 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	building1        = "3e5a7c9b-1d2f-4a6b-8c0d-4e6f8a0b2c01"
+	building1Name    = "Building1"
+	unit1            = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f01"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func TestGetAllFromOrganization(t *testing.T) {
+	logger.Debug("TestGetAll...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	buildingsOrgURL := fmt.Sprintf("%s/%s/buildings", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", buildingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestCreateBuilding(t *testing.T) {
+	logger.Debug("TestCreateBuilding...")
+	tbp.PrepareTestDatabase()
+	buildingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Building",
+			"description": "Building description.",
+			"address": "Building address",
+			"floors": 6,
+			"organizationID": "%s"
+		}
+	}
+	`, organization1)
+	tbp.Reader = strings.NewReader(buildingJSON)
+	buildingsURL := fmt.Sprintf("%s/%s/buildings", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", buildingsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestGetFromOrganization(t *testing.T) {
+	logger.Debug("TestGet...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	buildingOrgURL := fmt.Sprintf("%s/%s/buildings/%s", organizationsURL, organization1, building1)
+	request, _ := http.NewRequest("GET", buildingOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetByName(t *testing.T) {
+	logger.Debug("TestGetByName...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	buildingURL := fmt.Sprintf("%s/%s/buildings/%s", organizationsURL, organization1, building1Name)
+	request, _ := http.NewRequest("GET", buildingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestUpdateBuildingWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdateBuildingWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	newName := "Building new name"
+	newFloors := int64(5)
+	buildingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "%s",
+			"floors": %d,
+			"organizationID": "%s"
+		}
+	}
+	`, building1, newName, newFloors, organization1)
+	tbp.Reader = strings.NewReader(buildingJSON)
+	buildingURL := fmt.Sprintf("%s/%s/buildings/%s", organizationsURL, organization1, building1)
+	request, _ := http.NewRequest("PUT", buildingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		buildingRepo, err := repo.MakeBuildingRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		building, err := buildingRepo.Get(building1)
+		if err == nil {
+			if building.Name.String == newName && building.Floors.Int64 == newFloors {
+				logger.Debug("Building update: ok.")
+			} else {
+				error := fmt.Sprintf("Name: '%s' | Expected: '%s' - ", building.Name.String, newName)
+				error += fmt.Sprintf("Floors: '%d' | Expected: '%d'", building.Floors.Int64, newFloors)
+				t.Error(error)
+			}
+		} else {
+			t.Error(err.Error())
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteBuildingWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestDeleteBuildingWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	buildingURL := fmt.Sprintf("%s/%s/buildings/%s", organizationsURL, organization1, building1)
+	request, _ := http.NewRequest("DELETE", buildingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		unitRepo, err := repo.MakeUnitRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		unit, err := unitRepo.Get(unit1)
+		if err != nil {
+			logger.Debug("TestDeleteBuilding: ok")
+		} else {
+			t.Errorf("Unit: %s | Expected: 'nil'", unit.Name.String)
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteBuildingFromAnotherOrganization(t *testing.T) {
+	logger.Debug("TestDeleteBuildingFromAnotherOrganization...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	buildingURL := fmt.Sprintf("%s/%s/buildings/%s", organizationsURL, organization2, building1)
+	request, _ := http.NewRequest("DELETE", buildingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: not 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestGetBuildingOccupancy(t *testing.T) {
+	logger.Debug("TestGetBuildingOccupancy...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	occupancyURL := fmt.Sprintf("%s/%s/buildings/%s/occupancy", organizationsURL, organization1, building1)
+	request, _ := http.NewRequest("GET", occupancyURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			Units         int64   `json:"units"`
+			Occupied      int64   `json:"occupied"`
+			Area          float64 `json:"area"`
+			OccupancyRate float64 `json:"occupancyRate"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	occupancy := body.Data
+	if occupancy.Units != 2 || occupancy.Occupied != 1 || occupancy.Area != 75 || occupancy.OccupancyRate != 0.5 {
+		t.Errorf("Occupancy: %+v | Expected: 2 units, 1 occupied, 75 area, 0.5 rate", occupancy)
+	}
+}
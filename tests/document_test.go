@@ -0,0 +1,286 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	lease1           = "7d9f1b3d-5e7f-4a9b-8d0f-5e7a9c1b3d01"
+	lease2           = "7d9f1b3d-5e7f-4a9b-8d0f-5e7a9c1b3d02"
+	document1        = "4a6c8e0a-2c4e-4a6c-8e0a-2c4e6a8c0e01"
+	pdfContent       = "%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func documentsURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/documents", organizationsURL, orgid)
+}
+
+func documentRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func uploadDocument(t *testing.T, url, userID, username, fileName, content string, values map[string]string) *http.Response {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write([]byte(content))
+	for key, value := range values {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+	request, _ := http.NewRequest("POST", url, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+type documentBody struct {
+	Data struct {
+		ID          string `json:"id"`
+		Version     int64  `json:"version"`
+		ContentType string `json:"contentType"`
+		Size        int64  `json:"size"`
+		Checksum    string `json:"checksum"`
+	} `json:"data"`
+}
+
+func createLeaseDocument(t *testing.T, content string) documentBody {
+	values := map[string]string{"name": "Inventory", "kind": "contract", "holder-type": "lease", "holder-id": lease1}
+	res := uploadDocument(t, documentsURL(organization1), user1, "admin", "inventory.pdf", content, values)
+	var body documentBody
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return body
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	return body
+}
+
+func TestCreateDocument(t *testing.T) {
+	logger.Debug("TestCreateDocument...")
+	tbp.PrepareTestDatabase()
+	body := createLeaseDocument(t, pdfContent)
+	if body.Data.ContentType != "application/pdf" || body.Data.Version != 1 || body.Data.Size != int64(len(pdfContent)) || body.Data.Checksum != checksum(pdfContent) {
+		t.Errorf("Document: %+v | Expected: version 1 'application/pdf' of %d bytes", body.Data, len(pdfContent))
+		return
+	}
+	// Verify
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	versions, err := documentRepo.GetVersions(body.Data.ID)
+	if err != nil || len(versions) != 1 {
+		t.Errorf("Versions: %d | Expected: 1", len(versions))
+	}
+}
+
+func TestCreateDocumentWithUnacceptedType(t *testing.T) {
+	logger.Debug("TestCreateDocumentWithUnacceptedType...")
+	tbp.PrepareTestDatabase()
+	// File name and extension are not trusted
+	values := map[string]string{"kind": "other", "holder-type": "organization"}
+	res := uploadDocument(t, documentsURL(organization1), user1, "admin", "notes.pdf", "Plain text notes.\n", values)
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("Status: %d | Expected: 415-StatusUnsupportedMediaType", res.StatusCode)
+	}
+}
+
+func TestCreateDocumentForForeignLease(t *testing.T) {
+	logger.Debug("TestCreateDocumentForForeignLease...")
+	tbp.PrepareTestDatabase()
+	values := map[string]string{"kind": "contract", "holder-type": "lease", "holder-id": "00000000-0000-4000-8000-000000000000"}
+	res := uploadDocument(t, documentsURL(organization1), user1, "admin", "contract.pdf", pdfContent, values)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestCreateDocumentAsTenant(t *testing.T) {
+	logger.Debug("TestCreateDocumentAsTenant...")
+	tbp.PrepareTestDatabase()
+	values := map[string]string{"kind": "contract", "holder-type": "lease", "holder-id": lease1}
+	res := uploadDocument(t, documentsURL(organization1), user2, "user", "contract.pdf", pdfContent, values)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestAddDocumentVersionAndDownload(t *testing.T) {
+	logger.Debug("TestAddDocumentVersionAndDownload...")
+	tbp.PrepareTestDatabase()
+	body := createLeaseDocument(t, pdfContent)
+	documentURL := documentsURL(organization1) + "/" + body.Data.ID
+	revised := strings.Replace(pdfContent, "Catalog", "Catalog /Lang (en)", 1)
+	res := uploadDocument(t, documentURL+"/versions", user1, "admin", "inventory-rev.pdf", revised, nil)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	// Current version
+	res = documentRequest(t, "GET", documentURL+"/download", user1, "admin", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+		return
+	}
+	content, _ := ioutil.ReadAll(res.Body)
+	if string(content) != revised || res.Header.Get("ETag") != `"`+checksum(revised)+`"` {
+		t.Errorf("Download: '%s' | Expected: revised version", res.Header.Get("ETag"))
+	}
+	if !strings.Contains(res.Header.Get("Content-Disposition"), "inventory-rev.pdf") {
+		t.Errorf("Content-Disposition: '%s' | Expected: 'inventory-rev.pdf'", res.Header.Get("Content-Disposition"))
+	}
+	// Previous version
+	res = documentRequest(t, "GET", documentURL+"/versions/1/download", user1, "admin", "")
+	content, _ = ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(content) != pdfContent {
+		t.Errorf("Status: %d | Expected: 200-StatusOK with first version", res.StatusCode)
+	}
+}
+
+func TestDownloadDocumentAsTenant(t *testing.T) {
+	logger.Debug("TestDownloadDocumentAsTenant...")
+	tbp.PrepareTestDatabase()
+	body := createLeaseDocument(t, pdfContent)
+	// Tenant of lease1
+	res := documentRequest(t, "GET", documentsURL(organization1)+"/"+body.Data.ID+"/download", user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+	}
+	// Not tenant of lease2
+	url := fmt.Sprintf("%s?holder-type=lease&holder-id=%s", documentsURL(organization1), lease2)
+	res = documentRequest(t, "GET", url, user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+	// Whole collection is for managers
+	res = documentRequest(t, "GET", documentsURL(organization1), user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestDeleteRetainedDocument(t *testing.T) {
+	logger.Debug("TestDeleteRetainedDocument...")
+	tbp.PrepareTestDatabase()
+	res := documentRequest(t, "DELETE", documentsURL(organization1)+"/"+document1, user1, "admin", "")
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestShortenDocumentRetention(t *testing.T) {
+	logger.Debug("TestShortenDocumentRetention...")
+	tbp.PrepareTestDatabase()
+	documentJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "Lease1 contract",
+			"retainUntil": 1514764800
+		}
+	}
+	`, document1)
+	res := documentRequest(t, "PUT", documentsURL(organization1)+"/"+document1, user1, "admin", documentJSON)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestDeleteDocument(t *testing.T) {
+	logger.Debug("TestDeleteDocument...")
+	tbp.PrepareTestDatabase()
+	body := createLeaseDocument(t, pdfContent)
+	res := documentRequest(t, "DELETE", documentsURL(organization1)+"/"+body.Data.ID, user1, "admin", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	// Verify
+	documentRepo, err := repo.MakeDocumentRepository()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	_, err = documentRepo.Get(body.Data.ID)
+	if err == nil {
+		t.Errorf("Document '%s' still exists", body.Data.ID)
+	}
+}
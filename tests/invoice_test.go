@@ -0,0 +1,311 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	lease1           = "7d9f1b3d-5e7f-4a9b-8d0f-5e7a9c1b3d01"
+	lease2           = "7d9f1b3d-5e7f-4a9b-8d0f-5e7a9c1b3d02"
+	invoice1         = "9c3e5a7b-9d1f-4b3c-8e5a-7b9d1f3c5e01"
+	// 2017-03-12 12:00:00 UTC
+	until = "1489320000"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func invoicesURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/ledger/invoices", organizationsURL, orgid)
+}
+
+func leaseInvoicesURL(orgid, leaseid string) string {
+	return fmt.Sprintf("%s/%s/leases/%s/invoices", organizationsURL, orgid, leaseid)
+}
+
+func TestGetInvoices(t *testing.T) {
+	logger.Debug("TestGetInvoices...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s?status=%s", invoicesURL(organization1), models.InvoiceStatusPartiallyPaid)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != invoice1 {
+		t.Errorf("Invoices: %d | Expected: 1 (%s)", len(body.Data), invoice1)
+	}
+}
+
+func TestGetInvoiceFromAnotherOrganization(t *testing.T) {
+	logger.Debug("TestGetInvoiceFromAnotherOrganization...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/%s", invoicesURL(organization2), invoice1)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+}
+
+func TestGenerateLeaseInvoicesWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestGenerateLeaseInvoicesWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s?until=%s", leaseInvoicesURL(organization1, lease1), until)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	invoices, err := invoiceRepo.GetAllFromLease(lease1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	// Fixture invoice, February and March rent, late fees for January and February.
+	rent, lateFees := 0, 0
+	for _, invoice := range invoices {
+		switch invoice.Kind.String {
+		case models.InvoiceKindRent:
+			rent++
+		case models.InvoiceKindLateFee:
+			lateFees++
+			if invoice.Amount.Float64 != 50 {
+				t.Errorf("Late fee: %.2f | Expected: 50.00", invoice.Amount.Float64)
+			}
+		}
+	}
+	if rent != 3 || lateFees != 2 {
+		t.Errorf("Rent invoices: %d, late fees: %d | Expected: 3, 2", rent, lateFees)
+	}
+	// Generation is idempotent
+	tbp.Reader = strings.NewReader("")
+	request, _ = http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 0 {
+		t.Errorf("Invoices: %d | Expected: 0", len(body.Data))
+	}
+}
+
+func TestGenerateDraftLeaseInvoices(t *testing.T) {
+	logger.Debug("TestGenerateDraftLeaseInvoices...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s?until=%s", leaseInvoicesURL(organization1, lease2), until)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestGenerateLeaseInvoicesAsTenant(t *testing.T) {
+	logger.Debug("TestGenerateLeaseInvoicesAsTenant...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s?until=%s", leaseInvoicesURL(organization1, lease1), until)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestPayInvoiceWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestPayInvoiceWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	paymentJSON := `
+	{
+		"data": {
+			"amount": 700,
+			"paidAt": 1485216000
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(paymentJSON)
+	url := fmt.Sprintf("%s/%s/payments", invoicesURL(organization1), invoice1)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	invoice, err := invoiceRepo.Get(invoice1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if invoice.Balance.Float64 != 0 || invoice.Status.String != models.InvoiceStatusPaid {
+		t.Errorf("Balance: %.2f, status: '%s' | Expected: 0.00, '%s'", invoice.Balance.Float64, invoice.Status.String, models.InvoiceStatusPaid)
+	}
+}
+
+func TestPayInvoiceAsTenant(t *testing.T) {
+	logger.Debug("TestPayInvoiceAsTenant...")
+	tbp.PrepareTestDatabase()
+	paymentJSON := `
+	{
+		"data": {
+			"amount": 700,
+			"paidAt": 1485216000
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(paymentJSON)
+	url := fmt.Sprintf("%s/%s/payments", invoicesURL(organization1), invoice1)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestPayInvoiceOverBalance(t *testing.T) {
+	logger.Debug("TestPayInvoiceOverBalance...")
+	tbp.PrepareTestDatabase()
+	paymentJSON := `
+	{
+		"data": {
+			"amount": 800
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(paymentJSON)
+	url := fmt.Sprintf("%s/%s/payments", invoicesURL(organization1), invoice1)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
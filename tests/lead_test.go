@@ -0,0 +1,255 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	stageNew         = "4c6e8a0c-2e4a-4c6e-8a0c-2e4a6c8e0a01"
+	stageContacted   = "4c6e8a0c-2e4a-4c6e-8a0c-2e4a6c8e0a02"
+	stageWon         = "4c6e8a0c-2e4a-4c6e-8a0c-2e4a6c8e0a03"
+	lead1            = "8e0a2c4e-6a8c-4e0a-a2c4-6a8c0e2a4c01"
+	reminder1        = "0a2c4e6a-8c0e-4a2c-8e6a-8c0e2a4c6e02"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func crmURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/crm", organizationsURL, orgid)
+}
+
+func crmRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+type leadActivities struct {
+	Data []struct {
+		ID          string `json:"id"`
+		Kind        string `json:"kind"`
+		FromStageID string `json:"fromStageID"`
+		ToStageID   string `json:"toStageID"`
+	} `json:"data"`
+}
+
+func TestGetLeadsAsNonMember(t *testing.T) {
+	logger.Debug("TestGetLeadsAsNonMember...")
+	tbp.PrepareTestDatabase()
+	res := crmRequest(t, "GET", crmURL(organization1)+"/leads", user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestCreateLeadWithDefaultPipeline(t *testing.T) {
+	logger.Debug("TestCreateLeadWithDefaultPipeline...")
+	tbp.PrepareTestDatabase()
+	// Organization without pipeline gets the default one
+	leadJSON := `{"data": {"name": "Bruce Wayne", "email": "bruce@wayne.com", "source": "web", "value": 2500}}`
+	res := crmRequest(t, "POST", crmURL(organization2)+"/leads", user2, "user", leadJSON)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			ID      string `json:"id"`
+			StageID string `json:"stageID"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	leadRepo, err := repo.MakeLeadRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	stages, err := leadRepo.GetStages(organization2)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(stages) != 6 || stages[0].Name.String != "New" {
+		t.Errorf("Stages: %d | Expected: 6 starting at 'New'", len(stages))
+		return
+	}
+	if body.Data.StageID != stages[0].ID.String {
+		t.Errorf("Stage: '%s' | Expected: '%s'", body.Data.StageID, stages[0].ID.String)
+	}
+	activities, err := leadRepo.GetActivities(body.Data.ID)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(activities) != 1 || activities[0].Kind.String != "stage_change" {
+		t.Errorf("Activities: %d | Expected: 1 stage change", len(activities))
+	}
+}
+
+func TestCreateLeadWithForeignAssignee(t *testing.T) {
+	logger.Debug("TestCreateLeadWithForeignAssignee...")
+	tbp.PrepareTestDatabase()
+	leadJSON := fmt.Sprintf(`{"data": {"name": "Selina Kyle", "phone": "+48600000000", "assigneeID": "%s"}}`, user2)
+	res := crmRequest(t, "POST", crmURL(organization1)+"/leads", user1, "admin", leadJSON)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestMoveLead(t *testing.T) {
+	logger.Debug("TestMoveLead...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/leads/%s/move", crmURL(organization1), lead1)
+	moveJSON := fmt.Sprintf(`{"data": {"toStageID": "%s", "body": "Lease signed."}}`, stageWon)
+	res := crmRequest(t, "POST", url, user1, "admin", moveJSON)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	// Same stage
+	res = crmRequest(t, "POST", url, user1, "admin", moveJSON)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	// History
+	url = fmt.Sprintf("%s/leads/%s/activities", crmURL(organization1), lead1)
+	res = crmRequest(t, "GET", url, user1, "admin", "")
+	var body leadActivities
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	last := body.Data[len(body.Data)-1]
+	if last.Kind != "stage_change" || last.FromStageID != stageNew || last.ToStageID != stageWon {
+		t.Errorf("Activity: '%s' from '%s' to '%s' | Expected: 'stage_change' from '%s' to '%s'", last.Kind, last.FromStageID, last.ToStageID, stageNew, stageWon)
+	}
+}
+
+func TestDeleteLeadStageInUse(t *testing.T) {
+	logger.Debug("TestDeleteLeadStageInUse...")
+	tbp.PrepareTestDatabase()
+	res := crmRequest(t, "DELETE", crmURL(organization1)+"/stages/"+stageContacted, user1, "admin", "")
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	res = crmRequest(t, "DELETE", crmURL(organization1)+"/stages/"+stageWon, user1, "admin", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestCompleteLeadReminder(t *testing.T) {
+	logger.Debug("TestCompleteLeadReminder...")
+	tbp.PrepareTestDatabase()
+	res := crmRequest(t, "GET", fmt.Sprintf("%s/reminders?agent=%s", crmURL(organization1), user1), user1, "admin", "")
+	var body leadActivities
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != reminder1 {
+		t.Errorf("Reminders: %d | Expected: 1", len(body.Data))
+		return
+	}
+	url := fmt.Sprintf("%s/leads/%s/activities/%s/done", crmURL(organization1), lead1, reminder1)
+	res = crmRequest(t, "POST", url, user1, "admin", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	// Already done
+	res = crmRequest(t, "POST", url, user1, "admin", "")
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestGetLeadFunnel(t *testing.T) {
+	logger.Debug("TestGetLeadFunnel...")
+	tbp.PrepareTestDatabase()
+	res := crmRequest(t, "GET", crmURL(organization1)+"/funnel", user1, "admin", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			StageID string  `json:"stageID"`
+			Leads   int64   `json:"leads"`
+			Value   float64 `json:"value"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 4 {
+		t.Errorf("Stages: %d | Expected: 4", len(body.Data))
+		return
+	}
+	if body.Data[0].Leads != 1 || body.Data[0].Value != 1200 || body.Data[2].Leads != 0 {
+		t.Errorf("Funnel: %+v | Expected: 1 lead worth 1200 at 'New', none at 'Won'", body.Data)
+	}
+}
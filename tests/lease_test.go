@@ -0,0 +1,621 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	unit1            = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f01"
+	unit2            = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f02"
+	lease1           = "7d9f1b3d-5e7f-4a9b-8d0f-5e7a9c1b3d01"
+	lease1Name       = "Lease1"
+	lease2           = "7d9f1b3d-5e7f-4a9b-8d0f-5e7a9c1b3d02"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func leasesURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/leases", organizationsURL, orgid)
+}
+
+func TestGetAllFromOrganization(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganization...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetAllFromOrganizationAsTenant(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationAsTenant...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestCreateLease(t *testing.T) {
+	logger.Debug("TestCreateLease...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Lease3",
+			"description": "Lease3 description.",
+			"startedAt": 1485907200,
+			"rentAmount": 180,
+			"deposit": 360,
+			"currency": "PLN",
+			"paymentDay": 5,
+			"unitID": "%s",
+			"tenantIDs": ["%s"]
+		}
+	}
+	`, unit2, user2)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	request, _ := http.NewRequest("POST", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestCreateActiveLeaseWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestCreateActiveLeaseWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Lease3",
+			"startedAt": 1485907200,
+			"rentAmount": 180,
+			"currency": "PLN",
+			"status": "active",
+			"unitID": "%s",
+			"tenantIDs": ["%s"]
+		}
+	}
+	`, unit2, user2)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	request, _ := http.NewRequest("POST", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			ID        string `json:"id"`
+			StartedAt int64  `json:"startedAt"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if body.Data.StartedAt != 1485907200 {
+		t.Errorf("StartedAt: %d | Expected: 1485907200", body.Data.StartedAt)
+	}
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	tenants, err := leaseRepo.GetTenants(body.Data.ID)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(tenants) != 1 || tenants[0].ID.String != user2 {
+		t.Errorf("Tenants: %d | Expected: 1 (%s)", len(tenants), user2)
+	}
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	unit, err := unitRepo.Get(unit2)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if unit.Status.String != "occupied" {
+		t.Errorf("Unit status: '%s' | Expected: 'occupied'", unit.Status.String)
+	}
+}
+
+func TestCreateLeaseWithUnitInAnotherOrganization(t *testing.T) {
+	logger.Debug("TestCreateLeaseWithUnitInAnotherOrganization...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Lease3",
+			"rentAmount": 180,
+			"unitID": "%s"
+		}
+	}
+	`, unit2)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	request, _ := http.NewRequest("POST", leasesURL(organization2), tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestCreateLeaseWithInvalidPaymentDay(t *testing.T) {
+	logger.Debug("TestCreateLeaseWithInvalidPaymentDay...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Lease3",
+			"rentAmount": 180,
+			"paymentDay": 32,
+			"unitID": "%s"
+		}
+	}
+	`, unit2)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	request, _ := http.NewRequest("POST", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetByName(t *testing.T) {
+	logger.Debug("TestGetByName...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	leaseURL := fmt.Sprintf("%s/%s", leasesURL(organization1), lease1Name)
+	request, _ := http.NewRequest("GET", leaseURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetLeaseSchedule(t *testing.T) {
+	logger.Debug("TestGetLeaseSchedule...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	scheduleURL := fmt.Sprintf("%s/%s/schedule", leasesURL(organization1), lease1)
+	request, _ := http.NewRequest("GET", scheduleURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			Number  int64   `json:"number"`
+			Amount  float64 `json:"amount"`
+			DueDate int64   `json:"dueDate"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	// Lease1: 2017-01 to 2029-12, 1200 indexed 2.5% yearly, due on day 10.
+	if len(body.Data) != 156 {
+		t.Errorf("Installments: %d | Expected: 156", len(body.Data))
+		return
+	}
+	if body.Data[0].Amount != 1200 || body.Data[12].Amount != 1230 {
+		t.Errorf("Amounts: %f, %f | Expected: 1200, 1230", body.Data[0].Amount, body.Data[12].Amount)
+	}
+	if time.Unix(body.Data[0].DueDate, 0).Day() != 10 {
+		t.Errorf("Due day: %d | Expected: 10", time.Unix(body.Data[0].DueDate, 0).Day())
+	}
+}
+
+func TestUpdateLeaseStatusWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdateLeaseStatusWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "%s",
+			"status": "terminated"
+		}
+	}
+	`, lease1, lease1Name)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	leaseURL := fmt.Sprintf("%s/%s", leasesURL(organization1), lease1)
+	request, _ := http.NewRequest("PUT", leaseURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		leaseRepo, err := repo.MakeLeaseRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		unitRepo, err := repo.MakeUnitRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		lease, err := leaseRepo.Get(lease1)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		unit, err := unitRepo.Get(unit1)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if lease.Status.String == "terminated" && lease.EndsAt.Time.Before(time.Now()) && lease.RentAmount.Float64 == 1200 && unit.Status.String == "vacant" {
+			logger.Debug("Lease update: ok.")
+		} else {
+			error := fmt.Sprintf("Status: '%s' | Expected: 'terminated' - ", lease.Status.String)
+			error += fmt.Sprintf("Ends at: '%s' | Expected: before now - ", lease.EndsAt.Time)
+			error += fmt.Sprintf("Rent: '%f' | Expected: '1200' - ", lease.RentAmount.Float64)
+			error += fmt.Sprintf("Unit status: '%s' | Expected: 'vacant'", unit.Status.String)
+			t.Error(error)
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestUpdateLeaseWithInvalidStatusChange(t *testing.T) {
+	logger.Debug("TestUpdateLeaseWithInvalidStatusChange...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "Lease2",
+			"status": "terminated"
+		}
+	}
+	`, lease2)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	leaseURL := fmt.Sprintf("%s/%s", leasesURL(organization1), lease2)
+	request, _ := http.NewRequest("PUT", leaseURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestExpireLeasesWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestExpireLeasesWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	leaseJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Lease3",
+			"startedAt": 1451606400,
+			"endsAt": 1483228800,
+			"rentAmount": 180,
+			"status": "active",
+			"unitID": "%s"
+		}
+	}
+	`, unit2)
+	tbp.Reader = strings.NewReader(leaseJSON)
+	request, _ := http.NewRequest("POST", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	// Listing leases expires overdue ones
+	tbp.Reader = strings.NewReader("")
+	request, _ = http.NewRequest("GET", leasesURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	leaseRepo, err := repo.MakeLeaseRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	lease, err := leaseRepo.GetByNameInOrganization("Lease3", organization1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	unitRepo, err := repo.MakeUnitRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	unit, err := unitRepo.Get(unit2)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if lease.Status.String != "expired" || unit.Status.String != "vacant" {
+		t.Errorf("Status: '%s', unit status: '%s' | Expected: 'expired', 'vacant'", lease.Status.String, unit.Status.String)
+	}
+	// Lease1 has not reached its end date
+	lease, err = leaseRepo.Get(lease1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if lease.Status.String != "active" {
+		t.Errorf("Status: '%s' | Expected: 'active'", lease.Status.String)
+	}
+}
+
+func TestAddLeaseTenantWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestAddLeaseTenantWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	tenantURL := fmt.Sprintf("%s/%s/tenants/%s", leasesURL(organization1), lease1, user1)
+	request, _ := http.NewRequest("POST", tenantURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		leaseRepo, err := repo.MakeLeaseRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		tenants, err := leaseRepo.GetTenants(lease1)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if len(tenants) != 2 {
+			t.Errorf("Tenants: %d | Expected: 2", len(tenants))
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestGetLeaseAsTenant(t *testing.T) {
+	logger.Debug("TestGetLeaseAsTenant...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	leaseURL := fmt.Sprintf("%s/%s", leasesURL(organization1), lease1)
+	request, _ := http.NewRequest("GET", leaseURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestAddLeaseTenantAsTenant(t *testing.T) {
+	logger.Debug("TestAddLeaseTenantAsTenant...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	tenantURL := fmt.Sprintf("%s/%s/tenants/%s", leasesURL(organization1), lease1, user1)
+	request, _ := http.NewRequest("POST", tenantURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestRemoveLeaseTenantAsTenant(t *testing.T) {
+	logger.Debug("TestRemoveLeaseTenantAsTenant...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	tenantURL := fmt.Sprintf("%s/%s/tenants/%s", leasesURL(organization1), lease1, user2)
+	request, _ := http.NewRequest("DELETE", tenantURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestRemoveLeaseTenantWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestRemoveLeaseTenantWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	tenantURL := fmt.Sprintf("%s/%s/tenants/%s", leasesURL(organization1), lease1, user2)
+	request, _ := http.NewRequest("DELETE", tenantURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		leaseRepo, err := repo.MakeLeaseRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		tenants, err := leaseRepo.GetTenants(lease1)
+		if err != nil {
+			t.Error(err.Error())
+			return
+		}
+		if len(tenants) != 0 {
+			t.Errorf("Tenants: %d | Expected: 0", len(tenants))
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteLeaseWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestDeleteLeaseWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	leaseURL := fmt.Sprintf("%s/%s", leasesURL(organization1), lease1)
+	request, _ := http.NewRequest("DELETE", leaseURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		leaseRepo, err := repo.MakeLeaseRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		lease, err := leaseRepo.Get(lease1)
+		if err != nil {
+			logger.Debug("TestDeleteLease: ok")
+		} else {
+			t.Errorf("Lease: %s | Expected: 'nil'", lease.Name.String)
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
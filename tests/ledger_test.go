@@ -0,0 +1,309 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	unit1            = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f01"
+	invoice1         = "9c3e5a7b-9d1f-4b3c-8e5a-7b9d1f3c5e01"
+	invoiceEntry1    = "9b2d4f6a-8c0e-4a2b-9d4f-6a8c0e2b4d01"
+	paymentEntry1    = "9b2d4f6a-8c0e-4a2b-9d4f-6a8c0e2b4d02"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func ledgerURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/ledger", organizationsURL, orgid)
+}
+
+func TestGetAccounts(t *testing.T) {
+	logger.Debug("TestGetAccounts...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", ledgerURL(organization1)+"/accounts", tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetJournalEntry(t *testing.T) {
+	logger.Debug("TestGetJournalEntry...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/journal-entries/%s", ledgerURL(organization1), invoiceEntry1)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			Postings []struct {
+				Debit  float64 `json:"debit"`
+				Credit float64 `json:"credit"`
+			} `json:"postings"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data.Postings) != 2 {
+		t.Errorf("Postings: %d | Expected: 2", len(body.Data.Postings))
+	}
+}
+
+func TestReverseJournalEntryWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestReverseJournalEntryWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/journal-entries/%s/reversal", ledgerURL(organization1), paymentEntry1)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	invoice, err := invoiceRepo.Get(invoice1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if invoice.Balance.Float64 != 1200 || invoice.Status.String != models.InvoiceStatusOpen {
+		t.Errorf("Balance: %.2f, status: '%s' | Expected: 1200.00, '%s'", invoice.Balance.Float64, invoice.Status.String, models.InvoiceStatusOpen)
+	}
+	// Entries can be reversed only once
+	tbp.Reader = strings.NewReader("")
+	request, _ = http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err = http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestReverseInvoiceEntryWithPaymentsPosted(t *testing.T) {
+	logger.Debug("TestReverseInvoiceEntryWithPaymentsPosted...")
+	tbp.PrepareTestDatabase()
+	res := reverseJournalEntry(t, invoiceEntry1, user1)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+		return
+	}
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	invoice, err := invoiceRepo.Get(invoice1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if invoice.Balance.Float64 != 700 || invoice.Status.String != models.InvoiceStatusPartiallyPaid {
+		t.Errorf("Balance: %.2f, status: '%s' | Expected: 700.00, '%s'", invoice.Balance.Float64, invoice.Status.String, models.InvoiceStatusPartiallyPaid)
+	}
+}
+
+func TestReverseInvoiceEntryVoidsInvoice(t *testing.T) {
+	logger.Debug("TestReverseInvoiceEntryVoidsInvoice...")
+	tbp.PrepareTestDatabase()
+	// Payments must be reversed first
+	res := reverseJournalEntry(t, paymentEntry1, user1)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	res = reverseJournalEntry(t, invoiceEntry1, user1)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	invoiceRepo, err := repo.MakeInvoiceRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	invoice, err := invoiceRepo.Get(invoice1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if invoice.Balance.Float64 != 0 || invoice.Status.String != models.InvoiceStatusVoid {
+		t.Errorf("Balance: %.2f, status: '%s' | Expected: 0.00, '%s'", invoice.Balance.Float64, invoice.Status.String, models.InvoiceStatusVoid)
+	}
+}
+
+func TestReverseJournalEntryAsTenant(t *testing.T) {
+	logger.Debug("TestReverseJournalEntryAsTenant...")
+	tbp.PrepareTestDatabase()
+	res := reverseJournalEntry(t, paymentEntry1, user2)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestGetUnitStatement(t *testing.T) {
+	logger.Debug("TestGetUnitStatement...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/statements/units/%s", ledgerURL(organization1), unit1)
+	verifyStatementBalance(t, url, user1, 700)
+}
+
+func TestGetTenantStatement(t *testing.T) {
+	logger.Debug("TestGetTenantStatement...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/statements/tenants/%s", ledgerURL(organization1), user2)
+	verifyStatementBalance(t, url, user1, 700)
+}
+
+func TestGetTenantStatementAsTenant(t *testing.T) {
+	logger.Debug("TestGetTenantStatementAsTenant...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/statements/tenants/%s", ledgerURL(organization1), user2)
+	verifyStatementBalance(t, url, user2, 700)
+	// Other tenants statements are not visible
+	tbp.Reader = strings.NewReader("")
+	url = fmt.Sprintf("%s/statements/tenants/%s", ledgerURL(organization1), user1)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user2, "user", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func reverseJournalEntry(t *testing.T, id, userID string) *http.Response {
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s/journal-entries/%s/reversal", ledgerURL(organization1), id)
+	request, _ := http.NewRequest("POST", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func verifyStatementBalance(t *testing.T, url, userID string, expected float64) {
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			Balances []struct {
+				Currency string  `json:"currency"`
+				Balance  float64 `json:"balance"`
+			} `json:"balances"`
+			Lines []struct {
+				Balance float64 `json:"balance"`
+			} `json:"lines"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data.Balances) != 1 || body.Data.Balances[0].Balance != expected {
+		t.Errorf("Balances: %v | Expected: PLN %.2f", body.Data.Balances, expected)
+	}
+	if len(body.Data.Lines) != 2 {
+		t.Errorf("Lines: %d | Expected: 2", len(body.Data.Lines))
+	}
+}
@@ -0,0 +1,728 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	listing1         = "7b1f3e57-3b41-4a8e-9f2a-6f0d2c1a9e11"
+	listing2         = "2e4c8d90-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+	listing1Name     = "Listing1"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func undoListingFixture() {
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = listingRepo.Delete(listing1)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestGetAllFromOrganization(t *testing.T) {
+	logger.Debug("TestGetAll...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestCreateListing(t *testing.T) {
+	logger.Debug("TestCreateListing...")
+	tbp.PrepareTestDatabase()
+	undoListingFixture()
+	listingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Listing",
+		  "description": "Listing description.",
+			"price": 99000,
+			"currency": "EUR",
+			"operation": "sale",
+			"organizationID": "%s"
+		}
+	}
+	`, organization1)
+	tbp.Reader = strings.NewReader(listingJSON)
+	listingsURL := fmt.Sprintf("%s/%s/listings", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", listingsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestCreateListingWithInvalidOperation(t *testing.T) {
+	logger.Debug("TestCreateListingWithInvalidOperation...")
+	tbp.PrepareTestDatabase()
+	listingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "Listing",
+		  "description": "Listing description.",
+			"operation": "swap",
+			"organizationID": "%s"
+		}
+	}
+	`, organization1)
+	tbp.Reader = strings.NewReader(listingJSON)
+	listingsURL := fmt.Sprintf("%s/%s/listings", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", listingsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetFromOrganization(t *testing.T) {
+	logger.Debug("TestGet...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingOrgURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("GET", listingOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetByName(t *testing.T) {
+	logger.Debug("TestGetByName...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1Name)
+	request, _ := http.NewRequest("GET", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestUpdateListing(t *testing.T) {
+	logger.Debug("TestUpdateListing...")
+	tbp.PrepareTestDatabase()
+	listingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "Listing new name",
+		  "description": "Listing new description",
+			"organizationID": "%s"
+		}
+	}
+	`, listing1, organization1)
+	tbp.Reader = strings.NewReader(listingJSON)
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("PUT", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestUpdateListingWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdateListingWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	newName := "Listing new name"
+	newPrice := 115000.0
+	newStatus := "reserved"
+	listingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "%s",
+			"price": %f,
+			"status": "%s",
+			"organizationID": "%s"
+		}
+	}
+	`, listing1, newName, newPrice, newStatus, organization1)
+	tbp.Reader = strings.NewReader(listingJSON)
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("PUT", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		listingRepo, err := repo.MakeListingRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		listing, err := listingRepo.Get(listing1)
+		if err == nil {
+			if listing.Name.String == newName && listing.Price.Float64 == newPrice && listing.Status.String == newStatus {
+				logger.Debug("Listing update: ok.")
+			} else {
+				error := fmt.Sprintf("Name: '%s' | Expected: '%s' - ", listing.Name.String, newName)
+				error += fmt.Sprintf("Price: '%f' | Expected: '%f' - ", listing.Price.Float64, newPrice)
+				error += fmt.Sprintf("Status: '%s' | Expected: '%s'", listing.Status.String, newStatus)
+				t.Error(error)
+			}
+		} else {
+			t.Error(err.Error())
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteListing(t *testing.T) {
+	logger.Debug("TestDeleteListing...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("DELETE", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteListingWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestDeleteListingWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("DELETE", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		listingRepo, err := repo.MakeListingRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		listing, err := listingRepo.Get(listing1)
+		if err != nil {
+			logger.Debug("TestDeleteListing: ok")
+		} else {
+			t.Errorf("Listing: %s | Expected: 'nil'", listing.Name.String)
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteListingFromAnotherOrganization(t *testing.T) {
+	logger.Debug("TestDeleteListingFromAnotherOrganization...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing2)
+	request, _ := http.NewRequest("DELETE", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: not 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestCreateListingPropertiesSet(t *testing.T) {
+	logger.Debug("TestCreateListingPropertiesSet...")
+	tbp.PrepareTestDatabase()
+	propSetJSON := `
+	{
+		"data": {
+			"name": "Features",
+		  "description": "Listing features.",
+			"position": 0
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(propSetJSON)
+	propSetsURL := fmt.Sprintf("%s/%s/listings/%s/properties-sets", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("POST", propSetsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestGetListingPropertiesSets(t *testing.T) {
+	logger.Debug("TestGetListingPropertiesSets...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	propSetsURL := fmt.Sprintf("%s/%s/listings/%s/properties-sets", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("GET", propSetsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetAllFromOrganizationNear(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationNear...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?near=0.001,0.001&radius=1000", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID       string  `json:"id"`
+			Distance float64 `json:"distance"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != listing1 || body.Data[0].Distance <= 0 {
+		t.Errorf("Listings: %v | Expected: '%s' with distance", body.Data, listing1)
+	}
+}
+
+func TestGetListingOmitsDistance(t *testing.T) {
+	logger.Debug("TestGetListingOmitsDistance...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("GET", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if _, ok := body.Data["distance"]; ok {
+		t.Errorf("Listing: %v | Expected: no distance outside location searches", body.Data)
+	}
+}
+
+func TestGetAllFromOrganizationInBBox(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationInBBox...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?bbox=10,10,11,11", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 0 {
+		t.Errorf("Listings: %d | Expected: 0", len(body.Data))
+	}
+}
+
+func TestGetAllFromOrganizationWithInvalidLocation(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationWithInvalidLocation...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?near=95,0&radius=1000", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetAllFromOrganizationByProperties(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationByProperties...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?prop.rooms=2..&prop.area=50..80&prop.heating=gas,electric", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != listing1 {
+		t.Errorf("Listings: %v | Expected: '%s'", body.Data, listing1)
+	}
+}
+
+func TestGetAllFromOrganizationWithFacets(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationWithFacets...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?facets=heating,area:25", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data   []json.RawMessage `json:"data"`
+		Facets []struct {
+			Name    string `json:"name"`
+			Buckets []struct {
+				Value string `json:"value"`
+				Count int64  `json:"count"`
+			} `json:"buckets"`
+		} `json:"facets"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 2 || len(body.Facets) != 2 {
+		t.Errorf("Listings: %d, Facets: %d | Expected: 2, 2", len(body.Data), len(body.Facets))
+		return
+	}
+	area := body.Facets[1]
+	if area.Name != "area" || len(area.Buckets) != 2 || area.Buckets[0].Value != "25" || area.Buckets[1].Value != "50" {
+		t.Errorf("Area facet: %v | Expected: buckets '25' and '50'", area)
+	}
+}
+
+func TestGetAllFromOrganizationWithInvalidProperties(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationWithInvalidProperties...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?prop.rooms=..", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetListingHistory(t *testing.T) {
+	logger.Debug("TestGetListingHistory...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	historyURL := fmt.Sprintf("%s/%s/listings/%s/history", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("GET", historyURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			Price         float64 `json:"price"`
+			PreviousPrice float64 `json:"previousPrice"`
+		} `json:"data"`
+		Stats struct {
+			DaysOnMarket    int64   `json:"daysOnMarket"`
+			PriceReductions int64   `json:"priceReductions"`
+			PriceChange     float64 `json:"priceChange"`
+		} `json:"stats"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 2 || body.Data[1].PreviousPrice != 130000 || body.Data[1].Price != 120000 {
+		t.Errorf("History: %v | Expected: price reduced from 130000 to 120000", body.Data)
+	}
+	if body.Stats.PriceReductions != 1 || body.Stats.PriceChange != -7.69 || body.Stats.DaysOnMarket <= 0 {
+		t.Errorf("Stats: %v | Expected: 1 reduction, -7.69%% change, some days on market", body.Stats)
+	}
+}
+
+func TestUpdateListingRecordsHistory(t *testing.T) {
+	logger.Debug("TestUpdateListingRecordsHistory...")
+	tbp.PrepareTestDatabase()
+	newPrice := 110000.0
+	listingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "%s",
+			"price": %f,
+			"currency": "EUR",
+			"status": "closed",
+			"organizationID": "%s"
+		}
+	}
+	`, listing1, listing1Name, newPrice, organization1)
+	tbp.Reader = strings.NewReader(listingJSON)
+	listingURL := fmt.Sprintf("%s/%s/listings/%s", organizationsURL, organization1, listing1)
+	request, _ := http.NewRequest("PUT", listingURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	listingRepo, err := repo.MakeListingRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	history, err := listingRepo.GetHistory(listing1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(history) != 3 {
+		t.Errorf("History entries: %d | Expected: 3", len(history))
+		return
+	}
+	last := history[2]
+	if last.Price.Float64 != newPrice || last.PreviousPrice.Float64 != 120000 || last.Status.String != "closed" || last.PreviousStatus.String != "published" || last.UserID.String != user1 {
+		t.Errorf("Last entry: %v | Expected: closed at %f by %s", last, newPrice, user1)
+	}
+	stats, err := listingRepo.GetStats(listing1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if stats.PriceReductions != 2 {
+		t.Errorf("Price reductions: %d | Expected: 2", stats.PriceReductions)
+	}
+}
+
+func TestGetAllFromOrganizationPriceReduced(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationPriceReduced...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?price-reduced=true&min-days-on-market=1", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID    string `json:"id"`
+			Stats struct {
+				PriceReductions int64 `json:"priceReductions"`
+			} `json:"stats"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != listing1 || body.Data[0].Stats.PriceReductions != 1 {
+		t.Errorf("Listings: %v | Expected: only '%s' with 1 price reduction", body.Data, listing1)
+	}
+}
+
+func TestGetAllFromOrganizationWithInvalidStatsFilter(t *testing.T) {
+	logger.Debug("TestGetAllFromOrganizationWithInvalidStatsFilter...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	listingsOrgURL := fmt.Sprintf("%s/%s/listings?min-days-on-market=-1", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", listingsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp                 = testbootstrap.TestBootstrap
+	user1               = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2               = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL    string
+	organization1       = "d43809a2-5896-43c4-808e-549f2ee47783"
+	building1           = "3e5a7c9b-1d2f-4a6b-8c0d-4e6f8a0b2c01"
+	unit1               = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f01"
+	unit2               = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f02"
+	role1               = "9b6869e4-f51a-4197-9608-f2898bd764d8"
+	maintenanceRequest1 = "1c3e5a7c-9e1a-4c3e-8a5c-7e9a1c3e5b01"
+	maintenanceRequest2 = "1c3e5a7c-9e1a-4c3e-8a5c-7e9a1c3e5b02"
+	maintenanceRequest3 = "1c3e5a7c-9e1a-4c3e-8a5c-7e9a1c3e5b03"
+	workOrder1          = "2d4f6b8d-0f2b-4d4f-9b6d-8f0b2d4f6c01"
+	pngPhoto            = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func requestsURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/maintenance/requests", organizationsURL, orgid)
+}
+
+func maintenanceRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func verifyMaintenanceStatus(t *testing.T, id, status string) {
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	request, err := requestRepo.Get(id)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if request.Status.String != status {
+		t.Errorf("Status: '%s' | Expected: '%s'", request.Status.String, status)
+	}
+}
+
+type maintenanceRequests struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+func TestGetMaintenanceRequestsAsTenant(t *testing.T) {
+	logger.Debug("TestGetMaintenanceRequestsAsTenant...")
+	tbp.PrepareTestDatabase()
+	res := maintenanceRequest(t, "GET", requestsURL(organization1), user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body maintenanceRequests
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	// Reported request and request assigned to owned vendor organization
+	if len(body.Data) != 2 {
+		t.Errorf("Requests: %d | Expected: 2", len(body.Data))
+	}
+	for _, request := range body.Data {
+		if request.ID == maintenanceRequest3 {
+			t.Errorf("Request '%s' should not be visible", maintenanceRequest3)
+		}
+	}
+	// Not visible requests are not found
+	res = maintenanceRequest(t, "GET", requestsURL(organization1)+"/"+maintenanceRequest3, user2, "user", "")
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+}
+
+func TestGetMaintenanceRequestsAsGrantedManager(t *testing.T) {
+	logger.Debug("TestGetMaintenanceRequestsAsGrantedManager...")
+	tbp.PrepareTestDatabase()
+	userRoleRepo, err := repo.MakeUserRoleRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	userRole := models.UserRole{
+		OrganizationID: models.ToNullsString(organization1),
+		UserID:         models.ToNullsString(user2),
+		RoleID:         models.ToNullsString(role1),
+	}
+	err = userRoleRepo.Create(&userRole)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	res := maintenanceRequest(t, "GET", requestsURL(organization1), user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body maintenanceRequests
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 3 {
+		t.Errorf("Requests: %d | Expected: 3", len(body.Data))
+	}
+}
+
+func TestCreateMaintenanceRequestAsTenant(t *testing.T) {
+	logger.Debug("TestCreateMaintenanceRequestAsTenant...")
+	tbp.PrepareTestDatabase()
+	requestJSON := `
+	{
+		"data": {
+			"name": "Broken window",
+			"priority": "urgent",
+			"unitID": "%s"
+		}
+	}
+	`
+	res := maintenanceRequest(t, "POST", requestsURL(organization1), user2, "user", fmt.Sprintf(requestJSON, unit1))
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			BuildingID string `json:"buildingID"`
+			ReportedBy string `json:"reportedBy"`
+			Status     string `json:"status"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if body.Data.BuildingID != building1 || body.Data.ReportedBy != user2 || body.Data.Status != models.MaintenanceStatusReported {
+		t.Errorf("Building: '%s', reporter: '%s', status: '%s' | Expected: '%s', '%s', '%s'", body.Data.BuildingID, body.Data.ReportedBy, body.Data.Status, building1, user2, models.MaintenanceStatusReported)
+	}
+	// Tenants can't report requests for units they don't rent
+	res = maintenanceRequest(t, "POST", requestsURL(organization1), user2, "user", fmt.Sprintf(requestJSON, unit2))
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestTriageMaintenanceRequestAsTenant(t *testing.T) {
+	logger.Debug("TestTriageMaintenanceRequestAsTenant...")
+	tbp.PrepareTestDatabase()
+	requestJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"status": "triaged"
+		}
+	}
+	`, maintenanceRequest1)
+	res := maintenanceRequest(t, "PUT", requestsURL(organization1)+"/"+maintenanceRequest1, user2, "user", requestJSON)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+	verifyMaintenanceStatus(t, maintenanceRequest1, models.MaintenanceStatusReported)
+}
+
+func TestMaintenanceWorkflowWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestMaintenanceWorkflowWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	url := requestsURL(organization1) + "/" + maintenanceRequest1
+	// Triage
+	res := maintenanceRequest(t, "PUT", url, user1, "admin", fmt.Sprintf(`{"data": {"id": "%s", "status": "triaged"}}`, maintenanceRequest1))
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	// Assign
+	orderJSON := fmt.Sprintf(`{"data": {"name": "Replace tap", "assigneeUserID": "%s", "estimatedCost": 90, "currency": "EUR"}}`, user1)
+	res = maintenanceRequest(t, "POST", url+"/work-orders", user1, "admin", orderJSON)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	verifyMaintenanceStatus(t, maintenanceRequest1, models.MaintenanceStatusAssigned)
+	// Start and complete work
+	orderURL := url + "/work-orders/" + body.Data.ID
+	res = maintenanceRequest(t, "PUT", orderURL, user1, "admin", fmt.Sprintf(`{"data": {"id": "%s", "status": "in_progress"}}`, body.Data.ID))
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	verifyMaintenanceStatus(t, maintenanceRequest1, models.MaintenanceStatusInProgress)
+	res = maintenanceRequest(t, "PUT", orderURL, user1, "admin", fmt.Sprintf(`{"data": {"id": "%s", "status": "completed", "actualCost": 80}}`, body.Data.ID))
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	verifyMaintenanceStatus(t, maintenanceRequest1, models.MaintenanceStatusDone)
+	// Reporter verifies
+	res = maintenanceRequest(t, "PUT", url, user2, "user", fmt.Sprintf(`{"data": {"id": "%s", "status": "verified"}}`, maintenanceRequest1))
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	verifyMaintenanceStatus(t, maintenanceRequest1, models.MaintenanceStatusVerified)
+	// Costs
+	requestRepo, err := repo.MakeMaintenanceRequestRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	request, err := requestRepo.GetFromOrganization(maintenanceRequest1, organization1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(request.Costs) != 1 || request.Costs[0].Estimated != 90 || request.Costs[0].Actual != 80 {
+		t.Errorf("Costs: %+v | Expected: EUR 90.00 estimated, 80.00 actual", request.Costs)
+	}
+}
+
+func TestUpdateWorkOrderAsVendor(t *testing.T) {
+	logger.Debug("TestUpdateWorkOrderAsVendor...")
+	tbp.PrepareTestDatabase()
+	orderURL := requestsURL(organization1) + "/" + maintenanceRequest2 + "/work-orders/" + workOrder1
+	orderJSON := fmt.Sprintf(`{"data": {"id": "%s", "status": "in_progress", "estimatedCost": 500}}`, workOrder1)
+	res := maintenanceRequest(t, "PUT", orderURL, user2, "user", orderJSON)
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	verifyMaintenanceStatus(t, maintenanceRequest2, models.MaintenanceStatusInProgress)
+	// Vendors can't change estimated cost
+	orderRepo, err := repo.MakeWorkOrderRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	order, err := orderRepo.Get(workOrder1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if order.EstimatedCost.Float64 != 120 {
+		t.Errorf("Estimated cost: %.2f | Expected: 120.00", order.EstimatedCost.Float64)
+	}
+}
+
+func TestAddMaintenanceComment(t *testing.T) {
+	logger.Debug("TestAddMaintenanceComment...")
+	tbp.PrepareTestDatabase()
+	commentJSON := `{"data": {"body": "Still dripping."}}`
+	res := maintenanceRequest(t, "POST", requestsURL(organization1)+"/"+maintenanceRequest1+"/comments", user2, "user", commentJSON)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+	res = maintenanceRequest(t, "POST", requestsURL(organization1)+"/"+maintenanceRequest3+"/comments", user2, "user", commentJSON)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+}
+
+func TestAddMaintenancePhoto(t *testing.T) {
+	logger.Debug("TestAddMaintenancePhoto...")
+	tbp.PrepareTestDatabase()
+	url := requestsURL(organization1) + "/" + maintenanceRequest1 + "/photos"
+	photoJSON := `{"data": {"name": "tap.png", "contentType": "%s", "base64": "%s"}}`
+	res := maintenanceRequest(t, "POST", url, user2, "user", fmt.Sprintf(photoJSON, "image/png", pngPhoto))
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+	res = maintenanceRequest(t, "POST", url, user2, "user", fmt.Sprintf(photoJSON, "text/plain", "bm90IGEgcGhvdG8="))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
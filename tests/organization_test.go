@@ -24,6 +24,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -271,3 +272,118 @@ func TestDeleteOrganizationWithDatabaseVerify(t *testing.T) {
 		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
 	}
 }
+
+func TestGetAllAsGeoJSON(t *testing.T) {
+	logger.Debug("TestGetAllAsGeoJSON...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", organizationsURL, tbp.Reader)
+	request.Header.Set("Accept", "application/geo+json")
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	if res.Header.Get("Content-Type") != "application/geo+json" {
+		t.Errorf("Content-Type: %s | Expected: 'application/geo+json'", res.Header.Get("Content-Type"))
+	}
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&fc)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) == 0 {
+		t.Errorf("Type: '%s' - Features: %d | Expected: 'FeatureCollection' with features", fc.Type, len(fc.Features))
+		return
+	}
+	feature := fc.Features[0]
+	if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) != 2 || feature.Properties["name"] == nil {
+		t.Errorf("Feature: %v | Expected: Point geometry and name property", feature)
+	}
+}
+
+func TestGetOrganizationAsNonMember(t *testing.T) {
+	logger.Debug("TestGetOrganizationAsNonMember...")
+	tbp.PrepareTestDatabase()
+	for _, key := range []string{organization2, "Organization2."} {
+		res := organizationRequest(t, "GET", key, "")
+		if res.StatusCode != http.StatusForbidden {
+			t.Errorf("%s status: %d | Expected: 403-StatusForbidden", key, res.StatusCode)
+		}
+	}
+}
+
+func TestUpdateOrganizationAsNonMember(t *testing.T) {
+	logger.Debug("TestUpdateOrganizationAsNonMember...")
+	tbp.PrepareTestDatabase()
+	organizationJSON := fmt.Sprintf(`{"data": {"id": "%s", "name": "Taken", "userID": "%s"}}`, organization2, user1)
+	res := organizationRequest(t, "PUT", organization2, organizationJSON)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+	verifyOrganizationOwner(t, organization2, user2)
+}
+
+func TestDeleteOrganizationAsNonMember(t *testing.T) {
+	logger.Debug("TestDeleteOrganizationAsNonMember...")
+	tbp.PrepareTestDatabase()
+	res := organizationRequest(t, "DELETE", organization2, "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestUpdateOrganizationKeepsOwner(t *testing.T) {
+	logger.Debug("TestUpdateOrganizationKeepsOwner...")
+	tbp.PrepareTestDatabase()
+	organizationJSON := fmt.Sprintf(`{"data": {"id": "%s", "name": "Organization", "userID": "%s"}}`, organization1, user2)
+	res := organizationRequest(t, "PUT", organization1, organizationJSON)
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+	verifyOrganizationOwner(t, organization1, user1)
+}
+
+// organizationRequest - Sends a request as user1, owner of organization1 and unrelated to organization2.
+func organizationRequest(t *testing.T, method, key, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, fmt.Sprintf("%s/%s", organizationsURL, key), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return res
+}
+
+func verifyOrganizationOwner(t *testing.T, id, expected string) {
+	organizationRepo, err := repo.MakeOrganizationRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	organization, err := organizationRepo.Get(id)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if organization.UserID.String != expected {
+		t.Errorf("Owner: %s | Expected: %s", organization.UserID.String, expected)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/cache"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/services"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	role1            = "9b6869e4-f51a-4197-9608-f2898bd764d8"
+	role2            = "1a40baee-e968-4fb0-8cc9-ecb62e2f2a76"
+	user2Role2       = "fd1a1483-8c41-4400-9e07-713f6a350c92"
+	role2Permission2 = "f25a4af8-661f-4197-99b8-cdee96bc4e6f"
+	resource2Tag     = "394e9457"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func expectCan(t *testing.T, step string, expected bool) {
+	can, err := services.Can(user2, models.ActionRead, resource2Tag, organization2)
+	if err != nil || can != expected {
+		t.Errorf("%s - Can: %t, Error: %v | Expected: %t", step, can, err, expected)
+	}
+}
+
+func TestDecisionIsCached(t *testing.T) {
+	logger.Debug("TestDecisionIsCached...")
+	tbp.PrepareTestDatabase()
+	before := cache.Decisions.Stats()
+	expectCan(t, "First", true)
+	expectCan(t, "Second", true)
+	after := cache.Decisions.Stats()
+	if after.Misses-before.Misses != 1 || after.Hits-before.Hits != 1 {
+		t.Errorf("Misses: %d, Hits: %d | Expected: 1 miss, 1 hit", after.Misses-before.Misses, after.Hits-before.Hits)
+	}
+}
+
+func TestGetPermissionCacheStats(t *testing.T) {
+	logger.Debug("TestGetPermissionCacheStats...")
+	tbp.PrepareTestDatabase()
+	// Granted through role1 in organization1
+	before := getPermissionCacheStats(t, user1, http.StatusOK)
+	expectCan(t, "First", true)
+	expectCan(t, "Second", true)
+	after := getPermissionCacheStats(t, user1, http.StatusOK)
+	if after.Misses-before.Misses < 1 || after.Hits-before.Hits < 1 {
+		t.Errorf("Misses: %d, Hits: %d | Expected: at least 1 miss, 1 hit", after.Misses-before.Misses, after.Hits-before.Hits)
+	}
+}
+
+func TestGetPermissionCacheStatsNotGranted(t *testing.T) {
+	logger.Debug("TestGetPermissionCacheStatsNotGranted...")
+	tbp.PrepareTestDatabase()
+	getPermissionCacheStats(t, user2, http.StatusForbidden)
+}
+
+func TestGetPermissionCacheStatsGranted(t *testing.T) {
+	logger.Debug("TestGetPermissionCacheStatsGranted...")
+	tbp.PrepareTestDatabase()
+	userRoleRepo, err := repo.MakeUserRoleRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	userRole := models.UserRole{
+		OrganizationID: models.ToNullsString(organization1),
+		UserID:         models.ToNullsString(user2),
+		RoleID:         models.ToNullsString(role1),
+	}
+	err = userRoleRepo.Create(&userRole)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	getPermissionCacheStats(t, user2, http.StatusOK)
+}
+
+func getPermissionCacheStats(t *testing.T, userID string, expected int) cache.DecisionStats {
+	url := fmt.Sprintf("%s/permission-cache/stats", tbp.APIServerURL)
+	request, _ := http.NewRequest("GET", url, nil)
+	tbp.AuthorizeRequest(request, userID, "user", "member")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != expected {
+		t.Fatalf("Status: %d | Expected: %d", res.StatusCode, expected)
+	}
+	var body struct {
+		Data cache.DecisionStats `json:"data"`
+	}
+	if expected == http.StatusOK {
+		err = json.NewDecoder(res.Body).Decode(&body)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	return body.Data
+}
+
+func TestDecisionExpires(t *testing.T) {
+	logger.Debug("TestDecisionExpires...")
+	decisions := cache.NewDecisionCache(20*time.Millisecond, cache.DefaultMaxDecisions)
+	computed := 0
+	compute := func() (bool, error) {
+		computed++
+		return true, nil
+	}
+	decisions.Lookup("key", compute)
+	decisions.Lookup("key", compute)
+	time.Sleep(40 * time.Millisecond)
+	decisions.Lookup("key", compute)
+	if computed != 2 {
+		t.Errorf("Computed: %d | Expected: 2", computed)
+	}
+}
+
+func TestDecisionTakenDuringInvalidationIsNotCached(t *testing.T) {
+	logger.Debug("TestDecisionTakenDuringInvalidationIsNotCached...")
+	decisions := cache.NewDecisionCache(time.Minute, cache.DefaultMaxDecisions)
+	decisions.Lookup("key", func() (bool, error) {
+		// A grant changes while the decision is being taken
+		decisions.Invalidate()
+		return true, nil
+	})
+	if stats := decisions.Stats(); stats.Entries != 0 {
+		t.Errorf("Entries: %d | Expected: 0", stats.Entries)
+	}
+}
+
+func TestUserRoleChangeInvalidatesDecision(t *testing.T) {
+	logger.Debug("TestUserRoleChangeInvalidatesDecision...")
+	tbp.PrepareTestDatabase()
+	expectCan(t, "Granted", true)
+	userRoleRepo, err := repo.MakeUserRoleRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = userRoleRepo.Delete(user2Role2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expectCan(t, "Revoked", false)
+	userRole := models.UserRole{
+		OrganizationID: models.ToNullsString(organization2),
+		UserID:         models.ToNullsString(user2),
+		RoleID:         models.ToNullsString(role2),
+	}
+	err = userRoleRepo.Create(&userRole)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expectCan(t, "Granted again", true)
+}
+
+func TestRolePermissionChangeInvalidatesDecision(t *testing.T) {
+	logger.Debug("TestRolePermissionChangeInvalidatesDecision...")
+	tbp.PrepareTestDatabase()
+	expectCan(t, "Granted", true)
+	rolePermissionRepo, err := repo.MakeRolePermissionRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = rolePermissionRepo.Delete(role2Permission2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expectCan(t, "Revoked", false)
+}
+
+func TestDatabaseChangeInvalidatesDecision(t *testing.T) {
+	logger.Debug("TestDatabaseChangeInvalidatesDecision...")
+	tbp.PrepareTestDatabase()
+	expectCan(t, "Granted", true)
+	// Straight in the database, as another instance would
+	_, err := testbootstrap.TestBootstrap.DBInstance.Exec("DELETE FROM role_permissions WHERE id = $1", role2Permission2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// Notifications are delivered asynchronously
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		can, err := services.Can(user2, models.ActionRead, resource2Tag, organization2)
+		if err == nil && !can {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("Decision not invalidated after a database change")
+}
@@ -0,0 +1,465 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp               = testbootstrap.TestBootstrap
+	user1             = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	organizationsURL  string
+	propertiesSetsURL string
+	organization1     = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2     = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	template1         = "0c9d7a3e-7f1b-4d52-9a0e-3b6c2f8e1a01"
+	template1Name     = "Apartment"
+	template2         = "5e2b8c41-2d6a-4f0e-8b7c-9a1d3e5f7b02"
+	propertiesSet1    = "b2672e94-e1ba-4c23-a428-b91528d06d1f"
+	propertiesSet3    = "6f8a0b2c-4d6e-4f80-9a1b-2c3d4e5f6a03"
+	roomsProperty     = "4d5e6f70-8192-4a3b-9c4d-5e6f70819201"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	propertiesSetsURL = fmt.Sprintf("%s/properties-set", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func TestGetAllFromOrganization(t *testing.T) {
+	logger.Debug("TestGetAll...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	templatesURL := fmt.Sprintf("%s/%s/properties-set-templates", organizationsURL, organization1)
+	request, _ := http.NewRequest("GET", templatesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestCreatePropertiesSetTemplate(t *testing.T) {
+	logger.Debug("TestCreatePropertiesSetTemplate...")
+	tbp.PrepareTestDatabase()
+	templateJSON := `
+	{
+		"data": {
+			"name": "House",
+		  "description": "House properties.",
+			"propertyTemplates": [
+				{"name": "floors", "valueType": "i", "required": true, "minValue": 1, "maxValue": 5},
+				{"name": "garden", "valueType": "b"}
+			]
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(templateJSON)
+	templatesURL := fmt.Sprintf("%s/%s/properties-set-templates", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", templatesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestCreateInvalidPropertiesSetTemplate(t *testing.T) {
+	logger.Debug("TestCreateInvalidPropertiesSetTemplate...")
+	tbp.PrepareTestDatabase()
+	templateJSON := `
+	{
+		"data": {
+			"name": "House",
+			"propertyTemplates": [
+				{"name": "floors", "valueType": "x", "minValue": 5, "maxValue": 1}
+			]
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(templateJSON)
+	templatesURL := fmt.Sprintf("%s/%s/properties-set-templates", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", templatesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+		return
+	}
+	fields := validationFields(t, res)
+	if fields["propertyTemplates[0].valueType"] == "" || fields["propertyTemplates[0].minValue"] == "" {
+		t.Errorf("Fields: %v | Expected: valueType and minValue errors", fields)
+	}
+}
+
+func TestGetFromOrganization(t *testing.T) {
+	logger.Debug("TestGet...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	templateURL := fmt.Sprintf("%s/%s/properties-set-templates/%s", organizationsURL, organization1, template1)
+	request, _ := http.NewRequest("GET", templateURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetByName(t *testing.T) {
+	logger.Debug("TestGetByName...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	templateURL := fmt.Sprintf("%s/%s/properties-set-templates/%s", organizationsURL, organization1, template1Name)
+	request, _ := http.NewRequest("GET", templateURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestUpdatePropertiesSetTemplateWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdatePropertiesSetTemplateWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	newName := "Flat"
+	templateJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "%s",
+			"propertyTemplates": [
+				{"name": "rooms", "valueType": "i", "required": true, "minValue": 1}
+			]
+		}
+	}
+	`, template1, newName)
+	tbp.Reader = strings.NewReader(templateJSON)
+	templateURL := fmt.Sprintf("%s/%s/properties-set-templates/%s", organizationsURL, organization1, template1)
+	request, _ := http.NewRequest("PUT", templateURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		template, err := templateRepo.Get(template1)
+		if err == nil {
+			if template.Name.String == newName && len(template.PropertyTemplates) == 1 {
+				logger.Debug("PropertiesSetTemplate update: ok.")
+			} else {
+				error := fmt.Sprintf("Name: '%s' | Expected: '%s' - ", template.Name.String, newName)
+				error += fmt.Sprintf("PropertyTemplates: %d | Expected: 1", len(template.PropertyTemplates))
+				t.Error(error)
+			}
+		} else {
+			t.Error(err.Error())
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestUpdateSharedPropertiesSetTemplate(t *testing.T) {
+	logger.Debug("TestUpdateSharedPropertiesSetTemplate...")
+	tbp.PrepareTestDatabase()
+	templateJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "Land"
+		}
+	}
+	`, template2)
+	tbp.Reader = strings.NewReader(templateJSON)
+	templateURL := fmt.Sprintf("%s/%s/properties-set-templates/%s", organizationsURL, organization1, template2)
+	request, _ := http.NewRequest("PUT", templateURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Status: %d | Expected: 401-StatusUnauthorized", res.StatusCode)
+	}
+}
+
+func TestDeletePropertiesSetTemplateWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestDeletePropertiesSetTemplateWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	templateURL := fmt.Sprintf("%s/%s/properties-set-templates/%s", organizationsURL, organization1, template1)
+	request, _ := http.NewRequest("DELETE", templateURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		templateRepo, err := repo.MakePropertiesSetTemplateRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		template, err := templateRepo.Get(template1)
+		if err != nil {
+			logger.Debug("TestDeletePropertiesSetTemplate: ok")
+		} else {
+			t.Errorf("PropertiesSetTemplate: %s | Expected: 'nil'", template.Name)
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestCreateTemplatedProperty(t *testing.T) {
+	logger.Debug("TestCreateTemplatedProperty...")
+	tbp.PrepareTestDatabase()
+	propertyJSON := `
+	{
+		"data": {
+			"name": "rooms",
+			"intValue": 3
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertiesURL := fmt.Sprintf("%s/%s/properties", propertiesSetsURL, propertiesSet3)
+	request, _ := http.NewRequest("POST", propertiesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestCreateTemplatedPropertyOutOfRange(t *testing.T) {
+	logger.Debug("TestCreateTemplatedPropertyOutOfRange...")
+	tbp.PrepareTestDatabase()
+	propertyJSON := `
+	{
+		"data": {
+			"name": "rooms",
+			"valueType": "i",
+			"intValue": 50
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertiesURL := fmt.Sprintf("%s/%s/properties", propertiesSetsURL, propertiesSet3)
+	request, _ := http.NewRequest("POST", propertiesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+		return
+	}
+	fields := validationFields(t, res)
+	if fields["intValue"] == "" {
+		t.Errorf("Fields: %v | Expected: intValue error", fields)
+	}
+}
+
+func TestCreateTemplatedPropertyNotAllowedValue(t *testing.T) {
+	logger.Debug("TestCreateTemplatedPropertyNotAllowedValue...")
+	tbp.PrepareTestDatabase()
+	propertyJSON := `
+	{
+		"data": {
+			"name": "heating",
+			"valueType": "s",
+			"stringValue": "coal"
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertiesURL := fmt.Sprintf("%s/%s/properties", propertiesSetsURL, propertiesSet3)
+	request, _ := http.NewRequest("POST", propertiesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+		return
+	}
+	fields := validationFields(t, res)
+	if fields["stringValue"] == "" {
+		t.Errorf("Fields: %v | Expected: stringValue error", fields)
+	}
+}
+
+func TestCreateUndeclaredTemplatedProperty(t *testing.T) {
+	logger.Debug("TestCreateUndeclaredTemplatedProperty...")
+	tbp.PrepareTestDatabase()
+	propertyJSON := `
+	{
+		"data": {
+			"name": "pool",
+			"valueType": "b",
+			"booleanValue": true
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertiesURL := fmt.Sprintf("%s/%s/properties", propertiesSetsURL, propertiesSet3)
+	request, _ := http.NewRequest("POST", propertiesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+		return
+	}
+	fields := validationFields(t, res)
+	if fields["name"] == "" {
+		t.Errorf("Fields: %v | Expected: name error", fields)
+	}
+}
+
+func TestUpdateTemplatedPropertyOutOfRange(t *testing.T) {
+	logger.Debug("TestUpdateTemplatedPropertyOutOfRange...")
+	tbp.PrepareTestDatabase()
+	res := updateRoomsProperty(t, propertiesSet3, 50)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+	verifyRoomsProperty(t, 3)
+}
+
+func TestUpdateTemplatedPropertyThroughAnotherSet(t *testing.T) {
+	logger.Debug("TestUpdateTemplatedPropertyThroughAnotherSet...")
+	tbp.PrepareTestDatabase()
+	// PropertiesSet1 has no template, it must not be used to skip the one of PropertiesSet3
+	res := updateRoomsProperty(t, propertiesSet1, 50)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+	verifyRoomsProperty(t, 3)
+}
+
+func updateRoomsProperty(t *testing.T, propertiesSetID string, rooms int) *http.Response {
+	propertyJSON := fmt.Sprintf(`{"data": {"name": "rooms", "valueType": "i", "intValue": %d}}`, rooms)
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertyURL := fmt.Sprintf("%s/%s/properties/%s", propertiesSetsURL, propertiesSetID, roomsProperty)
+	request, _ := http.NewRequest("PUT", propertyURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return res
+}
+
+func verifyRoomsProperty(t *testing.T, expected int64) {
+	propertyRepo, err := repo.MakePropertyRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	property, err := propertyRepo.Get(roomsProperty)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if property.IntValue.Int64 != expected || property.PropertiesSetID.String != propertiesSet3 {
+		t.Errorf("Rooms: %d in %s | Expected: %d in %s", property.IntValue.Int64, property.PropertiesSetID.String, expected, propertiesSet3)
+	}
+}
+
+func validationFields(t *testing.T, res *http.Response) map[string]string {
+	var body struct {
+		Data struct {
+			Fields map[string]string `json:"fields"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	return body.Data.Fields
+}
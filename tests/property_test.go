@@ -24,6 +24,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -32,8 +33,10 @@ import (
 
 	"github.com/adrianpk/fundacja/bootstrap"
 	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
 	"github.com/adrianpk/fundacja/repo"
 	"github.com/adrianpk/fundacja/testbootstrap"
+	"github.com/adrianpk/fundacja/types"
 
 	_ "github.com/lib/pq"
 )
@@ -297,3 +300,199 @@ func TestDeletePropertyWithDatabaseVerify(t *testing.T) {
 		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
 	}
 }
+
+func TestUpdatePropertyPolygonValueWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdatePropertyPolygonValueWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	newValueType := "p"
+	propertyJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+		 	"name": "Plot",
+		 	"description": "Plot boundary.",
+		 	"polygonValue": {
+				"type": "Polygon",
+				"coordinates": [[[0, 0], [0, 0.001], [0.001, 0.001], [0.001, 0], [0, 0]]]
+			},
+		 	"valueType": "%s",
+		 	"position": 0,
+		 	"propertiesSetId": "%s"
+		}
+	}
+	`, property1, newValueType, propertiesSet1)
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertyURL := fmt.Sprintf("%s/%s/properties/%s", propertiesSetsURL, propertiesSet1, property1)
+	request, _ := http.NewRequest("PUT", propertyURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		propertyRepo, err := repo.MakePropertyRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		property, err := propertyRepo.Get(property1)
+		if err == nil {
+			polygon := property.PolygonValue.Polygon
+			if property.PolygonValue.Valid && polygon.IsValid() && polygon.Area() > 0 {
+				logger.Debug("Property polygon value update: ok.")
+			} else {
+				t.Errorf("Polygon value: '%s' | Expected: valid polygon", polygon.String())
+			}
+		} else {
+			t.Error(err.Error())
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestUpdatePropertyCompositeValuesWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdatePropertyCompositeValuesWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	cases := []struct {
+		valueType string
+		value     string
+		check     func(property models.Property) bool
+	}{
+		{"c", `"moneyValue": 1250.5, "currencyValue": "EUR"`, func(property models.Property) bool {
+			return property.MoneyValue.Float64 == 1250.5 && property.CurrencyValue.String == "EUR"
+		}},
+		{"r", `"dateRangeValue": {"from": "2017-06-01", "to": "2017-06-30"}`, func(property models.Property) bool {
+			dateRange := property.DateRangeValue.DateRange
+			return property.DateRangeValue.Valid && dateRange.String() == "[2017-06-01,2017-06-30]"
+		}},
+		{"a", `"listValue": ["pool", "garden"]`, func(property models.Property) bool {
+			return property.ListValue.Contains("pool") && property.ListValue.Contains("garden")
+		}},
+		{"x", fmt.Sprintf(`"referenceType": "user", "referenceID": "%s"`, user2), func(property models.Property) bool {
+			return property.ReferenceType.String == "user" && property.ReferenceID.String == user2
+		}},
+	}
+	for _, c := range cases {
+		propertyJSON := fmt.Sprintf(`
+		{
+			"data": {
+				"id": "%s",
+			 	"name": "%s",
+			 	%s,
+			 	"valueType": "%s",
+			 	"position": 0,
+			 	"propertiesSetId": "%s"
+			}
+		}
+		`, property1, property1Name, c.value, c.valueType, propertiesSet1)
+		tbp.Reader = strings.NewReader(propertyJSON)
+		propertyURL := fmt.Sprintf("%s/%s/properties/%s", propertiesSetsURL, propertiesSet1, property1)
+		request, _ := http.NewRequest("PUT", propertyURL, tbp.Reader)
+		tbp.AuthorizeRequest(request, user1, "admin", "admin")
+		res, err := http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatal(err)
+			t.Errorf("Error executing request: %s", err.Error())
+			return
+		}
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("Value type: '%s' | Status: %d | Expected: 204-StatusNoContent", c.valueType, res.StatusCode)
+			continue
+		}
+		propertyRepo, err := repo.MakePropertyRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		property, err := propertyRepo.Get(property1)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+		if !c.check(property) {
+			t.Errorf("Value type: '%s' | Value not persisted", c.valueType)
+		}
+	}
+}
+
+func TestUpdatePropertyMoneyValueWithoutCurrency(t *testing.T) {
+	logger.Debug("TestUpdatePropertyMoneyValueWithoutCurrency...")
+	tbp.PrepareTestDatabase()
+	propertyJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+		 	"name": "%s",
+		 	"moneyValue": 100,
+		 	"valueType": "c",
+		 	"position": 0,
+		 	"propertiesSetId": "%s"
+		}
+	}
+	`, property1, property1Name, propertiesSet1)
+	tbp.Reader = strings.NewReader(propertyJSON)
+	propertyURL := fmt.Sprintf("%s/%s/properties/%s", propertiesSetsURL, propertiesSet1, property1)
+	request, _ := http.NewRequest("PUT", propertyURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetPropertiesFilteredByListItem(t *testing.T) {
+	logger.Debug("TestGetPropertiesFilteredByListItem...")
+	tbp.PrepareTestDatabase()
+	propertyRepo, err := repo.MakePropertyRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	property, err := propertyRepo.Get(property1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	property.ValueType = models.ToNullsString("a")
+	property.ListValue = types.JSONList{"pool", "garden"}
+	err = propertyRepo.Update(&property)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	tbp.Reader = strings.NewReader("")
+	propertiesURL := fmt.Sprintf("%s/%s/properties?valueType=a&contains=pool", propertiesSetsURL, propertiesSet1)
+	request, _ := http.NewRequest("GET", propertiesURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != property1 {
+		t.Errorf("Properties: %v | Expected: '%s'", body.Data, property1)
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/services"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	role1            = "9b6869e4-f51a-4197-9608-f2898bd764d8"
+	viewerRole       = "2b4d6f8a-0c2e-4a6c-8e0a-2c4e6a8c0e03"
+	editorRole       = "4d6f8a0c-2e4a-4c8e-8a0c-2e4a6c8e0a04"
+	permission3      = "3f5a7c9e-1b3d-4f5a-8c9e-1b3d5f7a9c03"
+	listing1         = "7b1f3e57-3b41-4a8e-9f2a-6f0d2c1a9e11"
+	invoice1         = "9c3e5a7b-9d1f-4b3c-8e5a-7b9d1f3c5e01"
+	resource2Tag     = "394e9457"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func rbacRequest(t *testing.T, url, userID, username string) *http.Response {
+	return rbacMethodRequest(t, "GET", url, userID, username, "")
+}
+
+func rbacMethodRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+	}
+	return res
+}
+
+// verifyForbidden - Checks a user unrelated to organization2 is kept out of one of its routes.
+func verifyForbidden(t *testing.T, method, path, data string) {
+	url := fmt.Sprintf("%s/%s/%s", organizationsURL, organization2, path)
+	res := rbacMethodRequest(t, method, url, user1, "admin", data)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("%s %s status: %d | Expected: 403-StatusForbidden", method, path, res.StatusCode)
+	}
+}
+
+func grantRole(t *testing.T, userID, roleID, orgID string) {
+	userRoleRepo, err := repo.MakeUserRoleRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	userRole := models.UserRole{
+		OrganizationID: models.ToNullsString(orgID),
+		UserID:         models.ToNullsString(userID),
+		RoleID:         models.ToNullsString(roleID),
+	}
+	err = userRoleRepo.Create(&userRole)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestOrganizationOwnerIsAllowed(t *testing.T) {
+	logger.Debug("TestOrganizationOwnerIsAllowed...")
+	tbp.PrepareTestDatabase()
+	res := rbacRequest(t, fmt.Sprintf("%s/%s/roles", organizationsURL, organization1), user1, "admin")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestUserWithoutPermissionIsForbidden(t *testing.T) {
+	logger.Debug("TestUserWithoutPermissionIsForbidden...")
+	tbp.PrepareTestDatabase()
+	res := rbacRequest(t, fmt.Sprintf("%s/%s/listings", organizationsURL, organization1), user2, "user")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			Error  string `json:"error"`
+			Status int    `json:"status"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if body.Data.Error != "Forbidden" || body.Data.Status != http.StatusForbidden {
+		t.Errorf("Error: %v | Expected: 'Forbidden', 403", body.Data)
+	}
+}
+
+func TestUserWithRolePermissionIsAllowed(t *testing.T) {
+	logger.Debug("TestUserWithRolePermissionIsAllowed...")
+	tbp.PrepareTestDatabase()
+	grantRole(t, user2, role1, organization1)
+	res := rbacRequest(t, fmt.Sprintf("%s/%s/listings", organizationsURL, organization1), user2, "user")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestPermissionIsScopedToOrganization(t *testing.T) {
+	logger.Debug("TestPermissionIsScopedToOrganization...")
+	tbp.PrepareTestDatabase()
+	permissionRepo, err := repo.MakePermissionRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	can, err := permissionRepo.Can(user2, models.ActionRead, resource2Tag, organization2)
+	if err != nil || !can {
+		t.Errorf("Can: %t, Error: %v | Expected: permission in '%s'", can, err, organization2)
+	}
+	allowed, err := services.Can(user2, models.ActionRead, resource2Tag, organization1)
+	if err != nil || allowed {
+		t.Errorf("Allowed: %t, Error: %v | Expected: not allowed in '%s'", allowed, err, organization1)
+	}
+}
+
+func TestUserFromAnotherOrganizationIsForbidden(t *testing.T) {
+	logger.Debug("TestUserFromAnotherOrganizationIsForbidden...")
+	tbp.PrepareTestDatabase()
+	res := rbacRequest(t, fmt.Sprintf("%s/%s/roles", organizationsURL, organization2), user1, "admin")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestNonPartyIsForbiddenFromLedger(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromLedger...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "ledger/accounts", "")
+}
+
+func TestNonPartyIsForbiddenFromBank(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromBank...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "bank/statements", "")
+}
+
+func TestNonPartyIsForbiddenFromLeases(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromLeases...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "leases", "")
+}
+
+func TestNonPartyIsForbiddenFromMaintenance(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromMaintenance...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "maintenance/requests", "")
+}
+
+func TestNonPartyIsForbiddenFromCRM(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromCRM...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "crm/leads", "")
+}
+
+func TestNonPartyIsForbiddenFromGallery(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromGallery...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "POST", "albums", `{"data": {"name": "Photos", "ownerType": "organization"}}`)
+}
+
+func TestNonPartyIsForbiddenFromDocuments(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromDocuments...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "documents", "")
+}
+
+func TestNonPartyIsForbiddenFromAppointments(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromAppointments...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "appointments/viewings", "")
+}
+
+func TestNonPartyIsForbiddenFromPropertiesSetTemplates(t *testing.T) {
+	logger.Debug("TestNonPartyIsForbiddenFromPropertiesSetTemplates...")
+	tbp.PrepareTestDatabase()
+	verifyForbidden(t, "GET", "properties-set-templates", "")
+}
+
+func TestPartyIsAllowedThroughPartyRoutes(t *testing.T) {
+	logger.Debug("TestPartyIsAllowedThroughPartyRoutes...")
+	tbp.PrepareTestDatabase()
+	// Tenants reach maintenance requests, handlers narrow them to their own
+	res := rbacRequest(t, fmt.Sprintf("%s/%s/maintenance/requests", organizationsURL, organization1), user2, "user")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+	// But not bank statements
+	res = rbacRequest(t, fmt.Sprintf("%s/%s/bank/statements", organizationsURL, organization1), user2, "user")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestUserWithBankPermissionIsAllowed(t *testing.T) {
+	logger.Debug("TestUserWithBankPermissionIsAllowed...")
+	tbp.PrepareTestDatabase()
+	grantRole(t, user2, role1, organization1)
+	res := rbacRequest(t, fmt.Sprintf("%s/%s/bank/statements", organizationsURL, organization1), user2, "user")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestReadOnlyPermission(t *testing.T) {
+	logger.Debug("TestReadOnlyPermission...")
+	tbp.PrepareTestDatabase()
+	grantRole(t, user2, viewerRole, organization1)
+	listingsURL := fmt.Sprintf("%s/%s/listings", organizationsURL, organization1)
+	res := rbacRequest(t, listingsURL, user2, "user")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Read status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+	res = rbacMethodRequest(t, "POST", listingsURL, user2, "user", `{"data": {"name": "Listing4", "operation": "sale"}}`)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Create status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+	res = rbacMethodRequest(t, "DELETE", fmt.Sprintf("%s/%s", listingsURL, listing1), user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Delete status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestReadOnlyLedgerPermission(t *testing.T) {
+	logger.Debug("TestReadOnlyLedgerPermission...")
+	tbp.PrepareTestDatabase()
+	grantRole(t, user2, viewerRole, organization1)
+	ledgerURL := fmt.Sprintf("%s/%s/ledger", organizationsURL, organization1)
+	for _, path := range []string{"/accounts", "/invoices", "/invoices/" + invoice1} {
+		res := rbacRequest(t, ledgerURL+path, user2, "user")
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("Read %s status: %d | Expected: 200-StatusOk", path, res.StatusCode)
+		}
+	}
+	res := rbacMethodRequest(t, "POST", ledgerURL+"/invoices/"+invoice1+"/payments", user2, "user", `{"data": {"amount": 100}}`)
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Pay status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestManagePermissionGrantsAnyAction(t *testing.T) {
+	logger.Debug("TestManagePermissionGrantsAnyAction...")
+	tbp.PrepareTestDatabase()
+	grantRole(t, user2, role1, organization1)
+	for _, action := range models.Actions {
+		can, err := services.Can(user2, action, models.ListingResourceTag, organization1)
+		if err != nil || !can {
+			t.Errorf("Action: %s, Can: %t, Error: %v | Expected: allowed", action, can, err)
+		}
+	}
+}
+
+func TestInheritedPermission(t *testing.T) {
+	logger.Debug("TestInheritedPermission...")
+	tbp.PrepareTestDatabase()
+	grantRole(t, user2, editorRole, organization1)
+	listingsURL := fmt.Sprintf("%s/%s/listings", organizationsURL, organization1)
+	res := rbacRequest(t, listingsURL, user2, "user")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Read status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+	res = rbacMethodRequest(t, "DELETE", fmt.Sprintf("%s/%s", listingsURL, listing1), user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Delete status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+	permissionRepo, err := repo.MakePermissionRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ids, err := permissionRepo.GetUserPermissionIDs(user2)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	found := false
+	for _, id := range ids {
+		found = found || id == permission3
+	}
+	if !found {
+		t.Errorf("Permissions: %v | Expected: inherited '%s'", ids, permission3)
+	}
+}
+
+func TestMethodAction(t *testing.T) {
+	logger.Debug("TestMethodAction...")
+	expected := map[string]string{
+		"GET":    models.ActionRead,
+		"POST":   models.ActionCreate,
+		"PUT":    models.ActionUpdate,
+		"PATCH":  models.ActionUpdate,
+		"DELETE": models.ActionDelete,
+		"TRACE":  models.ActionManage,
+	}
+	for method, action := range expected {
+		if services.MethodAction(method) != action {
+			t.Errorf("Method: %s, Action: %s | Expected: %s", method, services.MethodAction(method), action)
+		}
+	}
+}
@@ -24,6 +24,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -263,3 +264,81 @@ func TestDeleteResourcePermissionWithDatabaseVerify(t *testing.T) {
 		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
 	}
 }
+
+func TestCreateResourcePermissionWithAction(t *testing.T) {
+	logger.Debug("TestCreateResourcePermissionWithAction...")
+	tbp.PrepareTestDatabase()
+	resourcePermissionJSON := fmt.Sprintf(`
+	{
+		"data": {
+				"organizationID": "%s",
+				"resourceID": "%s",
+				"permissionID": "%s",
+				"action": "read"
+		}
+	}
+	`, organization1, resource1, permission2)
+	tbp.Reader = strings.NewReader(resourcePermissionJSON)
+	resourcePermissionsOrgURL := fmt.Sprintf("%s/%s/resource-permissions", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", resourcePermissionsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	resourcePermRepo, err := repo.MakeResourcePermissionRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	resourcePerm, err := resourcePermRepo.Get(body.Data.ID)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if resourcePerm.Action.String != "read" {
+		t.Errorf("Action: '%s' | Expected: 'read'", resourcePerm.Action.String)
+	}
+}
+
+func TestCreateResourcePermissionWithInvalidAction(t *testing.T) {
+	logger.Debug("TestCreateResourcePermissionWithInvalidAction...")
+	tbp.PrepareTestDatabase()
+	resourcePermissionJSON := fmt.Sprintf(`
+	{
+		"data": {
+				"organizationID": "%s",
+				"resourceID": "%s",
+				"permissionID": "%s",
+				"action": "publish"
+		}
+	}
+	`, organization1, resource1, permission2)
+	tbp.Reader = strings.NewReader(resourcePermissionJSON)
+	resourcePermissionsOrgURL := fmt.Sprintf("%s/%s/resource-permissions", organizationsURL, organization1)
+	request, _ := http.NewRequest("POST", resourcePermissionsOrgURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
@@ -24,6 +24,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -48,6 +49,9 @@ var (
 	role1            = "9b6869e4-f51a-4197-9608-f2898bd764d8"
 	role2            = "1a40baee-e968-4fb0-8cc9-ecb62e2f2a76"
 	role1Name        = "Role1"
+	viewerRole       = "2b4d6f8a-0c2e-4a6c-8e0a-2c4e6a8c0e03"
+	editorRole       = "4d6f8a0c-2e4a-4c8e-8a0c-2e4a6c8e0a04"
+	permission3      = "3f5a7c9e-1b3d-4f5a-8c9e-1b3d5f7a9c03"
 )
 
 func init() {
@@ -275,3 +279,129 @@ func TestDeleteRoleWithDatabaseVerify(t *testing.T) {
 		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
 	}
 }
+
+func roleRequest(t *testing.T, method, url, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+	}
+	return res
+}
+
+func roleParentJSON(parentID string) string {
+	return fmt.Sprintf(`{"data": {"parentID": "%s"}}`, parentID)
+}
+
+func TestGetRoleParents(t *testing.T) {
+	logger.Debug("TestGetRoleParents...")
+	tbp.PrepareTestDatabase()
+	res := roleRequest(t, "GET", fmt.Sprintf("%s/%s/roles/%s/parents", organizationsURL, organization1, editorRole), "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != viewerRole {
+		t.Errorf("Parents: %v | Expected: '%s'", body.Data, viewerRole)
+	}
+}
+
+func TestGetRoleEffectivePermissions(t *testing.T) {
+	logger.Debug("TestGetRoleEffectivePermissions...")
+	tbp.PrepareTestDatabase()
+	res := roleRequest(t, "GET", fmt.Sprintf("%s/%s/roles/%s/permissions", organizationsURL, organization1, editorRole), "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) != 1 || body.Data[0].ID != permission3 {
+		t.Errorf("Permissions: %v | Expected: inherited '%s'", body.Data, permission3)
+	}
+}
+
+func TestAddRoleParent(t *testing.T) {
+	logger.Debug("TestAddRoleParent...")
+	tbp.PrepareTestDatabase()
+	parentsURL := fmt.Sprintf("%s/%s/roles/%s/parents", organizationsURL, organization1, role1)
+	res := roleRequest(t, "POST", parentsURL, roleParentJSON(editorRole))
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	roleRepo, err := repo.MakeRoleRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+	permissions, err := roleRepo.GetEffectivePermissions(role1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	found := false
+	for _, permission := range permissions {
+		found = found || permission.ID.String == permission3
+	}
+	if !found {
+		t.Errorf("Permissions: %d | Expected: '%s' inherited through '%s'", len(permissions), permission3, editorRole)
+	}
+}
+
+func TestAddRoleParentCycle(t *testing.T) {
+	logger.Debug("TestAddRoleParentCycle...")
+	tbp.PrepareTestDatabase()
+	res := roleRequest(t, "POST", fmt.Sprintf("%s/%s/roles/%s/parents", organizationsURL, organization1, viewerRole), roleParentJSON(editorRole))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+	res = roleRequest(t, "POST", fmt.Sprintf("%s/%s/roles/%s/parents", organizationsURL, organization1, viewerRole), roleParentJSON(viewerRole))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Self status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestAddRoleParentFromAnotherOrganization(t *testing.T) {
+	logger.Debug("TestAddRoleParentFromAnotherOrganization...")
+	tbp.PrepareTestDatabase()
+	res := roleRequest(t, "POST", fmt.Sprintf("%s/%s/roles/%s/parents", organizationsURL, organization1, role1), roleParentJSON(role2))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestDeleteRoleParent(t *testing.T) {
+	logger.Debug("TestDeleteRoleParent...")
+	tbp.PrepareTestDatabase()
+	parentURL := fmt.Sprintf("%s/%s/roles/%s/parents/%s", organizationsURL, organization1, editorRole, viewerRole)
+	res := roleRequest(t, "DELETE", parentURL, "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	res = roleRequest(t, "DELETE", parentURL, "")
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+}
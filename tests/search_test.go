@@ -0,0 +1,239 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/models"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	searchURL        string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	searchURL = fmt.Sprintf("%s/search", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func setOrganizationSearchLanguage(t *testing.T, language string) {
+	organizationRepo, err := repo.MakeOrganizationRepository()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	organization, err := organizationRepo.Get(organization1)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	organization.SearchLanguage = models.ToNullsString(language)
+	err = organizationRepo.Update(&organization)
+	if err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func createSearchListing(t *testing.T, userID, orgID, name, description string) {
+	listingJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"name": "%s",
+			"description": "%s",
+			"price": 99000,
+			"currency": "EUR",
+			"operation": "sale",
+			"organizationID": "%s"
+		}
+	}
+	`, name, description, orgID)
+	tbp.Reader = strings.NewReader(listingJSON)
+	listingsURL := fmt.Sprintf("%s/%s/listings", organizationsURL, orgID)
+	request, _ := http.NewRequest("POST", listingsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+		return
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestSearchListingWithStemming(t *testing.T) {
+	logger.Debug("TestSearchListingWithStemming...")
+	tbp.PrepareTestDatabase()
+	setOrganizationSearchLanguage(t, "english")
+	createSearchListing(t, user1, organization1, "Riverside loft", "Bright renovated apartments by the river.")
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s?q=apartment&types=listing&organization=%s", searchURL, organization1)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			Type    string `json:"type"`
+			Title   string `json:"title"`
+			Snippet string `json:"snippet"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(body.Data) == 0 || body.Data[0].Type != "listing" || body.Data[0].Title != "Riverside loft" {
+		t.Errorf("Results: %v | Expected: 'Riverside loft' listing", body.Data)
+		return
+	}
+	if !strings.Contains(body.Data[0].Snippet, "<em>apartments</em>") {
+		t.Errorf("Snippet: '%s' | Expected: highlighted 'apartments'", body.Data[0].Snippet)
+	}
+}
+
+func TestSearchWithoutQuery(t *testing.T) {
+	logger.Debug("TestSearchWithoutQuery...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", searchURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestSearchWithInvalidType(t *testing.T) {
+	logger.Debug("TestSearchWithInvalidType...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	url := fmt.Sprintf("%s?q=listing&types=listing,invoice", searchURL)
+	request, _ := http.NewRequest("GET", url, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func searchIDs(t *testing.T, userID, text string) []string {
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", fmt.Sprintf("%s?q=%s", searchURL, text), tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOK", res.StatusCode)
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	ids := []string{}
+	for _, result := range body.Data {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+func TestSearchHidesDraftListingsOfOtherOrganizations(t *testing.T) {
+	logger.Debug("TestSearchHidesDraftListingsOfOtherOrganizations...")
+	tbp.PrepareTestDatabase()
+	// Listings are drafts until published
+	createSearchListing(t, user2, organization2, "Zanzibar penthouse", "Unpublished penthouse.")
+	if ids := searchIDs(t, user2, "zanzibar"); len(ids) == 0 {
+		t.Errorf("Results: %v | Expected: draft visible to its organization", ids)
+	}
+	if ids := searchIDs(t, user1, "zanzibar"); len(ids) != 0 {
+		t.Errorf("Results: %v | Expected: draft hidden from other organizations", ids)
+	}
+}
+
+func TestSearchHidesResourcesOfOtherOrganizations(t *testing.T) {
+	logger.Debug("TestSearchHidesResourcesOfOtherOrganizations...")
+	tbp.PrepareTestDatabase()
+	_, err := testbootstrap.TestBootstrap.DBInstance.Exec("INSERT INTO resources (id, name, description, tag, organization_id, is_active, is_logical_deleted, created_at, updated_at) VALUES ('6c8e0a2c-4e6a-4c0e-8a2c-4e6a8c0e2a07', 'Kilimanjaro', 'Kilimanjaro reports', '4e2a07aa', $1, true, false, now(), now())", organization2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ids := searchIDs(t, user2, "kilimanjaro"); len(ids) == 0 {
+		t.Errorf("Results: %v | Expected: resource visible to its organization", ids)
+	}
+	if ids := searchIDs(t, user1, "kilimanjaro"); len(ids) != 0 {
+		t.Errorf("Results: %v | Expected: resource hidden from other organizations", ids)
+	}
+}
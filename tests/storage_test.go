@@ -0,0 +1,203 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/storage"
+	"github.com/adrianpk/fundacja/testbootstrap"
+)
+
+var (
+	tbp = testbootstrap.TestBootstrap
+)
+
+func init() {
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+// S3 compatible store tests run against the service set in these variables, e.g. a local MinIO:
+// FUNDACJA_TEST_S3_ENDPOINT=http://127.0.0.1:9000 FUNDACJA_TEST_S3_BUCKET=fundacja-test
+// FUNDACJA_TEST_S3_ACCESS_KEY=minioadmin FUNDACJA_TEST_S3_SECRET_KEY=minioadmin
+func s3TestConfig(t *testing.T) storage.Config {
+	endpoint := os.Getenv("FUNDACJA_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("FUNDACJA_TEST_S3_ENDPOINT not set")
+	}
+	return storage.Config{
+		Driver:    storage.S3Driver,
+		Endpoint:  endpoint,
+		Bucket:    os.Getenv("FUNDACJA_TEST_S3_BUCKET"),
+		AccessKey: os.Getenv("FUNDACJA_TEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("FUNDACJA_TEST_S3_SECRET_KEY"),
+		PathStyle: true,
+	}
+}
+
+func localTestStore(t *testing.T) *storage.LocalStore {
+	root, err := ioutil.TempDir("", "fundacja-blobs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.MakeLocalStore(storage.Config{Root: root, URLBase: "/blobs", SigningKey: []byte("secret")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func verifyRoundTrip(t *testing.T, store storage.BlobStore) {
+	key := "tests/" + time.Now().Format("20060102150405.000") + "/sample file.txt"
+	content := "Sample blob content."
+	size, err := store.Put(key, strings.NewReader(content))
+	if err != nil || size != int64(len(content)) {
+		t.Errorf("Size: %d, error: %v | Expected: %d", size, err, len(content))
+		return
+	}
+	blob, err := store.Get(key)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	read, _ := ioutil.ReadAll(blob)
+	blob.Close()
+	if string(read) != content {
+		t.Errorf("Content: '%s' | Expected: '%s'", read, content)
+	}
+	err = store.Delete(key)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	_, err = store.Get(key)
+	if err != storage.ErrBlobNotFound {
+		t.Errorf("Error: %v | Expected: '%s'", err, storage.ErrBlobNotFound)
+	}
+	// Missing keys
+	err = store.Delete(key)
+	if err != nil {
+		t.Errorf("Error: %s | Expected: no error deleting missing key", err.Error())
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	logger.Debug("TestLocalStore...")
+	store := localTestStore(t)
+	defer os.RemoveAll(store.Root)
+	verifyRoundTrip(t, store)
+	for _, key := range []string{"", "/", "../outside", "a/../../outside"} {
+		_, err := store.Put(key, strings.NewReader("x"))
+		if err != storage.ErrInvalidKey {
+			t.Errorf("Key: '%s', error: %v | Expected: '%s'", key, err, storage.ErrInvalidKey)
+		}
+	}
+}
+
+func TestLocalStoreSignedURL(t *testing.T) {
+	logger.Debug("TestLocalStoreSignedURL...")
+	store := localTestStore(t)
+	defer os.RemoveAll(store.Root)
+	key := "profiles/1/avatar_1.png"
+	signed, err := store.SignedURL(key, time.Minute)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	u, _ := url.Parse(signed)
+	query := u.Query()
+	if u.Path != "/blobs/"+key {
+		t.Errorf("Path: '%s' | Expected: '/blobs/%s'", u.Path, key)
+	}
+	err = store.VerifySignature(key, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		t.Error(err.Error())
+	}
+	err = store.VerifySignature("profiles/2/avatar_1.png", query.Get("expires"), query.Get("signature"))
+	if err != storage.ErrURLSignature {
+		t.Errorf("Error: %v | Expected: '%s'", err, storage.ErrURLSignature)
+	}
+	// Expired
+	signed, _ = store.SignedURL(key, -time.Minute)
+	u, _ = url.Parse(signed)
+	err = store.VerifySignature(key, u.Query().Get("expires"), u.Query().Get("signature"))
+	if err != storage.ErrURLExpired {
+		t.Errorf("Error: %v | Expected: '%s'", err, storage.ErrURLExpired)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	logger.Debug("TestS3Store...")
+	store, err := storage.MakeS3Store(s3TestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyRoundTrip(t, store)
+}
+
+func TestS3StoreSignedURL(t *testing.T) {
+	logger.Debug("TestS3StoreSignedURL...")
+	store, err := storage.MakeS3Store(s3TestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "tests/signed.txt"
+	_, err = store.Put(key, strings.NewReader("Signed content."))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer store.Delete(key)
+	signed, err := store.SignedURL(key, time.Minute)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	res, err := http.Get(signed)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	defer res.Body.Close()
+	read, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(read) != "Signed content." {
+		t.Errorf("Status: %d, content: '%s' | Expected: 200-StatusOK 'Signed content.'", res.StatusCode, read)
+	}
+	if !strings.HasPrefix(res.Header.Get("Cache-Control"), "private, max-age=") {
+		t.Errorf("Cache-Control: '%s' | Expected: 'private, max-age=...'", res.Header.Get("Cache-Control"))
+	}
+}
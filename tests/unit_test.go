@@ -0,0 +1,275 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/repo"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	building1        = "3e5a7c9b-1d2f-4a6b-8c0d-4e6f8a0b2c01"
+	unit1            = "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f01"
+	unit1Name        = "Flat1"
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func unitsURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/buildings/%s/units", organizationsURL, orgid, building1)
+}
+
+func TestGetAllFromBuilding(t *testing.T) {
+	logger.Debug("TestGetAllFromBuilding...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", unitsURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestGetAllFromBuildingInAnotherOrganization(t *testing.T) {
+	logger.Debug("TestGetAllFromBuildingInAnotherOrganization...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	request, _ := http.NewRequest("GET", unitsURL(organization2), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestCreateUnit(t *testing.T) {
+	logger.Debug("TestCreateUnit...")
+	tbp.PrepareTestDatabase()
+	unitJSON := `
+	{
+		"data": {
+			"name": "Storage1",
+			"description": "Storage1 description.",
+			"kind": "storage",
+			"floor": -1,
+			"area": 4.5,
+			"share": 0.02
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(unitJSON)
+	request, _ := http.NewRequest("POST", unitsURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestCreateUnitWithInvalidShare(t *testing.T) {
+	logger.Debug("TestCreateUnitWithInvalidShare...")
+	tbp.PrepareTestDatabase()
+	unitJSON := `
+	{
+		"data": {
+			"name": "Storage1",
+			"kind": "storage",
+			"share": 1.5
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(unitJSON)
+	request, _ := http.NewRequest("POST", unitsURL(organization1), tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestGetByName(t *testing.T) {
+	logger.Debug("TestGetByName...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	unitURL := fmt.Sprintf("%s/%s", unitsURL(organization1), unit1Name)
+	request, _ := http.NewRequest("GET", unitURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestUpdateUnitWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestUpdateUnitWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	newStatus := "vacant"
+	newArea := 62.5
+	unitJSON := fmt.Sprintf(`
+	{
+		"data": {
+			"id": "%s",
+			"name": "%s",
+			"area": %f,
+			"share": 0.4,
+			"floor": 1,
+			"status": "%s"
+		}
+	}
+	`, unit1, unit1Name, newArea, newStatus)
+	tbp.Reader = strings.NewReader(unitJSON)
+	unitURL := fmt.Sprintf("%s/%s", unitsURL(organization1), unit1)
+	request, _ := http.NewRequest("PUT", unitURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		unitRepo, err := repo.MakeUnitRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		unit, err := unitRepo.Get(unit1)
+		if err == nil {
+			if unit.Status.String == newStatus && unit.Area.Float64 == newArea && unit.Kind.String == "flat" {
+				logger.Debug("Unit update: ok.")
+			} else {
+				error := fmt.Sprintf("Status: '%s' | Expected: '%s' - ", unit.Status.String, newStatus)
+				error += fmt.Sprintf("Area: '%f' | Expected: '%f' - ", unit.Area.Float64, newArea)
+				error += fmt.Sprintf("Kind: '%s' | Expected: 'flat'", unit.Kind.String)
+				t.Error(error)
+			}
+		} else {
+			t.Error(err.Error())
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestDeleteUnitWithDatabaseVerify(t *testing.T) {
+	logger.Debug("TestDeleteUnitWithDatabaseVerify...")
+	tbp.PrepareTestDatabase()
+	tbp.Reader = strings.NewReader("")
+	unitURL := fmt.Sprintf("%s/%s", unitsURL(organization1), unit1)
+	request, _ := http.NewRequest("DELETE", unitURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Errorf("Error executing request: %s", err.Error())
+		return
+	}
+	if res.StatusCode == http.StatusNoContent {
+		unitRepo, err := repo.MakeUnitRepository()
+		if err != nil {
+			log.Fatal(err)
+			return
+		}
+		unit, err := unitRepo.Get(unit1)
+		if err != nil {
+			logger.Debug("TestDeleteUnit: ok")
+		} else {
+			t.Errorf("Unit: %s | Expected: 'nil'", unit.Name.String)
+		}
+	} else {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+	}
+}
+
+func TestCreateUnitPropertiesSet(t *testing.T) {
+	logger.Debug("TestCreateUnitPropertiesSet...")
+	tbp.PrepareTestDatabase()
+	propertiesSetJSON := `
+	{
+		"data": {
+			"name": "Equipment",
+			"description": "Unit equipment."
+		}
+	}
+	`
+	tbp.Reader = strings.NewReader(propertiesSetJSON)
+	propertiesSetsURL := fmt.Sprintf("%s/%s/properties-sets", unitsURL(organization1), unit1)
+	request, _ := http.NewRequest("POST", propertiesSetsURL, tbp.Reader)
+	tbp.AuthorizeRequest(request, user1, "admin", "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/fundacja/bootstrap"
+	"github.com/adrianpk/fundacja/logger"
+	"github.com/adrianpk/fundacja/testbootstrap"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	tbp              = testbootstrap.TestBootstrap
+	user1            = "5958b185-8150-4aae-b53f-0c44771ddec5"
+	user2            = "3c05e701-b495-4443-b454-2c37e2ecccdf"
+	organizationsURL string
+	organization1    = "d43809a2-5896-43c4-808e-549f2ee47783"
+	organization2    = "b8cef4be-1ec3-44b4-9cbd-551f039f4fc7"
+	listing1         = "7b1f3e57-3b41-4a8e-9f2a-6f0d2c1a9e11"
+	listing2         = "2e4c8d90-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+	listing3         = "9c2d4e6f-8a0b-4c1d-9e2f-3a4b5c6d7e03"
+	viewing1         = "6b8d0f2b-4d6f-4b8d-8f2b-4d6f8b0d2a01"
+	windowStart      = time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+)
+
+func init() {
+	organizationsURL = fmt.Sprintf("%s/organizations", tbp.APIServerURL)
+	bootstrap.SetBootParameters(testbootstrap.BootParameters())
+	bootstrap.Boot()
+}
+
+func TestMain(m *testing.M) {
+	tbp.Start(m)
+}
+
+func appointmentsURL(orgid string) string {
+	return fmt.Sprintf("%s/%s/appointments", organizationsURL, orgid)
+}
+
+func appointmentRequest(t *testing.T, method, url, userID, username, data string) *http.Response {
+	tbp.Reader = strings.NewReader(data)
+	request, _ := http.NewRequest(method, url, tbp.Reader)
+	tbp.AuthorizeRequest(request, userID, username, "admin")
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	return res
+}
+
+func bookViewing(t *testing.T, listingID string, startsAt time.Time) *http.Response {
+	viewingJSON := fmt.Sprintf(`{"data": {"listingID": "%s", "startsAt": %d, "prospectPhone": "+48 600 000 000"}}`, listingID, startsAt.Unix())
+	return appointmentRequest(t, "POST", appointmentsURL(organization1)+"/viewings", user2, "user", viewingJSON)
+}
+
+func TestGetViewingSlots(t *testing.T) {
+	logger.Debug("TestGetViewingSlots...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/slots?listing=%s&from=%d&to=%d", appointmentsURL(organization1), listing1, windowStart.Unix(), windowStart.Add(4*time.Hour).Unix())
+	res := appointmentRequest(t, "GET", url, user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data []struct {
+			AgentID  string `json:"agentID"`
+			StartsAt int64  `json:"startsAt"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	// Six half hour slots, first one already booked
+	if len(body.Data) != 5 {
+		t.Errorf("Slots: %d | Expected: 5", len(body.Data))
+		return
+	}
+	if body.Data[0].AgentID != user1 || body.Data[0].StartsAt != windowStart.Add(30*time.Minute).Unix() {
+		t.Errorf("Slot: %s at %d | Expected: %s at %d", body.Data[0].AgentID, body.Data[0].StartsAt, user1, windowStart.Add(30*time.Minute).Unix())
+	}
+}
+
+func TestGetDraftListingViewingSlots(t *testing.T) {
+	logger.Debug("TestGetDraftListingViewingSlots...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/slots?listing=%s&from=%d&to=%d", appointmentsURL(organization2), listing2, windowStart.Unix(), windowStart.Add(4*time.Hour).Unix())
+	// Not taking part in the organization
+	res := appointmentRequest(t, "GET", url, user1, "admin", "")
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Status: %d | Expected: 404-StatusNotFound", res.StatusCode)
+	}
+	// Organization owner
+	res = appointmentRequest(t, "GET", url, user2, "user", "")
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+	}
+}
+
+func TestBookDraftListingViewing(t *testing.T) {
+	logger.Debug("TestBookDraftListingViewing...")
+	tbp.PrepareTestDatabase()
+	viewingJSON := fmt.Sprintf(`{"data": {"listingID": "%s", "startsAt": %d, "prospectPhone": "+48 600 000 000"}}`, listing2, windowStart.Unix())
+	url := appointmentsURL(organization2) + "/viewings"
+	// Not taking part in the organization, listing is not disclosed
+	res := appointmentRequest(t, "POST", url, user1, "admin", viewingJSON)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+	// Organization owner
+	res = appointmentRequest(t, "POST", url, user2, "user", viewingJSON)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+}
+
+func TestBookViewingRejectsOverlaps(t *testing.T) {
+	logger.Debug("TestBookViewingRejectsOverlaps...")
+	tbp.PrepareTestDatabase()
+	// Booked slot
+	res := bookViewing(t, listing1, windowStart)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	// Free slot
+	res = bookViewing(t, listing1, windowStart.Add(time.Hour))
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+		return
+	}
+	var body struct {
+		Data struct {
+			AgentID string `json:"agentID"`
+			EndsAt  int64  `json:"endsAt"`
+		} `json:"data"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if body.Data.AgentID != user1 || body.Data.EndsAt != windowStart.Add(90*time.Minute).Unix() {
+		t.Errorf("Agent: %s, ends at: %d | Expected: %s, %d", body.Data.AgentID, body.Data.EndsAt, user1, windowStart.Add(90*time.Minute).Unix())
+	}
+	// Agent is busy at that time for other listings too
+	res = bookViewing(t, listing3, windowStart.Add(75*time.Minute))
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	// No agent available
+	res = bookViewing(t, listing1, windowStart.Add(-time.Hour))
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	// Listing from another organization
+	res = bookViewing(t, listing2, windowStart.Add(2*time.Hour))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status: %d | Expected: 400-StatusBadRequest", res.StatusCode)
+	}
+}
+
+func TestCreateAvailabilityWindow(t *testing.T) {
+	logger.Debug("TestCreateAvailabilityWindow...")
+	tbp.PrepareTestDatabase()
+	windowJSON := `{"data": {"startsAt": %d, "endsAt": %d, "slotMinutes": 45}}`
+	url := appointmentsURL(organization1) + "/availability"
+	// Overlapping window
+	res := appointmentRequest(t, "POST", url, user1, "admin", fmt.Sprintf(windowJSON, windowStart.Add(2*time.Hour).Unix(), windowStart.Add(4*time.Hour).Unix()))
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	res = appointmentRequest(t, "POST", url, user1, "admin", fmt.Sprintf(windowJSON, windowStart.Add(24*time.Hour).Unix(), windowStart.Add(27*time.Hour).Unix()))
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+	// Only organization members are agents
+	res = appointmentRequest(t, "POST", url, user2, "user", fmt.Sprintf(windowJSON, windowStart.Add(48*time.Hour).Unix(), windowStart.Add(50*time.Hour).Unix()))
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
+
+func TestCancelViewing(t *testing.T) {
+	logger.Debug("TestCancelViewing...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/viewings/%s/cancel", appointmentsURL(organization1), viewing1)
+	res := appointmentRequest(t, "POST", url, user2, "user", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Errorf("Status: %d | Expected: 204-StatusNoContent", res.StatusCode)
+		return
+	}
+	res = appointmentRequest(t, "POST", url, user2, "user", "")
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("Status: %d | Expected: 409-StatusConflict", res.StatusCode)
+	}
+	// Slot is free again
+	res = bookViewing(t, listing1, windowStart)
+	if res.StatusCode != http.StatusCreated {
+		t.Errorf("Status: %d | Expected: 201-StatusCreated", res.StatusCode)
+	}
+}
+
+func TestGetAgentViewingsCalendar(t *testing.T) {
+	logger.Debug("TestGetAgentViewingsCalendar...")
+	tbp.PrepareTestDatabase()
+	// Calendar clients pass the token as query value
+	token, _ := bootstrap.GenerateJWT(user1, "admin", "admin")
+	url := fmt.Sprintf("%s/agents/%s/viewings.ics?access_token=%s", appointmentsURL(organization1), user1, token)
+	res, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+		t.Error(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Status: %d | Expected: 200-StatusOk", res.StatusCode)
+		return
+	}
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/calendar") {
+		t.Errorf("Content-Type: '%s' | Expected: 'text/calendar'", res.Header.Get("Content-Type"))
+	}
+	cal, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	for _, line := range []string{"BEGIN:VCALENDAR\r\n", "UID:" + viewing1 + "@fundacja\r\n", "DTSTART:20300107T090000Z\r\n", "LOCATION:Listing1 address\r\n", "END:VCALENDAR\r\n"} {
+		if !strings.Contains(string(cal), line) {
+			t.Errorf("Calendar lacks line '%s'", strings.TrimSpace(line))
+		}
+	}
+}
+
+func TestGetListingViewingsCalendarAsProspect(t *testing.T) {
+	logger.Debug("TestGetListingViewingsCalendarAsProspect...")
+	tbp.PrepareTestDatabase()
+	url := fmt.Sprintf("%s/listings/%s/viewings.ics", appointmentsURL(organization1), listing1)
+	res := appointmentRequest(t, "GET", url, user2, "user", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Status: %d | Expected: 403-StatusForbidden", res.StatusCode)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+// BBox - Spatial bounding box
+type BBox struct {
+	MinLng float64 `json:"minLng"`
+	MinLat float64 `json:"minLat"`
+	MaxLng float64 `json:"maxLng"`
+	MaxLat float64 `json:"maxLat"`
+}
+
+// Center - Bounding box center point.
+func (b *BBox) Center() Point {
+	return Point{
+		Lng: (b.MinLng + b.MaxLng) / 2,
+		Lat: (b.MinLat + b.MaxLat) / 2,
+	}
+}
+
+// IsValid - Check bounding box corners.
+func (b *BBox) IsValid() bool {
+	return b.MinLng <= b.MaxLng && b.MinLat <= b.MaxLat &&
+		isLng(b.MinLng) && isLng(b.MaxLng) && isLat(b.MinLat) && isLat(b.MaxLat)
+}
+
+// GeoFilter - Radius and bounding box spatial filter.
+// Radius is expressed in meters.
+type GeoFilter struct {
+	Near      NullPoint
+	Radius    float64
+	BBox      BBox
+	BBoxValid bool
+}
+
+// IsEmpty - True if no spatial condition was set.
+func (f *GeoFilter) IsEmpty() bool {
+	return !f.Near.Valid && !f.BBoxValid
+}
+
+// HasRadius - True if results must be limited to a radius around Near.
+func (f *GeoFilter) HasRadius() bool {
+	return f.Near.Valid && f.Radius > 0
+}
+
+// Origin - Point from where distances are measured.
+// Near if set, otherwise the bounding box center.
+func (f *GeoFilter) Origin() Point {
+	if f.Near.Valid {
+		return f.Near.Point
+	}
+	return f.BBox.Center()
+}
+
+func isLng(v float64) bool {
+	return v >= -180 && v <= 180
+}
+
+func isLat(v float64) bool {
+	return v >= -90 && v <= 90
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import "encoding/json"
+
+// GeoJSONMediaType - GeoJSON media type (RFC 7946).
+const GeoJSONMediaType = "application/geo+json"
+
+// Feature - GeoJSON feature
+type Feature struct {
+	Type       string                     `json:"type"`
+	ID         json.RawMessage            `json:"id,omitempty"`
+	Geometry   json.RawMessage            `json:"geometry"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// FeatureCollection - GeoJSON feature collection
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// MakeFeatureCollection - FeatureCollection constructor.
+func MakeFeatureCollection() FeatureCollection {
+	return FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+// AddFeature - Appends a feature built from a JSON object.
+// The first non null member named in geometryKeys is used as geometry,
+// 'id' as feature id and all remaining members as feature properties.
+func (fc *FeatureCollection) AddFeature(object map[string]json.RawMessage, geometryKeys ...string) {
+	feature := Feature{Type: "Feature", Geometry: json.RawMessage("null"), Properties: object}
+	for _, key := range geometryKeys {
+		geometry, ok := object[key]
+		if !ok {
+			continue
+		}
+		delete(object, key)
+		if string(geometry) != "null" && string(feature.Geometry) == "null" {
+			feature.Geometry = geometry
+		}
+	}
+	if id, ok := object["id"]; ok {
+		feature.ID = id
+		delete(object, "id")
+	}
+	fc.Features = append(fc.Features, feature)
+}
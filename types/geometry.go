@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import "math"
+
+// Earth mean radius in meters (WGS84 semi-major axis).
+const earthRadius = 6378137.0
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// distance - Haversine distance in meters between two points.
+func distance(a, b Point) float64 {
+	dLat := toRadians(b.Lat - a.Lat)
+	dLng := toRadians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(a.Lat))*math.Cos(toRadians(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// pathLength - Length in meters of a sequence of points.
+func pathLength(points []Point) float64 {
+	var length float64
+	for i := 1; i < len(points); i++ {
+		length += distance(points[i-1], points[i])
+	}
+	return length
+}
+
+// ringArea - Approximate area in square meters of a ring over the sphere.
+// Chamberlain & Duquette, "Some Algorithms for Polygons on a Sphere".
+func ringArea(ring []Point) float64 {
+	n := len(ring)
+	if n < 3 {
+		return 0
+	}
+	var area float64
+	for i := 0; i < n; i++ {
+		p1 := ring[i]
+		p2 := ring[(i+1)%n]
+		area += toRadians(p2.Lng-p1.Lng) * (2 + math.Sin(toRadians(p1.Lat)) + math.Sin(toRadians(p2.Lat)))
+	}
+	return math.Abs(area * earthRadius * earthRadius / 2)
+}
+
+// ringContains - Ray casting point in ring test over planar coordinates.
+func ringContains(ring []Point, p Point) bool {
+	in := false
+	n := len(ring)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			in = !in
+		}
+	}
+	return in
+}
+
+// isClosedRing - A ring needs at least four points and the same first and last point.
+func isClosedRing(ring []Point) bool {
+	return len(ring) >= 4 && ring[0] == ring[len(ring)-1]
+}
+
+// GeoJSON coordinates helpers
+
+func toCoords(points []Point) [][]float64 {
+	coords := make([][]float64, len(points))
+	for i, p := range points {
+		coords[i] = []float64{p.Lng, p.Lat}
+	}
+	return coords
+}
+
+func fromCoords(coords [][]float64) []Point {
+	points := make([]Point, 0, len(coords))
+	for _, c := range coords {
+		if len(c) < 2 {
+			continue
+		}
+		points = append(points, Point{Lng: c[0], Lat: c[1]})
+	}
+	return points
+}
+
+func toRingsCoords(rings [][]Point) [][][]float64 {
+	coords := make([][][]float64, len(rings))
+	for i, ring := range rings {
+		coords[i] = toCoords(ring)
+	}
+	return coords
+}
+
+func fromRingsCoords(coords [][][]float64) [][]Point {
+	rings := make([][]Point, len(coords))
+	for i, c := range coords {
+		rings[i] = fromCoords(c)
+	}
+	return rings
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONList - Nullable JSON array
+type JSONList []interface{}
+
+// Strings - List items as strings.
+func (jl JSONList) Strings() []string {
+	items := make([]string, len(jl))
+	for i, item := range jl {
+		items[i] = fmt.Sprintf("%v", item)
+	}
+	return items
+}
+
+// Contains - True if list includes item.
+func (jl JSONList) Contains(item string) bool {
+	for _, s := range jl.Strings() {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan implements the Scanner interface.
+func (jl *JSONList) Scan(val interface{}) error {
+	if val == nil {
+		*jl = nil
+		return nil
+	}
+	var b []byte
+	switch v := val.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("Invalid JSON list value type %T", val)
+	}
+	list := []interface{}{}
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*jl = list
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (jl JSONList) Value() (driver.Value, error) {
+	if jl == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal([]interface{}(jl))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
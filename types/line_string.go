@@ -0,0 +1,152 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// LineString - Spatial line string
+type LineString struct {
+	Points []Point
+}
+
+type lineStringJSON struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+func (ls *LineString) String() string {
+	return fmt.Sprintf("SRID=4326;LINESTRING%s", wktPoints(ls.Points))
+}
+
+// Length - Line string length in meters.
+func (ls *LineString) Length() float64 {
+	return pathLength(ls.Points)
+}
+
+// IsValid - A line string needs at least two valid points.
+func (ls *LineString) IsValid() bool {
+	if len(ls.Points) < 2 {
+		return false
+	}
+	for _, p := range ls.Points {
+		if !p.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Scan implements the Scanner interface.
+func (ls *LineString) Scan(val interface{}) error {
+	d, err := newWKBDecoder(val)
+	if err != nil {
+		return err
+	}
+	if err := d.expect(wkbLineString); err != nil {
+		return err
+	}
+	points, err := d.points()
+	if err != nil {
+		return err
+	}
+	ls.Points = points
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (ls LineString) Value() (driver.Value, error) {
+	return ls.String(), nil
+}
+
+// MarshalJSON - Marshals as a GeoJSON geometry.
+func (ls LineString) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lineStringJSON{Type: "LineString", Coordinates: toCoords(ls.Points)})
+}
+
+// UnmarshalJSON - Unmarshals from a GeoJSON geometry.
+func (ls *LineString) UnmarshalJSON(data []byte) error {
+	aux := lineStringJSON{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Type != "LineString" {
+		return fmt.Errorf("Invalid geometry type '%s', expected 'LineString'", aux.Type)
+	}
+	ls.Points = fromCoords(aux.Coordinates)
+	return nil
+}
+
+// NullLineString - Spatial nullable line string
+type NullLineString struct {
+	LineString LineString
+	Valid      bool
+}
+
+// Scan implements the Scanner interface.
+func (nls *NullLineString) Scan(val interface{}) error {
+	if val == nil {
+		nls.LineString, nls.Valid = LineString{}, false
+		return nil
+	}
+	ls := LineString{}
+	if err := ls.Scan(val); err != nil {
+		nls.LineString, nls.Valid = LineString{}, false
+		return nil
+	}
+	nls.LineString, nls.Valid = ls, true
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (nls NullLineString) Value() (driver.Value, error) {
+	if !nls.Valid {
+		return nil, nil
+	}
+	return nls.LineString.String(), nil
+}
+
+// MarshalJSON implements the json Marshaler interface.
+func (nls NullLineString) MarshalJSON() ([]byte, error) {
+	if !nls.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(nls.LineString)
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (nls *NullLineString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		nls.LineString, nls.Valid = LineString{}, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &nls.LineString); err != nil {
+		return err
+	}
+	nls.Valid = true
+	return nil
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MultiPolygon - Spatial multipolygon
+type MultiPolygon struct {
+	Polygons []Polygon
+}
+
+type multiPolygonJSON struct {
+	Type        string          `json:"type"`
+	Coordinates [][][][]float64 `json:"coordinates"`
+}
+
+func (mp *MultiPolygon) String() string {
+	parts := make([]string, len(mp.Polygons))
+	for i, p := range mp.Polygons {
+		parts[i] = wktRings(p.Rings)
+	}
+	return fmt.Sprintf("SRID=4326;MULTIPOLYGON(%s)", strings.Join(parts, ", "))
+}
+
+// Area - Sum of polygons area in square meters.
+func (mp *MultiPolygon) Area() float64 {
+	var area float64
+	for _, p := range mp.Polygons {
+		area += p.Area()
+	}
+	return area
+}
+
+// Perimeter - Sum of polygons perimeter in meters.
+func (mp *MultiPolygon) Perimeter() float64 {
+	var perimeter float64
+	for _, p := range mp.Polygons {
+		perimeter += p.Perimeter()
+	}
+	return perimeter
+}
+
+// Contains - True if any of the polygons contains the point.
+func (mp *MultiPolygon) Contains(point Point) bool {
+	for _, p := range mp.Polygons {
+		if p.Contains(point) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid - Every polygon must be valid.
+func (mp *MultiPolygon) IsValid() bool {
+	if len(mp.Polygons) == 0 {
+		return false
+	}
+	for _, p := range mp.Polygons {
+		if !p.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Scan implements the Scanner interface.
+func (mp *MultiPolygon) Scan(val interface{}) error {
+	d, err := newWKBDecoder(val)
+	if err != nil {
+		return err
+	}
+	if err := d.expect(wkbMultiPolygon); err != nil {
+		return err
+	}
+	n, err := d.count()
+	if err != nil {
+		return err
+	}
+	polygons := make([]Polygon, n)
+	for i := range polygons {
+		if err := d.expect(wkbPolygon); err != nil {
+			return err
+		}
+		rings, err := d.rings()
+		if err != nil {
+			return err
+		}
+		polygons[i] = Polygon{Rings: rings}
+	}
+	mp.Polygons = polygons
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (mp MultiPolygon) Value() (driver.Value, error) {
+	return mp.String(), nil
+}
+
+// MarshalJSON - Marshals as a GeoJSON geometry.
+func (mp MultiPolygon) MarshalJSON() ([]byte, error) {
+	coords := make([][][][]float64, len(mp.Polygons))
+	for i, p := range mp.Polygons {
+		coords[i] = toRingsCoords(p.Rings)
+	}
+	return json.Marshal(multiPolygonJSON{Type: "MultiPolygon", Coordinates: coords})
+}
+
+// UnmarshalJSON - Unmarshals from a GeoJSON geometry.
+func (mp *MultiPolygon) UnmarshalJSON(data []byte) error {
+	aux := multiPolygonJSON{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Type != "MultiPolygon" {
+		return fmt.Errorf("Invalid geometry type '%s', expected 'MultiPolygon'", aux.Type)
+	}
+	polygons := make([]Polygon, len(aux.Coordinates))
+	for i, c := range aux.Coordinates {
+		polygons[i] = Polygon{Rings: fromRingsCoords(c)}
+	}
+	mp.Polygons = polygons
+	return nil
+}
+
+// NullMultiPolygon - Spatial nullable multipolygon
+type NullMultiPolygon struct {
+	MultiPolygon MultiPolygon
+	Valid        bool
+}
+
+// Scan implements the Scanner interface.
+func (nmp *NullMultiPolygon) Scan(val interface{}) error {
+	if val == nil {
+		nmp.MultiPolygon, nmp.Valid = MultiPolygon{}, false
+		return nil
+	}
+	mp := MultiPolygon{}
+	if err := mp.Scan(val); err != nil {
+		nmp.MultiPolygon, nmp.Valid = MultiPolygon{}, false
+		return nil
+	}
+	nmp.MultiPolygon, nmp.Valid = mp, true
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (nmp NullMultiPolygon) Value() (driver.Value, error) {
+	if !nmp.Valid {
+		return nil, nil
+	}
+	return nmp.MultiPolygon.String(), nil
+}
+
+// MarshalJSON implements the json Marshaler interface.
+func (nmp NullMultiPolygon) MarshalJSON() ([]byte, error) {
+	if !nmp.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(nmp.MultiPolygon)
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (nmp *NullMultiPolygon) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		nmp.MultiPolygon, nmp.Valid = MultiPolygon{}, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &nmp.MultiPolygon); err != nil {
+		return err
+	}
+	nmp.Valid = true
+	return nil
+}
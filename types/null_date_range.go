@@ -0,0 +1,224 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// DateRange - Date range, both bounds inclusive.
+// Zero From or To means unbounded.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+type dateRangeJSON struct {
+	From *string `json:"from"`
+	To   *string `json:"to"`
+}
+
+// String - Postgres daterange literal.
+func (dr *DateRange) String() string {
+	var from, to string
+	if !dr.From.IsZero() {
+		from = dr.From.Format(dateLayout)
+	}
+	if !dr.To.IsZero() {
+		to = dr.To.Format(dateLayout)
+	}
+	return fmt.Sprintf("[%s,%s]", from, to)
+}
+
+// Contains - True if date is inside the range.
+func (dr *DateRange) Contains(date time.Time) bool {
+	d := truncateDate(date)
+	if !dr.From.IsZero() && d.Before(dr.From) {
+		return false
+	}
+	if !dr.To.IsZero() && d.After(dr.To) {
+		return false
+	}
+	return true
+}
+
+// IsValid - From must not be after To.
+func (dr *DateRange) IsValid() bool {
+	return dr.From.IsZero() || dr.To.IsZero() || !dr.From.After(dr.To)
+}
+
+// Scan implements the Scanner interface.
+// Postgres returns canonical date ranges with exclusive upper bound: [2017-01-01,2017-02-01)
+func (dr *DateRange) Scan(val interface{}) error {
+	var s string
+	switch v := val.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("Invalid date range value type %T", val)
+	}
+	s = strings.TrimSpace(s)
+	if s == "empty" {
+		*dr = DateRange{}
+		return nil
+	}
+	if len(s) < 3 {
+		return fmt.Errorf("Invalid date range '%s'", s)
+	}
+	lowerInc := s[0] == '['
+	upperInc := s[len(s)-1] == ']'
+	bounds := strings.Split(s[1:len(s)-1], ",")
+	if len(bounds) != 2 {
+		return fmt.Errorf("Invalid date range '%s'", s)
+	}
+	from, err := parseRangeBound(bounds[0])
+	if err != nil {
+		return err
+	}
+	to, err := parseRangeBound(bounds[1])
+	if err != nil {
+		return err
+	}
+	if !from.IsZero() && !lowerInc {
+		from = from.AddDate(0, 0, 1)
+	}
+	if !to.IsZero() && !upperInc {
+		to = to.AddDate(0, 0, -1)
+	}
+	dr.From, dr.To = from, to
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (dr DateRange) Value() (driver.Value, error) {
+	return dr.String(), nil
+}
+
+// MarshalJSON - Marshals as {"from": "2006-01-02", "to": "2006-01-02"}.
+func (dr DateRange) MarshalJSON() ([]byte, error) {
+	aux := dateRangeJSON{}
+	if !dr.From.IsZero() {
+		from := dr.From.Format(dateLayout)
+		aux.From = &from
+	}
+	if !dr.To.IsZero() {
+		to := dr.To.Format(dateLayout)
+		aux.To = &to
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON - Unmarshals from {"from": "2006-01-02", "to": "2006-01-02"}.
+func (dr *DateRange) UnmarshalJSON(data []byte) error {
+	aux := dateRangeJSON{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*dr = DateRange{}
+	if aux.From != nil && *aux.From != "" {
+		from, err := time.Parse(dateLayout, *aux.From)
+		if err != nil {
+			return err
+		}
+		dr.From = from
+	}
+	if aux.To != nil && *aux.To != "" {
+		to, err := time.Parse(dateLayout, *aux.To)
+		if err != nil {
+			return err
+		}
+		dr.To = to
+	}
+	return nil
+}
+
+// NullDateRange - Nullable date range
+type NullDateRange struct {
+	DateRange DateRange
+	Valid     bool
+}
+
+// Scan implements the Scanner interface.
+func (ndr *NullDateRange) Scan(val interface{}) error {
+	if val == nil {
+		ndr.DateRange, ndr.Valid = DateRange{}, false
+		return nil
+	}
+	dr := DateRange{}
+	if err := dr.Scan(val); err != nil {
+		ndr.DateRange, ndr.Valid = DateRange{}, false
+		return nil
+	}
+	ndr.DateRange, ndr.Valid = dr, true
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (ndr NullDateRange) Value() (driver.Value, error) {
+	if !ndr.Valid {
+		return nil, nil
+	}
+	return ndr.DateRange.String(), nil
+}
+
+// MarshalJSON implements the json Marshaler interface.
+func (ndr NullDateRange) MarshalJSON() ([]byte, error) {
+	if !ndr.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(ndr.DateRange)
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (ndr *NullDateRange) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		ndr.DateRange, ndr.Valid = DateRange{}, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &ndr.DateRange); err != nil {
+		return err
+	}
+	ndr.Valid = true
+	return nil
+}
+
+func parseRangeBound(bound string) (time.Time, error) {
+	bound = strings.Trim(strings.TrimSpace(bound), `"`)
+	if bound == "" || bound == "infinity" || bound == "-infinity" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateLayout, bound)
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
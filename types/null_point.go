@@ -24,10 +24,8 @@
 package types
 
 import (
-	"bytes"
 	"database/sql/driver"
-	"encoding/binary"
-	"encoding/hex"
+	"encoding/json"
 	// "github.com/dewski/spatial"
 	"fmt"
 )
@@ -42,37 +40,25 @@ func (p *Point) String() string {
 	return fmt.Sprintf("SRID=4326;POINT(%v %v)", p.Lng, p.Lat)
 }
 
+// IsValid - Check point coordinates.
+func (p *Point) IsValid() bool {
+	return isLng(p.Lng) && isLat(p.Lat)
+}
+
 // Scan implements the Scanner interface.
 func (p *Point) Scan(val interface{}) error {
-	b, err := hex.DecodeString(string(val.([]uint8)))
+	d, err := newWKBDecoder(val)
 	if err != nil {
 		return err
 	}
-	r := bytes.NewReader(b)
-	var wkbByteOrder uint8
-	if err := binary.Read(r, binary.LittleEndian, &wkbByteOrder); err != nil {
-		return err
-	}
-
-	var byteOrder binary.ByteOrder
-	switch wkbByteOrder {
-	case 0:
-		byteOrder = binary.BigEndian
-	case 1:
-		byteOrder = binary.LittleEndian
-	default:
-		return fmt.Errorf("Invalid byte order %d", wkbByteOrder)
-	}
-
-	var wkbGeometryType uint64
-	if err := binary.Read(r, byteOrder, &wkbGeometryType); err != nil {
+	if err := d.expect(wkbPoint); err != nil {
 		return err
 	}
-
-	if err := binary.Read(r, byteOrder, p); err != nil {
+	point, err := d.point()
+	if err != nil {
 		return err
 	}
-
+	*p = point
 	return nil
 }
 
@@ -114,5 +100,54 @@ func (np NullPoint) Value() (driver.Value, error) {
 	if !np.Valid {
 		return nil, nil
 	}
-	return np.Point, nil
+	return np.Point.String(), nil
+}
+
+type pointJSON struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+	// Legacy format
+	Point *Point `json:"Point,omitempty"`
+	Valid *bool  `json:"Valid,omitempty"`
+}
+
+// MarshalJSON - Marshals as a GeoJSON Point geometry.
+func (np NullPoint) MarshalJSON() ([]byte, error) {
+	if !np.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}{
+		Type:        "Point",
+		Coordinates: []float64{np.Point.Lng, np.Point.Lat},
+	})
+}
+
+// UnmarshalJSON - Unmarshals from a GeoJSON Point geometry.
+// Previous {"Point": {"lng", "lat"}, "Valid"} format is also accepted.
+func (np *NullPoint) UnmarshalJSON(data []byte) error {
+	np.Point, np.Valid = Point{}, false
+	if string(data) == "null" {
+		return nil
+	}
+	aux := pointJSON{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Point != nil {
+		np.Point = *aux.Point
+		np.Valid = aux.Valid == nil || *aux.Valid
+		return nil
+	}
+	if aux.Type != "Point" {
+		return fmt.Errorf("Invalid geometry type '%s', expected 'Point'", aux.Type)
+	}
+	if len(aux.Coordinates) < 2 {
+		return fmt.Errorf("Invalid Point coordinates")
+	}
+	np.Point = Point{Lng: aux.Coordinates[0], Lat: aux.Coordinates[1]}
+	np.Valid = true
+	return nil
 }
@@ -0,0 +1,186 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Polygon - Spatial polygon
+// First ring is the exterior boundary, the remaining ones are holes.
+type Polygon struct {
+	Rings [][]Point
+}
+
+type polygonJSON struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+func (p *Polygon) String() string {
+	return fmt.Sprintf("SRID=4326;POLYGON%s", wktRings(p.Rings))
+}
+
+// Area - Polygon area in square meters, holes excluded.
+func (p *Polygon) Area() float64 {
+	if len(p.Rings) == 0 {
+		return 0
+	}
+	area := ringArea(p.Rings[0])
+	for _, hole := range p.Rings[1:] {
+		area -= ringArea(hole)
+	}
+	return area
+}
+
+// Perimeter - Exterior ring length in meters.
+func (p *Polygon) Perimeter() float64 {
+	if len(p.Rings) == 0 {
+		return 0
+	}
+	return pathLength(p.Rings[0])
+}
+
+// Contains - True if point is inside exterior ring and outside holes.
+func (p *Polygon) Contains(point Point) bool {
+	if len(p.Rings) == 0 || !ringContains(p.Rings[0], point) {
+		return false
+	}
+	for _, hole := range p.Rings[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValid - Every ring must be closed and made of valid points.
+func (p *Polygon) IsValid() bool {
+	if len(p.Rings) == 0 {
+		return false
+	}
+	for _, ring := range p.Rings {
+		if !isClosedRing(ring) {
+			return false
+		}
+		for _, point := range ring {
+			if !point.IsValid() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Scan implements the Scanner interface.
+func (p *Polygon) Scan(val interface{}) error {
+	d, err := newWKBDecoder(val)
+	if err != nil {
+		return err
+	}
+	if err := d.expect(wkbPolygon); err != nil {
+		return err
+	}
+	rings, err := d.rings()
+	if err != nil {
+		return err
+	}
+	p.Rings = rings
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (p Polygon) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// MarshalJSON - Marshals as a GeoJSON geometry.
+func (p Polygon) MarshalJSON() ([]byte, error) {
+	return json.Marshal(polygonJSON{Type: "Polygon", Coordinates: toRingsCoords(p.Rings)})
+}
+
+// UnmarshalJSON - Unmarshals from a GeoJSON geometry.
+func (p *Polygon) UnmarshalJSON(data []byte) error {
+	aux := polygonJSON{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Type != "Polygon" {
+		return fmt.Errorf("Invalid geometry type '%s', expected 'Polygon'", aux.Type)
+	}
+	p.Rings = fromRingsCoords(aux.Coordinates)
+	return nil
+}
+
+// NullPolygon - Spatial nullable polygon
+type NullPolygon struct {
+	Polygon Polygon
+	Valid   bool
+}
+
+// Scan implements the Scanner interface.
+func (np *NullPolygon) Scan(val interface{}) error {
+	if val == nil {
+		np.Polygon, np.Valid = Polygon{}, false
+		return nil
+	}
+	p := Polygon{}
+	if err := p.Scan(val); err != nil {
+		np.Polygon, np.Valid = Polygon{}, false
+		return nil
+	}
+	np.Polygon, np.Valid = p, true
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (np NullPolygon) Value() (driver.Value, error) {
+	if !np.Valid {
+		return nil, nil
+	}
+	return np.Polygon.String(), nil
+}
+
+// MarshalJSON implements the json Marshaler interface.
+func (np NullPolygon) MarshalJSON() ([]byte, error) {
+	if !np.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(np.Polygon)
+}
+
+// UnmarshalJSON implements the json Unmarshaler interface.
+func (np *NullPolygon) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		np.Polygon, np.Valid = Polygon{}, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &np.Polygon); err != nil {
+		return err
+	}
+	np.Valid = true
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2017 Kuguar <licenses@kuguar.io> Author: Adrian P.K. <apk@kuguar.io>
+//
+// MIT License
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WKB geometry types.
+const (
+	wkbPoint        = 1
+	wkbLineString   = 2
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+)
+
+// EWKB flags.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// wkbDecoder - WKB / EWKB reader.
+type wkbDecoder struct {
+	r     *bytes.Reader
+	order binary.ByteOrder
+	dims  int
+	SRID  uint32
+}
+
+// newWKBDecoder - Creates a decoder for a raw or hex encoded WKB / EWKB value.
+func newWKBDecoder(val interface{}) (*wkbDecoder, error) {
+	var b []byte
+	switch v := val.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, fmt.Errorf("Invalid geometry value type %T", val)
+	}
+	// PostGIS returns geography columns as hex encoded EWKB.
+	if decoded, err := hex.DecodeString(string(b)); err == nil {
+		b = decoded
+	}
+	return &wkbDecoder{r: bytes.NewReader(b)}, nil
+}
+
+// header - Reads byte order and geometry type, returning the base geometry type.
+func (d *wkbDecoder) header() (uint32, error) {
+	var byteOrder uint8
+	if err := binary.Read(d.r, binary.LittleEndian, &byteOrder); err != nil {
+		return 0, err
+	}
+	switch byteOrder {
+	case 0:
+		d.order = binary.BigEndian
+	case 1:
+		d.order = binary.LittleEndian
+	default:
+		return 0, fmt.Errorf("Invalid byte order %d", byteOrder)
+	}
+	var geomType uint32
+	if err := binary.Read(d.r, d.order, &geomType); err != nil {
+		return 0, err
+	}
+	d.dims = 2
+	if geomType&ewkbZ != 0 {
+		d.dims++
+	}
+	if geomType&ewkbM != 0 {
+		d.dims++
+	}
+	if geomType&ewkbSRID != 0 {
+		if err := binary.Read(d.r, d.order, &d.SRID); err != nil {
+			return 0, err
+		}
+	}
+	base := geomType &^ (ewkbZ | ewkbM | ewkbSRID)
+	// ISO WKB Z, M and ZM variants.
+	switch {
+	case base > 3000:
+		d.dims += 2
+		base -= 3000
+	case base > 2000:
+		d.dims++
+		base -= 2000
+	case base > 1000:
+		d.dims++
+		base -= 1000
+	}
+	return base, nil
+}
+
+// expect - Reads header and checks geometry type.
+func (d *wkbDecoder) expect(geomType uint32) error {
+	t, err := d.header()
+	if err != nil {
+		return err
+	}
+	if t != geomType {
+		return fmt.Errorf("Invalid geometry type %d, expected %d", t, geomType)
+	}
+	return nil
+}
+
+func (d *wkbDecoder) point() (Point, error) {
+	coords := make([]float64, d.dims)
+	if err := binary.Read(d.r, d.order, coords); err != nil {
+		return Point{}, err
+	}
+	return Point{Lng: coords[0], Lat: coords[1]}, nil
+}
+
+func (d *wkbDecoder) count() (int, error) {
+	var n uint32
+	if err := binary.Read(d.r, d.order, &n); err != nil {
+		return 0, err
+	}
+	// Sanity check, each element takes at least one byte.
+	if int(n) > d.r.Len() {
+		return 0, fmt.Errorf("Invalid element count %d", n)
+	}
+	return int(n), nil
+}
+
+func (d *wkbDecoder) points() ([]Point, error) {
+	n, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	points := make([]Point, n)
+	for i := range points {
+		points[i], err = d.point()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func (d *wkbDecoder) rings() ([][]Point, error) {
+	n, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	rings := make([][]Point, n)
+	for i := range rings {
+		rings[i], err = d.points()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rings, nil
+}
+
+// WKT helpers
+
+func wktCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func wktPoints(points []Point) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = wktCoord(p.Lng) + " " + wktCoord(p.Lat)
+	}
+	return "(" + strings.Join(coords, ", ") + ")"
+}
+
+func wktRings(rings [][]Point) string {
+	parts := make([]string, len(rings))
+	for i, ring := range rings {
+		parts[i] = wktPoints(ring)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}